@@ -0,0 +1,22 @@
+package dto
+
+import "time"
+
+type FailedAttempt struct {
+	Reason string `json:"reason" form:"reason" binding:"required,oneof=recipient_absent wrong_address refused no_access other"`
+	Note   string `json:"note" form:"note" binding:"max=1000"`
+}
+
+type Reschedule struct {
+	WindowStart time.Time `json:"window_start" binding:"required"`
+	WindowEnd   time.Time `json:"window_end" binding:"required"`
+	// Zone is the delivery zone the window is booked in, the zone of the
+	// delivery is kept when it is left out.
+	Zone string `json:"zone" binding:"max=64"`
+}
+
+type Return struct {
+	// WindowStart and WindowEnd optionally request when the return is picked up.
+	WindowStart *time.Time `json:"window_start" binding:"required_with=WindowEnd"`
+	WindowEnd   *time.Time `json:"window_end" binding:"required_with=WindowStart"`
+}
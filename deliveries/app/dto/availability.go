@@ -0,0 +1,9 @@
+package dto
+
+type Availability struct {
+	Status string `json:"status" binding:"required,oneof=online offline on_break"`
+}
+
+type Capacity struct {
+	MaxActiveDeliveries uint `json:"max_active_deliveries" binding:"required,min=1"`
+}
@@ -0,0 +1,12 @@
+package dto
+
+type CashHandover struct {
+	Amount string `json:"amount" binding:"required" example:"1250.00"`
+	Note   string `json:"note" binding:"max=500"`
+}
+
+type CashReconciliation struct {
+	// Counted is the cash counted at the courier.
+	Counted string `json:"counted" binding:"required" example:"3000.00"`
+	Note    string `json:"note" binding:"max=500"`
+}
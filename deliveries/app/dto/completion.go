@@ -0,0 +1,14 @@
+package dto
+
+type Completion struct {
+	HandoverCode string `json:"handover_code"`
+	// CollectedAmount and PaymentMethod are required for cash on delivery.
+	CollectedAmount string `json:"collected_amount" example:"1250.00"`
+	PaymentMethod   string `json:"payment_method" binding:"omitempty,oneof=cash card"`
+}
+
+type Override struct {
+	Reason          string `json:"reason" binding:"required"`
+	CollectedAmount string `json:"collected_amount" example:"1250.00"`
+	PaymentMethod   string `json:"payment_method" binding:"omitempty,oneof=cash card"`
+}
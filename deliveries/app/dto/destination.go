@@ -1,5 +1,27 @@
 package dto
 
+import "time"
+
 type Destination struct {
-	D string `json:"destination"`
+	D         string   `json:"destination"`
+	Latitude  *float64 `json:"latitude" binding:"required_with=Longitude,omitempty,min=-90,max=90"`
+	Longitude *float64 `json:"longitude" binding:"required_with=Latitude,omitempty,min=-180,max=180"`
+	// Origin is the optional pickup address, returns are dropped off there.
+	Origin          string   `json:"origin"`
+	OriginLatitude  *float64 `json:"origin_latitude" binding:"required_with=OriginLongitude,omitempty,min=-90,max=90"`
+	OriginLongitude *float64 `json:"origin_longitude" binding:"required_with=OriginLatitude,omitempty,min=-180,max=180"`
+	// WindowStart and WindowEnd optionally request when the delivery arrives.
+	WindowStart *time.Time `json:"window_start" binding:"required_with=WindowEnd"`
+	WindowEnd   *time.Time `json:"window_end" binding:"required_with=WindowStart"`
+	// Zone is the delivery zone the window is booked in, it may be left out
+	// when a single zone is configured.
+	Zone string `json:"zone" binding:"max=64"`
+	// QuoteId locks the price of a quote from POST /quotes onto the delivery.
+	QuoteId string `json:"quote_id"`
+	// ServiceLevel is standard when left out.
+	ServiceLevel string `json:"service_level" binding:"omitempty,oneof=standard express same_day"`
+	// CodAmount is the optional cash on delivery, like 1250.00.
+	CodAmount string `json:"cod_amount"`
+	// ScheduledFor optionally requests the day of the delivery, like 2026-10-19.
+	ScheduledFor string `json:"scheduled_for"`
 }
@@ -0,0 +1,10 @@
+package dto
+
+type GenerateInvoices struct {
+	// Period is the month to invoice, like 2026-09.
+	Period string `json:"period" binding:"required" example:"2026-09"`
+	// MerchantId limits the generation to one user outside of organizations,
+	// OrganizationId to one organization.
+	MerchantId     uint `json:"merchant_id"`
+	OrganizationId uint `json:"organization_id"`
+}
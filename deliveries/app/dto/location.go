@@ -0,0 +1,14 @@
+package dto
+
+import "time"
+
+type LocationFix struct {
+	Lat       *float64  `json:"lat" binding:"required,min=-90,max=90"`
+	Lng       *float64  `json:"lng" binding:"required,min=-180,max=180"`
+	Accuracy  float64   `json:"accuracy" binding:"min=0"`
+	Timestamp time.Time `json:"timestamp" binding:"required"`
+}
+
+type LocationBatch struct {
+	Fixes []LocationFix `json:"fixes" binding:"required,min=1,max=500,dive"`
+}
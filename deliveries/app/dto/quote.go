@@ -0,0 +1,15 @@
+package dto
+
+type Quote struct {
+	ServiceLevel    string   `json:"service_level" binding:"omitempty,oneof=standard express same_day"`
+	Zone            string   `json:"zone" binding:"max=64"`
+	OriginLatitude  *float64 `json:"origin_latitude" binding:"required_with=OriginLongitude,omitempty,min=-90,max=90"`
+	OriginLongitude *float64 `json:"origin_longitude" binding:"required_with=OriginLatitude,omitempty,min=-180,max=180"`
+	Latitude        *float64 `json:"latitude" binding:"required_with=Longitude,omitempty,min=-90,max=90"`
+	Longitude       *float64 `json:"longitude" binding:"required_with=Latitude,omitempty,min=-180,max=180"`
+	WeightKg        float64  `json:"weight_kg" binding:"required,gt=0,max=1000"`
+	LengthCm        uint     `json:"length_cm" binding:"required,max=1000"`
+	WidthCm         uint     `json:"width_cm" binding:"required,max=1000"`
+	HeightCm        uint     `json:"height_cm" binding:"required,max=1000"`
+	Fragile         bool     `json:"fragile"`
+}
@@ -0,0 +1,10 @@
+package dto
+
+import "time"
+
+type Shift struct {
+	CourierId uint      `json:"courier_id" binding:"required"`
+	StartsAt  time.Time `json:"starts_at" binding:"required"`
+	EndsAt    time.Time `json:"ends_at" binding:"required"`
+	Zone      string    `json:"zone" binding:"required"`
+}
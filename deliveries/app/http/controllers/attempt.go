@@ -0,0 +1,291 @@
+package controllers
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/zhanbolat18/parcel/deliveries/app/dto"
+	"github.com/zhanbolat18/parcel/deliveries/internal/entities"
+	"github.com/zhanbolat18/parcel/deliveries/internal/repositories"
+	"github.com/zhanbolat18/parcel/deliveries/internal/services"
+	"github.com/zhanbolat18/parcel/deliveries/internal/valueobjects"
+	httpLib "github.com/zhanbolat18/parcel/libs/http"
+	"net/http"
+)
+
+// FailAttempt godoc
+// @Summary      report failed delivery attempt
+// @Description  Report that the delivery could not be handed over. Only assigned courier have permission.
+// @Description  The delivery waits for the recipient to reschedule it, after DELIVERY_MAX_ATTEMPTS failed attempts it is
+// @Description  returned to sender. A multipart/form-data request may attach a photo.
+// @Accept 		 json,mpfd
+// @Produce      json
+// @Param 		 Authorization  header    string  true  "Authentication header. Usage 'Bearer {token}'"
+// @Param 		 If-Match  		header    string  false  "expected delivery ETag"
+// @Param 		 Idempotency-Key  header    string  false  "unique request key, repeated requests replay the first response"
+// @Param 		 id  			path	integer	true	"delivery id"
+// @Param        message  body  dto.FailedAttempt  false  "reason and note, when not sent as form fields"
+// @Param 		 reason		formData	string	false	"recipient_absent, wrong_address, refused, no_access or other"
+// @Param 		 note		formData	string	false	"note of the courier"
+// @Param 		 photo		formData	file	false	"photo of the attempt"
+// @Success      200  {object}  entities.Delivery
+// @Header       200  {string}  ETag  "delivery version"
+// @Failure      400  {object}  object{error=string}
+// @Failure      401  {object}  object{error=string}
+// @Failure      403  {object}  object{error=string}
+// @Failure      404  {object}  object{error=string}
+// @Failure      409  {object}  object{error=string}
+// @Failure      412  {object}  object{error=string}
+// @Failure      500  {object}  object{error=string}
+// @Router       /deliveries/{id}/attempts [post]
+func (d *Delivery) FailAttempt(ctx *gin.Context) {
+	u, ok := d.getUser(ctx)
+	if !ok {
+		return
+	}
+	id, ok := d.getUintParam(ctx, "id")
+	if !ok {
+		return
+	}
+	version, ok := d.getIfMatch(ctx)
+	if !ok {
+		return
+	}
+
+	ctx.Request.Body = http.MaxBytesReader(ctx.Writer, ctx.Request.Body, d.proofs.MaxUploadSize())
+	attempt := &dto.FailedAttempt{}
+	if err := ctx.ShouldBind(attempt); err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, httpLib.BadRequest(err.Error()))
+		return
+	}
+	if form := ctx.Request.MultipartForm; form != nil {
+		defer func() { _ = form.RemoveAll() }()
+	}
+	req := services.FailedAttempt{
+		ExpectedVersion: version,
+		Reason:          valueobjects.AttemptReason(attempt.Reason),
+		Note:            attempt.Note,
+	}
+	if ctx.ContentType() == "multipart/form-data" {
+		if req.Photo, ok = d.uploadAttemptPhoto(ctx, id); !ok {
+			return
+		}
+	}
+
+	delivery, err := d.srv.FailAttempt(ctx, id, u, req)
+	if err != nil {
+		if req.Photo != nil {
+			d.proofs.DiscardFile(ctx, req.Photo)
+		}
+		if d.abortOnConflict(ctx, err, version) {
+			return
+		}
+		switch {
+		case errors.Is(err, repositories.ErrDeliveryNotFound):
+			ctx.AbortWithStatusJSON(http.StatusNotFound, httpLib.NotFound())
+		case errors.Is(err, services.ErrNotAssigned):
+			ctx.AbortWithStatusJSON(http.StatusForbidden, httpLib.Forbidden())
+		case errors.Is(err, services.ErrInvalidAttempt):
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, httpLib.BadRequest(err.Error()))
+		case errors.Is(err, services.ErrNotInDelivery):
+			ctx.AbortWithStatusJSON(http.StatusConflict, httpLib.Conflict(err.Error()))
+		default:
+			ctx.AbortWithStatusJSON(http.StatusInternalServerError, httpLib.InternalServErr(err.Error()))
+		}
+		return
+	}
+
+	d.setETag(ctx, delivery)
+	ctx.JSON(http.StatusOK, delivery)
+}
+
+// GetAttempts godoc
+// @Summary      failed delivery attempts
+// @Description  Get the failed attempts of the delivery, with the visibility rules of getting one delivery.
+// @Produce      json
+// @Param 		 Authorization  header    string  true  "Authentication header. Usage 'Bearer {token}' or 'ApiKey {key}' with deliveries:read"
+// @Param 		 id  			path	integer	true	"delivery id"
+// @Success      200  {array}  entities.Attempt
+// @Failure      400  {object}  object{error=string}
+// @Failure      401  {object}  object{error=string}
+// @Failure      403  {object}  object{error=string}
+// @Failure      404  {object}  object{error=string}
+// @Router       /deliveries/{id}/attempts [get]
+func (d *Delivery) GetAttempts(ctx *gin.Context) {
+	attempts, ok := d.getVisibleAttempts(ctx)
+	if !ok {
+		return
+	}
+	ctx.JSON(http.StatusOK, attempts)
+}
+
+// GetAttemptPhoto godoc
+// @Summary      failed delivery attempt photo
+// @Description  Download the photo of a failed attempt, with the visibility rules of getting one delivery.
+// @Produce      png,jpeg,image/webp
+// @Param 		 Authorization  header    string  true  "Authentication header. Usage 'Bearer {token}' or 'ApiKey {key}' with deliveries:read"
+// @Param 		 id  			path	integer	true	"delivery id"
+// @Param 		 attemptId  	path	integer	true	"attempt id"
+// @Success      200  {file}  binary
+// @Failure      400  {object}  object{error=string}
+// @Failure      401  {object}  object{error=string}
+// @Failure      403  {object}  object{error=string}
+// @Failure      404  {object}  object{error=string}
+// @Router       /deliveries/{id}/attempts/{attemptId}/photo [get]
+func (d *Delivery) GetAttemptPhoto(ctx *gin.Context) {
+	attemptId, ok := d.getUintParam(ctx, "attemptId")
+	if !ok {
+		return
+	}
+	attempts, ok := d.getVisibleAttempts(ctx)
+	if !ok {
+		return
+	}
+	var photo *entities.ProofFile
+	for _, attempt := range attempts {
+		if attempt.Id == attemptId {
+			photo = attempt.Photo
+		}
+	}
+	if photo == nil {
+		ctx.AbortWithStatusJSON(http.StatusNotFound, httpLib.NotFound())
+		return
+	}
+	content, err := d.proofs.Open(ctx, photo)
+	if errors.Is(err, repositories.ErrBlobNotFound) {
+		ctx.AbortWithStatusJSON(http.StatusNotFound, httpLib.NotFound())
+		return
+	}
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, httpLib.InternalServErr(err.Error()))
+		return
+	}
+	defer content.Close()
+	ctx.DataFromReader(http.StatusOK, photo.Size, photo.ContentType, content, nil)
+}
+
+// Reschedule godoc
+// @Summary      reschedule delivery
+// @Description  Move the delivery to a new time window. Only the recipient have permission, while the delivery is
+// @Description  created or after a failed attempt. A delivery with a failed attempt goes back to dispatch.
+// @Description  The window is booked in the given zone or, when it is left out, in the zone of the delivery; a delivery
+// @Description  priced by a quote can not move to another zone.
+// @Accept 		 json
+// @Produce      json
+// @Param 		 Authorization  header    string  true  "Authentication header. Usage 'Bearer {token}' or 'ApiKey {key}' with deliveries:update"
+// @Param 		 If-Match  		header    string  false  "expected delivery ETag"
+// @Param 		 Idempotency-Key  header    string  false  "unique request key, repeated requests replay the first response"
+// @Param 		 id  			path	integer	true	"delivery id"
+// @Param        message  body  dto.Reschedule  true  "new time window"
+// @Success      200  {object}  entities.Delivery
+// @Header       200  {string}  ETag  "delivery version"
+// @Failure      400  {object}  object{error=string}
+// @Failure      401  {object}  object{error=string}
+// @Failure      403  {object}  object{error=string}
+// @Failure      404  {object}  object{error=string}
+// @Failure      409  {object}  object{error=string}
+// @Failure      412  {object}  object{error=string}
+// @Router       /deliveries/{id}/reschedule [put]
+func (d *Delivery) Reschedule(ctx *gin.Context) {
+	u, ok := d.getUser(ctx)
+	if !ok {
+		return
+	}
+	id, ok := d.getUintParam(ctx, "id")
+	if !ok {
+		return
+	}
+	version, ok := d.getIfMatch(ctx)
+	if !ok {
+		return
+	}
+	reschedule := &dto.Reschedule{}
+	if err := ctx.ShouldBindJSON(reschedule); err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, httpLib.BadRequest(err.Error()))
+		return
+	}
+	window, err := valueobjects.NewTimeWindow(reschedule.WindowStart, reschedule.WindowEnd)
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, httpLib.BadRequest(err.Error()))
+		return
+	}
+
+	delivery, err := d.srv.Reschedule(ctx, id, u, reschedule.Zone, window, version)
+	if err != nil {
+		if d.abortOnConflict(ctx, err, version) {
+			return
+		}
+		switch {
+		case errors.Is(err, repositories.ErrDeliveryNotFound):
+			ctx.AbortWithStatusJSON(http.StatusNotFound, httpLib.NotFound())
+		case errors.Is(err, services.ErrNotRecipient):
+			ctx.AbortWithStatusJSON(http.StatusForbidden, httpLib.Forbidden())
+		case errors.Is(err, services.ErrNotReschedulable), errors.Is(err, services.ErrSlotFull):
+			ctx.AbortWithStatusJSON(http.StatusConflict, httpLib.Conflict(err.Error()))
+		default:
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, httpLib.BadRequest(err.Error()))
+		}
+		return
+	}
+
+	d.setETag(ctx, delivery)
+	ctx.JSON(http.StatusOK, delivery)
+}
+
+// getVisibleAttempts returns the attempts of the delivery in the id param
+// when the user may see the delivery.
+func (d *Delivery) getVisibleAttempts(ctx *gin.Context) ([]*entities.Attempt, bool) {
+	user, ok := d.getUser(ctx)
+	if !ok {
+		return nil, false
+	}
+	id, ok := d.getUintParam(ctx, "id")
+	if !ok {
+		return nil, false
+	}
+	delivery, err := d.srv.GetOne(ctx, id)
+	if errors.Is(err, repositories.ErrDeliveryNotFound) {
+		ctx.AbortWithStatusJSON(http.StatusNotFound, httpLib.NotFound())
+		return nil, false
+	}
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, httpLib.InternalServErr(err.Error()))
+		return nil, false
+	}
+	if !d.canSee(user, delivery) {
+		ctx.AbortWithStatusJSON(http.StatusForbidden, httpLib.Forbidden())
+		return nil, false
+	}
+	attempts, err := d.srv.GetAttempts(ctx, id)
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, httpLib.InternalServErr(err.Error()))
+		return nil, false
+	}
+	return attempts, true
+}
+
+func (d *Delivery) uploadAttemptPhoto(ctx *gin.Context, deliveryId uint) (*entities.ProofFile, bool) {
+	header, err := ctx.FormFile("photo")
+	if errors.Is(err, http.ErrMissingFile) {
+		return nil, true
+	}
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, httpLib.BadRequest(err.Error()))
+		return nil, false
+	}
+	f, err := header.Open()
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, httpLib.BadRequest(err.Error()))
+		return nil, false
+	}
+	defer f.Close()
+	photo, err := d.proofs.UploadPhoto(ctx, deliveryId, f)
+	if errors.Is(err, services.ErrInvalidProof) {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, httpLib.BadRequest(err.Error()))
+		return nil, false
+	}
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, httpLib.InternalServErr(err.Error()))
+		return nil, false
+	}
+	return photo, true
+}
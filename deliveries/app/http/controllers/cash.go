@@ -0,0 +1,184 @@
+package controllers
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/zhanbolat18/parcel/deliveries/app/dto"
+	"github.com/zhanbolat18/parcel/deliveries/internal/entities"
+	"github.com/zhanbolat18/parcel/deliveries/internal/repositories"
+	"github.com/zhanbolat18/parcel/deliveries/internal/services"
+	"github.com/zhanbolat18/parcel/deliveries/internal/valueobjects"
+	httpLib "github.com/zhanbolat18/parcel/libs/http"
+	"net/http"
+	"strconv"
+)
+
+type Cash struct {
+	srv *services.ManageCash
+}
+
+func NewCashController(srv *services.ManageCash) *Cash {
+	return &Cash{srv: srv}
+}
+
+// MyLedger godoc
+// @Summary      courier cash ledger
+// @Description  get the cash held by the authenticated courier and the ledger entries behind it. Only courier have permission.
+// @Produce      json
+// @Param 		 Authorization  header    string  true  "Authentication header. Usage 'Bearer {token}'"
+// @Success      200  {object}  entities.CashLedger
+// @Failure      401  {object}  object{error=string}
+// @Failure      403  {object}  object{error=string}
+// @Router       /couriers/me/cash [get]
+func (c *Cash) MyLedger(ctx *gin.Context) {
+	u := ctx.MustGet("user").(*entities.User)
+	ledger, err := c.srv.Ledger(ctx, u.Id)
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, httpLib.InternalServErr(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusOK, ledger)
+}
+
+// Ledger godoc
+// @Summary      courier cash ledger
+// @Description  get the cash held by the courier and the ledger entries behind it. Only admin have permission.
+// @Produce      json
+// @Param 		 Authorization  header    string  true  "Authentication header. Usage 'Bearer {token}'"
+// @Param 		 id  			path	integer	true	"courier id"
+// @Success      200  {object}  entities.CashLedger
+// @Failure      400  {object}  object{error=string}
+// @Failure      401  {object}  object{error=string}
+// @Failure      403  {object}  object{error=string}
+// @Router       /couriers/{id}/cash [get]
+func (c *Cash) Ledger(ctx *gin.Context) {
+	id, ok := c.getCourierId(ctx)
+	if !ok {
+		return
+	}
+	ledger, err := c.srv.Ledger(ctx, id)
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, httpLib.InternalServErr(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusOK, ledger)
+}
+
+// Handover godoc
+// @Summary      record cash handover
+// @Description  record the cash the courier handed over, it can not exceed the cash the courier holds. Only admin have permission.
+// @Accept 		 json
+// @Produce      json
+// @Param 		 Authorization  header    string  true  "Authentication header. Usage 'Bearer {token}'"
+// @Param 		 id  			path	integer	true	"courier id"
+// @Param        message  body  dto.CashHandover  true  "cash handed over"
+// @Success      200  {object}  entities.CashEntry
+// @Failure      400  {object}  object{error=string}
+// @Failure      401  {object}  object{error=string}
+// @Failure      403  {object}  object{error=string}
+// @Failure      409  {object}  object{error=string}
+// @Router       /couriers/{id}/cash/handovers [post]
+func (c *Cash) Handover(ctx *gin.Context) {
+	u := ctx.MustGet("user").(*entities.User)
+	id, ok := c.getCourierId(ctx)
+	if !ok {
+		return
+	}
+	req := &dto.CashHandover{}
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, httpLib.BadRequest(err.Error()))
+		return
+	}
+	amount, err := valueobjects.ParseMoney(req.Amount)
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, httpLib.BadRequest(err.Error()))
+		return
+	}
+	entry, err := c.srv.Handover(ctx, id, u, amount, req.Note)
+	c.respond(ctx, entry, err)
+}
+
+// Reconcile godoc
+// @Summary      reconcile courier cash
+// @Description  record the cash counted at the courier. The entry brings the ledger balance to the counted cash, its
+// @Description  amount is the discrepancy. Only admin have permission.
+// @Accept 		 json
+// @Produce      json
+// @Param 		 Authorization  header    string  true  "Authentication header. Usage 'Bearer {token}'"
+// @Param 		 id  			path	integer	true	"courier id"
+// @Param        message  body  dto.CashReconciliation  true  "cash counted"
+// @Success      200  {object}  entities.CashEntry
+// @Failure      400  {object}  object{error=string}
+// @Failure      401  {object}  object{error=string}
+// @Failure      403  {object}  object{error=string}
+// @Failure      409  {object}  object{error=string}
+// @Router       /couriers/{id}/cash/reconciliations [post]
+func (c *Cash) Reconcile(ctx *gin.Context) {
+	u := ctx.MustGet("user").(*entities.User)
+	id, ok := c.getCourierId(ctx)
+	if !ok {
+		return
+	}
+	req := &dto.CashReconciliation{}
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, httpLib.BadRequest(err.Error()))
+		return
+	}
+	counted, err := valueobjects.ParseMoney(req.Counted)
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, httpLib.BadRequest(err.Error()))
+		return
+	}
+	entry, err := c.srv.Reconcile(ctx, id, u, counted, req.Note)
+	c.respond(ctx, entry, err)
+}
+
+// Discrepancies godoc
+// @Summary      cash discrepancies
+// @Description  get the reconciliations that found other cash than the ledger balance. Only admin have permission.
+// @Produce      json
+// @Param 		 Authorization  header    string  true  "Authentication header. Usage 'Bearer {token}'"
+// @Param 		 courier_id  	query	integer	false	"only discrepancies of the courier"
+// @Success      200  {array}  entities.CashEntry
+// @Failure      400  {object}  object{error=string}
+// @Failure      401  {object}  object{error=string}
+// @Failure      403  {object}  object{error=string}
+// @Router       /cash/discrepancies [get]
+func (c *Cash) Discrepancies(ctx *gin.Context) {
+	var courierId uint64
+	if q := ctx.Query("courier_id"); q != "" {
+		var err error
+		if courierId, err = strconv.ParseUint(q, 10, 64); err != nil {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, httpLib.BadRequest("invalid courier_id"))
+			return
+		}
+	}
+	entries, err := c.srv.Discrepancies(ctx, uint(courierId))
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, httpLib.InternalServErr(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusOK, entries)
+}
+
+func (c *Cash) respond(ctx *gin.Context, entry *entities.CashEntry, err error) {
+	switch {
+	case errors.Is(err, services.ErrInvalidCashAmount):
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, httpLib.BadRequest(err.Error()))
+	case errors.Is(err, services.ErrInsufficientCash), errors.Is(err, repositories.ErrConcurrentModification):
+		ctx.AbortWithStatusJSON(http.StatusConflict, httpLib.Conflict(err.Error()))
+	case err != nil:
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, httpLib.InternalServErr(err.Error()))
+	default:
+		ctx.JSON(http.StatusOK, entry)
+	}
+}
+
+func (c *Cash) getCourierId(ctx *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, httpLib.BadRequest("invalid id"))
+		return 0, false
+	}
+	return uint(id), true
+}
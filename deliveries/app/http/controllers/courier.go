@@ -0,0 +1,98 @@
+package controllers
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/zhanbolat18/parcel/deliveries/app/dto"
+	"github.com/zhanbolat18/parcel/deliveries/internal/entities"
+	"github.com/zhanbolat18/parcel/deliveries/internal/services"
+	"github.com/zhanbolat18/parcel/deliveries/internal/valueobjects"
+	httpLib "github.com/zhanbolat18/parcel/libs/http"
+	"net/http"
+	"strconv"
+)
+
+type Courier struct {
+	srv *services.ManageCourier
+}
+
+func NewCourierController(srv *services.ManageCourier) *Courier {
+	return &Courier{srv: srv}
+}
+
+// MyAvailability godoc
+// @Summary      courier availability
+// @Description  get availability of the authenticated courier. Only courier have permission.
+// @Produce      json
+// @Param 		 Authorization  header    string  true  "Authentication header. Usage 'Bearer {token}'"
+// @Success      200  {object}  entities.CourierAvailability
+// @Failure      401  {object}  object{error=string}
+// @Failure      403  {object}  object{error=string}
+// @Router       /couriers/me/availability [get]
+func (c *Courier) MyAvailability(ctx *gin.Context) {
+	u := ctx.MustGet("user").(*entities.User)
+	a, err := c.srv.Availability(ctx, u.Id)
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, httpLib.InternalServErr(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusOK, a)
+}
+
+// SetMyAvailability godoc
+// @Summary      set courier availability
+// @Description  switch the authenticated courier online, offline or on break. Only courier have permission.
+// @Accept 		 json
+// @Produce      json
+// @Param 		 Authorization  header    string  true  "Authentication header. Usage 'Bearer {token}'"
+// @Param        message  body  dto.Availability  true  "availability status"
+// @Success      200  {object}  entities.CourierAvailability
+// @Failure      400  {object}  object{error=string}
+// @Failure      401  {object}  object{error=string}
+// @Failure      403  {object}  object{error=string}
+// @Router       /couriers/me/availability [put]
+func (c *Courier) SetMyAvailability(ctx *gin.Context) {
+	u := ctx.MustGet("user").(*entities.User)
+	req := &dto.Availability{}
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, httpLib.BadRequest(err.Error()))
+		return
+	}
+	a, err := c.srv.SetStatus(ctx, u.Id, valueobjects.Availability(req.Status))
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, httpLib.InternalServErr(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusOK, a)
+}
+
+// SetCapacity godoc
+// @Summary      set courier capacity
+// @Description  set how many deliveries the courier may deliver at once. Only admin have permission.
+// @Accept 		 json
+// @Produce      json
+// @Param 		 Authorization  header    string  true  "Authentication header. Usage 'Bearer {token}'"
+// @Param 		 id  			path	integer	true	"courier id"
+// @Param        message  body  dto.Capacity  true  "max active deliveries"
+// @Success      200  {object}  entities.CourierAvailability
+// @Failure      400  {object}  object{error=string}
+// @Failure      401  {object}  object{error=string}
+// @Failure      403  {object}  object{error=string}
+// @Router       /couriers/{id}/capacity [put]
+func (c *Courier) SetCapacity(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, httpLib.BadRequest("invalid id"))
+		return
+	}
+	req := &dto.Capacity{}
+	if err = ctx.ShouldBindJSON(req); err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, httpLib.BadRequest(err.Error()))
+		return
+	}
+	a, err := c.srv.SetMaxActiveDeliveries(ctx, uint(id), req.MaxActiveDeliveries)
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, httpLib.InternalServErr(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusOK, a)
+}
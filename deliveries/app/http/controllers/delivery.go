@@ -1,35 +1,47 @@
 package controllers
 
 import (
+	"errors"
 	"fmt"
 	"github.com/gin-gonic/gin"
 	"github.com/zhanbolat18/parcel/deliveries/app/dto"
 	"github.com/zhanbolat18/parcel/deliveries/internal/entities"
+	"github.com/zhanbolat18/parcel/deliveries/internal/repositories"
 	"github.com/zhanbolat18/parcel/deliveries/internal/services"
+	"github.com/zhanbolat18/parcel/deliveries/internal/valueobjects"
 	httpLib "github.com/zhanbolat18/parcel/libs/http"
+	"mime/multipart"
 	"net/http"
 	"strconv"
+	"strings"
 )
 
 type Delivery struct {
-	srv *services.ManageDelivery
+	srv    *services.ManageDelivery
+	proofs *services.ManageProof
 }
 
-func NewDeliveryController(srv *services.ManageDelivery) *Delivery {
-	return &Delivery{srv: srv}
+func NewDeliveryController(srv *services.ManageDelivery, proofs *services.ManageProof) *Delivery {
+	return &Delivery{srv: srv, proofs: proofs}
 }
 
 // Create godoc
 // @Summary      create delivery order
 // @Description  create delivery order with required destination. Only user have permission.
+// @Description  The optional window must lie within one bookable slot of the zone, see GET /slots.
+// @Description  The service level sets when the delivery is due. A quote_id from POST /quotes locks its price onto the delivery.
+// @Description  A cod_amount makes the delivery cash on delivery, collected by the courier on handover.
 // @Accept 		 json
 // @Produce      json
-// @Param 		 Authorization  header    string  true  "Authentication header. Usage 'Bearer {token}'"
+// @Param 		 Authorization  header    string  true  "Authentication header. Usage 'Bearer {token}' or 'ApiKey {key}' with deliveries:create"
 // @Param        message  body  dto.Destination  true  "destination info"
+// @Param 		 Idempotency-Key  header    string  false  "unique request key, repeated requests replay the first response"
 // @Success      200  {object}  entities.Delivery
 // @Failure      400  {object}  object{error=string}
 // @Failure      401  {object}  object{error=string}
 // @Failure      403  {object}  object{error=string}
+// @Failure      409  {object}  object{error=string}
+// @Failure      422  {object}  object{error=string}
 // @Router       /deliveries [post]
 func (d *Delivery) Create(ctx *gin.Context) {
 	u, ok := d.getUser(ctx)
@@ -42,7 +54,41 @@ func (d *Delivery) Create(ctx *gin.Context) {
 		ctx.AbortWithStatusJSON(http.StatusBadRequest, httpLib.BadRequest(err.Error()))
 		return
 	}
-	delivery, err := d.srv.Create(ctx, u, dest.D)
+	req := services.CreateDelivery{Origin: strings.TrimSpace(dest.Origin), Destination: dest.D}
+	req.Zone, req.ScheduledFor = dest.Zone, dest.ScheduledFor
+	req.ServiceLevel = valueobjects.ServiceLevel(dest.ServiceLevel)
+	req.QuoteId = strings.TrimSpace(dest.QuoteId)
+	if dest.CodAmount != "" {
+		cod, err := valueobjects.ParseMoney(dest.CodAmount)
+		if err != nil {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, httpLib.BadRequest(err.Error()))
+			return
+		}
+		req.COD = &cod
+	}
+	if dest.Latitude != nil && dest.Longitude != nil {
+		req.Location = &valueobjects.Location{Latitude: *dest.Latitude, Longitude: *dest.Longitude}
+	}
+	if dest.OriginLatitude != nil && dest.OriginLongitude != nil {
+		req.OriginLocation = &valueobjects.Location{Latitude: *dest.OriginLatitude, Longitude: *dest.OriginLongitude}
+	}
+	if dest.WindowStart != nil && dest.WindowEnd != nil {
+		window, err := valueobjects.NewTimeWindow(*dest.WindowStart, *dest.WindowEnd)
+		if err != nil {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, httpLib.BadRequest(err.Error()))
+			return
+		}
+		req.Window = window
+	}
+	delivery, err := d.srv.Create(ctx, u, req)
+	switch {
+	case errors.Is(err, services.ErrSlotFull):
+		ctx.AbortWithStatusJSON(http.StatusConflict, httpLib.Conflict(err.Error()))
+		return
+	case errors.Is(err, services.ErrInvalidQuote), errors.Is(err, services.ErrQuoteExpired):
+		ctx.AbortWithStatusJSON(http.StatusUnprocessableEntity, httpLib.UnprocessableEntity(err.Error()))
+		return
+	}
 	if err != nil {
 		ctx.AbortWithStatusJSON(http.StatusBadRequest, httpLib.BadRequest(err.Error()))
 		return
@@ -51,17 +97,83 @@ func (d *Delivery) Create(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, delivery)
 }
 
+// Return godoc
+// @Summary      return delivery
+// @Description  Create the return of a completed delivery, picked up at its destination and dropped off at its origin.
+// @Description  Only the recipient have permission, within RETURN_WINDOW after completion and once per delivery.
+// @Accept 		 json
+// @Produce      json
+// @Param 		 Authorization  header    string  true  "Authentication header. Usage 'Bearer {token}' or 'ApiKey {key}' with deliveries:create"
+// @Param 		 Idempotency-Key  header    string  false  "unique request key, repeated requests replay the first response"
+// @Param 		 id  			path	integer	true	"delivery id"
+// @Param        message  body  dto.Return  false  "optional pickup time window"
+// @Success      200  {object}  entities.Delivery
+// @Failure      400  {object}  object{error=string}
+// @Failure      401  {object}  object{error=string}
+// @Failure      403  {object}  object{error=string}
+// @Failure      404  {object}  object{error=string}
+// @Failure      409  {object}  object{error=string}
+// @Router       /deliveries/{id}/return [post]
+func (d *Delivery) Return(ctx *gin.Context) {
+	u, ok := d.getUser(ctx)
+	if !ok {
+		return
+	}
+	id, ok := d.getUintParam(ctx, "id")
+	if !ok {
+		return
+	}
+	ret := &dto.Return{}
+	if ctx.Request.ContentLength != 0 {
+		if err := ctx.ShouldBindJSON(ret); err != nil {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, httpLib.BadRequest(err.Error()))
+			return
+		}
+	}
+	req := services.CreateReturn{}
+	if ret.WindowStart != nil && ret.WindowEnd != nil {
+		window, err := valueobjects.NewTimeWindow(*ret.WindowStart, *ret.WindowEnd)
+		if err != nil {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, httpLib.BadRequest(err.Error()))
+			return
+		}
+		req.Window = window
+	}
+
+	delivery, err := d.srv.Return(ctx, id, u, req)
+	switch {
+	case errors.Is(err, repositories.ErrDeliveryNotFound):
+		ctx.AbortWithStatusJSON(http.StatusNotFound, httpLib.NotFound())
+	case errors.Is(err, services.ErrNotRecipient):
+		ctx.AbortWithStatusJSON(http.StatusForbidden, httpLib.Forbidden())
+	case errors.Is(err, services.ErrNotReturnable), errors.Is(err, services.ErrSlotFull):
+		ctx.AbortWithStatusJSON(http.StatusConflict, httpLib.Conflict(err.Error()))
+	case errors.Is(err, services.ErrInvalidSchedule):
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, httpLib.BadRequest(err.Error()))
+	case err != nil:
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, httpLib.InternalServErr(err.Error()))
+	default:
+		ctx.JSON(http.StatusOK, delivery)
+	}
+}
+
 // AssignToCourier godoc
 // @Summary      assign to courier
 // @Description  assign to courier the delivery order. Only admin have permission.
 // @Produce      json
 // @Param 		 Authorization  header    string  true  "Authentication header. Usage 'Bearer {token}'"
+// @Param 		 If-Match  		header    string  false  "expected delivery ETag"
+// @Param 		 Idempotency-Key  header    string  false  "unique request key, repeated requests replay the first response"
 // @Param 		 id  			path	integer	true	"delivery id"
 // @Param 		 courierId  	path	integer	true	"courier id"
 // @Success      200  {object}  entities.Delivery
+// @Header       200  {string}  ETag  "delivery version"
 // @Failure      400  {object}  object{error=string}
 // @Failure      401  {object}  object{error=string}
 // @Failure      403  {object}  object{error=string}
+// @Failure      409  {object}  object{error=string}
+// @Failure      412  {object}  object{error=string}
+// @Failure      422  {object}  object{error=string}
 // @Router       /deliveries/{id}/courier/{courierId} [post]
 func (d *Delivery) AssignToCourier(ctx *gin.Context) {
 	id, ok := d.getUintParam(ctx, "id")
@@ -72,12 +184,20 @@ func (d *Delivery) AssignToCourier(ctx *gin.Context) {
 	if !ok {
 		return
 	}
-	delivery, err := d.srv.AssignToCourier(ctx, id, courierId)
+	version, ok := d.getIfMatch(ctx)
+	if !ok {
+		return
+	}
+	delivery, err := d.srv.AssignToCourier(ctx, id, courierId, version)
 	if err != nil {
+		if d.abortOnConflict(ctx, err, version) {
+			return
+		}
 		ctx.AbortWithStatusJSON(http.StatusBadRequest, httpLib.BadRequest(err.Error()))
 		return
 	}
 
+	d.setETag(ctx, delivery)
 	ctx.JSON(http.StatusOK, delivery)
 }
 
@@ -85,8 +205,10 @@ func (d *Delivery) AssignToCourier(ctx *gin.Context) {
 // @Summary      fetch all deliveries
 // @Description  Fetch all deliveries. Only admin have permission to see all.
 // @Description  If endpoint called with courier, only assigned deliveries returned
+// @Description  If endpoint called with user, own deliveries returned with the handover codes of those being delivered
 // @Produce      json
-// @Param 		 Authorization  header    string  true  "Authentication header. Usage 'Bearer {token}'"
+// @Param 		 Authorization  header    string  true  "Authentication header. Usage 'Bearer {token}' or 'ApiKey {key}' with deliveries:read"
+// @Param 		 sla  query  string  false  "only deliveries of the SLA status"  Enums(on_track, at_risk, breached)
 // @Success      200  {array}  []entities.Delivery
 // @Failure      400  {object}  object{error=string}
 // @Failure      401  {object}  object{error=string}
@@ -97,22 +219,28 @@ func (d *Delivery) GetAllDeliveries(ctx *gin.Context) {
 	if !ok {
 		return
 	}
+	filter := repositories.DeliveryFilter{SLA: valueobjects.SLAStatus(ctx.Query("sla"))}
+	if filter.SLA != "" && !filter.SLA.Valid() {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, httpLib.BadRequest("invalid sla"))
+		return
+	}
 
 	var deliveries []*entities.Delivery
 	var err error
 
 	switch user.Role {
 	case "admin":
-		deliveries, err = d.srv.GetAll(ctx)
+		deliveries, err = d.srv.GetAll(ctx, filter)
 	case "courier":
-		deliveries, err = d.srv.GetAllByCourier(ctx, user)
+		deliveries, err = d.srv.GetAllByCourier(ctx, user, filter)
+	case "user":
+		deliveries, err = d.srv.GetAllByRecipient(ctx, user, filter)
 	}
 
 	if err != nil {
 		ctx.AbortWithStatusJSON(http.StatusInternalServerError, httpLib.InternalServErr(err.Error()))
 		return
 	}
-
 	ctx.JSON(http.StatusOK, deliveries)
 }
 
@@ -120,13 +248,16 @@ func (d *Delivery) GetAllDeliveries(ctx *gin.Context) {
 // @Summary      get one delivery
 // @Description  Get one delivery. Only admin have permission to see all.
 // @Description  If endpoint called with courier, only assigned deliveries returned
+// @Description  If endpoint called with user, only own deliveries returned, with the handover code while being delivered
 // @Produce      json
-// @Param 		 Authorization  header    string  true  "Authentication header. Usage 'Bearer {token}'"
+// @Param 		 Authorization  header    string  true  "Authentication header. Usage 'Bearer {token}' or 'ApiKey {key}' with deliveries:read"
 // @Param 		 id  			path	integer	true	"delivery id"
 // @Success      200  {array}  entities.Delivery
+// @Header       200  {string}  ETag  "delivery version"
 // @Failure      400  {object}  object{error=string}
 // @Failure      401  {object}  object{error=string}
 // @Failure      403  {object}  object{error=string}
+// @Failure      404  {object}  object{error=string}
 // @Router       /deliveries/{id} [get]
 func (d *Delivery) GetOneDelivery(ctx *gin.Context) {
 	user, ok := d.getUser(ctx)
@@ -139,29 +270,60 @@ func (d *Delivery) GetOneDelivery(ctx *gin.Context) {
 	}
 
 	delivery, err := d.srv.GetOne(ctx, id)
+	if errors.Is(err, repositories.ErrDeliveryNotFound) {
+		ctx.AbortWithStatusJSON(http.StatusNotFound, httpLib.NotFound())
+		return
+	}
 	if err != nil {
 		ctx.AbortWithStatusJSON(http.StatusInternalServerError, httpLib.InternalServErr(err.Error()))
 		return
 	}
 
-	if user.Role == "courier" && (delivery.CourierId == nil || *delivery.CourierId != user.Id) {
+	if !d.canSee(user, delivery) {
 		ctx.AbortWithStatusJSON(http.StatusForbidden, httpLib.Forbidden())
 		return
 	}
+	if user.Role == "user" {
+		d.srv.RevealHandoverCode(delivery)
+	}
 
+	d.setETag(ctx, delivery)
 	ctx.JSON(http.StatusOK, delivery)
 }
 
 // CompleteDelivery godoc
 // @Summary      complete delivery
 // @Description  Complete delivery. Only assigned courier have permission.
+// @Description  The handover code shown to the recipient is required, either as a JSON body or a form field. After too
+// @Description  many wrong codes the handover is locked and only an admin can complete the delivery.
+// @Description  A multipart/form-data request attaches the proof of delivery: the signature image, photos, the name of
+// @Description  the receiver and the coordinates of the handover.
+// @Description  Cash on delivery requires the collected amount, equal to cod_amount, and the payment method.
+// @Accept 		 json,mpfd
 // @Produce      json
 // @Param 		 Authorization  header    string  true  "Authentication header. Usage 'Bearer {token}'"
+// @Param 		 If-Match  		header    string  false  "expected delivery ETag"
+// @Param 		 Idempotency-Key  header    string  false  "unique request key, repeated requests replay the first response"
 // @Param 		 id  			path	integer	true	"delivery id"
+// @Param        message  body  dto.Completion  false  "handover code, when not sent as a form field"
+// @Param 		 handover_code	formData	string	false	"handover code"
+// @Param 		 received_by	formData	string	false	"name of the person who received the delivery"
+// @Param 		 latitude		formData	number	false	"latitude of the handover"
+// @Param 		 longitude		formData	number	false	"longitude of the handover"
+// @Param 		 collected_amount	formData	string	false	"amount collected on cash on delivery"
+// @Param 		 payment_method	formData	string	false	"payment method of cash on delivery"  Enums(cash, card)
+// @Param 		 signature		formData	file	false	"signature image"
+// @Param 		 photos			formData	file	false	"photos, the field may repeat"
 // @Success      200  {object}  entities.Delivery
+// @Header       200  {string}  ETag  "delivery version"
 // @Failure      400  {object}  object{error=string}
 // @Failure      401  {object}  object{error=string}
 // @Failure      403  {object}  object{error=string}
+// @Failure      404  {object}  object{error=string}
+// @Failure      409  {object}  object{error=string}
+// @Failure      412  {object}  object{error=string}
+// @Failure      415  {object}  object{error=string}
+// @Failure      422  {object}  object{error=string}
 // @Router       /deliveries/{id}/complete [put]
 func (d *Delivery) CompleteDelivery(ctx *gin.Context) {
 	u, ok := d.getUser(ctx)
@@ -172,17 +334,228 @@ func (d *Delivery) CompleteDelivery(ctx *gin.Context) {
 	if !ok {
 		return
 	}
+	version, ok := d.getIfMatch(ctx)
+	if !ok {
+		return
+	}
 
-	delivery, err := d.srv.Complete(ctx, uint(id), u)
+	req := services.CompleteDelivery{ExpectedVersion: version}
+	switch ctx.ContentType() {
+	case "multipart/form-data":
+		if req.Proof, ok = d.uploadProof(ctx, id); !ok {
+			return
+		}
+		req.HandoverCode = ctx.PostForm("handover_code")
+		if req.Collection, ok = d.getCollection(ctx, ctx.PostForm("collected_amount"), ctx.PostForm("payment_method")); !ok {
+			if req.Proof != nil {
+				d.proofs.Discard(ctx, req.Proof)
+			}
+			return
+		}
+	case "application/json":
+		completion := &dto.Completion{}
+		if err := ctx.ShouldBindJSON(completion); err != nil {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, httpLib.BadRequest(err.Error()))
+			return
+		}
+		req.HandoverCode = completion.HandoverCode
+		if req.Collection, ok = d.getCollection(ctx, completion.CollectedAmount, completion.PaymentMethod); !ok {
+			return
+		}
+	default:
+		// an empty handover code would count as a wrong attempt
+		ctx.AbortWithStatusJSON(http.StatusUnsupportedMediaType,
+			httpLib.UnsupportedMediaType("use application/json or multipart/form-data"))
+		return
+	}
+
+	delivery, err := d.srv.Complete(ctx, id, u, req)
 	if err != nil {
+		if req.Proof != nil {
+			d.proofs.Discard(ctx, req.Proof)
+		}
+		if d.abortOnConflict(ctx, err, version) {
+			return
+		}
+		if errors.Is(err, repositories.ErrDeliveryNotFound) {
+			ctx.AbortWithStatusJSON(http.StatusNotFound, httpLib.NotFound())
+			return
+		}
+		if errors.Is(err, services.ErrWrongHandoverCode) || errors.Is(err, services.ErrInvalidCollection) {
+			ctx.AbortWithStatusJSON(http.StatusUnprocessableEntity, httpLib.UnprocessableEntity(err.Error()))
+			return
+		}
 		fmt.Println(err)
 		ctx.AbortWithStatusJSON(http.StatusInternalServerError, httpLib.InternalServErr(err.Error()))
 		return
 	}
 
+	d.setETag(ctx, delivery)
 	ctx.JSON(http.StatusOK, delivery)
 }
 
+// OverrideCompletion godoc
+// @Summary      complete delivery without handover code
+// @Description  Complete delivery without the handover code, e.g. once the handover is locked. Only admin have
+// @Description  permission. The override is recorded in the audit trail of the delivery with its reason.
+// @Description  The cash on delivery collected is credited to the assigned courier.
+// @Accept 		 json
+// @Produce      json
+// @Param 		 Authorization  header    string  true  "Authentication header. Usage 'Bearer {token}'"
+// @Param 		 If-Match  		header    string  false  "expected delivery ETag"
+// @Param 		 Idempotency-Key  header    string  false  "unique request key, repeated requests replay the first response"
+// @Param 		 id  			path	integer	true	"delivery id"
+// @Param        message  body  dto.Override  true  "reason of the override and the cash on delivery collected"
+// @Success      200  {object}  entities.Delivery
+// @Header       200  {string}  ETag  "delivery version"
+// @Failure      400  {object}  object{error=string}
+// @Failure      401  {object}  object{error=string}
+// @Failure      403  {object}  object{error=string}
+// @Failure      404  {object}  object{error=string}
+// @Failure      409  {object}  object{error=string}
+// @Failure      412  {object}  object{error=string}
+// @Failure      422  {object}  object{error=string}
+// @Router       /deliveries/{id}/complete/override [put]
+func (d *Delivery) OverrideCompletion(ctx *gin.Context) {
+	u, ok := d.getUser(ctx)
+	if !ok {
+		return
+	}
+	id, ok := d.getUintParam(ctx, "id")
+	if !ok {
+		return
+	}
+	version, ok := d.getIfMatch(ctx)
+	if !ok {
+		return
+	}
+	override := &dto.Override{}
+	if err := ctx.ShouldBindJSON(override); err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, httpLib.BadRequest(err.Error()))
+		return
+	}
+
+	collection, ok := d.getCollection(ctx, override.CollectedAmount, override.PaymentMethod)
+	if !ok {
+		return
+	}
+
+	delivery, err := d.srv.CompleteByAdmin(ctx, id, u, override.Reason, collection, version)
+	if err != nil {
+		if d.abortOnConflict(ctx, err, version) {
+			return
+		}
+		if errors.Is(err, repositories.ErrDeliveryNotFound) {
+			ctx.AbortWithStatusJSON(http.StatusNotFound, httpLib.NotFound())
+			return
+		}
+		if errors.Is(err, services.ErrInvalidCollection) {
+			ctx.AbortWithStatusJSON(http.StatusUnprocessableEntity, httpLib.UnprocessableEntity(err.Error()))
+			return
+		}
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, httpLib.BadRequest(err.Error()))
+		return
+	}
+
+	d.setETag(ctx, delivery)
+	ctx.JSON(http.StatusOK, delivery)
+}
+
+// GetAudit godoc
+// @Summary      delivery audit trail
+// @Description  Get the audit trail of the delivery: failed handover codes, locks and admin overrides. Only admin have
+// @Description  permission.
+// @Produce      json
+// @Param 		 Authorization  header    string  true  "Authentication header. Usage 'Bearer {token}'"
+// @Param 		 id  			path	integer	true	"delivery id"
+// @Success      200  {array}  entities.AuditEntry
+// @Failure      400  {object}  object{error=string}
+// @Failure      401  {object}  object{error=string}
+// @Failure      403  {object}  object{error=string}
+// @Failure      404  {object}  object{error=string}
+// @Router       /deliveries/{id}/audit [get]
+func (d *Delivery) GetAudit(ctx *gin.Context) {
+	id, ok := d.getUintParam(ctx, "id")
+	if !ok {
+		return
+	}
+	entries, err := d.srv.GetAudit(ctx, id)
+	if errors.Is(err, repositories.ErrDeliveryNotFound) {
+		ctx.AbortWithStatusJSON(http.StatusNotFound, httpLib.NotFound())
+		return
+	}
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, httpLib.InternalServErr(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusOK, entries)
+}
+
+// uploadProof stores the files of the proof of delivery sent with the
+// completion.
+func (d *Delivery) uploadProof(ctx *gin.Context, deliveryId uint) (*entities.Proof, bool) {
+	ctx.Request.Body = http.MaxBytesReader(ctx.Writer, ctx.Request.Body, d.proofs.MaxUploadSize())
+	form, err := ctx.MultipartForm()
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, httpLib.BadRequest(err.Error()))
+		return nil, false
+	}
+	defer func() { _ = form.RemoveAll() }()
+
+	upload := &services.ProofUpload{ReceivedBy: ctx.PostForm("received_by")}
+	lat, lng := ctx.PostForm("latitude"), ctx.PostForm("longitude")
+	if lat != "" || lng != "" {
+		latitude, latErr := strconv.ParseFloat(lat, 64)
+		longitude, lngErr := strconv.ParseFloat(lng, 64)
+		if latErr != nil || lngErr != nil || latitude < -90 || latitude > 90 || longitude < -180 || longitude > 180 {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, httpLib.BadRequest("invalid latitude or longitude"))
+			return nil, false
+		}
+		upload.Location = &valueobjects.Location{Latitude: latitude, Longitude: longitude}
+	}
+
+	files := make([]multipart.File, 0)
+	defer func() {
+		for _, f := range files {
+			_ = f.Close()
+		}
+	}()
+	open := func(header *multipart.FileHeader) (multipart.File, bool) {
+		f, err := header.Open()
+		if err != nil {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, httpLib.BadRequest(err.Error()))
+			return nil, false
+		}
+		files = append(files, f)
+		return f, true
+	}
+	if signatures := form.File["signature"]; len(signatures) > 0 {
+		f, ok := open(signatures[0])
+		if !ok {
+			return nil, false
+		}
+		upload.Signature = f
+	}
+	for _, header := range form.File["photos"] {
+		f, ok := open(header)
+		if !ok {
+			return nil, false
+		}
+		upload.Photos = append(upload.Photos, f)
+	}
+
+	proof, err := d.proofs.Upload(ctx, deliveryId, upload)
+	if errors.Is(err, services.ErrInvalidProof) {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, httpLib.BadRequest(err.Error()))
+		return nil, false
+	}
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, httpLib.InternalServErr(err.Error()))
+		return nil, false
+	}
+	return proof, true
+}
+
 func (d *Delivery) getUintParam(ctx *gin.Context, param string) (uint, bool) {
 	idStr := ctx.Param(param)
 	if idStr == "" {
@@ -197,6 +570,73 @@ func (d *Delivery) getUintParam(ctx *gin.Context, param string) (uint, bool) {
 	return uint(id), true
 }
 
+func (d *Delivery) setETag(ctx *gin.Context, delivery *entities.Delivery) {
+	ctx.Header("ETag", fmt.Sprintf("\"%d\"", delivery.Version))
+}
+
+// getIfMatch returns the delivery version expected by the If-Match header,
+// zero means the client has no precondition.
+func (d *Delivery) getIfMatch(ctx *gin.Context) (uint, bool) {
+	header := strings.TrimSpace(ctx.GetHeader("If-Match"))
+	if header == "" || header == "*" {
+		return 0, true
+	}
+	tag := strings.Trim(strings.TrimPrefix(header, "W/"), "\"")
+	version, err := strconv.Atoi(tag)
+	if err != nil || version < 1 {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, httpLib.BadRequest("invalid If-Match"))
+		return 0, false
+	}
+	return uint(version), true
+}
+
+// abortOnConflict reports lost updates as 412 when the client sent If-Match
+// and as 409 otherwise.
+func (d *Delivery) abortOnConflict(ctx *gin.Context, err error, version uint) bool {
+	switch {
+	case errors.Is(err, services.ErrPreconditionFailed),
+		errors.Is(err, repositories.ErrConcurrentModification) && version != 0:
+		ctx.AbortWithStatusJSON(http.StatusPreconditionFailed, httpLib.PreconditionFailed(err.Error()))
+		return true
+	case errors.Is(err, repositories.ErrConcurrentModification),
+		errors.Is(err, services.ErrCourierUnavailable),
+		errors.Is(err, services.ErrCourierAtCapacity),
+		errors.Is(err, services.ErrNoShift),
+		errors.Is(err, services.ErrHandoverLocked),
+		errors.Is(err, services.ErrSlotFull):
+		ctx.AbortWithStatusJSON(http.StatusConflict, httpLib.Conflict(err.Error()))
+		return true
+	}
+	return false
+}
+
+// getCollection parses the cash on delivery collected, nil when nothing was.
+func (d *Delivery) getCollection(ctx *gin.Context, amount, method string) (*valueobjects.Collection, bool) {
+	if amount == "" && method == "" {
+		return nil, true
+	}
+	collected, err := valueobjects.ParseMoney(amount)
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, httpLib.BadRequest(err.Error()))
+		return nil, false
+	}
+	return &valueobjects.Collection{Amount: collected, Method: valueobjects.PaymentMethod(method)}, true
+}
+
+// canSee reports whether the delivery is visible to the user: admins see all,
+// couriers the assigned deliveries and users those of their tenant.
+func (d *Delivery) canSee(user *entities.User, delivery *entities.Delivery) bool {
+	switch user.Role {
+	case "admin":
+		return true
+	case "courier":
+		return delivery.CourierId != nil && *delivery.CourierId == user.Id
+	case "user":
+		return entities.TenantOf(user).Owns(delivery)
+	}
+	return false
+}
+
 func (d *Delivery) getUser(ctx *gin.Context) (*entities.User, bool) {
 	user, exists := ctx.Get("user")
 	if !exists {
@@ -0,0 +1,95 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/zhanbolat18/parcel/deliveries/internal/repositories"
+	"github.com/zhanbolat18/parcel/deliveries/internal/services"
+	httpLib "github.com/zhanbolat18/parcel/libs/http"
+	"net/http"
+	"strconv"
+)
+
+type Dispatch struct {
+	dispatcher *services.Dispatcher
+}
+
+func NewDispatchController(dispatcher *services.Dispatcher) *Dispatch {
+	return &Dispatch{dispatcher: dispatcher}
+}
+
+// DispatchOne godoc
+// @Summary      dispatch delivery
+// @Description  assign created delivery to the courier chosen by the dispatch strategy. Only admin have permission.
+// @Produce      json
+// @Param 		 Authorization  header    string  true  "Authentication header. Usage 'Bearer {token}'"
+// @Param 		 id  			path	integer	true	"delivery id"
+// @Param 		 strategy  		query	string	false	"round_robin, least_active or nearest, configured default if omitted"
+// @Success      200  {object}  entities.Delivery
+// @Header       200  {string}  ETag  "delivery version"
+// @Failure      400  {object}  object{error=string}
+// @Failure      401  {object}  object{error=string}
+// @Failure      403  {object}  object{error=string}
+// @Failure      404  {object}  object{error=string}
+// @Failure      409  {object}  object{error=string}
+// @Failure      422  {object}  object{error=string}
+// @Router       /deliveries/{id}/dispatch [post]
+func (d *Dispatch) DispatchOne(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, httpLib.BadRequest("invalid id"))
+		return
+	}
+	delivery, err := d.dispatcher.Dispatch(ctx, uint(id), ctx.Query("strategy"))
+	if err != nil {
+		d.abort(ctx, err)
+		return
+	}
+	ctx.Header("ETag", fmt.Sprintf("\"%d\"", delivery.Version))
+	ctx.JSON(http.StatusOK, delivery)
+}
+
+// DispatchAll godoc
+// @Summary      dispatch all created deliveries
+// @Description  assign every created delivery to the courier chosen by the dispatch strategy, with dry_run only the plan is returned. Only admin have permission.
+// @Produce      json
+// @Param 		 Authorization  header    string  true  "Authentication header. Usage 'Bearer {token}'"
+// @Param 		 strategy  		query	string	false	"round_robin, least_active or nearest, configured default if omitted"
+// @Param 		 dry_run  		query	boolean	false	"return the plan without assigning"
+// @Success      200  {object}  services.Plan
+// @Failure      400  {object}  object{error=string}
+// @Failure      401  {object}  object{error=string}
+// @Failure      403  {object}  object{error=string}
+// @Router       /deliveries/dispatch [post]
+func (d *Dispatch) DispatchAll(ctx *gin.Context) {
+	dryRun := false
+	if v := ctx.Query("dry_run"); v != "" {
+		var err error
+		if dryRun, err = strconv.ParseBool(v); err != nil {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, httpLib.BadRequest("invalid dry_run"))
+			return
+		}
+	}
+	plan, err := d.dispatcher.DispatchAll(ctx, ctx.Query("strategy"), dryRun)
+	if err != nil {
+		d.abort(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, plan)
+}
+
+func (d *Dispatch) abort(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrUnknownStrategy):
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, httpLib.BadRequest(err.Error()))
+	case errors.Is(err, repositories.ErrDeliveryNotFound):
+		ctx.AbortWithStatusJSON(http.StatusNotFound, httpLib.NotFound())
+	case errors.Is(err, services.ErrPreconditionFailed), errors.Is(err, repositories.ErrConcurrentModification):
+		ctx.AbortWithStatusJSON(http.StatusConflict, httpLib.Conflict(err.Error()))
+	case errors.Is(err, services.ErrNoCourier):
+		ctx.AbortWithStatusJSON(http.StatusUnprocessableEntity, httpLib.UnprocessableEntity(err.Error()))
+	default:
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, httpLib.BadRequest(err.Error()))
+	}
+}
@@ -0,0 +1,158 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/zhanbolat18/parcel/deliveries/internal/entities"
+	"github.com/zhanbolat18/parcel/deliveries/internal/repositories"
+	"github.com/zhanbolat18/parcel/deliveries/internal/services"
+	httpLib "github.com/zhanbolat18/parcel/libs/http"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+type Events struct {
+	srv       *services.ManageDelivery
+	broker    *services.Broker
+	heartbeat time.Duration
+}
+
+func NewEventsController(srv *services.ManageDelivery, broker *services.Broker, heartbeat time.Duration) *Events {
+	return &Events{srv: srv, broker: broker, heartbeat: heartbeat}
+}
+
+// Stream godoc
+// @Summary      delivery events stream
+// @Description  stream status changes of all deliveries as server-sent events. Only admin have permission to see all.
+// @Description  If endpoint called with courier, only events of assigned deliveries are streamed
+// @Description  If endpoint called with user, only events of deliveries of their organization, or their own ones, are streamed
+// @Produce      text/event-stream
+// @Param 		 Authorization  header    string  true  "Authentication header. Usage 'Bearer {token}'"
+// @Param 		 Last-Event-ID  header    string  false  "id of the last received event, newer kept events are replayed"
+// @Success      200  {object}  entities.Delivery  "event data"
+// @Failure      400  {object}  object{error=string}
+// @Failure      401  {object}  object{error=string}
+// @Failure      403  {object}  object{error=string}
+// @Router       /deliveries/stream [get]
+func (e *Events) Stream(ctx *gin.Context) {
+	user := ctx.MustGet("user").(*entities.User)
+	e.stream(ctx, func(event *services.DeliveryEvent) bool {
+		return e.visible(user, &event.Delivery)
+	})
+}
+
+// DeliveryEvents godoc
+// @Summary      delivery events
+// @Description  stream status changes of one delivery as server-sent events. Only admin have permission to see all.
+// @Description  If endpoint called with courier, only assigned deliveries are streamed
+// @Description  If endpoint called with user, only deliveries of their organization, or their own ones, are streamed
+// @Produce      text/event-stream
+// @Param 		 Authorization  header    string  true  "Authentication header. Usage 'Bearer {token}'"
+// @Param 		 Last-Event-ID  header    string  false  "id of the last received event, newer kept events are replayed"
+// @Param 		 id  			path	integer	true	"delivery id"
+// @Success      200  {object}  entities.Delivery  "event data"
+// @Failure      400  {object}  object{error=string}
+// @Failure      401  {object}  object{error=string}
+// @Failure      403  {object}  object{error=string}
+// @Failure      404  {object}  object{error=string}
+// @Router       /deliveries/{id}/events [get]
+func (e *Events) DeliveryEvents(ctx *gin.Context) {
+	user := ctx.MustGet("user").(*entities.User)
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, httpLib.BadRequest("invalid id"))
+		return
+	}
+	delivery, err := e.srv.GetOne(ctx, uint(id))
+	if errors.Is(err, repositories.ErrDeliveryNotFound) {
+		ctx.AbortWithStatusJSON(http.StatusNotFound, httpLib.NotFound())
+		return
+	}
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, httpLib.InternalServErr(err.Error()))
+		return
+	}
+	if !e.visible(user, delivery) {
+		ctx.AbortWithStatusJSON(http.StatusForbidden, httpLib.Forbidden())
+		return
+	}
+	e.stream(ctx, func(event *services.DeliveryEvent) bool {
+		return event.Delivery.Id == delivery.Id && e.visible(user, &event.Delivery)
+	})
+}
+
+// visible applies the rules of the read routes: couriers only see the
+// deliveries assigned to them, users the ones of their tenant.
+func (e *Events) visible(user *entities.User, delivery *entities.Delivery) bool {
+	switch user.Role {
+	case "admin":
+		return true
+	case "courier":
+		return delivery.CourierId != nil && *delivery.CourierId == user.Id
+	case "user":
+		return entities.TenantOf(user).Owns(delivery)
+	}
+	return false
+}
+
+// stream writes the events until the client disconnects or the subscription
+// ends, with a comment every heartbeat to keep proxies from closing the idle
+// connection.
+func (e *Events) stream(ctx *gin.Context, filter func(*services.DeliveryEvent) bool) {
+	var lastEventId uint64
+	if header := ctx.GetHeader("Last-Event-ID"); header != "" {
+		id, err := strconv.ParseUint(header, 10, 64)
+		if err != nil {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, httpLib.BadRequest("invalid Last-Event-ID"))
+			return
+		}
+		lastEventId = id
+	}
+	sub, backlog := e.broker.Subscribe(lastEventId, filter)
+	defer sub.Close()
+
+	ctx.Header("Content-Type", "text/event-stream")
+	ctx.Header("Cache-Control", "no-cache")
+	ctx.Header("Connection", "keep-alive")
+	ctx.Header("X-Accel-Buffering", "no")
+	ctx.Status(http.StatusOK)
+	for i := range backlog {
+		if err := e.write(ctx, &backlog[i]); err != nil {
+			return
+		}
+	}
+	ctx.Writer.Flush()
+
+	ticker := time.NewTicker(e.heartbeat)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Request.Context().Done():
+			return
+		case event, ok := <-sub.C:
+			if !ok {
+				return
+			}
+			if err := e.write(ctx, &event); err != nil {
+				return
+			}
+		case <-ticker.C:
+			if _, err := fmt.Fprint(ctx.Writer, ": heartbeat\n\n"); err != nil {
+				return
+			}
+		}
+		ctx.Writer.Flush()
+	}
+}
+
+func (e *Events) write(ctx *gin.Context, event *services.DeliveryEvent) error {
+	data, err := json.Marshal(event.Delivery)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(ctx.Writer, "id: %d\nevent: %s\ndata: %s\n\n", event.Id, event.Type, data)
+	return err
+}
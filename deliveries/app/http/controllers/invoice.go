@@ -0,0 +1,175 @@
+package controllers
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/zhanbolat18/parcel/deliveries/app/dto"
+	"github.com/zhanbolat18/parcel/deliveries/internal/entities"
+	"github.com/zhanbolat18/parcel/deliveries/internal/repositories"
+	"github.com/zhanbolat18/parcel/deliveries/internal/services"
+	httpLib "github.com/zhanbolat18/parcel/libs/http"
+	"io"
+	"net/http"
+	"strconv"
+)
+
+type Invoice struct {
+	srv *services.ManageInvoice
+}
+
+func NewInvoiceController(srv *services.ManageInvoice) *Invoice {
+	return &Invoice{srv: srv}
+}
+
+// Generate godoc
+// @Summary      generate invoices
+// @Description  invoice the merchants for the deliveries completed in an ended month at the price stored on each of
+// @Description  them. A merchant is invoiced once per period, repeated calls return the invoices generated first.
+// @Description  Deliveries of organizations are billed to the organization. Only admin have permission.
+// @Accept 		 json
+// @Produce      json
+// @Param 		 Authorization  header    string  true  "Authentication header. Usage 'Bearer {token}'"
+// @Param        message  body  dto.GenerateInvoices  true  "period and optional merchant"
+// @Success      200  {array}  entities.Invoice
+// @Failure      400  {object}  object{error=string}
+// @Failure      401  {object}  object{error=string}
+// @Failure      403  {object}  object{error=string}
+// @Failure      422  {object}  object{error=string}
+// @Router       /invoices [post]
+func (i *Invoice) Generate(ctx *gin.Context) {
+	req := &dto.GenerateInvoices{}
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, httpLib.BadRequest(err.Error()))
+		return
+	}
+	var merchant *entities.Tenant
+	switch {
+	case req.MerchantId != 0 && req.OrganizationId != 0:
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, httpLib.BadRequest("merchant_id and organization_id are exclusive"))
+		return
+	case req.MerchantId != 0:
+		merchant = &entities.Tenant{UserId: req.MerchantId}
+	case req.OrganizationId != 0:
+		merchant = &entities.Tenant{OrganizationId: req.OrganizationId}
+	}
+	invoices, err := i.srv.Generate(ctx, req.Period, merchant)
+	switch {
+	case errors.Is(err, services.ErrInvalidPeriod):
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, httpLib.BadRequest(err.Error()))
+	case errors.Is(err, services.ErrPeriodOpen):
+		ctx.AbortWithStatusJSON(http.StatusUnprocessableEntity, httpLib.UnprocessableEntity(err.Error()))
+	case err != nil:
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, httpLib.InternalServErr(err.Error()))
+	default:
+		ctx.JSON(http.StatusOK, invoices)
+	}
+}
+
+// GetAll godoc
+// @Summary      fetch invoices
+// @Description  fetch invoices without their lines, latest period first. Admin sees all, user the invoices of own
+// @Description  organization, or own invoices outside of organizations.
+// @Produce      json
+// @Param 		 Authorization  header    string  true  "Authentication header. Usage 'Bearer {token}' or 'ApiKey {key}' with invoices:read"
+// @Param 		 period  		query	string	false	"only invoices of the month, like 2026-09"
+// @Param 		 merchant_id  	query	integer	false	"only invoices of the merchant, admin only"
+// @Param 		 organization_id  	query	integer	false	"only invoices of the organization, admin only"
+// @Success      200  {array}  entities.Invoice
+// @Failure      400  {object}  object{error=string}
+// @Failure      401  {object}  object{error=string}
+// @Failure      403  {object}  object{error=string}
+// @Router       /invoices [get]
+func (i *Invoice) GetAll(ctx *gin.Context) {
+	u := ctx.MustGet("user").(*entities.User)
+	filter := repositories.InvoiceFilter{Period: ctx.Query("period")}
+	if q := ctx.Query("merchant_id"); q != "" {
+		merchantId, err := strconv.ParseUint(q, 10, 64)
+		if err != nil {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, httpLib.BadRequest("invalid merchant_id"))
+			return
+		}
+		filter.MerchantId = uint(merchantId)
+	}
+	if q := ctx.Query("organization_id"); q != "" {
+		organizationId, err := strconv.ParseUint(q, 10, 64)
+		if err != nil {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, httpLib.BadRequest("invalid organization_id"))
+			return
+		}
+		filter.OrganizationId = uint(organizationId)
+	}
+	if u.Role != "admin" {
+		merchant := entities.TenantOf(u)
+		filter.MerchantId, filter.OrganizationId = merchant.UserId, merchant.OrganizationId
+	}
+	invoices, err := i.srv.GetAll(ctx, filter)
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, httpLib.InternalServErr(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusOK, invoices)
+}
+
+// GetPDF godoc
+// @Summary      invoice pdf
+// @Description  download the invoice as a PDF document. Only admin and the merchant have permission.
+// @Produce      application/pdf
+// @Param 		 Authorization  header    string  true  "Authentication header. Usage 'Bearer {token}' or 'ApiKey {key}' with invoices:read"
+// @Param 		 id  			path	integer	true	"invoice id"
+// @Success      200  {file}  binary
+// @Failure      400  {object}  object{error=string}
+// @Failure      401  {object}  object{error=string}
+// @Failure      403  {object}  object{error=string}
+// @Failure      404  {object}  object{error=string}
+// @Router       /invoices/{id}/pdf [get]
+func (i *Invoice) GetPDF(ctx *gin.Context) {
+	i.export(ctx, "application/pdf", "pdf", services.WriteInvoicePDF)
+}
+
+// GetCSV godoc
+// @Summary      invoice csv
+// @Description  export the invoice lines as CSV. Only admin and the merchant have permission.
+// @Produce      text/csv
+// @Param 		 Authorization  header    string  true  "Authentication header. Usage 'Bearer {token}' or 'ApiKey {key}' with invoices:read"
+// @Param 		 id  			path	integer	true	"invoice id"
+// @Success      200  {file}  binary
+// @Failure      400  {object}  object{error=string}
+// @Failure      401  {object}  object{error=string}
+// @Failure      403  {object}  object{error=string}
+// @Failure      404  {object}  object{error=string}
+// @Router       /invoices/{id}/csv [get]
+func (i *Invoice) GetCSV(ctx *gin.Context) {
+	i.export(ctx, "text/csv", "csv", services.WriteInvoiceCSV)
+}
+
+// export renders the whole invoice before responding, so that a failure
+// still gets an error status.
+func (i *Invoice) export(ctx *gin.Context, contentType, extension string, write func(io.Writer, *entities.Invoice) error) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, httpLib.BadRequest("invalid id"))
+		return
+	}
+	invoice, err := i.srv.GetOne(ctx, ctx.MustGet("user").(*entities.User), uint(id))
+	switch {
+	case errors.Is(err, services.ErrNotMerchant):
+		ctx.AbortWithStatusJSON(http.StatusForbidden, httpLib.Forbidden())
+		return
+	case errors.Is(err, repositories.ErrInvoiceNotFound):
+		ctx.AbortWithStatusJSON(http.StatusNotFound, httpLib.NotFound())
+		return
+	case err != nil:
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, httpLib.InternalServErr(err.Error()))
+		return
+	}
+	buf := &bytes.Buffer{}
+	if err = write(buf, invoice); err != nil {
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, httpLib.InternalServErr(err.Error()))
+		return
+	}
+	disposition := fmt.Sprintf("attachment; filename=\"invoice-%d-%s.%s\"", invoice.Id, invoice.Period, extension)
+	ctx.Header("Content-Disposition", disposition)
+	ctx.Data(http.StatusOK, contentType, buf.Bytes())
+}
@@ -0,0 +1,97 @@
+package controllers
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/zhanbolat18/parcel/deliveries/app/dto"
+	"github.com/zhanbolat18/parcel/deliveries/internal/entities"
+	"github.com/zhanbolat18/parcel/deliveries/internal/repositories"
+	"github.com/zhanbolat18/parcel/deliveries/internal/services"
+	httpLib "github.com/zhanbolat18/parcel/libs/http"
+	"net/http"
+	"strconv"
+)
+
+type Location struct {
+	srv *services.ManageLocation
+}
+
+func NewLocationController(srv *services.ManageLocation) *Location {
+	return &Location{srv: srv}
+}
+
+// ReportMine godoc
+// @Summary      report courier location
+// @Description  store a batch of GPS fixes of the authenticated courier, fixes older than the retention are skipped. Only courier have permission.
+// @Accept 		 json
+// @Produce      json
+// @Param 		 Authorization  header    string  true  "Authentication header. Usage 'Bearer {token}'"
+// @Param        message  body  dto.LocationBatch  true  "GPS fixes"
+// @Success      202  {object}  object{accepted=integer}
+// @Failure      400  {object}  object{error=string}
+// @Failure      401  {object}  object{error=string}
+// @Failure      403  {object}  object{error=string}
+// @Router       /couriers/me/location [post]
+func (l *Location) ReportMine(ctx *gin.Context) {
+	u := ctx.MustGet("user").(*entities.User)
+	req := &dto.LocationBatch{}
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, httpLib.BadRequest(err.Error()))
+		return
+	}
+	fixes := make([]*entities.LocationFix, 0, len(req.Fixes))
+	for _, f := range req.Fixes {
+		fixes = append(fixes, &entities.LocationFix{
+			Latitude:   *f.Lat,
+			Longitude:  *f.Lng,
+			Accuracy:   f.Accuracy,
+			RecordedAt: f.Timestamp,
+		})
+	}
+	accepted, err := l.srv.Report(ctx, u.Id, fixes)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidLocation) {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, httpLib.BadRequest(err.Error()))
+			return
+		}
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, httpLib.InternalServErr(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusAccepted, gin.H{"accepted": accepted})
+}
+
+// DeliveryLocation godoc
+// @Summary      delivery location
+// @Description  last known position of the courier carrying the delivery, available while the delivery is in delivers status. Only the recipient have permission.
+// @Produce      json
+// @Param 		 Authorization  header    string  true  "Authentication header. Usage 'Bearer {token}' or 'ApiKey {key}' with deliveries:read"
+// @Param 		 id  			path	integer	true	"delivery id"
+// @Success      200  {object}  entities.LocationFix
+// @Failure      400  {object}  object{error=string}
+// @Failure      401  {object}  object{error=string}
+// @Failure      403  {object}  object{error=string}
+// @Failure      404  {object}  object{error=string}
+// @Failure      409  {object}  object{error=string}
+// @Router       /deliveries/{id}/location [get]
+func (l *Location) DeliveryLocation(ctx *gin.Context) {
+	u := ctx.MustGet("user").(*entities.User)
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, httpLib.BadRequest("invalid id"))
+		return
+	}
+	fix, err := l.srv.DeliveryLocation(ctx, u, uint(id))
+	switch {
+	case err == nil:
+		ctx.JSON(http.StatusOK, fix)
+	case errors.Is(err, services.ErrNotRecipient):
+		ctx.AbortWithStatusJSON(http.StatusForbidden, httpLib.Forbidden())
+	case errors.Is(err, services.ErrNotInDelivery):
+		ctx.AbortWithStatusJSON(http.StatusConflict, httpLib.Conflict(err.Error()))
+	case errors.Is(err, repositories.ErrDeliveryNotFound),
+		errors.Is(err, repositories.ErrLocationNotFound):
+		ctx.AbortWithStatusJSON(http.StatusNotFound, httpLib.NotFound())
+	default:
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, httpLib.InternalServErr(err.Error()))
+	}
+}
@@ -0,0 +1,98 @@
+package controllers
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/zhanbolat18/parcel/deliveries/internal/entities"
+	"github.com/zhanbolat18/parcel/deliveries/internal/repositories"
+	"github.com/zhanbolat18/parcel/deliveries/internal/services"
+	httpLib "github.com/zhanbolat18/parcel/libs/http"
+	"net/http"
+	"strconv"
+)
+
+type Proof struct {
+	srv *services.ManageProof
+}
+
+func NewProofController(srv *services.ManageProof) *Proof {
+	return &Proof{srv: srv}
+}
+
+// GetProof godoc
+// @Summary      proof of delivery
+// @Description  get the proof collected on completion. Only admin and the recipient have permission.
+// @Produce      json
+// @Param 		 Authorization  header    string  true  "Authentication header. Usage 'Bearer {token}' or 'ApiKey {key}' with deliveries:read"
+// @Param 		 id  			path	integer	true	"delivery id"
+// @Success      200  {object}  entities.Proof
+// @Failure      400  {object}  object{error=string}
+// @Failure      401  {object}  object{error=string}
+// @Failure      403  {object}  object{error=string}
+// @Failure      404  {object}  object{error=string}
+// @Router       /deliveries/{id}/proof [get]
+func (p *Proof) GetProof(ctx *gin.Context) {
+	id, ok := p.uintParam(ctx, "id")
+	if !ok {
+		return
+	}
+	proof, err := p.srv.Get(ctx, ctx.MustGet("user").(*entities.User), id)
+	if err != nil {
+		p.abort(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, proof)
+}
+
+// GetFile godoc
+// @Summary      proof of delivery file
+// @Description  download the signature or a photo of the proof. Only admin and the recipient have permission.
+// @Produce      png,jpeg,image/webp
+// @Param 		 Authorization  header    string  true  "Authentication header. Usage 'Bearer {token}' or 'ApiKey {key}' with deliveries:read"
+// @Param 		 id  			path	integer	true	"delivery id"
+// @Param 		 fileId  		path	integer	true	"proof file id"
+// @Success      200  {file}  binary
+// @Failure      400  {object}  object{error=string}
+// @Failure      401  {object}  object{error=string}
+// @Failure      403  {object}  object{error=string}
+// @Failure      404  {object}  object{error=string}
+// @Router       /deliveries/{id}/proof/files/{fileId} [get]
+func (p *Proof) GetFile(ctx *gin.Context) {
+	id, ok := p.uintParam(ctx, "id")
+	if !ok {
+		return
+	}
+	fileId, ok := p.uintParam(ctx, "fileId")
+	if !ok {
+		return
+	}
+	file, content, err := p.srv.OpenFile(ctx, ctx.MustGet("user").(*entities.User), id, fileId)
+	if err != nil {
+		p.abort(ctx, err)
+		return
+	}
+	defer content.Close()
+	ctx.DataFromReader(http.StatusOK, file.Size, file.ContentType, content, nil)
+}
+
+func (p *Proof) uintParam(ctx *gin.Context, param string) (uint, bool) {
+	v, err := strconv.Atoi(ctx.Param(param))
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, httpLib.BadRequest("invalid "+param))
+		return 0, false
+	}
+	return uint(v), true
+}
+
+func (p *Proof) abort(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrNotRecipient):
+		ctx.AbortWithStatusJSON(http.StatusForbidden, httpLib.Forbidden())
+	case errors.Is(err, repositories.ErrDeliveryNotFound),
+		errors.Is(err, repositories.ErrProofNotFound),
+		errors.Is(err, repositories.ErrBlobNotFound):
+		ctx.AbortWithStatusJSON(http.StatusNotFound, httpLib.NotFound())
+	default:
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, httpLib.InternalServErr(err.Error()))
+	}
+}
@@ -0,0 +1,70 @@
+package controllers
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/zhanbolat18/parcel/deliveries/app/dto"
+	"github.com/zhanbolat18/parcel/deliveries/internal/entities"
+	"github.com/zhanbolat18/parcel/deliveries/internal/services"
+	"github.com/zhanbolat18/parcel/deliveries/internal/valueobjects"
+	httpLib "github.com/zhanbolat18/parcel/libs/http"
+	"net/http"
+)
+
+type Quote struct {
+	srv *services.Pricing
+}
+
+func NewQuoteController(srv *services.Pricing) *Quote {
+	return &Quote{srv: srv}
+}
+
+// Create godoc
+// @Summary      quote delivery price
+// @Description  price a delivery by distance, or by zone without both locations, chargeable weight and service level,
+// @Description  with fragile, oversize and remote zone surcharges. POST /deliveries with the quote id locks the price
+// @Description  until expires_at. Only user have permission.
+// @Accept 		 json
+// @Produce      json
+// @Param 		 Authorization  header    string  true  "Authentication header. Usage 'Bearer {token}' or 'ApiKey {key}' with deliveries:create"
+// @Param        message  body  dto.Quote  true  "parcel and route"
+// @Success      200  {object}  entities.Quote
+// @Failure      400  {object}  object{error=string}
+// @Failure      401  {object}  object{error=string}
+// @Failure      403  {object}  object{error=string}
+// @Router       /quotes [post]
+func (q *Quote) Create(ctx *gin.Context) {
+	u := ctx.MustGet("user").(*entities.User)
+	req := &dto.Quote{}
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, httpLib.BadRequest(err.Error()))
+		return
+	}
+	quoteReq := services.QuoteRequest{
+		ServiceLevel: valueobjects.ServiceLevel(req.ServiceLevel),
+		Zone:         req.Zone,
+		Parcel: services.Parcel{
+			WeightKg: req.WeightKg,
+			LengthCm: req.LengthCm,
+			WidthCm:  req.WidthCm,
+			HeightCm: req.HeightCm,
+			Fragile:  req.Fragile,
+		},
+	}
+	if req.OriginLatitude != nil && req.OriginLongitude != nil {
+		quoteReq.OriginLocation = &valueobjects.Location{Latitude: *req.OriginLatitude, Longitude: *req.OriginLongitude}
+	}
+	if req.Latitude != nil && req.Longitude != nil {
+		quoteReq.Location = &valueobjects.Location{Latitude: *req.Latitude, Longitude: *req.Longitude}
+	}
+
+	quote, err := q.srv.Quote(u, quoteReq)
+	switch {
+	case errors.Is(err, services.ErrInvalidParcel), errors.Is(err, services.ErrInvalidServiceLevel):
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, httpLib.BadRequest(err.Error()))
+	case err != nil:
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, httpLib.InternalServErr(err.Error()))
+	default:
+		ctx.JSON(http.StatusOK, quote)
+	}
+}
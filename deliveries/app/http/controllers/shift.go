@@ -0,0 +1,243 @@
+package controllers
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/zhanbolat18/parcel/deliveries/app/dto"
+	"github.com/zhanbolat18/parcel/deliveries/internal/entities"
+	"github.com/zhanbolat18/parcel/deliveries/internal/repositories"
+	"github.com/zhanbolat18/parcel/deliveries/internal/services"
+	httpLib "github.com/zhanbolat18/parcel/libs/http"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+type Shift struct {
+	srv *services.ManageShift
+}
+
+func NewShiftController(srv *services.ManageShift) *Shift {
+	return &Shift{srv: srv}
+}
+
+// Create godoc
+// @Summary      create shift
+// @Description  plan a courier shift, shifts of one courier must not overlap. Only admin have permission.
+// @Accept 		 json
+// @Produce      json
+// @Param 		 Authorization  header    string  true  "Authentication header. Usage 'Bearer {token}'"
+// @Param        message  body  dto.Shift  true  "shift"
+// @Success      201  {object}  entities.Shift
+// @Failure      400  {object}  object{error=string}
+// @Failure      401  {object}  object{error=string}
+// @Failure      403  {object}  object{error=string}
+// @Failure      409  {object}  object{error=string}
+// @Router       /shifts [post]
+func (s *Shift) Create(ctx *gin.Context) {
+	shift, ok := s.bind(ctx)
+	if !ok {
+		return
+	}
+	if err := s.srv.Create(ctx, shift); err != nil {
+		s.abort(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusCreated, shift)
+}
+
+// GetAll godoc
+// @Summary      list shifts
+// @Description  list shifts overlapping the period, optionally of one courier. Only admin have permission.
+// @Produce      json
+// @Param 		 Authorization  header    string  true  "Authentication header. Usage 'Bearer {token}'"
+// @Param 		 courier_id  	query	integer	false	"courier id"
+// @Param 		 from  			query	string	false	"RFC3339 period start"
+// @Param 		 to  			query	string	false	"RFC3339 period end"
+// @Success      200  {array}  entities.Shift
+// @Failure      400  {object}  object{error=string}
+// @Failure      401  {object}  object{error=string}
+// @Failure      403  {object}  object{error=string}
+// @Router       /shifts [get]
+func (s *Shift) GetAll(ctx *gin.Context) {
+	filter, ok := s.filter(ctx)
+	if !ok {
+		return
+	}
+	if v := ctx.Query("courier_id"); v != "" {
+		id, err := strconv.Atoi(v)
+		if err != nil {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, httpLib.BadRequest("invalid courier_id"))
+			return
+		}
+		filter.CourierId = uint(id)
+	}
+	shifts, err := s.srv.GetAll(ctx, filter)
+	if err != nil {
+		s.abort(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, shifts)
+}
+
+// GetOne godoc
+// @Summary      get shift
+// @Description  get one shift. Only admin have permission.
+// @Produce      json
+// @Param 		 Authorization  header    string  true  "Authentication header. Usage 'Bearer {token}'"
+// @Param 		 id  			path	integer	true	"shift id"
+// @Success      200  {object}  entities.Shift
+// @Failure      400  {object}  object{error=string}
+// @Failure      401  {object}  object{error=string}
+// @Failure      403  {object}  object{error=string}
+// @Failure      404  {object}  object{error=string}
+// @Router       /shifts/{id} [get]
+func (s *Shift) GetOne(ctx *gin.Context) {
+	id, ok := s.id(ctx)
+	if !ok {
+		return
+	}
+	shift, err := s.srv.Get(ctx, id)
+	if err != nil {
+		s.abort(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, shift)
+}
+
+// Update godoc
+// @Summary      update shift
+// @Description  replace a shift, shifts of one courier must not overlap. Only admin have permission.
+// @Accept 		 json
+// @Produce      json
+// @Param 		 Authorization  header    string  true  "Authentication header. Usage 'Bearer {token}'"
+// @Param 		 id  			path	integer	true	"shift id"
+// @Param        message  body  dto.Shift  true  "shift"
+// @Success      200  {object}  entities.Shift
+// @Failure      400  {object}  object{error=string}
+// @Failure      401  {object}  object{error=string}
+// @Failure      403  {object}  object{error=string}
+// @Failure      404  {object}  object{error=string}
+// @Failure      409  {object}  object{error=string}
+// @Router       /shifts/{id} [put]
+func (s *Shift) Update(ctx *gin.Context) {
+	id, ok := s.id(ctx)
+	if !ok {
+		return
+	}
+	shift, ok := s.bind(ctx)
+	if !ok {
+		return
+	}
+	shift.Id = id
+	if err := s.srv.Update(ctx, shift); err != nil {
+		s.abort(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, shift)
+}
+
+// Delete godoc
+// @Summary      delete shift
+// @Description  delete a shift. Only admin have permission.
+// @Param 		 Authorization  header    string  true  "Authentication header. Usage 'Bearer {token}'"
+// @Param 		 id  			path	integer	true	"shift id"
+// @Success      204
+// @Failure      400  {object}  object{error=string}
+// @Failure      401  {object}  object{error=string}
+// @Failure      403  {object}  object{error=string}
+// @Failure      404  {object}  object{error=string}
+// @Router       /shifts/{id} [delete]
+func (s *Shift) Delete(ctx *gin.Context) {
+	id, ok := s.id(ctx)
+	if !ok {
+		return
+	}
+	if err := s.srv.Delete(ctx, id); err != nil {
+		s.abort(ctx, err)
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
+
+// MyShifts godoc
+// @Summary      courier shifts
+// @Description  list shifts of the authenticated courier, upcoming ones by default. Only courier have permission.
+// @Produce      json
+// @Param 		 Authorization  header    string  true  "Authentication header. Usage 'Bearer {token}'"
+// @Param 		 from  			query	string	false	"RFC3339 period start, now by default"
+// @Param 		 to  			query	string	false	"RFC3339 period end"
+// @Success      200  {array}  entities.Shift
+// @Failure      400  {object}  object{error=string}
+// @Failure      401  {object}  object{error=string}
+// @Failure      403  {object}  object{error=string}
+// @Router       /couriers/me/shifts [get]
+func (s *Shift) MyShifts(ctx *gin.Context) {
+	filter, ok := s.filter(ctx)
+	if !ok {
+		return
+	}
+	if filter.From.IsZero() {
+		filter.From = time.Now()
+	}
+	filter.CourierId = ctx.MustGet("user").(*entities.User).Id
+	shifts, err := s.srv.GetAll(ctx, filter)
+	if err != nil {
+		s.abort(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, shifts)
+}
+
+func (s *Shift) bind(ctx *gin.Context) (*entities.Shift, bool) {
+	req := &dto.Shift{}
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, httpLib.BadRequest(err.Error()))
+		return nil, false
+	}
+	return &entities.Shift{
+		CourierId: req.CourierId,
+		StartsAt:  req.StartsAt,
+		EndsAt:    req.EndsAt,
+		Zone:      req.Zone,
+	}, true
+}
+
+func (s *Shift) id(ctx *gin.Context) (uint, bool) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, httpLib.BadRequest("invalid id"))
+		return 0, false
+	}
+	return uint(id), true
+}
+
+func (s *Shift) filter(ctx *gin.Context) (repositories.ShiftFilter, bool) {
+	filter := repositories.ShiftFilter{}
+	for param, dst := range map[string]*time.Time{"from": &filter.From, "to": &filter.To} {
+		v := ctx.Query(param)
+		if v == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, httpLib.BadRequest("invalid "+param))
+			return filter, false
+		}
+		*dst = t
+	}
+	return filter, true
+}
+
+func (s *Shift) abort(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrInvalidShift):
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, httpLib.BadRequest(err.Error()))
+	case errors.Is(err, services.ErrShiftOverlap):
+		ctx.AbortWithStatusJSON(http.StatusConflict, httpLib.Conflict(err.Error()))
+	case errors.Is(err, repositories.ErrShiftNotFound):
+		ctx.AbortWithStatusJSON(http.StatusNotFound, httpLib.NotFound())
+	default:
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, httpLib.InternalServErr(err.Error()))
+	}
+}
@@ -0,0 +1,43 @@
+package controllers
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/zhanbolat18/parcel/deliveries/internal/services"
+	httpLib "github.com/zhanbolat18/parcel/libs/http"
+	"net/http"
+)
+
+type Slot struct {
+	srv *services.ManageSchedule
+}
+
+func NewSlotController(srv *services.ManageSchedule) *Slot {
+	return &Slot{srv: srv}
+}
+
+// GetAll godoc
+// @Summary      bookable slots
+// @Description  list the bookable delivery slots with their remaining capacity per zone, for the days from and to inclusive.
+// @Description  The days default to the next week and span 31 days at most. Only user and admin have permission.
+// @Produce      json
+// @Param 		 Authorization  header    string  true  "Authentication header. Usage 'Bearer {token}' or 'ApiKey {key}' with deliveries:create"
+// @Param 		 zone  query  string  false  "delivery zone, all zones by default"
+// @Param 		 from  query  string  false  "first day, like 2026-10-19"
+// @Param 		 to  	query  string  false  "last day, like 2026-10-25"
+// @Success      200  {array}   entities.Slot
+// @Failure      400  {object}  object{error=string}
+// @Failure      401  {object}  object{error=string}
+// @Failure      403  {object}  object{error=string}
+// @Router       /slots [get]
+func (s *Slot) GetAll(ctx *gin.Context) {
+	slots, err := s.srv.Slots(ctx, ctx.Query("zone"), ctx.Query("from"), ctx.Query("to"))
+	switch {
+	case errors.Is(err, services.ErrInvalidSchedule):
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, httpLib.BadRequest(err.Error()))
+	case err != nil:
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, httpLib.InternalServErr(err.Error()))
+	default:
+		ctx.JSON(http.StatusOK, slots)
+	}
+}
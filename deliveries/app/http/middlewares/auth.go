@@ -7,12 +7,15 @@ import (
 	"github.com/gin-gonic/gin"
 	jsoniter "github.com/json-iterator/go"
 	"github.com/zhanbolat18/parcel/deliveries/internal/entities"
+	"github.com/zhanbolat18/parcel/deliveries/internal/repositories"
 	httpLib "github.com/zhanbolat18/parcel/libs/http"
 	"io/ioutil"
 	"net/http"
 	"strings"
 )
 
+const ApiKeySchema = "ApiKey "
+
 type AuthMiddleware struct {
 	client       *http.Client
 	usersAuthUrl string
@@ -30,19 +33,29 @@ func NewAuthMiddleware(client *http.Client, usersAuthUrl string) *AuthMiddleware
 
 type userModel struct {
 	Data struct {
-		Id    uint   `json:"id"`
-		Email string `json:"email"`
-		Role  string `json:"role"`
+		Id             uint     `json:"id"`
+		Email          string   `json:"email"`
+		Role           string   `json:"role"`
+		OrganizationId *uint    `json:"organization_id"`
+		OrgRole        string   `json:"org_role"`
+		Scopes         []string `json:"scopes"`
 	} `json:"data"`
 }
 
-func (a *AuthMiddleware) Auth() gin.HandlerFunc {
+// Auth accepts bearer tokens and, on routes listing scopes, API keys granted
+// every one of them. Routes without scopes are for people only.
+func (a *AuthMiddleware) Auth(scopes ...string) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		authHeader := ctx.GetHeader("Authorization")
 		if authHeader == "" {
 			ctx.AbortWithStatusJSON(http.StatusUnauthorized, httpLib.Unauthorized())
 			return
 		}
+		apiKey := strings.HasPrefix(authHeader, ApiKeySchema)
+		if apiKey && len(scopes) == 0 {
+			ctx.AbortWithStatusJSON(http.StatusForbidden, httpLib.Forbidden("api keys are not accepted"))
+			return
+		}
 		um, err := a.makeRequest(ctx, authHeader)
 		fmt.Println(err)
 		fmt.Println(a.usersAuthUrl)
@@ -50,11 +63,28 @@ func (a *AuthMiddleware) Auth() gin.HandlerFunc {
 			ctx.AbortWithStatusJSON(http.StatusUnauthorized, httpLib.Unauthorized(err))
 			return
 		}
-		ctx.Set("user", &entities.User{
-			Id:    um.Data.Id,
-			Email: um.Data.Email,
-			Role:  um.Data.Role,
-		})
+		user := &entities.User{
+			Id:             um.Data.Id,
+			Email:          um.Data.Email,
+			Role:           um.Data.Role,
+			OrganizationId: um.Data.OrganizationId,
+			OrgRole:        um.Data.OrgRole,
+		}
+		if apiKey {
+			user.Scopes = um.Data.Scopes
+			if !user.HasScopes(scopes...) {
+				ctx.AbortWithStatusJSON(http.StatusForbidden,
+					httpLib.Forbidden(fmt.Sprintf("api key requires scopes %s", strings.Join(scopes, ", "))))
+				return
+			}
+		}
+		ctx.Set("user", user)
+		if user.Role == "user" {
+			// every repository called for the request only sees the data of
+			// the tenant
+			scoped := repositories.WithTenant(ctx.Request.Context(), entities.TenantOf(user))
+			ctx.Request = ctx.Request.WithContext(scoped)
+		}
 		ctx.Next()
 	}
 }
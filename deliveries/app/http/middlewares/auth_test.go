@@ -0,0 +1,65 @@
+package middlewares_test
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/zhanbolat18/parcel/deliveries/app/http/middlewares"
+	"github.com/zhanbolat18/parcel/deliveries/internal/entities"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// usersService answers /auth like the users service, API keys resolve to an
+// organization staff member holding deliveries:read only.
+func usersService(t *testing.T) *httptest.Server {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.Header.Get("Authorization") {
+		case "Bearer token":
+			_, _ = w.Write([]byte(`{"data":{"id":1,"email":"owner@acme.com","role":"user","organization_id":7,"org_role":"owner"}}`))
+		case "ApiKey pk_acme.secret":
+			_, _ = w.Write([]byte(`{"data":{"id":1,"email":"owner@acme.com","role":"user","organization_id":7,"org_role":"staff","scopes":["deliveries:read"]}}`))
+		default:
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"error":"unauthorized"}`))
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestAuthMiddleware_Scopes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mw := middlewares.NewAuthMiddleware(http.DefaultClient, usersService(t).URL)
+	engine := gin.New()
+	ok := func(ctx *gin.Context) {
+		ctx.JSON(http.StatusOK, ctx.MustGet("user").(*entities.User).Scopes)
+	}
+	engine.GET("/read", mw.Auth("deliveries:read"), ok)
+	engine.POST("/create", mw.Auth("deliveries:create"), ok)
+	engine.POST("/people", mw.Auth(), ok)
+
+	cases := []struct {
+		method, path, header string
+		status               int
+	}{
+		{http.MethodGet, "/read", "Bearer token", http.StatusOK},
+		{http.MethodPost, "/create", "Bearer token", http.StatusOK},
+		{http.MethodPost, "/people", "Bearer token", http.StatusOK},
+		{http.MethodGet, "/read", "ApiKey pk_acme.secret", http.StatusOK},
+		{http.MethodPost, "/create", "ApiKey pk_acme.secret", http.StatusForbidden},
+		{http.MethodPost, "/people", "ApiKey pk_acme.secret", http.StatusForbidden},
+		{http.MethodGet, "/read", "ApiKey pk_acme.wrong", http.StatusUnauthorized},
+		{http.MethodGet, "/read", "", http.StatusUnauthorized},
+	}
+	for _, c := range cases {
+		req := httptest.NewRequest(c.method, c.path, nil)
+		if c.header != "" {
+			req.Header.Set("Authorization", c.header)
+		}
+		rec := httptest.NewRecorder()
+		engine.ServeHTTP(rec, req)
+		assert.Equal(t, c.status, rec.Code, "%s %s with %q", c.method, c.path, c.header)
+	}
+}
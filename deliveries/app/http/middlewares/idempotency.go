@@ -0,0 +1,30 @@
+package middlewares
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/zhanbolat18/parcel/deliveries/internal/entities"
+	"github.com/zhanbolat18/parcel/libs/idempotency"
+	"strconv"
+)
+
+type IdempotencyMiddleware struct {
+	idempotency *idempotency.Idempotency
+}
+
+func NewIdempotencyMiddleware(idem *idempotency.Idempotency) *IdempotencyMiddleware {
+	if idem == nil {
+		panic("idempotency must be set")
+	}
+	return &IdempotencyMiddleware{idempotency: idem}
+}
+
+// Idempotent must run after Auth, keys are scoped to the authenticated user.
+func (i *IdempotencyMiddleware) Idempotent() gin.HandlerFunc {
+	return i.idempotency.Middleware(func(ctx *gin.Context) string {
+		u, exists := ctx.Get("user")
+		if !exists {
+			return ""
+		}
+		return strconv.FormatUint(uint64(u.(*entities.User).Id), 10)
+	})
+}
@@ -37,3 +37,29 @@ func (r *RoleMiddleware) CheckRole(role ...string) gin.HandlerFunc {
 		ctx.Next()
 	}
 }
+
+// CheckOrgRole lets members of organizations through with one of the roles,
+// users outside of organizations always pass.
+func (r *RoleMiddleware) CheckOrgRole(role ...string) gin.HandlerFunc {
+	if len(role) == 0 {
+		panic("roles must be set")
+	}
+	rolesMap := make(map[string]struct{})
+	for _, v := range role {
+		rolesMap[v] = struct{}{}
+	}
+	return func(ctx *gin.Context) {
+		u, exists := ctx.Get("user")
+		if !exists {
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, httpLib.Unauthorized())
+			return
+		}
+		user := u.(*entities.User)
+
+		if _, ok := rolesMap[user.OrgRole]; user.OrganizationId != nil && !ok {
+			ctx.AbortWithStatusJSON(http.StatusForbidden, httpLib.Forbidden())
+			return
+		}
+		ctx.Next()
+	}
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"github.com/gin-gonic/gin"
 	"github.com/jmoiron/sqlx"
@@ -11,17 +12,31 @@ import (
 	"github.com/zhanbolat18/parcel/deliveries/app/http/controllers"
 	"github.com/zhanbolat18/parcel/deliveries/app/http/middlewares"
 	"github.com/zhanbolat18/parcel/deliveries/config"
+	"github.com/zhanbolat18/parcel/deliveries/database/postgres/migrations"
 	_ "github.com/zhanbolat18/parcel/deliveries/docs"
 	"github.com/zhanbolat18/parcel/deliveries/internal/repositories"
+	"github.com/zhanbolat18/parcel/deliveries/internal/repositories/filesystem"
 	httpRepository "github.com/zhanbolat18/parcel/deliveries/internal/repositories/http"
+	"github.com/zhanbolat18/parcel/deliveries/internal/repositories/memory"
 	"github.com/zhanbolat18/parcel/deliveries/internal/repositories/postgres"
 	"github.com/zhanbolat18/parcel/deliveries/internal/services"
+	"github.com/zhanbolat18/parcel/deliveries/internal/valueobjects"
 	"github.com/zhanbolat18/parcel/deliveries/pkg/http/request"
+	"github.com/zhanbolat18/parcel/libs/health"
+	"github.com/zhanbolat18/parcel/libs/idempotency"
+	"github.com/zhanbolat18/parcel/libs/metrics"
+	"github.com/zhanbolat18/parcel/libs/migrate"
+	"github.com/zhanbolat18/parcel/libs/tracing"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 	"go.uber.org/dig"
 	"log"
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
+	"syscall"
+	"time"
 )
 
 // @title Parcel Delivery Service
@@ -37,25 +52,137 @@ import (
 func main() {
 	c := dig.New()
 	provideDependencies(c)
-	mustWork(c.Invoke(func(engine *gin.Engine) {
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		runMigrations(c, os.Args[2:])
+		return
+	}
+	mustWork(c.Invoke(func(cfg *config.Config, migrator *migrate.Migrator) {
+		if !cfg.PgSQL.AutoMigrate {
+			return
+		}
+		if err := migrator.Up(context.Background()); err != nil {
+			log.Fatalf("auto migrate: %v", err)
+		}
+	}))
+	mustWork(c.Invoke(func(engine *gin.Engine, m *metrics.Metrics, h *health.Health) {
 		engine.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
+		engine.GET("/metrics", m.Handler())
+		engine.GET("/healthz", h.Liveness())
+		engine.GET("/readyz", h.Readiness())
 	}))
 	mustWork(c.Invoke(func(engine *gin.Engine,
 		controller *controllers.Delivery,
+		dispatch *controllers.Dispatch,
+		courier *controllers.Courier,
+		shift *controllers.Shift,
+		location *controllers.Location,
+		events *controllers.Events,
+		proof *controllers.Proof,
+		slot *controllers.Slot,
+		quote *controllers.Quote,
+		cash *controllers.Cash,
+		invoice *controllers.Invoice,
 		roleMw *middlewares.RoleMiddleware,
 		authProxyMw *middlewares.ApiAuthProxyMiddleware,
+		idempotencyMw *middlewares.IdempotencyMiddleware,
 		authMw *middlewares.AuthMiddleware) {
-		engine.POST("/deliveries", authMw.Auth(), roleMw.CheckRole("user"), controller.Create)
-		engine.GET("/deliveries", authMw.Auth(), roleMw.CheckRole("admin", "courier"), controller.GetAllDeliveries)
-		engine.GET("/deliveries/:id", authMw.Auth(), roleMw.CheckRole("admin", "courier"), controller.GetOneDelivery)
-		engine.PUT("/deliveries/:id/complete", authMw.Auth(), roleMw.CheckRole("courier"), controller.CompleteDelivery)
+		engine.POST("/deliveries",
+			authMw.Auth("deliveries:create"),
+			roleMw.CheckRole("user"),
+			roleMw.CheckOrgRole("owner", "staff"),
+			idempotencyMw.Idempotent(),
+			controller.Create)
+		engine.GET("/deliveries", authMw.Auth("deliveries:read"), roleMw.CheckRole("admin", "courier", "user"), controller.GetAllDeliveries)
+		engine.GET("/deliveries/:id", authMw.Auth("deliveries:read"), roleMw.CheckRole("admin", "courier", "user"), controller.GetOneDelivery)
+		engine.GET("/deliveries/stream", authMw.Auth("deliveries:read"), roleMw.CheckRole("admin", "courier", "user"), events.Stream)
+		engine.GET("/deliveries/:id/events", authMw.Auth("deliveries:read"), roleMw.CheckRole("admin", "courier", "user"), events.DeliveryEvents)
+		engine.PUT("/deliveries/:id/complete",
+			authMw.Auth(),
+			roleMw.CheckRole("courier"),
+			idempotencyMw.Idempotent(),
+			controller.CompleteDelivery)
+		engine.PUT("/deliveries/:id/complete/override",
+			authMw.Auth(),
+			roleMw.CheckRole("admin"),
+			idempotencyMw.Idempotent(),
+			controller.OverrideCompletion)
+		engine.GET("/deliveries/:id/audit", authMw.Auth(), roleMw.CheckRole("admin"), controller.GetAudit)
+		engine.POST("/deliveries/:id/attempts",
+			authMw.Auth(),
+			roleMw.CheckRole("courier"),
+			idempotencyMw.Idempotent(),
+			controller.FailAttempt)
+		engine.GET("/deliveries/:id/attempts", authMw.Auth("deliveries:read"), roleMw.CheckRole("admin", "courier", "user"), controller.GetAttempts)
+		engine.GET("/deliveries/:id/attempts/:attemptId/photo",
+			authMw.Auth("deliveries:read"),
+			roleMw.CheckRole("admin", "courier", "user"),
+			controller.GetAttemptPhoto)
+		engine.POST("/deliveries/:id/return",
+			authMw.Auth("deliveries:create"),
+			roleMw.CheckRole("user"),
+			roleMw.CheckOrgRole("owner", "staff"),
+			idempotencyMw.Idempotent(),
+			controller.Return)
+		engine.PUT("/deliveries/:id/reschedule",
+			authMw.Auth("deliveries:update"),
+			roleMw.CheckRole("user"),
+			roleMw.CheckOrgRole("owner", "staff"),
+			idempotencyMw.Idempotent(),
+			controller.Reschedule)
 		engine.POST("/deliveries/:id/courier/:courierId",
 			authMw.Auth(),
 			roleMw.CheckRole("admin"),
+			idempotencyMw.Idempotent(),
 			authProxyMw.Proxy(),
 			controller.AssignToCourier)
+		engine.GET("/deliveries/:id/proof", authMw.Auth("deliveries:read"), roleMw.CheckRole("admin", "user"), proof.GetProof)
+		engine.GET("/deliveries/:id/proof/files/:fileId", authMw.Auth("deliveries:read"), roleMw.CheckRole("admin", "user"), proof.GetFile)
+		engine.GET("/deliveries/:id/location", authMw.Auth("deliveries:read"), roleMw.CheckRole("user"), location.DeliveryLocation)
+		engine.POST("/deliveries/:id/dispatch",
+			authMw.Auth(),
+			roleMw.CheckRole("admin"),
+			authProxyMw.Proxy(),
+			dispatch.DispatchOne)
+		engine.POST("/deliveries/dispatch",
+			authMw.Auth(),
+			roleMw.CheckRole("admin"),
+			authProxyMw.Proxy(),
+			dispatch.DispatchAll)
+		engine.POST("/quotes", authMw.Auth("deliveries:create"), roleMw.CheckRole("user"), roleMw.CheckOrgRole("owner", "staff"), quote.Create)
+		engine.GET("/slots", authMw.Auth("deliveries:create"), roleMw.CheckRole("user", "admin"), slot.GetAll)
+		engine.GET("/couriers/me/availability", authMw.Auth(), roleMw.CheckRole("courier"), courier.MyAvailability)
+		engine.PUT("/couriers/me/availability", authMw.Auth(), roleMw.CheckRole("courier"), courier.SetMyAvailability)
+		engine.PUT("/couriers/:id/capacity", authMw.Auth(), roleMw.CheckRole("admin"), courier.SetCapacity)
+		engine.POST("/couriers/me/location", authMw.Auth(), roleMw.CheckRole("courier"), location.ReportMine)
+		engine.GET("/couriers/me/shifts", authMw.Auth(), roleMw.CheckRole("courier"), shift.MyShifts)
+		engine.GET("/couriers/me/cash", authMw.Auth(), roleMw.CheckRole("courier"), cash.MyLedger)
+		engine.GET("/couriers/:id/cash", authMw.Auth(), roleMw.CheckRole("admin"), cash.Ledger)
+		engine.POST("/couriers/:id/cash/handovers", authMw.Auth(), roleMw.CheckRole("admin"), cash.Handover)
+		engine.POST("/couriers/:id/cash/reconciliations", authMw.Auth(), roleMw.CheckRole("admin"), cash.Reconcile)
+		engine.GET("/cash/discrepancies", authMw.Auth(), roleMw.CheckRole("admin"), cash.Discrepancies)
+		engine.POST("/invoices", authMw.Auth(), roleMw.CheckRole("admin"), invoice.Generate)
+		engine.GET("/invoices", authMw.Auth("invoices:read"), roleMw.CheckRole("admin", "user"), invoice.GetAll)
+		engine.GET("/invoices/:id/pdf", authMw.Auth("invoices:read"), roleMw.CheckRole("admin", "user"), invoice.GetPDF)
+		engine.GET("/invoices/:id/csv", authMw.Auth("invoices:read"), roleMw.CheckRole("admin", "user"), invoice.GetCSV)
+		engine.POST("/shifts", authMw.Auth(), roleMw.CheckRole("admin"), shift.Create)
+		engine.GET("/shifts", authMw.Auth(), roleMw.CheckRole("admin"), shift.GetAll)
+		engine.GET("/shifts/:id", authMw.Auth(), roleMw.CheckRole("admin"), shift.GetOne)
+		engine.PUT("/shifts/:id", authMw.Auth(), roleMw.CheckRole("admin"), shift.Update)
+		engine.DELETE("/shifts/:id", authMw.Auth(), roleMw.CheckRole("admin"), shift.Delete)
 	}))
 
+	mustWork(c.Invoke(func(store idempotency.Store, cfg *config.Config) {
+		go idempotency.RunCleanup(context.Background(), store, cfg.Idempotency.CleanupInterval)
+	}))
+	mustWork(c.Invoke(func(locations *services.ManageLocation) error {
+		return locations.PreparePartitions(context.Background())
+	}))
+	mustWork(c.Invoke(func(locations *services.ManageLocation, cfg *config.Config) {
+		go locations.RunCleanup(context.Background(), cfg.Locations.CleanupInterval)
+	}))
+	mustWork(c.Invoke(func(sla *services.ManageSLA, cfg *config.Config) {
+		go sla.Run(context.Background(), cfg.SLA.CheckInterval)
+	}))
 	mustWork(c.Invoke(func(server *http.Server) {
 		go func() {
 			err := server.ListenAndServe()
@@ -69,10 +196,35 @@ func main() {
 
 func provideDependencies(container *dig.Container) {
 	mustWork(container.Provide(config.NewConfig))
-	mustWork(container.Provide(func(cfg *config.Config) *sqlx.DB {
+	mustWork(container.Provide(func() *metrics.Metrics {
+		return metrics.NewMetrics("deliveries")
+	}))
+	mustWork(container.Provide(func(cfg *config.Config) (*sdktrace.TracerProvider, error) {
+		return tracing.NewTracerProvider(context.Background(), "deliveries", tracing.ExporterConfig{
+			Exporter:     cfg.Tracing.Exporter,
+			OtlpEndpoint: cfg.Tracing.OtlpEndpoint,
+			OtlpInsecure: cfg.Tracing.OtlpInsecure,
+		})
+	}))
+	mustWork(container.Provide(func(tp *sdktrace.TracerProvider) *tracing.Tracing {
+		return tracing.NewTracing(tp, otel.GetTextMapPropagator())
+	}))
+	mustWork(container.Provide(func(cfg *config.Config, m *metrics.Metrics, tr *tracing.Tracing) *sqlx.DB {
 		connStr := fmt.Sprintf("user=%s password=%s dbname=%s host=%s port=%d sslmode=disable",
 			cfg.PgSQL.User, cfg.PgSQL.Password, cfg.PgSQL.DBName, cfg.PgSQL.Host, cfg.PgSQL.Port)
-		return sqlx.MustConnect(cfg.PgSQL.Driver, connStr)
+		sqlDb, err := tr.OpenDB(cfg.PgSQL.Driver, connStr)
+		mustWork(err)
+		err = health.WaitFor(context.Background(), "database", health.DBCheck(sqlDb),
+			cfg.PgSQL.ConnectAttempts, cfg.PgSQL.ConnectInterval)
+		if err != nil {
+			log.Fatalf("connect to database: %v", err)
+		}
+		db := sqlx.NewDb(sqlDb, cfg.PgSQL.Driver)
+		m.RegisterDB(db.DB, cfg.PgSQL.DBName)
+		return db
+	}))
+	mustWork(container.Provide(func(db *sqlx.DB) (*migrate.Migrator, error) {
+		return migrate.NewMigrator(db.DB, migrations.FS, os.Stdout)
 	}))
 
 	mustWork(container.Provide(func(
@@ -87,22 +239,226 @@ func provideDependencies(container *dig.Container) {
 		return decoratorMap
 	}))
 	mustWork(container.Provide(postgres.NewDeliveryRepository))
-	mustWork(container.Provide(services.NewManageDelivery))
+	mustWork(container.Provide(postgres.NewTxManager))
+	mustWork(container.Provide(postgres.NewAvailabilityRepository))
+	mustWork(container.Provide(postgres.NewShiftsRepository))
+	mustWork(container.Provide(services.NewManageShift))
+	mustWork(container.Provide(func(
+		availabilityRepo repositories.AvailabilityRepository,
+		deliveryRepo repositories.DeliveriesRepository,
+		cfg *config.Config,
+	) *services.ManageCourier {
+		return services.NewManageCourier(availabilityRepo, deliveryRepo, cfg.Couriers.MaxActiveDeliveries)
+	}))
+	mustWork(container.Provide(func(cfg *config.Config) *services.Broker {
+		return services.NewBroker(cfg.Events.HistorySize)
+	}))
+	mustWork(container.Provide(postgres.NewAuditRepository))
+	mustWork(container.Provide(postgres.NewAttemptsRepository))
+	mustWork(container.Provide(func(cfg *config.Config) (*services.HandoverCodes, error) {
+		return services.NewHandoverCodes(cfg.Handover.Secret, cfg.Handover.Digits, cfg.Handover.MaxAttempts)
+	}))
+	mustWork(container.Provide(func(m *metrics.Metrics) metrics.Counter {
+		return m.Events("deliveries_total", "Number of delivery lifecycle events.", "event",
+			services.EventCreated, services.EventAssigned, services.EventCompleted, services.EventCanceled,
+			services.EventHandoverLocked, services.EventAttemptFailed, services.EventRescheduled, services.EventReturned,
+			services.EventReturnCreated, services.EventSLAAtRisk, services.EventSLABreached)
+	}))
+	mustWork(container.Provide(func(cfg *config.Config) services.SLAPolicy {
+		return services.SLAPolicy{
+			Deadlines: map[valueobjects.ServiceLevel]time.Duration{
+				valueobjects.Standard: cfg.SLA.Standard,
+				valueobjects.Express:  cfg.SLA.Express,
+				valueobjects.SameDay:  cfg.SLA.SameDay,
+			},
+			AtRisk: cfg.SLA.AtRisk,
+		}
+	}))
+	mustWork(container.Provide(func(
+		deliveryRepo repositories.DeliveriesRepository,
+		policy services.SLAPolicy,
+		events metrics.Counter,
+		broker *services.Broker,
+	) *services.ManageSLA {
+		return services.NewManageSLA(deliveryRepo, policy, events, broker)
+	}))
+	mustWork(container.Provide(func(cfg *config.Config) (*services.Pricing, error) {
+		rules := services.PricingRules{
+			Currency:          cfg.Pricing.Currency,
+			RemoteZones:       cfg.Pricing.RemoteZones,
+			OversizeCm:        cfg.Pricing.OversizeCm,
+			OversizeKg:        cfg.Pricing.OversizeKg,
+			VolumetricDivisor: cfg.Pricing.VolumetricDivisor,
+		}
+		if err := json.Unmarshal([]byte(cfg.Pricing.Rates), &rules.Rates); err != nil {
+			return nil, fmt.Errorf("pricing rates: %w", err)
+		}
+		surcharges := []struct {
+			amount string
+			dst    *valueobjects.Money
+		}{
+			{cfg.Pricing.FragileSurcharge, &rules.Surcharges.Fragile},
+			{cfg.Pricing.OversizeSurcharge, &rules.Surcharges.Oversize},
+			{cfg.Pricing.RemoteSurcharge, &rules.Surcharges.Remote},
+		}
+		for _, surcharge := range surcharges {
+			money, err := valueobjects.ParseMoney(surcharge.amount)
+			if err != nil {
+				return nil, fmt.Errorf("pricing surcharge: %w", err)
+			}
+			*surcharge.dst = money
+		}
+		return services.NewPricing(rules, cfg.Quotes.Secret, cfg.Quotes.Ttl)
+	}))
+	mustWork(container.Provide(postgres.NewSlotBookingsRepository))
+	mustWork(container.Provide(func(cfg *config.Config) (*services.Calendar, error) {
+		return services.NewCalendar(services.CalendarRules{
+			TimeZone:     cfg.Calendar.TimeZone,
+			WorkingHours: cfg.Calendar.WorkingHours,
+			WorkingDays:  cfg.Calendar.WorkingDays,
+			Holidays:     cfg.Calendar.Holidays,
+			MinLeadTime:  cfg.Calendar.MinLeadTime,
+			SlotDuration: cfg.Slots.Duration,
+		})
+	}))
+	mustWork(container.Provide(func(
+		calendar *services.Calendar,
+		bookingsRepo repositories.SlotBookingsRepository,
+		cfg *config.Config,
+	) (*services.ManageSchedule, error) {
+		return services.NewManageSchedule(calendar, bookingsRepo, cfg.Slots.Zones, cfg.Slots.Capacity)
+	}))
+	mustWork(container.Provide(postgres.NewCashLedgerRepository))
+	mustWork(container.Provide(postgres.NewInvoicesRepository))
+	mustWork(container.Provide(services.NewManageInvoice))
+	mustWork(container.Provide(func(
+		ledgerRepo repositories.CashLedgerRepository,
+		txManager repositories.TxManager,
+		cfg *config.Config,
+	) *services.ManageCash {
+		return services.NewManageCash(ledgerRepo, txManager, cfg.Pricing.Currency)
+	}))
+	mustWork(container.Provide(func(
+		deliveryRepo repositories.DeliveriesRepository,
+		usersRepo repositories.UsersRepository,
+		proofsRepo repositories.ProofsRepository,
+		auditRepo repositories.AuditRepository,
+		attemptsRepo repositories.AttemptsRepository,
+		txManager repositories.TxManager,
+		couriers *services.ManageCourier,
+		shifts *services.ManageShift,
+		handover *services.HandoverCodes,
+		schedule *services.ManageSchedule,
+		pricing *services.Pricing,
+		cash *services.ManageCash,
+		sla services.SLAPolicy,
+		broker *services.Broker,
+		cfg *config.Config,
+		events metrics.Counter,
+	) *services.ManageDelivery {
+		guard := services.AssignmentGuard(couriers)
+		if cfg.Couriers.RequireShift {
+			guard = services.Guards(couriers, shifts)
+		}
+		returns := services.ReturnPolicy{Window: cfg.Returns.Window, Address: cfg.Returns.Address}
+		return services.NewManageDelivery(services.DeliveryDeps{
+			Deliveries: deliveryRepo,
+			Users:      usersRepo,
+			Proofs:     proofsRepo,
+			Audit:      auditRepo,
+			Attempts:   attemptsRepo,
+			TxManager:  txManager,
+			Guard:      guard,
+			Handover:   handover,
+			Schedule:   schedule,
+			Pricing:    pricing,
+			Cash:       cash,
+			Events:     events,
+			Publisher:  broker,
+		}, services.DeliveryPolicy{MaxAttempts: cfg.Deliveries.MaxAttempts, Returns: returns, SLA: sla})
+	}))
+	mustWork(container.Provide(func() repositories.LocationsRepository {
+		return memory.NewLocationsRepository()
+	}))
+	mustWork(container.Provide(func(
+		deliveryRepo repositories.DeliveriesRepository,
+		usersRepo repositories.UsersRepository,
+		locationsRepo repositories.LocationsRepository,
+		manage *services.ManageDelivery,
+		couriers *services.ManageCourier,
+		cfg *config.Config,
+	) *services.Dispatcher {
+		return services.NewDispatcher(deliveryRepo, usersRepo, locationsRepo, manage, couriers, cfg.Dispatch.Strategy)
+	}))
+	mustWork(container.Provide(postgres.NewProofsRepository))
+	mustWork(container.Provide(func(cfg *config.Config) (repositories.BlobStore, error) {
+		return filesystem.NewBlobStore(cfg.Proof.StoragePath)
+	}))
+	mustWork(container.Provide(func(
+		blobStore repositories.BlobStore,
+		proofsRepo repositories.ProofsRepository,
+		deliveryRepo repositories.DeliveriesRepository,
+		cfg *config.Config,
+	) *services.ManageProof {
+		return services.NewManageProof(blobStore, proofsRepo, deliveryRepo, cfg.Proof.MaxPhotos, cfg.Proof.MaxFileSize)
+	}))
+	mustWork(container.Provide(postgres.NewLocationHistoryRepository))
+	mustWork(container.Provide(func(
+		historyRepo repositories.LocationHistoryRepository,
+		locationsRepo repositories.LocationsRepository,
+		deliveryRepo repositories.DeliveriesRepository,
+		cfg *config.Config,
+	) *services.ManageLocation {
+		return services.NewManageLocation(historyRepo, locationsRepo, deliveryRepo, cfg.Locations.Retention)
+	}))
 	mustWork(container.Provide(middlewares.NewRoleMiddleware))
 	mustWork(container.Provide(middlewares.NewApiAuthProxyMiddleware))
+	mustWork(container.Provide(func(db *sqlx.DB) idempotency.Store {
+		return idempotency.NewPostgresStore(db.DB, "idempotency_keys")
+	}))
+	mustWork(container.Provide(func(
+		store idempotency.Store,
+		cfg *config.Config,
+		proofs *services.ManageProof,
+	) *idempotency.Idempotency {
+		// completions carrying a proof are the largest idempotent requests
+		return idempotency.NewIdempotency(store, cfg.Idempotency.Ttl, proofs.MaxUploadSize())
+	}))
+	mustWork(container.Provide(middlewares.NewIdempotencyMiddleware))
 	mustWork(container.Provide(func(client *http.Client, cfg *config.Config) *middlewares.AuthMiddleware {
 		return middlewares.NewAuthMiddleware(client, cfg.Services.UsersBaseUrl)
 	}))
 	mustWork(container.Provide(controllers.NewDeliveryController))
+	mustWork(container.Provide(controllers.NewDispatchController))
+	mustWork(container.Provide(controllers.NewCourierController))
+	mustWork(container.Provide(controllers.NewShiftController))
+	mustWork(container.Provide(controllers.NewLocationController))
+	mustWork(container.Provide(controllers.NewProofController))
+	mustWork(container.Provide(controllers.NewSlotController))
+	mustWork(container.Provide(controllers.NewQuoteController))
+	mustWork(container.Provide(controllers.NewCashController))
+	mustWork(container.Provide(controllers.NewInvoiceController))
+	mustWork(container.Provide(func(srv *services.ManageDelivery, broker *services.Broker, cfg *config.Config) *controllers.Events {
+		return controllers.NewEventsController(srv, broker, cfg.Events.HeartbeatInterval)
+	}))
 
-	mustWork(container.Provide(func(cfg *config.Config) *http.Client {
-		return &http.Client{
+	mustWork(container.Provide(func(cfg *config.Config, m *metrics.Metrics, tr *tracing.Tracing) *http.Client {
+		client := m.InstrumentClient(&http.Client{
 			Timeout: cfg.HttpClient.Timeout,
-		}
+		}, "users")
+		return tr.InstrumentClient(client)
 	}))
-	mustWork(container.Provide(func() *gin.Engine {
+	mustWork(container.Provide(func(cfg *config.Config, db *sqlx.DB, client *http.Client) *health.Health {
+		h := health.NewHealth(cfg.Health.CheckTimeout)
+		h.Register("database", health.DBCheck(db.DB))
+		h.Register("users", health.HttpCheck(client,
+			fmt.Sprintf("%s/healthz", strings.TrimRight(cfg.Services.UsersBaseUrl, "/"))))
+		return h
+	}))
+	mustWork(container.Provide(func(m *metrics.Metrics, tr *tracing.Tracing) *gin.Engine {
 		engine := gin.Default()
-		engine.Use(gin.Logger(), gin.Recovery())
+		engine.ContextWithFallback = true
+		engine.Use(gin.Logger(), gin.Recovery(), tr.Middleware(), m.Middleware())
 		return engine
 	}))
 	mustWork(container.Provide(func(engine *gin.Engine, cfg *config.Config) *http.Server {
@@ -114,6 +470,18 @@ func provideDependencies(container *dig.Container) {
 
 }
 
+func runMigrations(c *dig.Container, args []string) {
+	if len(args) != 1 {
+		log.Fatalf("usage: %s migrate %s|%s|%s|%s", os.Args[0],
+			migrate.CommandUp, migrate.CommandDown, migrate.CommandStatus, migrate.CommandRedo)
+	}
+	mustWork(c.Invoke(func(migrator *migrate.Migrator) {
+		if err := migrator.Run(context.Background(), args[0]); err != nil {
+			log.Fatalf("migrate %s: %v", args[0], err)
+		}
+	}))
+}
+
 func mustWork(e error) {
 	if e != nil {
 		panic(e)
@@ -121,15 +489,30 @@ func mustWork(e error) {
 }
 
 func gracefulShutdown(c *dig.Container) {
-	ch := make(chan os.Signal)
-	signal.Notify(ch, os.Interrupt)
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, os.Interrupt, syscall.SIGTERM)
 	<-ch
-	mustWork(c.Invoke(func(server *http.Server, cfg *config.Config) {
+	mustWork(c.Invoke(func(
+		server *http.Server,
+		tp *sdktrace.TracerProvider,
+		h *health.Health,
+		broker *services.Broker,
+		cfg *config.Config,
+	) {
+		h.Shutdown()
+		time.Sleep(cfg.Server.ReadinessTime)
+		// event streams never end on their own, closing them lets Shutdown
+		// wait only for regular requests
+		broker.Close()
 		ctx, cf := context.WithTimeout(context.Background(), cfg.Server.ShutdownTime)
 		defer cf()
 		err := server.Shutdown(ctx)
 		if err != nil {
 			log.Printf("server shutting down with error %v", err)
 		}
+		err = tp.Shutdown(ctx)
+		if err != nil {
+			log.Printf("tracer provider shutting down with error %v", err)
+		}
 	}))
 }
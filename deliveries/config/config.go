@@ -2,14 +2,31 @@ package config
 
 import (
 	"github.com/spf13/viper"
+	"strings"
 	"time"
 )
 
 type Config struct {
-	PgSQL      *PgSQLConfig
-	Server     *Listener
-	Services   *Services
-	HttpClient *HttpClient
+	PgSQL       *PgSQLConfig
+	Server      *Listener
+	Tracing     *TracingConfig
+	Health      *HealthConfig
+	Idempotency *IdempotencyConfig
+	Dispatch    *DispatchConfig
+	Couriers    *CouriersConfig
+	Locations   *LocationsConfig
+	Events      *EventsConfig
+	Proof       *ProofConfig
+	Handover    *HandoverConfig
+	Deliveries  *DeliveriesConfig
+	Returns     *ReturnsConfig
+	Calendar    *CalendarConfig
+	Slots       *SlotsConfig
+	SLA         *SLAConfig
+	Pricing     *PricingConfig
+	Quotes      *QuotesConfig
+	Services    *Services
+	HttpClient  *HttpClient
 }
 
 type HttpClient struct {
@@ -27,11 +44,112 @@ type PgSQLConfig struct {
 	User     string
 	Password string
 	DBName   string
+
+	ConnectAttempts int
+	ConnectInterval time.Duration
+	AutoMigrate     bool
+}
+
+type TracingConfig struct {
+	Exporter     string
+	OtlpEndpoint string
+	OtlpInsecure bool
+}
+
+type HealthConfig struct {
+	CheckTimeout time.Duration
+}
+
+type IdempotencyConfig struct {
+	Ttl             time.Duration
+	CleanupInterval time.Duration
+}
+
+type DispatchConfig struct {
+	Strategy string
+}
+
+type CouriersConfig struct {
+	MaxActiveDeliveries uint
+	RequireShift        bool
+}
+
+type LocationsConfig struct {
+	Retention       time.Duration
+	CleanupInterval time.Duration
+}
+
+type EventsConfig struct {
+	HeartbeatInterval time.Duration
+	HistorySize       int
+}
+
+type ProofConfig struct {
+	StoragePath string
+	MaxPhotos   int
+	MaxFileSize int64
+}
+
+type HandoverConfig struct {
+	Secret      string
+	Digits      int
+	MaxAttempts uint
+}
+
+type DeliveriesConfig struct {
+	MaxAttempts uint
+}
+
+type ReturnsConfig struct {
+	Window  time.Duration
+	Address string
+}
+
+type CalendarConfig struct {
+	TimeZone     string
+	WorkingHours string
+	WorkingDays  []string
+	Holidays     []string
+	MinLeadTime  time.Duration
+}
+
+type SlotsConfig struct {
+	Duration time.Duration
+	Capacity uint
+	Zones    []string
+}
+
+type SLAConfig struct {
+	Standard      time.Duration
+	Express       time.Duration
+	SameDay       time.Duration
+	AtRisk        time.Duration
+	CheckInterval time.Duration
+}
+
+// PricingConfig keeps amounts as decimal strings, Rates is a JSON object of
+// rate tables by service level.
+type PricingConfig struct {
+	Currency          string
+	Rates             string
+	FragileSurcharge  string
+	OversizeSurcharge string
+	RemoteSurcharge   string
+	RemoteZones       []string
+	OversizeCm        uint
+	OversizeKg        float64
+	VolumetricDivisor uint
+}
+
+type QuotesConfig struct {
+	Secret string
+	Ttl    time.Duration
 }
 
 type Listener struct {
-	Port         string
-	ShutdownTime time.Duration
+	Port          string
+	ShutdownTime  time.Duration
+	ReadinessTime time.Duration
 }
 
 func NewConfig() *Config {
@@ -39,7 +157,53 @@ func NewConfig() *Config {
 	vpr.AutomaticEnv()
 	vpr.SetDefault(PgDriverName, "postgres")
 	vpr.SetDefault(Port, ":8080")
+	vpr.SetDefault(PgConnectAttempts, 10)
+	vpr.SetDefault(PgConnectInterval, 2*time.Second)
 	vpr.SetDefault(ShutdownTime, 10*time.Second)
+	vpr.SetDefault(ShutdownReadinessTime, 0)
+	vpr.SetDefault(HealthCheckTimeout, 2*time.Second)
+	vpr.SetDefault(TracingExporter, "none")
+	vpr.SetDefault(DispatchStrategy, "least_active")
+	vpr.SetDefault(CourierMaxActiveDeliveries, 10)
+	vpr.SetDefault(AssignmentRequireShift, false)
+	vpr.SetDefault(LocationRetention, 7*24*time.Hour)
+	vpr.SetDefault(LocationCleanupInterval, time.Hour)
+	vpr.SetDefault(EventsHeartbeatInterval, 15*time.Second)
+	vpr.SetDefault(EventsHistorySize, 1000)
+	vpr.SetDefault(ProofStoragePath, "storage/proofs")
+	vpr.SetDefault(ProofMaxPhotos, 5)
+	vpr.SetDefault(ProofMaxFileSize, 5<<20)
+	vpr.SetDefault(HandoverCodeDigits, 6)
+	vpr.SetDefault(HandoverMaxAttempts, 5)
+	vpr.SetDefault(DeliveryMaxAttempts, 3)
+	vpr.SetDefault(ReturnWindow, 14*24*time.Hour)
+	vpr.SetDefault(CalendarTimeZone, "UTC")
+	vpr.SetDefault(CalendarWorkingHours, "09:00-21:00")
+	vpr.SetDefault(CalendarWorkingDays, "mon,tue,wed,thu,fri,sat")
+	vpr.SetDefault(CalendarMinLeadTime, 2*time.Hour)
+	vpr.SetDefault(SlotDuration, 2*time.Hour)
+	vpr.SetDefault(SlotCapacity, 20)
+	vpr.SetDefault(SlotZones, "default")
+	vpr.SetDefault(SLAStandard, 72*time.Hour)
+	vpr.SetDefault(SLAExpress, 24*time.Hour)
+	vpr.SetDefault(SLASameDay, 8*time.Hour)
+	vpr.SetDefault(SLAAtRisk, time.Hour)
+	vpr.SetDefault(SLACheckInterval, time.Minute)
+	vpr.SetDefault(PricingCurrency, "KZT")
+	vpr.SetDefault(PricingRates, `{
+		"standard": {"base": "1000.00", "per_km": "100.00", "per_kg": "200.00", "zone": "1500.00"},
+		"express": {"base": "2000.00", "per_km": "150.00", "per_kg": "250.00", "zone": "2500.00"},
+		"same_day": {"base": "3000.00", "per_km": "200.00", "per_kg": "300.00", "zone": "3500.00"}
+	}`)
+	vpr.SetDefault(PricingFragileSurcharge, "500.00")
+	vpr.SetDefault(PricingOversizeSurcharge, "1500.00")
+	vpr.SetDefault(PricingRemoteSurcharge, "1000.00")
+	vpr.SetDefault(PricingOversizeCm, 120)
+	vpr.SetDefault(PricingOversizeKg, 30)
+	vpr.SetDefault(PricingVolumetricDivisor, 5000)
+	vpr.SetDefault(QuoteTtl, 15*time.Minute)
+	vpr.SetDefault(IdempotencyTtl, 24*time.Hour)
+	vpr.SetDefault(IdempotencyCleanupInterval, time.Hour)
 	vpr.SetDefault(HttpClientTimeout, 10*time.Second)
 
 	return &Config{
@@ -50,10 +214,93 @@ func NewConfig() *Config {
 			User:     vpr.GetString(PgUser),
 			Password: vpr.GetString(PgPwd),
 			DBName:   vpr.GetString(PgDbName),
+
+			ConnectAttempts: vpr.GetInt(PgConnectAttempts),
+			ConnectInterval: vpr.GetDuration(PgConnectInterval),
+			AutoMigrate:     vpr.GetBool(AutoMigrate),
 		},
 		Server: &Listener{
-			Port:         vpr.GetString(Port),
-			ShutdownTime: vpr.GetDuration(ShutdownTime),
+			Port:          vpr.GetString(Port),
+			ShutdownTime:  vpr.GetDuration(ShutdownTime),
+			ReadinessTime: vpr.GetDuration(ShutdownReadinessTime),
+		},
+		Health: &HealthConfig{
+			CheckTimeout: vpr.GetDuration(HealthCheckTimeout),
+		},
+		Idempotency: &IdempotencyConfig{
+			Ttl:             vpr.GetDuration(IdempotencyTtl),
+			CleanupInterval: vpr.GetDuration(IdempotencyCleanupInterval),
+		},
+		Dispatch: &DispatchConfig{
+			Strategy: vpr.GetString(DispatchStrategy),
+		},
+		Couriers: &CouriersConfig{
+			MaxActiveDeliveries: vpr.GetUint(CourierMaxActiveDeliveries),
+			RequireShift:        vpr.GetBool(AssignmentRequireShift),
+		},
+		Locations: &LocationsConfig{
+			Retention:       vpr.GetDuration(LocationRetention),
+			CleanupInterval: vpr.GetDuration(LocationCleanupInterval),
+		},
+		Events: &EventsConfig{
+			HeartbeatInterval: vpr.GetDuration(EventsHeartbeatInterval),
+			HistorySize:       vpr.GetInt(EventsHistorySize),
+		},
+		Proof: &ProofConfig{
+			StoragePath: vpr.GetString(ProofStoragePath),
+			MaxPhotos:   vpr.GetInt(ProofMaxPhotos),
+			MaxFileSize: vpr.GetInt64(ProofMaxFileSize),
+		},
+		Handover: &HandoverConfig{
+			Secret:      vpr.GetString(HandoverSecret),
+			Digits:      vpr.GetInt(HandoverCodeDigits),
+			MaxAttempts: vpr.GetUint(HandoverMaxAttempts),
+		},
+		Deliveries: &DeliveriesConfig{
+			MaxAttempts: vpr.GetUint(DeliveryMaxAttempts),
+		},
+		Returns: &ReturnsConfig{
+			Window:  vpr.GetDuration(ReturnWindow),
+			Address: vpr.GetString(ReturnAddress),
+		},
+		Calendar: &CalendarConfig{
+			TimeZone:     vpr.GetString(CalendarTimeZone),
+			WorkingHours: vpr.GetString(CalendarWorkingHours),
+			WorkingDays:  splitList(vpr.GetString(CalendarWorkingDays)),
+			Holidays:     splitList(vpr.GetString(CalendarHolidays)),
+			MinLeadTime:  vpr.GetDuration(CalendarMinLeadTime),
+		},
+		Slots: &SlotsConfig{
+			Duration: vpr.GetDuration(SlotDuration),
+			Capacity: vpr.GetUint(SlotCapacity),
+			Zones:    splitList(vpr.GetString(SlotZones)),
+		},
+		SLA: &SLAConfig{
+			Standard:      vpr.GetDuration(SLAStandard),
+			Express:       vpr.GetDuration(SLAExpress),
+			SameDay:       vpr.GetDuration(SLASameDay),
+			AtRisk:        vpr.GetDuration(SLAAtRisk),
+			CheckInterval: vpr.GetDuration(SLACheckInterval),
+		},
+		Pricing: &PricingConfig{
+			Currency:          vpr.GetString(PricingCurrency),
+			Rates:             vpr.GetString(PricingRates),
+			FragileSurcharge:  vpr.GetString(PricingFragileSurcharge),
+			OversizeSurcharge: vpr.GetString(PricingOversizeSurcharge),
+			RemoteSurcharge:   vpr.GetString(PricingRemoteSurcharge),
+			RemoteZones:       splitList(vpr.GetString(PricingRemoteZones)),
+			OversizeCm:        vpr.GetUint(PricingOversizeCm),
+			OversizeKg:        vpr.GetFloat64(PricingOversizeKg),
+			VolumetricDivisor: vpr.GetUint(PricingVolumetricDivisor),
+		},
+		Quotes: &QuotesConfig{
+			Secret: vpr.GetString(QuoteSecret),
+			Ttl:    vpr.GetDuration(QuoteTtl),
+		},
+		Tracing: &TracingConfig{
+			Exporter:     vpr.GetString(TracingExporter),
+			OtlpEndpoint: vpr.GetString(TracingOtlpEndpoint),
+			OtlpInsecure: vpr.GetBool(TracingOtlpInsecure),
 		},
 		Services: &Services{
 			UsersBaseUrl: vpr.GetString(UsersServiceUrl),
@@ -63,3 +310,14 @@ func NewConfig() *Config {
 		},
 	}
 }
+
+// splitList splits a comma separated env value, skipping empty items.
+func splitList(value string) []string {
+	items := make([]string, 0)
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -7,11 +7,102 @@ const (
 	PgPwd        = "PG_PASSWORD"
 	PgDbName     = "PG_DBNAME"
 	PgDriverName = "PG_DRIVERNAME"
+
+	PgConnectAttempts = "PG_CONNECT_ATTEMPTS"
+	PgConnectInterval = "PG_CONNECT_INTERVAL"
+	AutoMigrate       = "AUTO_MIGRATE"
+)
+
+const (
+	Port                  = "APP_PORT"
+	ShutdownTime          = "SHUTDOWN_TIME"
+	ShutdownReadinessTime = "SHUTDOWN_READINESS_TIME"
+	HealthCheckTimeout    = "HEALTH_CHECK_TIMEOUT"
+)
+
+const (
+	TracingExporter     = "TRACING_EXPORTER"
+	TracingOtlpEndpoint = "TRACING_OTLP_ENDPOINT"
+	TracingOtlpInsecure = "TRACING_OTLP_INSECURE"
+)
+
+const (
+	IdempotencyTtl             = "IDEMPOTENCY_TTL"
+	IdempotencyCleanupInterval = "IDEMPOTENCY_CLEANUP_INTERVAL"
+)
+
+const (
+	DispatchStrategy           = "DISPATCH_STRATEGY"
+	CourierMaxActiveDeliveries = "COURIER_MAX_ACTIVE_DELIVERIES"
+	AssignmentRequireShift     = "ASSIGNMENT_REQUIRE_SHIFT"
+)
+
+const (
+	LocationRetention       = "LOCATION_RETENTION"
+	LocationCleanupInterval = "LOCATION_CLEANUP_INTERVAL"
+)
+
+const (
+	EventsHeartbeatInterval = "EVENTS_HEARTBEAT_INTERVAL"
+	EventsHistorySize       = "EVENTS_HISTORY_SIZE"
+)
+
+const (
+	ProofStoragePath = "PROOF_STORAGE_PATH"
+	ProofMaxPhotos   = "PROOF_MAX_PHOTOS"
+	ProofMaxFileSize = "PROOF_MAX_FILE_SIZE"
+)
+
+const (
+	HandoverSecret      = "HANDOVER_SECRET"
+	HandoverCodeDigits  = "HANDOVER_CODE_DIGITS"
+	HandoverMaxAttempts = "HANDOVER_MAX_ATTEMPTS"
+)
+
+const DeliveryMaxAttempts = "DELIVERY_MAX_ATTEMPTS"
+
+const (
+	ReturnWindow  = "RETURN_WINDOW"
+	ReturnAddress = "RETURN_ADDRESS"
+)
+
+const (
+	CalendarTimeZone     = "CALENDAR_TIMEZONE"
+	CalendarWorkingHours = "CALENDAR_WORKING_HOURS"
+	CalendarWorkingDays  = "CALENDAR_WORKING_DAYS"
+	CalendarHolidays     = "CALENDAR_HOLIDAYS"
+	CalendarMinLeadTime  = "CALENDAR_MIN_LEAD_TIME"
+)
+
+const (
+	SlotDuration = "SLOT_DURATION"
+	SlotCapacity = "SLOT_CAPACITY"
+	SlotZones    = "SLOT_ZONES"
+)
+
+const (
+	SLAStandard      = "SLA_STANDARD"
+	SLAExpress       = "SLA_EXPRESS"
+	SLASameDay       = "SLA_SAME_DAY"
+	SLAAtRisk        = "SLA_AT_RISK"
+	SLACheckInterval = "SLA_CHECK_INTERVAL"
+)
+
+const (
+	PricingCurrency          = "PRICING_CURRENCY"
+	PricingRates             = "PRICING_RATES"
+	PricingFragileSurcharge  = "PRICING_FRAGILE_SURCHARGE"
+	PricingOversizeSurcharge = "PRICING_OVERSIZE_SURCHARGE"
+	PricingRemoteSurcharge   = "PRICING_REMOTE_SURCHARGE"
+	PricingRemoteZones       = "PRICING_REMOTE_ZONES"
+	PricingOversizeCm        = "PRICING_OVERSIZE_CM"
+	PricingOversizeKg        = "PRICING_OVERSIZE_KG"
+	PricingVolumetricDivisor = "PRICING_VOLUMETRIC_DIVISOR"
 )
 
 const (
-	Port         = "APP_PORT"
-	ShutdownTime = "SHUTDOWN_TIME"
+	QuoteSecret = "QUOTE_SECRET"
+	QuoteTtl    = "QUOTE_TTL"
 )
 
 const UsersServiceUrl = "USERS_BASE_URL"
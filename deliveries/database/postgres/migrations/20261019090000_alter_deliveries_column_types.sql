@@ -0,0 +1,33 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE deliveries
+    ALTER COLUMN recipient_id TYPE BIGINT USING recipient_id::BIGINT,
+    ALTER COLUMN courier_id TYPE BIGINT USING NULLIF(courier_id, '')::BIGINT,
+    ALTER COLUMN created_at TYPE TIMESTAMPTZ USING COALESCE(NULLIF(created_at, '')::TIMESTAMPTZ, now()),
+    ALTER COLUMN updated_at TYPE TIMESTAMPTZ USING COALESCE(NULLIF(updated_at, '')::TIMESTAMPTZ, now());
+ALTER TABLE deliveries
+    ALTER COLUMN created_at SET DEFAULT now(),
+    ALTER COLUMN created_at SET NOT NULL,
+    ALTER COLUMN updated_at SET DEFAULT now(),
+    ALTER COLUMN updated_at SET NOT NULL;
+CREATE INDEX deliveries_courier_id_idx ON deliveries (courier_id);
+CREATE INDEX deliveries_recipient_id_idx ON deliveries (recipient_id);
+CREATE INDEX deliveries_status_idx ON deliveries (status);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX deliveries_status_idx;
+DROP INDEX deliveries_recipient_id_idx;
+DROP INDEX deliveries_courier_id_idx;
+ALTER TABLE deliveries
+    ALTER COLUMN created_at DROP NOT NULL,
+    ALTER COLUMN created_at DROP DEFAULT,
+    ALTER COLUMN updated_at DROP NOT NULL,
+    ALTER COLUMN updated_at DROP DEFAULT;
+ALTER TABLE deliveries
+    ALTER COLUMN recipient_id TYPE VARCHAR(255) USING recipient_id::VARCHAR,
+    ALTER COLUMN courier_id TYPE VARCHAR(255) USING courier_id::VARCHAR,
+    ALTER COLUMN created_at TYPE VARCHAR(255) USING to_char(created_at AT TIME ZONE 'UTC', 'YYYY-MM-DD"T"HH24:MI:SS"Z"'),
+    ALTER COLUMN updated_at TYPE VARCHAR(255) USING to_char(updated_at AT TIME ZONE 'UTC', 'YYYY-MM-DD"T"HH24:MI:SS"Z"');
+-- +goose StatementEnd
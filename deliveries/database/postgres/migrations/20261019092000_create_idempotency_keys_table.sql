@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE idempotency_keys
+(
+    key          VARCHAR(320) PRIMARY KEY,
+    fingerprint  VARCHAR(64) NOT NULL,
+    completed    BOOLEAN     NOT NULL DEFAULT FALSE,
+    status_code  INTEGER,
+    content_type VARCHAR(255),
+    body         BYTEA,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT now(),
+    expires_at   TIMESTAMPTZ NOT NULL
+);
+CREATE INDEX idempotency_keys_expires_at_idx ON idempotency_keys (expires_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE idempotency_keys;
+-- +goose StatementEnd
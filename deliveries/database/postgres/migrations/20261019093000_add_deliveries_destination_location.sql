@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE deliveries
+    ADD COLUMN destination_lat DOUBLE PRECISION DEFAULT NULL,
+    ADD COLUMN destination_lng DOUBLE PRECISION DEFAULT NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE deliveries
+    DROP COLUMN destination_lat,
+    DROP COLUMN destination_lng;
+-- +goose StatementEnd
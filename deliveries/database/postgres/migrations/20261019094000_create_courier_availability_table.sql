@@ -0,0 +1,15 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE courier_availability
+(
+    courier_id            BIGINT PRIMARY KEY,
+    status                VARCHAR(255) NOT NULL,
+    max_active_deliveries INTEGER DEFAULT NULL,
+    updated_at            TIMESTAMPTZ  NOT NULL DEFAULT now()
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE courier_availability;
+-- +goose StatementEnd
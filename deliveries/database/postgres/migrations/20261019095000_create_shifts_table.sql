@@ -0,0 +1,26 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE shifts
+(
+    id         BIGSERIAL PRIMARY KEY,
+    courier_id BIGINT       NOT NULL,
+    starts_at  TIMESTAMPTZ  NOT NULL,
+    ends_at    TIMESTAMPTZ  NOT NULL,
+    zone       VARCHAR(255) NOT NULL,
+    CHECK (ends_at > starts_at)
+);
+CREATE INDEX shifts_courier_id_starts_at_idx ON shifts (courier_id, starts_at);
+
+ALTER TABLE deliveries
+    ADD COLUMN window_start TIMESTAMPTZ DEFAULT NULL,
+    ADD COLUMN window_end   TIMESTAMPTZ DEFAULT NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE deliveries
+    DROP COLUMN window_start,
+    DROP COLUMN window_end;
+
+DROP TABLE shifts;
+-- +goose StatementEnd
@@ -0,0 +1,21 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE courier_locations
+(
+    courier_id  BIGINT           NOT NULL,
+    latitude    DOUBLE PRECISION NOT NULL,
+    longitude   DOUBLE PRECISION NOT NULL,
+    accuracy    DOUBLE PRECISION NOT NULL,
+    recorded_at TIMESTAMPTZ      NOT NULL
+) PARTITION BY RANGE (recorded_at);
+CREATE INDEX courier_locations_courier_id_recorded_at_idx ON courier_locations (courier_id, recorded_at);
+
+-- daily partitions are created by the service, the default one only catches
+-- fixes recorded before they exist
+CREATE TABLE courier_locations_default PARTITION OF courier_locations DEFAULT;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE courier_locations;
+-- +goose StatementEnd
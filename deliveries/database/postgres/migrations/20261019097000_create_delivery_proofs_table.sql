@@ -0,0 +1,28 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE delivery_proofs
+(
+    delivery_id BIGINT PRIMARY KEY REFERENCES deliveries (id),
+    received_by VARCHAR(255)     NOT NULL,
+    latitude    DOUBLE PRECISION DEFAULT NULL,
+    longitude   DOUBLE PRECISION DEFAULT NULL,
+    created_at  TIMESTAMPTZ      NOT NULL
+);
+
+CREATE TABLE delivery_proof_files
+(
+    id           BIGSERIAL PRIMARY KEY,
+    delivery_id  BIGINT       NOT NULL REFERENCES delivery_proofs (delivery_id),
+    kind         VARCHAR(16)  NOT NULL,
+    content_type VARCHAR(255) NOT NULL,
+    size         BIGINT       NOT NULL,
+    blob_key     VARCHAR(512) NOT NULL
+);
+CREATE INDEX delivery_proof_files_delivery_id_idx ON delivery_proof_files (delivery_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE delivery_proof_files;
+DROP TABLE delivery_proofs;
+-- +goose StatementEnd
@@ -0,0 +1,30 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE deliveries
+    ADD COLUMN handover_nonce     VARCHAR(64) DEFAULT NULL,
+    ADD COLUMN handover_code_hash VARCHAR(64) DEFAULT NULL,
+    ADD COLUMN handover_attempts  INTEGER     NOT NULL DEFAULT 0,
+    ADD COLUMN handover_locked    BOOLEAN     NOT NULL DEFAULT FALSE;
+
+CREATE TABLE delivery_audit
+(
+    id          BIGSERIAL PRIMARY KEY,
+    delivery_id BIGINT      NOT NULL,
+    actor_id    BIGINT      DEFAULT NULL,
+    action      VARCHAR(64) NOT NULL,
+    details     TEXT        NOT NULL DEFAULT '',
+    created_at  TIMESTAMPTZ NOT NULL
+);
+CREATE INDEX delivery_audit_delivery_id_idx ON delivery_audit (delivery_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE delivery_audit;
+
+ALTER TABLE deliveries
+    DROP COLUMN handover_nonce,
+    DROP COLUMN handover_code_hash,
+    DROP COLUMN handover_attempts,
+    DROP COLUMN handover_locked;
+-- +goose StatementEnd
@@ -0,0 +1,27 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE deliveries
+    ADD COLUMN attempts INTEGER NOT NULL DEFAULT 0;
+
+CREATE TABLE delivery_attempts
+(
+    id                 BIGSERIAL PRIMARY KEY,
+    delivery_id        BIGINT       NOT NULL REFERENCES deliveries (id),
+    courier_id         BIGINT       NOT NULL,
+    reason             VARCHAR(32)  NOT NULL,
+    note               TEXT         NOT NULL DEFAULT '',
+    photo_content_type VARCHAR(255) DEFAULT NULL,
+    photo_size         BIGINT       DEFAULT NULL,
+    photo_key          VARCHAR(512) DEFAULT NULL,
+    created_at         TIMESTAMPTZ  NOT NULL
+);
+CREATE INDEX delivery_attempts_delivery_id_idx ON delivery_attempts (delivery_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE delivery_attempts;
+
+ALTER TABLE deliveries
+    DROP COLUMN attempts;
+-- +goose StatementEnd
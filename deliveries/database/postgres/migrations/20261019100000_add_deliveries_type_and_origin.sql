@@ -0,0 +1,25 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE deliveries
+    ADD COLUMN type         VARCHAR(16)      NOT NULL DEFAULT 'forward',
+    ADD COLUMN original_id  BIGINT           DEFAULT NULL REFERENCES deliveries (id),
+    ADD COLUMN origin       VARCHAR(255)     DEFAULT NULL,
+    ADD COLUMN origin_lat   DOUBLE PRECISION DEFAULT NULL,
+    ADD COLUMN origin_lng   DOUBLE PRECISION DEFAULT NULL,
+    ADD COLUMN completed_at TIMESTAMPTZ      DEFAULT NULL;
+-- a delivery is returned at most once
+CREATE UNIQUE INDEX deliveries_original_id_idx ON deliveries (original_id) WHERE original_id IS NOT NULL;
+UPDATE deliveries SET completed_at = updated_at WHERE status = 'completed';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX deliveries_original_id_idx;
+ALTER TABLE deliveries
+    DROP COLUMN type,
+    DROP COLUMN original_id,
+    DROP COLUMN origin,
+    DROP COLUMN origin_lat,
+    DROP COLUMN origin_lng,
+    DROP COLUMN completed_at;
+-- +goose StatementEnd
@@ -0,0 +1,25 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE deliveries
+    ADD COLUMN zone          VARCHAR(64) DEFAULT NULL,
+    ADD COLUMN scheduled_for DATE        DEFAULT NULL,
+    ADD COLUMN slot_start    TIMESTAMPTZ DEFAULT NULL,
+    ADD COLUMN slot_end      TIMESTAMPTZ DEFAULT NULL;
+CREATE TABLE slot_bookings
+(
+    zone       VARCHAR(64) NOT NULL,
+    slot_start TIMESTAMPTZ NOT NULL,
+    booked     INTEGER     NOT NULL DEFAULT 0 CHECK (booked >= 0),
+    PRIMARY KEY (zone, slot_start)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE slot_bookings;
+ALTER TABLE deliveries
+    DROP COLUMN zone,
+    DROP COLUMN scheduled_for,
+    DROP COLUMN slot_start,
+    DROP COLUMN slot_end;
+-- +goose StatementEnd
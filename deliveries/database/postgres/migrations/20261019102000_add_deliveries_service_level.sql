@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE deliveries
+    ADD COLUMN service_level VARCHAR(16) NOT NULL DEFAULT 'standard',
+    ADD COLUMN due_at        TIMESTAMPTZ DEFAULT NULL,
+    ADD COLUMN sla_status    VARCHAR(16) NOT NULL DEFAULT 'on_track';
+CREATE INDEX deliveries_due_at_idx ON deliveries (due_at) WHERE sla_status <> 'breached';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX deliveries_due_at_idx;
+ALTER TABLE deliveries
+    DROP COLUMN service_level,
+    DROP COLUMN due_at,
+    DROP COLUMN sla_status;
+-- +goose StatementEnd
@@ -0,0 +1,14 @@
+-- +goose Up
+-- +goose StatementBegin
+-- amounts are kept in minor units
+ALTER TABLE deliveries
+    ADD COLUMN price_amount   BIGINT     DEFAULT NULL,
+    ADD COLUMN price_currency VARCHAR(3) DEFAULT NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE deliveries
+    DROP COLUMN price_amount,
+    DROP COLUMN price_currency;
+-- +goose StatementEnd
@@ -0,0 +1,45 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE deliveries
+    ADD COLUMN cod_amount        BIGINT      DEFAULT NULL,
+    ADD COLUMN cod_currency      VARCHAR(3)  DEFAULT NULL,
+    ADD COLUMN collected_amount  BIGINT      DEFAULT NULL,
+    ADD COLUMN collection_method VARCHAR(16) DEFAULT NULL;
+CREATE TABLE cash_ledger
+(
+    id          BIGSERIAL PRIMARY KEY,
+    courier_id  BIGINT      NOT NULL,
+    seq         INTEGER     NOT NULL,
+    kind        VARCHAR(16) NOT NULL,
+    delivery_id BIGINT      DEFAULT NULL REFERENCES deliveries (id),
+    amount      BIGINT      NOT NULL,
+    balance     BIGINT      NOT NULL,
+    currency    VARCHAR(3)  NOT NULL,
+    note        TEXT        DEFAULT NULL,
+    actor_id    BIGINT      NOT NULL,
+    created_at  TIMESTAMPTZ NOT NULL,
+    UNIQUE (courier_id, seq)
+);
+CREATE INDEX cash_ledger_kind_idx ON cash_ledger (kind);
+-- the ledger is append-only
+CREATE FUNCTION cash_ledger_append_only() RETURNS trigger AS
+$$
+BEGIN
+    RAISE EXCEPTION 'cash ledger entries can not be changed';
+END;
+$$ LANGUAGE plpgsql;
+CREATE TRIGGER cash_ledger_append_only
+    BEFORE UPDATE OR DELETE ON cash_ledger
+    FOR EACH ROW EXECUTE FUNCTION cash_ledger_append_only();
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE cash_ledger;
+DROP FUNCTION cash_ledger_append_only();
+ALTER TABLE deliveries
+    DROP COLUMN cod_amount,
+    DROP COLUMN cod_currency,
+    DROP COLUMN collected_amount,
+    DROP COLUMN collection_method;
+-- +goose StatementEnd
@@ -0,0 +1,32 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE invoices
+(
+    id          BIGSERIAL PRIMARY KEY,
+    merchant_id BIGINT      NOT NULL,
+    period      VARCHAR(7)  NOT NULL,
+    currency    VARCHAR(3)  NOT NULL,
+    total       BIGINT      NOT NULL,
+    deliveries  INTEGER     NOT NULL,
+    created_at  TIMESTAMPTZ NOT NULL,
+    UNIQUE (merchant_id, period)
+);
+CREATE TABLE invoice_lines
+(
+    invoice_id    BIGINT       NOT NULL REFERENCES invoices (id) ON DELETE CASCADE,
+    delivery_id   BIGINT       NOT NULL UNIQUE REFERENCES deliveries (id),
+    completed_at  TIMESTAMPTZ  NOT NULL,
+    destination   VARCHAR(255) NOT NULL,
+    service_level VARCHAR(16)  NOT NULL,
+    amount        BIGINT       NOT NULL
+);
+CREATE INDEX invoice_lines_invoice_id_idx ON invoice_lines (invoice_id);
+CREATE INDEX deliveries_completed_at_idx ON deliveries (completed_at) WHERE status = 'completed';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX deliveries_completed_at_idx;
+DROP TABLE invoice_lines;
+DROP TABLE invoices;
+-- +goose StatementEnd
@@ -0,0 +1,23 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE deliveries ADD COLUMN organization_id BIGINT;
+CREATE INDEX deliveries_organization_id_idx ON deliveries (organization_id) WHERE organization_id IS NOT NULL;
+
+-- organizations are billed as a whole, their invoices leave merchant_id zero
+ALTER TABLE invoices ADD COLUMN organization_id BIGINT;
+ALTER TABLE invoices DROP CONSTRAINT invoices_merchant_id_period_key;
+CREATE UNIQUE INDEX invoices_merchant_id_period_key ON invoices (merchant_id, period) WHERE organization_id IS NULL;
+CREATE UNIQUE INDEX invoices_organization_id_period_key ON invoices (organization_id, period)
+    WHERE organization_id IS NOT NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX invoices_organization_id_period_key;
+DROP INDEX invoices_merchant_id_period_key;
+DELETE FROM invoices WHERE organization_id IS NOT NULL;
+ALTER TABLE invoices ADD CONSTRAINT invoices_merchant_id_period_key UNIQUE (merchant_id, period);
+ALTER TABLE invoices DROP COLUMN organization_id;
+DROP INDEX deliveries_organization_id_idx;
+ALTER TABLE deliveries DROP COLUMN organization_id;
+-- +goose StatementEnd
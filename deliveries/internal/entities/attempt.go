@@ -0,0 +1,18 @@
+package entities
+
+import (
+	"github.com/zhanbolat18/parcel/deliveries/internal/valueobjects"
+	"time"
+)
+
+// Attempt is a failed try of the courier to hand the delivery over. The id
+// of its optional photo is the id of the attempt.
+type Attempt struct {
+	Id         uint                       `json:"id"`
+	DeliveryId uint                       `json:"delivery_id"`
+	CourierId  uint                       `json:"courier_id"`
+	Reason     valueobjects.AttemptReason `json:"reason"`
+	Note       string                     `json:"note,omitempty"`
+	Photo      *ProofFile                 `json:"photo,omitempty"`
+	CreatedAt  time.Time                  `json:"created_at"`
+}
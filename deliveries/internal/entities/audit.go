@@ -0,0 +1,20 @@
+package entities
+
+import "time"
+
+const (
+	AuditHandoverFailed   = "handover_failed"
+	AuditHandoverLocked   = "handover_locked"
+	AuditHandoverOverride = "handover_override"
+)
+
+// AuditEntry records a sensitive action taken on a delivery. ActorId is nil
+// for actions taken by the service itself.
+type AuditEntry struct {
+	Id         uint      `json:"id"`
+	DeliveryId uint      `json:"delivery_id"`
+	ActorId    *uint     `json:"actor_id,omitempty"`
+	Action     string    `json:"action"`
+	Details    string    `json:"details,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+}
@@ -0,0 +1,40 @@
+package entities
+
+import (
+	"github.com/zhanbolat18/parcel/deliveries/internal/valueobjects"
+	"time"
+)
+
+const (
+	// CashCollection adds the cash collected on a delivery.
+	CashCollection = "collection"
+	// CashHandover subtracts the cash the courier handed over to the office.
+	CashHandover = "handover"
+	// CashReconciliation brings the balance to the counted cash, its amount
+	// is the discrepancy.
+	CashReconciliation = "reconciliation"
+)
+
+// CashEntry is an entry of the cash ledger of a courier. Entries are never
+// changed, Seq orders them per courier and Balance is the cash held after
+// the entry.
+type CashEntry struct {
+	Id         uint               `json:"id"`
+	CourierId  uint               `json:"courier_id"`
+	Seq        uint               `json:"seq"`
+	Kind       string             `json:"kind"`
+	DeliveryId *uint              `json:"delivery_id,omitempty"`
+	Amount     valueobjects.Money `json:"amount" swaggertype:"string" example:"-1250.00"`
+	Balance    valueobjects.Money `json:"balance" swaggertype:"string" example:"3000.00"`
+	Currency   string             `json:"currency"`
+	Note       string             `json:"note,omitempty"`
+	ActorId    uint               `json:"actor_id"`
+	CreatedAt  time.Time          `json:"created_at"`
+}
+
+type CashLedger struct {
+	CourierId uint               `json:"courier_id"`
+	Balance   valueobjects.Money `json:"balance" swaggertype:"string" example:"3000.00"`
+	Currency  string             `json:"currency"`
+	Entries   []*CashEntry       `json:"entries"`
+}
@@ -0,0 +1,15 @@
+package entities
+
+import (
+	"github.com/zhanbolat18/parcel/deliveries/internal/valueobjects"
+	"time"
+)
+
+type CourierAvailability struct {
+	CourierId uint                      `json:"courier_id"`
+	Status    valueobjects.Availability `json:"status"`
+	// MaxActiveDeliveries limits deliveries in delivers status at once,
+	// zero means the configured default.
+	MaxActiveDeliveries uint      `json:"max_active_deliveries"`
+	UpdatedAt           time.Time `json:"updatedAt"`
+}
@@ -6,21 +6,75 @@ import (
 )
 
 type Delivery struct {
-	Id          uint                `json:"id"`
-	Status      valueobjects.Status `json:"status"`
-	Destination string              `json:"destination"`
+	Id     uint                      `json:"id"`
+	Type   valueobjects.DeliveryType `json:"type"`
+	Status valueobjects.Status       `json:"status"`
+	// OriginalId links a return to the delivery it returns.
+	OriginalId *uint `json:"original_id,omitempty"`
+	// Origin is the optional pickup address.
+	Origin         string                 `json:"origin,omitempty"`
+	OriginLocation *valueobjects.Location `json:"origin_location,omitempty"`
+	Destination    string                 `json:"destination"`
+	// DestinationLocation is optional, deliveries without it are never
+	// dispatched by distance.
+	DestinationLocation *valueobjects.Location `json:"destination_location,omitempty"`
+	// Window is the optional time the recipient expects the delivery in,
+	// it lies within the booked Slot of the Zone.
+	Window *valueobjects.TimeWindow `json:"window,omitempty"`
+	Slot   *valueobjects.TimeWindow `json:"slot,omitempty"`
+	Zone   string                   `json:"zone,omitempty"`
+	// ScheduledFor is the optional day of the delivery, like 2026-10-19.
+	ScheduledFor string                    `json:"scheduled_for,omitempty"`
+	ServiceLevel valueobjects.ServiceLevel `json:"service_level"`
+	// DueAt is when the service level expects the delivery completed.
+	DueAt *time.Time             `json:"due_at,omitempty"`
+	SLA   valueobjects.SLAStatus `json:"sla"`
+	// COD is the optional amount the recipient pays the courier on handover,
+	// Collection is what was collected on completion.
+	COD        *valueobjects.Price      `json:"cod,omitempty"`
+	Collection *valueobjects.Collection `json:"collection,omitempty"`
+	// Price is locked by the quote the delivery was created with.
+	Price       *valueobjects.Price `json:"price,omitempty"`
 	RecipientId uint                `json:"recipient_id"`
-	CourierId   *uint               `json:"courier_id,omitempty"`
-	CreatedAt   time.Time           `json:"createdAt"`
-	UpdatedAt   time.Time           `json:"updatedAt"`
+	// OrganizationId is the organization the recipient ordered the delivery
+	// for, its members share the delivery.
+	OrganizationId *uint      `json:"organization_id,omitempty"`
+	CourierId      *uint      `json:"courier_id,omitempty"`
+	CreatedAt      time.Time  `json:"createdAt"`
+	UpdatedAt      time.Time  `json:"updatedAt"`
+	CompletedAt    *time.Time `json:"completed_at,omitempty"`
+	Version        uint       `json:"version"`
+	// Attempts counts the failed attempts to hand the delivery over.
+	Attempts uint                   `json:"attempts"`
+	Handover *valueobjects.Handover `json:"-"`
+	// HandoverCode is not stored, it is only filled in for the recipient.
+	HandoverCode string `json:"handover_code,omitempty"`
 }
 
-func NewDelivery(destination string, recipient *User) *Delivery {
+func NewDelivery(destination string, location *valueobjects.Location, recipient *User) *Delivery {
+	var organizationId *uint
+	if recipient.OrganizationId != nil {
+		id := *recipient.OrganizationId
+		organizationId = &id
+	}
 	return &Delivery{
-		Destination: destination,
-		RecipientId: recipient.Id,
-		Status:      valueobjects.Created,
-		CreatedAt:   time.Now(),
-		UpdatedAt:   time.Now(),
+		Destination:         destination,
+		DestinationLocation: location,
+		RecipientId:         recipient.Id,
+		OrganizationId:      organizationId,
+		Type:                valueobjects.Forward,
+		Status:              valueobjects.Created,
+		ServiceLevel:        valueobjects.Standard,
+		SLA:                 valueobjects.OnTrack,
+		CreatedAt:           time.Now(),
+		UpdatedAt:           time.Now(),
+		Version:             1,
+	}
+}
+
+func (d *Delivery) Tenant() Tenant {
+	if d.OrganizationId != nil {
+		return Tenant{OrganizationId: *d.OrganizationId}
 	}
+	return Tenant{UserId: d.RecipientId}
 }
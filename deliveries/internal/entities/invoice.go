@@ -0,0 +1,46 @@
+package entities
+
+import (
+	"github.com/zhanbolat18/parcel/deliveries/internal/valueobjects"
+	"time"
+)
+
+// Invoice bills a merchant, the tenant who ordered the deliveries, for the
+// deliveries completed in a month. Period looks like 2026-09. MerchantId is
+// the user for deliveries outside of organizations, zero otherwise.
+type Invoice struct {
+	Id             uint               `json:"id"`
+	MerchantId     uint               `json:"merchant_id,omitempty"`
+	OrganizationId *uint              `json:"organization_id,omitempty"`
+	Period         string             `json:"period" example:"2026-09"`
+	Currency       string             `json:"currency"`
+	Total          valueobjects.Money `json:"total" swaggertype:"string" example:"12500.00"`
+	Deliveries     uint               `json:"deliveries"`
+	CreatedAt      time.Time          `json:"created_at"`
+	// Lines are left out of invoice lists.
+	Lines []InvoiceLine `json:"lines,omitempty"`
+}
+
+func NewInvoice(merchant Tenant, period, currency string) *Invoice {
+	invoice := &Invoice{MerchantId: merchant.UserId, Period: period, Currency: currency}
+	if merchant.OrganizationId != 0 {
+		id := merchant.OrganizationId
+		invoice.OrganizationId = &id
+	}
+	return invoice
+}
+
+func (i *Invoice) Merchant() Tenant {
+	if i.OrganizationId != nil {
+		return Tenant{OrganizationId: *i.OrganizationId}
+	}
+	return Tenant{UserId: i.MerchantId}
+}
+
+type InvoiceLine struct {
+	DeliveryId   uint                      `json:"delivery_id"`
+	CompletedAt  time.Time                 `json:"completed_at"`
+	Destination  string                    `json:"destination"`
+	ServiceLevel valueobjects.ServiceLevel `json:"service_level"`
+	Amount       valueobjects.Money        `json:"amount" swaggertype:"string" example:"1250.00"`
+}
@@ -0,0 +1,20 @@
+package entities
+
+import (
+	"github.com/zhanbolat18/parcel/deliveries/internal/valueobjects"
+	"time"
+)
+
+// LocationFix is a GPS position reported by the courier app, accuracy is the
+// radius in meters.
+type LocationFix struct {
+	CourierId  uint      `json:"courier_id"`
+	Latitude   float64   `json:"latitude"`
+	Longitude  float64   `json:"longitude"`
+	Accuracy   float64   `json:"accuracy"`
+	RecordedAt time.Time `json:"recorded_at"`
+}
+
+func (f *LocationFix) Location() valueobjects.Location {
+	return valueobjects.Location{Latitude: f.Latitude, Longitude: f.Longitude}
+}
@@ -0,0 +1,38 @@
+package entities
+
+import (
+	"github.com/zhanbolat18/parcel/deliveries/internal/valueobjects"
+	"time"
+)
+
+const (
+	ProofFileSignature = "signature"
+	ProofFilePhoto     = "photo"
+)
+
+// Proof is the evidence a courier collects when handing a delivery over.
+type Proof struct {
+	DeliveryId uint                   `json:"delivery_id"`
+	ReceivedBy string                 `json:"received_by"`
+	Location   *valueobjects.Location `json:"location,omitempty"`
+	Files      []ProofFile            `json:"files"`
+	CreatedAt  time.Time              `json:"created_at"`
+}
+
+type ProofFile struct {
+	Id          uint   `json:"id"`
+	Kind        string `json:"kind"`
+	ContentType string `json:"content_type"`
+	Size        int64  `json:"size"`
+	// Key locates the content in the blob store.
+	Key string `json:"-"`
+}
+
+func (p *Proof) File(id uint) (*ProofFile, bool) {
+	for i := range p.Files {
+		if p.Files[i].Id == id {
+			return &p.Files[i], true
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,27 @@
+package entities
+
+import (
+	"github.com/zhanbolat18/parcel/deliveries/internal/valueobjects"
+	"time"
+)
+
+// Quote is the price of a delivery, its Id locks the price onto the delivery
+// created with it until it expires.
+type Quote struct {
+	Id           string                    `json:"id"`
+	ServiceLevel valueobjects.ServiceLevel `json:"service_level"`
+	Zone         string                    `json:"zone,omitempty"`
+	// DistanceKm is left out when the price depends on the zone only.
+	DistanceKm float64 `json:"distance_km,omitempty"`
+	// WeightKg is the chargeable weight, the larger of the actual and the
+	// volumetric one.
+	WeightKg  float64            `json:"weight_kg"`
+	Lines     []PriceLine        `json:"lines"`
+	Price     valueobjects.Price `json:"price"`
+	ExpiresAt time.Time          `json:"expires_at"`
+}
+
+type PriceLine struct {
+	Name   string             `json:"name"`
+	Amount valueobjects.Money `json:"amount" swaggertype:"string" example:"500.00"`
+}
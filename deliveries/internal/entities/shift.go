@@ -0,0 +1,11 @@
+package entities
+
+import "time"
+
+type Shift struct {
+	Id        uint      `json:"id"`
+	CourierId uint      `json:"courier_id"`
+	StartsAt  time.Time `json:"starts_at"`
+	EndsAt    time.Time `json:"ends_at"`
+	Zone      string    `json:"zone"`
+}
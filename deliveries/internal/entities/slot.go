@@ -0,0 +1,12 @@
+package entities
+
+import "time"
+
+// Slot is a bookable delivery window of a zone.
+type Slot struct {
+	Zone      string    `json:"zone"`
+	Start     time.Time `json:"start"`
+	End       time.Time `json:"end"`
+	Capacity  uint      `json:"capacity"`
+	Remaining uint      `json:"remaining"`
+}
@@ -0,0 +1,20 @@
+package entities
+
+// Tenant owns deliveries: the organization of a user or, for users outside
+// of organizations, the user alone. Exactly one of the ids is set.
+type Tenant struct {
+	OrganizationId uint
+	UserId         uint
+}
+
+func TenantOf(user *User) Tenant {
+	if user.OrganizationId != nil {
+		return Tenant{OrganizationId: *user.OrganizationId}
+	}
+	return Tenant{UserId: user.Id}
+}
+
+// Owns reports whether the delivery belongs to the tenant.
+func (t Tenant) Owns(delivery *Delivery) bool {
+	return delivery.Tenant() == t
+}
@@ -4,4 +4,28 @@ type User struct {
 	Id    uint
 	Email string
 	Role  string
+	// OrganizationId is set for users acting for an organization, OrgRole is
+	// then owner, staff or viewer.
+	OrganizationId *uint
+	OrgRole        string
+	// Scopes are set when the request is authenticated by an API key, it may
+	// only do what they grant.
+	Scopes []string
+}
+
+// HasScopes reports whether every one of the scopes is granted.
+func (u *User) HasScopes(scopes ...string) bool {
+	for _, scope := range scopes {
+		granted := false
+		for _, s := range u.Scopes {
+			if s == scope {
+				granted = true
+				break
+			}
+		}
+		if !granted {
+			return false
+		}
+	}
+	return true
 }
@@ -0,0 +1,11 @@
+package repositories
+
+import (
+	"context"
+	"github.com/zhanbolat18/parcel/deliveries/internal/entities"
+)
+
+type AttemptsRepository interface {
+	Store(ctx context.Context, attempt *entities.Attempt) error
+	GetByDelivery(ctx context.Context, deliveryId uint) ([]*entities.Attempt, error)
+}
@@ -0,0 +1,11 @@
+package repositories
+
+import (
+	"context"
+	"github.com/zhanbolat18/parcel/deliveries/internal/entities"
+)
+
+type AuditRepository interface {
+	Record(ctx context.Context, entry *entities.AuditEntry) error
+	GetByDelivery(ctx context.Context, deliveryId uint) ([]*entities.AuditEntry, error)
+}
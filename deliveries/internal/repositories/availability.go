@@ -0,0 +1,17 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"github.com/zhanbolat18/parcel/deliveries/internal/entities"
+)
+
+var ErrAvailabilityNotFound = errors.New("courier availability not found")
+
+type AvailabilityRepository interface {
+	// GetByCourier locks the row until the end of the transaction when called
+	// within one.
+	GetByCourier(ctx context.Context, courierId uint) (*entities.CourierAvailability, error)
+	GetByCouriers(ctx context.Context, courierIds ...uint) (map[uint]*entities.CourierAvailability, error)
+	Save(ctx context.Context, availability *entities.CourierAvailability) error
+}
@@ -0,0 +1,17 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+var ErrBlobNotFound = errors.New("blob not found")
+
+// BlobStore keeps binary content, such as proof of delivery files, by key.
+// Keys are slash separated paths.
+type BlobStore interface {
+	Put(ctx context.Context, key string, content io.Reader) error
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+}
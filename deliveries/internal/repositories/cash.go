@@ -0,0 +1,20 @@
+package repositories
+
+import (
+	"context"
+	"github.com/zhanbolat18/parcel/deliveries/internal/entities"
+)
+
+// CashLedgerRepository is append-only. Append numbers the entry and computes
+// its balance, an entry appended concurrently for the same courier fails with
+// ErrConcurrentModification.
+type CashLedgerRepository interface {
+	Append(ctx context.Context, entry *entities.CashEntry) error
+	GetByCourier(ctx context.Context, courierId uint) ([]*entities.CashEntry, error)
+	// GetDiscrepancies returns the reconciliations with a non-zero amount, of
+	// the courier when courierId is not zero.
+	GetDiscrepancies(ctx context.Context, courierId uint) ([]*entities.CashEntry, error)
+	// LockCourier serializes appends to the courier ledger until the end of
+	// the current transaction, outside a transaction it does nothing.
+	LockCourier(ctx context.Context, courierId uint) error
+}
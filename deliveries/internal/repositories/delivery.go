@@ -2,13 +2,42 @@ package repositories
 
 import (
 	"context"
+	"errors"
 	"github.com/zhanbolat18/parcel/deliveries/internal/entities"
+	"github.com/zhanbolat18/parcel/deliveries/internal/valueobjects"
+	"time"
 )
 
+var ErrDeliveryNotFound = errors.New("delivery not found")
+
+// ErrConcurrentModification is returned by Update when the stored delivery
+// version differs from the one being updated.
+var ErrConcurrentModification = errors.New("delivery was modified concurrently")
+
+// DeliveryFilter narrows delivery listings, zero values match all.
+type DeliveryFilter struct {
+	SLA valueobjects.SLAStatus
+}
+
 type DeliveriesRepository interface {
-	GetAll(ctx context.Context) ([]*entities.Delivery, error)
-	GetAllByCourier(ctx context.Context, courierId uint) ([]*entities.Delivery, error)
+	GetAll(ctx context.Context, filter DeliveryFilter) ([]*entities.Delivery, error)
+	GetAllByCourier(ctx context.Context, courierId uint, filter DeliveryFilter) ([]*entities.Delivery, error)
+	GetAllByRecipient(ctx context.Context, recipientId uint) ([]*entities.Delivery, error)
+	GetAllByStatus(ctx context.Context, status valueobjects.Status) ([]*entities.Delivery, error)
+	CountByCourier(ctx context.Context, courierId uint, status valueobjects.Status) (int, error)
 	GetById(ctx context.Context, id uint) (*entities.Delivery, error)
+	// GetReturnOf returns the return of the original delivery,
+	// ErrDeliveryNotFound when there is none.
+	GetReturnOf(ctx context.Context, originalId uint) (*entities.Delivery, error)
+	// GetAllDueBefore returns the deliveries being delivered and not breached
+	// yet that are due before the given time, earliest first.
+	GetAllDueBefore(ctx context.Context, before time.Time) ([]*entities.Delivery, error)
+	// GetAllCompletedBetween returns the deliveries completed within
+	// [from, to), earliest first.
+	GetAllCompletedBetween(ctx context.Context, from, to time.Time) ([]*entities.Delivery, error)
 	Store(ctx context.Context, delivery *entities.Delivery) error
 	Update(ctx context.Context, delivery *entities.Delivery) error
+	// UpdateSLA sets the SLA status of the delivery when it is still the
+	// expected one, reporting whether it did. It leaves the version as is.
+	UpdateSLA(ctx context.Context, id uint, expected, status valueobjects.SLAStatus) (bool, error)
 }
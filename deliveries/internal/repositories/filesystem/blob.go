@@ -0,0 +1,81 @@
+package filesystem
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/zhanbolat18/parcel/deliveries/internal/repositories"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+type blobStore struct {
+	root string
+}
+
+// NewBlobStore keeps blobs as files under root, creating it if needed.
+func NewBlobStore(root string) (repositories.BlobStore, error) {
+	if err := os.MkdirAll(root, 0o750); err != nil {
+		return nil, fmt.Errorf("create blob store root: %w", err)
+	}
+	return &blobStore{root: root}, nil
+}
+
+// Put writes the content to a temporary file first, so that a failed upload
+// never leaves a partial blob behind.
+func (b *blobStore) Put(_ context.Context, key string, content io.Reader) error {
+	path, err := b.path(key)
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err = io.Copy(tmp, content); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+func (b *blobStore) Open(_ context.Context, key string) (io.ReadCloser, error) {
+	path, err := b.path(key)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, repositories.ErrBlobNotFound
+	}
+	return f, err
+}
+
+func (b *blobStore) Delete(_ context.Context, key string) error {
+	path, err := b.path(key)
+	if err != nil {
+		return err
+	}
+	err = os.Remove(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return repositories.ErrBlobNotFound
+	}
+	return err
+}
+
+func (b *blobStore) path(key string) (string, error) {
+	clean := filepath.Clean(filepath.FromSlash(key))
+	if key == "" || filepath.IsAbs(clean) || clean == ".." || strings.HasPrefix(clean, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("invalid blob key \"%s\"", key)
+	}
+	return filepath.Join(b.root, clean), nil
+}
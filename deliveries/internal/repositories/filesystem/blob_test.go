@@ -0,0 +1,37 @@
+package filesystem_test
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zhanbolat18/parcel/deliveries/internal/repositories"
+	"github.com/zhanbolat18/parcel/deliveries/internal/repositories/filesystem"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestBlobStore(t *testing.T) {
+	ctx := context.Background()
+	store, err := filesystem.NewBlobStore(t.TempDir())
+	require.Nil(t, err)
+	asrt := assert.New(t)
+
+	require.Nil(t, store.Put(ctx, "deliveries/1/signature", strings.NewReader("content")))
+	f, err := store.Open(ctx, "deliveries/1/signature")
+	require.Nil(t, err)
+	content, err := io.ReadAll(f)
+	asrt.Nil(err)
+	asrt.Nil(f.Close())
+	asrt.Equal("content", string(content))
+
+	asrt.Nil(store.Delete(ctx, "deliveries/1/signature"))
+	_, err = store.Open(ctx, "deliveries/1/signature")
+	asrt.True(errors.Is(err, repositories.ErrBlobNotFound))
+	asrt.True(errors.Is(store.Delete(ctx, "deliveries/1/signature"), repositories.ErrBlobNotFound))
+
+	for _, key := range []string{"", "../outside", "deliveries/../../outside", "/etc/passwd"} {
+		asrt.NotNil(store.Put(ctx, key, strings.NewReader("content")), key)
+	}
+}
@@ -74,6 +74,38 @@ func (u *UserRepository) GetCourier(ctx context.Context, id uint) (*entities.Use
 	}, nil
 }
 
+func (u *UserRepository) GetCouriers(ctx context.Context) ([]*entities.User, error) {
+	path := fmt.Sprintf("%s/couriers", u.baseUrl)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, path, nil)
+	if err != nil {
+		return nil, err
+	}
+	u.requestDecorator.Decorate(req)
+	res, err := u.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, u.responseNotOk(res)
+	}
+
+	ums := make([]userModel, 0)
+	err = jsoniter.NewDecoder(res.Body).Decode(&ums)
+	if err != nil {
+		return nil, err
+	}
+	couriers := make([]*entities.User, 0, len(ums))
+	for _, um := range ums {
+		couriers = append(couriers, &entities.User{
+			Id:    um.Id,
+			Email: um.Email,
+			Role:  um.Role,
+		})
+	}
+	return couriers, nil
+}
+
 func (u *UserRepository) GetRecipient(ctx context.Context, id uint) (*entities.User, error) {
 	path := fmt.Sprintf("%s/auth", u.baseUrl)
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, path, nil)
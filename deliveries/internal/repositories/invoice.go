@@ -0,0 +1,35 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"github.com/zhanbolat18/parcel/deliveries/internal/entities"
+)
+
+var (
+	ErrInvoiceNotFound = errors.New("invoice not found")
+	// ErrInvoiceExists is returned by Store when the merchant already has an
+	// invoice for the period.
+	ErrInvoiceExists = errors.New("invoice of the period already exists")
+)
+
+// InvoiceFilter selects invoices, zero values match all.
+type InvoiceFilter struct {
+	MerchantId     uint
+	OrganizationId uint
+	Period         string
+}
+
+type InvoicesRepository interface {
+	// Store expects to run within a transaction, otherwise a failure may
+	// leave the invoice without some of its lines.
+	Store(ctx context.Context, invoice *entities.Invoice) error
+	// GetById returns the invoice with its lines.
+	GetById(ctx context.Context, id uint) (*entities.Invoice, error)
+	// GetByPeriod returns the invoice of the merchant for the period with its
+	// lines.
+	GetByPeriod(ctx context.Context, merchant entities.Tenant, period string) (*entities.Invoice, error)
+	// GetAll returns matching invoices without their lines, latest period
+	// first.
+	GetAll(ctx context.Context, filter InvoiceFilter) ([]*entities.Invoice, error)
+}
@@ -0,0 +1,29 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"github.com/zhanbolat18/parcel/deliveries/internal/entities"
+	"github.com/zhanbolat18/parcel/deliveries/internal/valueobjects"
+	"time"
+)
+
+var ErrLocationNotFound = errors.New("courier location not found")
+
+type LocationsRepository interface {
+	// LastKnown returns the latest position of every given courier that has
+	// reported one.
+	LastKnown(ctx context.Context, courierIds ...uint) (map[uint]valueobjects.Location, error)
+	Latest(ctx context.Context, courierId uint) (*entities.LocationFix, error)
+	// Remember keeps the given fixes unless a more recent one of the same
+	// courier is already known.
+	Remember(ctx context.Context, fixes ...*entities.LocationFix) error
+}
+
+type LocationHistoryRepository interface {
+	Store(ctx context.Context, fixes ...*entities.LocationFix) error
+	GetByCourier(ctx context.Context, courierId uint, from, to time.Time) ([]*entities.LocationFix, error)
+	// PreparePartitions creates the storage for fixes recorded in the period.
+	PreparePartitions(ctx context.Context, from, to time.Time) error
+	DeleteBefore(ctx context.Context, before time.Time) error
+}
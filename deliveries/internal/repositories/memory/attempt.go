@@ -0,0 +1,60 @@
+package memory
+
+import (
+	"context"
+	"github.com/zhanbolat18/parcel/deliveries/internal/entities"
+	"github.com/zhanbolat18/parcel/deliveries/internal/repositories"
+	"sync"
+)
+
+type attempt struct {
+	mu       sync.RWMutex
+	attempts []entities.Attempt
+}
+
+func NewAttemptsRepository() repositories.AttemptsRepository {
+	return &attempt{}
+}
+
+func (a *attempt) Store(ctx context.Context, attempt *entities.Attempt) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	attempt.Id = uint(len(a.attempts) + 1)
+	id := attempt.Id
+	onRollback(ctx, func() {
+		a.mu.Lock()
+		defer a.mu.Unlock()
+		for i := range a.attempts {
+			if a.attempts[i].Id == id {
+				a.attempts = append(a.attempts[:i], a.attempts[i+1:]...)
+				return
+			}
+		}
+	})
+	stored := *attempt
+	if attempt.Photo != nil {
+		attempt.Photo.Id = attempt.Id
+		photo := *attempt.Photo
+		stored.Photo = &photo
+	}
+	a.attempts = append(a.attempts, stored)
+	return nil
+}
+
+func (a *attempt) GetByDelivery(_ context.Context, deliveryId uint) ([]*entities.Attempt, error) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	found := make([]*entities.Attempt, 0)
+	for _, stored := range a.attempts {
+		stored := stored
+		if stored.DeliveryId != deliveryId {
+			continue
+		}
+		if stored.Photo != nil {
+			photo := *stored.Photo
+			stored.Photo = &photo
+		}
+		found = append(found, &stored)
+	}
+	return found, nil
+}
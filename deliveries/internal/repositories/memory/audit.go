@@ -0,0 +1,49 @@
+package memory
+
+import (
+	"context"
+	"github.com/zhanbolat18/parcel/deliveries/internal/entities"
+	"github.com/zhanbolat18/parcel/deliveries/internal/repositories"
+	"sync"
+)
+
+type audit struct {
+	mu      sync.RWMutex
+	entries []entities.AuditEntry
+}
+
+func NewAuditRepository() repositories.AuditRepository {
+	return &audit{}
+}
+
+func (a *audit) Record(ctx context.Context, entry *entities.AuditEntry) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	entry.Id = uint(len(a.entries) + 1)
+	id := entry.Id
+	onRollback(ctx, func() {
+		a.mu.Lock()
+		defer a.mu.Unlock()
+		for i := range a.entries {
+			if a.entries[i].Id == id {
+				a.entries = append(a.entries[:i], a.entries[i+1:]...)
+				return
+			}
+		}
+	})
+	a.entries = append(a.entries, *entry)
+	return nil
+}
+
+func (a *audit) GetByDelivery(_ context.Context, deliveryId uint) ([]*entities.AuditEntry, error) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	found := make([]*entities.AuditEntry, 0)
+	for _, entry := range a.entries {
+		entry := entry
+		if entry.DeliveryId == deliveryId {
+			found = append(found, &entry)
+		}
+	}
+	return found, nil
+}
@@ -0,0 +1,57 @@
+package memory
+
+import (
+	"context"
+	"github.com/zhanbolat18/parcel/deliveries/internal/entities"
+	"github.com/zhanbolat18/parcel/deliveries/internal/repositories"
+	"sync"
+)
+
+type availability struct {
+	mu    sync.RWMutex
+	items map[uint]entities.CourierAvailability
+}
+
+func NewAvailabilityRepository() repositories.AvailabilityRepository {
+	return &availability{items: make(map[uint]entities.CourierAvailability)}
+}
+
+func (a *availability) GetByCourier(_ context.Context, courierId uint) (*entities.CourierAvailability, error) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	item, ok := a.items[courierId]
+	if !ok {
+		return nil, repositories.ErrAvailabilityNotFound
+	}
+	return &item, nil
+}
+
+func (a *availability) GetByCouriers(_ context.Context, courierIds ...uint) (map[uint]*entities.CourierAvailability, error) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	found := make(map[uint]*entities.CourierAvailability, len(courierIds))
+	for _, id := range courierIds {
+		if item, ok := a.items[id]; ok {
+			found[id] = &item
+		}
+	}
+	return found, nil
+}
+
+func (a *availability) Save(ctx context.Context, availability *entities.CourierAvailability) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	previous, existed := a.items[availability.CourierId]
+	courierId := availability.CourierId
+	a.items[courierId] = *availability
+	onRollback(ctx, func() {
+		a.mu.Lock()
+		defer a.mu.Unlock()
+		if existed {
+			a.items[courierId] = previous
+			return
+		}
+		delete(a.items, courierId)
+	})
+	return nil
+}
@@ -0,0 +1,49 @@
+package memory
+
+import (
+	"bytes"
+	"context"
+	"github.com/zhanbolat18/parcel/deliveries/internal/repositories"
+	"io"
+	"sync"
+)
+
+type blobStore struct {
+	mu    sync.RWMutex
+	blobs map[string][]byte
+}
+
+func NewBlobStore() repositories.BlobStore {
+	return &blobStore{blobs: make(map[string][]byte)}
+}
+
+func (b *blobStore) Put(_ context.Context, key string, content io.Reader) error {
+	data, err := io.ReadAll(content)
+	if err != nil {
+		return err
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.blobs[key] = data
+	return nil
+}
+
+func (b *blobStore) Open(_ context.Context, key string) (io.ReadCloser, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	data, ok := b.blobs[key]
+	if !ok {
+		return nil, repositories.ErrBlobNotFound
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+func (b *blobStore) Delete(_ context.Context, key string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, ok := b.blobs[key]; !ok {
+		return repositories.ErrBlobNotFound
+	}
+	delete(b.blobs, key)
+	return nil
+}
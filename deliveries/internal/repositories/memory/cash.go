@@ -0,0 +1,72 @@
+package memory
+
+import (
+	"context"
+	"github.com/zhanbolat18/parcel/deliveries/internal/entities"
+	"github.com/zhanbolat18/parcel/deliveries/internal/repositories"
+	"sync"
+)
+
+type cashLedger struct {
+	mu      sync.RWMutex
+	entries []entities.CashEntry
+}
+
+func NewCashLedgerRepository() repositories.CashLedgerRepository {
+	return &cashLedger{}
+}
+
+func (c *cashLedger) Append(ctx context.Context, entry *entities.CashEntry) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry.Seq, entry.Balance = 1, entry.Amount
+	for i := len(c.entries) - 1; i >= 0; i-- {
+		if c.entries[i].CourierId == entry.CourierId {
+			entry.Seq, entry.Balance = c.entries[i].Seq+1, c.entries[i].Balance+entry.Amount
+			break
+		}
+	}
+	entry.Id = uint(len(c.entries) + 1)
+	c.entries = append(c.entries, *entry)
+	id := entry.Id
+	onRollback(ctx, func() {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		for i := range c.entries {
+			if c.entries[i].Id == id {
+				c.entries = append(c.entries[:i], c.entries[i+1:]...)
+				return
+			}
+		}
+	})
+	return nil
+}
+
+func (c *cashLedger) GetByCourier(_ context.Context, courierId uint) ([]*entities.CashEntry, error) {
+	return c.filter(func(e *entities.CashEntry) bool { return e.CourierId == courierId }), nil
+}
+
+func (c *cashLedger) GetDiscrepancies(_ context.Context, courierId uint) ([]*entities.CashEntry, error) {
+	return c.filter(func(e *entities.CashEntry) bool {
+		return e.Kind == entities.CashReconciliation && e.Amount != 0 && (courierId == 0 || e.CourierId == courierId)
+	}), nil
+}
+
+// LockCourier does nothing, the memory TxManager already serializes units of
+// work.
+func (c *cashLedger) LockCourier(_ context.Context, _ uint) error {
+	return nil
+}
+
+func (c *cashLedger) filter(match func(*entities.CashEntry) bool) []*entities.CashEntry {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	entries := make([]*entities.CashEntry, 0)
+	for i := range c.entries {
+		if match(&c.entries[i]) {
+			entry := c.entries[i]
+			entries = append(entries, &entry)
+		}
+	}
+	return entries
+}
@@ -0,0 +1,235 @@
+package memory
+
+import (
+	"context"
+	"github.com/zhanbolat18/parcel/deliveries/internal/entities"
+	"github.com/zhanbolat18/parcel/deliveries/internal/repositories"
+	"github.com/zhanbolat18/parcel/deliveries/internal/valueobjects"
+	"sort"
+	"sync"
+	"time"
+)
+
+type delivery struct {
+	mu         sync.RWMutex
+	lastId     uint
+	deliveries map[uint]*entities.Delivery
+}
+
+// NewDeliveryRepository keeps deliveries in memory, it behaves like the
+// postgres repository and is meant for tests and local runs.
+func NewDeliveryRepository() repositories.DeliveriesRepository {
+	return &delivery{deliveries: make(map[uint]*entities.Delivery)}
+}
+
+func (d *delivery) GetAll(ctx context.Context, filter repositories.DeliveryFilter) ([]*entities.Delivery, error) {
+	return d.filter(ctx, func(dl *entities.Delivery) bool { return matches(dl, filter) }), nil
+}
+
+func (d *delivery) GetAllByCourier(
+	ctx context.Context,
+	courierId uint,
+	filter repositories.DeliveryFilter,
+) ([]*entities.Delivery, error) {
+	return d.filter(ctx, func(dl *entities.Delivery) bool {
+		return dl.CourierId != nil && *dl.CourierId == courierId && matches(dl, filter)
+	}), nil
+}
+
+func matches(dl *entities.Delivery, filter repositories.DeliveryFilter) bool {
+	return filter.SLA == "" || dl.SLA == filter.SLA
+}
+
+func (d *delivery) GetAllByRecipient(ctx context.Context, recipientId uint) ([]*entities.Delivery, error) {
+	return d.filter(ctx, func(dl *entities.Delivery) bool { return dl.RecipientId == recipientId }), nil
+}
+
+func (d *delivery) GetAllByStatus(ctx context.Context, status valueobjects.Status) ([]*entities.Delivery, error) {
+	return d.filter(ctx, func(dl *entities.Delivery) bool { return dl.Status == status }), nil
+}
+
+func (d *delivery) CountByCourier(ctx context.Context, courierId uint, status valueobjects.Status) (int, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	count := 0
+	for _, dl := range d.deliveries {
+		if dl.CourierId != nil && *dl.CourierId == courierId && dl.Status == status && visible(ctx, dl) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (d *delivery) GetById(ctx context.Context, id uint) (*entities.Delivery, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	dl, ok := d.deliveries[id]
+	if !ok || !visible(ctx, dl) {
+		return nil, repositories.ErrDeliveryNotFound
+	}
+	return clone(dl), nil
+}
+
+func (d *delivery) GetReturnOf(ctx context.Context, originalId uint) (*entities.Delivery, error) {
+	returns := d.filter(ctx, func(dl *entities.Delivery) bool {
+		return dl.OriginalId != nil && *dl.OriginalId == originalId
+	})
+	if len(returns) == 0 {
+		return nil, repositories.ErrDeliveryNotFound
+	}
+	return returns[0], nil
+}
+
+func (d *delivery) GetAllDueBefore(ctx context.Context, before time.Time) ([]*entities.Delivery, error) {
+	dls := d.filter(ctx, func(dl *entities.Delivery) bool {
+		return dl.DueAt != nil && dl.DueAt.Before(before) && dl.SLA != valueobjects.Breached && isOpen(dl.Status)
+	})
+	sort.SliceStable(dls, func(i, j int) bool { return dls[i].DueAt.Before(*dls[j].DueAt) })
+	return dls, nil
+}
+
+func (d *delivery) GetAllCompletedBetween(ctx context.Context, from, to time.Time) ([]*entities.Delivery, error) {
+	dls := d.filter(ctx, func(dl *entities.Delivery) bool {
+		return dl.Status == valueobjects.Completed && dl.CompletedAt != nil &&
+			!dl.CompletedAt.Before(from) && dl.CompletedAt.Before(to)
+	})
+	sort.SliceStable(dls, func(i, j int) bool { return dls[i].CompletedAt.Before(*dls[j].CompletedAt) })
+	return dls, nil
+}
+
+func (d *delivery) Store(ctx context.Context, delivery *entities.Delivery) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.lastId++
+	delivery.Id = d.lastId
+	delivery.Version = 1
+	d.deliveries[delivery.Id] = clone(delivery)
+	d.restoreOnRollback(ctx, delivery.Id, nil)
+	return nil
+}
+
+func (d *delivery) Update(ctx context.Context, delivery *entities.Delivery) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	stored, ok := d.deliveries[delivery.Id]
+	if !ok || !visible(ctx, stored) || stored.Version != delivery.Version {
+		return repositories.ErrConcurrentModification
+	}
+	delivery.Version++
+	d.deliveries[delivery.Id] = clone(delivery)
+	d.restoreOnRollback(ctx, delivery.Id, stored)
+	return nil
+}
+
+func (d *delivery) UpdateSLA(ctx context.Context, id uint, expected, status valueobjects.SLAStatus) (bool, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	stored, ok := d.deliveries[id]
+	if !ok || !visible(ctx, stored) || stored.SLA != expected {
+		return false, nil
+	}
+	d.restoreOnRollback(ctx, id, clone(stored))
+	stored.SLA = status
+	return true, nil
+}
+
+// restoreOnRollback puts back the delivery as it was, nil removes it.
+func (d *delivery) restoreOnRollback(ctx context.Context, id uint, previous *entities.Delivery) {
+	onRollback(ctx, func() {
+		d.mu.Lock()
+		defer d.mu.Unlock()
+		if previous == nil {
+			delete(d.deliveries, id)
+			return
+		}
+		d.deliveries[id] = previous
+	})
+}
+
+// isOpen reports whether the delivery is still on its way.
+func isOpen(status valueobjects.Status) bool {
+	switch status {
+	case valueobjects.Created, valueobjects.Delivers, valueobjects.AttemptFailed:
+		return true
+	}
+	return false
+}
+
+// visible reports whether the delivery belongs to the tenant the context is
+// scoped to, if any.
+func visible(ctx context.Context, delivery *entities.Delivery) bool {
+	tenant, ok := repositories.TenantFromContext(ctx)
+	return !ok || tenant.Owns(delivery)
+}
+
+func (d *delivery) filter(ctx context.Context, match func(*entities.Delivery) bool) []*entities.Delivery {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	dls := make([]*entities.Delivery, 0)
+	for _, dl := range d.deliveries {
+		if match(dl) && visible(ctx, dl) {
+			dls = append(dls, clone(dl))
+		}
+	}
+	sort.Slice(dls, func(i, j int) bool { return dls[i].Id < dls[j].Id })
+	return dls
+}
+
+// clone keeps stored deliveries unaffected by changes callers make to the
+// returned ones, as it is with a database.
+func clone(delivery *entities.Delivery) *entities.Delivery {
+	c := *delivery
+	if delivery.CourierId != nil {
+		id := *delivery.CourierId
+		c.CourierId = &id
+	}
+	if delivery.OriginalId != nil {
+		id := *delivery.OriginalId
+		c.OriginalId = &id
+	}
+	if delivery.OrganizationId != nil {
+		id := *delivery.OrganizationId
+		c.OrganizationId = &id
+	}
+	if delivery.OriginLocation != nil {
+		location := *delivery.OriginLocation
+		c.OriginLocation = &location
+	}
+	if delivery.DestinationLocation != nil {
+		location := *delivery.DestinationLocation
+		c.DestinationLocation = &location
+	}
+	if delivery.Window != nil {
+		window := *delivery.Window
+		c.Window = &window
+	}
+	if delivery.Slot != nil {
+		slot := *delivery.Slot
+		c.Slot = &slot
+	}
+	if delivery.COD != nil {
+		cod := *delivery.COD
+		c.COD = &cod
+	}
+	if delivery.Collection != nil {
+		collection := *delivery.Collection
+		c.Collection = &collection
+	}
+	if delivery.Price != nil {
+		price := *delivery.Price
+		c.Price = &price
+	}
+	if delivery.DueAt != nil {
+		dueAt := *delivery.DueAt
+		c.DueAt = &dueAt
+	}
+	if delivery.CompletedAt != nil {
+		completedAt := *delivery.CompletedAt
+		c.CompletedAt = &completedAt
+	}
+	if delivery.Handover != nil {
+		handover := *delivery.Handover
+		c.Handover = &handover
+	}
+	return &c
+}
@@ -0,0 +1,100 @@
+package memory
+
+import (
+	"context"
+	"github.com/zhanbolat18/parcel/deliveries/internal/entities"
+	"github.com/zhanbolat18/parcel/deliveries/internal/repositories"
+	"sort"
+	"sync"
+)
+
+type invoice struct {
+	mu       sync.RWMutex
+	lastId   uint
+	invoices map[uint]*entities.Invoice
+}
+
+func NewInvoicesRepository() repositories.InvoicesRepository {
+	return &invoice{invoices: make(map[uint]*entities.Invoice)}
+}
+
+func (i *invoice) Store(ctx context.Context, invoice *entities.Invoice) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	for _, stored := range i.invoices {
+		if stored.Merchant() == invoice.Merchant() && stored.Period == invoice.Period {
+			return repositories.ErrInvoiceExists
+		}
+	}
+	i.lastId++
+	invoice.Id = i.lastId
+	i.invoices[invoice.Id] = cloneInvoice(invoice, true)
+	id := invoice.Id
+	onRollback(ctx, func() {
+		i.mu.Lock()
+		defer i.mu.Unlock()
+		delete(i.invoices, id)
+	})
+	return nil
+}
+
+func (i *invoice) GetById(ctx context.Context, id uint) (*entities.Invoice, error) {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	stored, ok := i.invoices[id]
+	if !ok || !invoiceVisible(ctx, stored) {
+		return nil, repositories.ErrInvoiceNotFound
+	}
+	return cloneInvoice(stored, true), nil
+}
+
+func (i *invoice) GetByPeriod(ctx context.Context, merchant entities.Tenant, period string) (*entities.Invoice, error) {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	for _, stored := range i.invoices {
+		if stored.Merchant() == merchant && stored.Period == period && invoiceVisible(ctx, stored) {
+			return cloneInvoice(stored, true), nil
+		}
+	}
+	return nil, repositories.ErrInvoiceNotFound
+}
+
+func (i *invoice) GetAll(ctx context.Context, filter repositories.InvoiceFilter) ([]*entities.Invoice, error) {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	invoices := make([]*entities.Invoice, 0)
+	for _, stored := range i.invoices {
+		if (filter.MerchantId == 0 || stored.MerchantId == filter.MerchantId) &&
+			(filter.OrganizationId == 0 || stored.Merchant().OrganizationId == filter.OrganizationId) &&
+			(filter.Period == "" || stored.Period == filter.Period) && invoiceVisible(ctx, stored) {
+			invoices = append(invoices, cloneInvoice(stored, false))
+		}
+	}
+	sort.Slice(invoices, func(a, b int) bool {
+		if invoices[a].Period != invoices[b].Period {
+			return invoices[a].Period > invoices[b].Period
+		}
+		return invoices[a].Id < invoices[b].Id
+	})
+	return invoices, nil
+}
+
+// invoiceVisible reports whether the invoice bills the tenant the context is
+// scoped to, if any.
+func invoiceVisible(ctx context.Context, invoice *entities.Invoice) bool {
+	tenant, ok := repositories.TenantFromContext(ctx)
+	return !ok || invoice.Merchant() == tenant
+}
+
+func cloneInvoice(invoice *entities.Invoice, withLines bool) *entities.Invoice {
+	c := *invoice
+	if invoice.OrganizationId != nil {
+		id := *invoice.OrganizationId
+		c.OrganizationId = &id
+	}
+	c.Lines = nil
+	if withLines {
+		c.Lines = append([]entities.InvoiceLine{}, invoice.Lines...)
+	}
+	return &c
+}
@@ -0,0 +1,105 @@
+package memory
+
+import (
+	"context"
+	"github.com/zhanbolat18/parcel/deliveries/internal/entities"
+	"github.com/zhanbolat18/parcel/deliveries/internal/repositories"
+	"github.com/zhanbolat18/parcel/deliveries/internal/valueobjects"
+	"sort"
+	"sync"
+	"time"
+)
+
+type location struct {
+	mu        sync.RWMutex
+	locations map[uint]entities.LocationFix
+}
+
+func NewLocationsRepository() repositories.LocationsRepository {
+	return &location{locations: make(map[uint]entities.LocationFix)}
+}
+
+func (l *location) LastKnown(_ context.Context, courierIds ...uint) (map[uint]valueobjects.Location, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	found := make(map[uint]valueobjects.Location, len(courierIds))
+	for _, id := range courierIds {
+		if fix, ok := l.locations[id]; ok {
+			found[id] = fix.Location()
+		}
+	}
+	return found, nil
+}
+
+func (l *location) Latest(_ context.Context, courierId uint) (*entities.LocationFix, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	fix, ok := l.locations[courierId]
+	if !ok {
+		return nil, repositories.ErrLocationNotFound
+	}
+	return &fix, nil
+}
+
+func (l *location) Remember(_ context.Context, fixes ...*entities.LocationFix) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, fix := range fixes {
+		if known, ok := l.locations[fix.CourierId]; ok && !fix.RecordedAt.After(known.RecordedAt) {
+			continue
+		}
+		l.locations[fix.CourierId] = *fix
+	}
+	return nil
+}
+
+type locationHistory struct {
+	mu    sync.RWMutex
+	fixes []entities.LocationFix
+}
+
+func NewLocationHistoryRepository() repositories.LocationHistoryRepository {
+	return &locationHistory{}
+}
+
+func (l *locationHistory) Store(_ context.Context, fixes ...*entities.LocationFix) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, fix := range fixes {
+		l.fixes = append(l.fixes, *fix)
+	}
+	return nil
+}
+
+func (l *locationHistory) GetByCourier(_ context.Context, courierId uint, from, to time.Time) ([]*entities.LocationFix, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	found := make([]*entities.LocationFix, 0)
+	for _, fix := range l.fixes {
+		fix := fix
+		if fix.CourierId == courierId && !fix.RecordedAt.Before(from) && fix.RecordedAt.Before(to) {
+			found = append(found, &fix)
+		}
+	}
+	sort.SliceStable(found, func(i, j int) bool {
+		return found[i].RecordedAt.Before(found[j].RecordedAt)
+	})
+	return found, nil
+}
+
+func (l *locationHistory) PreparePartitions(_ context.Context, _, _ time.Time) error {
+	return nil
+}
+
+func (l *locationHistory) DeleteBefore(_ context.Context, before time.Time) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	kept := l.fixes[:0]
+	for _, fix := range l.fixes {
+		if !fix.RecordedAt.Before(before) {
+			kept = append(kept, fix)
+		}
+	}
+	l.fixes = kept
+	return nil
+}
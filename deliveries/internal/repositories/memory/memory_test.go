@@ -0,0 +1,74 @@
+package memory_test
+
+import (
+	"github.com/zhanbolat18/parcel/deliveries/internal/repositories"
+	"github.com/zhanbolat18/parcel/deliveries/internal/repositories/memory"
+	"github.com/zhanbolat18/parcel/deliveries/internal/repositories/repositorytest"
+	"testing"
+)
+
+func TestDeliveryRepository(t *testing.T) {
+	repositorytest.DeliveriesRepository(t, func(t *testing.T) repositories.DeliveriesRepository {
+		return memory.NewDeliveryRepository()
+	})
+}
+
+func TestAvailabilityRepository(t *testing.T) {
+	repositorytest.AvailabilityRepository(t, func(t *testing.T) repositories.AvailabilityRepository {
+		return memory.NewAvailabilityRepository()
+	})
+}
+
+func TestShiftsRepository(t *testing.T) {
+	repositorytest.ShiftsRepository(t, func(t *testing.T) repositories.ShiftsRepository {
+		return memory.NewShiftsRepository()
+	})
+}
+
+func TestLocationHistoryRepository(t *testing.T) {
+	repositorytest.LocationHistoryRepository(t, func(t *testing.T) repositories.LocationHistoryRepository {
+		return memory.NewLocationHistoryRepository()
+	})
+}
+
+func TestProofsRepository(t *testing.T) {
+	repositorytest.ProofsRepository(t, func(t *testing.T) repositories.ProofsRepository {
+		return memory.NewProofsRepository()
+	})
+}
+
+func TestAuditRepository(t *testing.T) {
+	repositorytest.AuditRepository(t, func(t *testing.T) repositories.AuditRepository {
+		return memory.NewAuditRepository()
+	})
+}
+
+func TestAttemptsRepository(t *testing.T) {
+	repositorytest.AttemptsRepository(t, func(t *testing.T) repositories.AttemptsRepository {
+		return memory.NewAttemptsRepository()
+	})
+}
+
+func TestSlotBookingsRepository(t *testing.T) {
+	repositorytest.SlotBookingsRepository(t, func(t *testing.T) repositories.SlotBookingsRepository {
+		return memory.NewSlotBookingsRepository()
+	})
+}
+
+func TestCashLedgerRepository(t *testing.T) {
+	repositorytest.CashLedgerRepository(t, func(t *testing.T) repositories.CashLedgerRepository {
+		return memory.NewCashLedgerRepository()
+	})
+}
+
+func TestInvoicesRepository(t *testing.T) {
+	repositorytest.InvoicesRepository(t, func(t *testing.T) repositories.InvoicesRepository {
+		return memory.NewInvoicesRepository()
+	})
+}
+
+func TestTxManager(t *testing.T) {
+	repositorytest.TxManager(t, func(t *testing.T) (repositories.TxManager, repositories.DeliveriesRepository, repositories.SlotBookingsRepository) {
+		return memory.NewTxManager(), memory.NewDeliveryRepository(), memory.NewSlotBookingsRepository()
+	})
+}
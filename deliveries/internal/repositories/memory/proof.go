@@ -0,0 +1,53 @@
+package memory
+
+import (
+	"context"
+	"github.com/zhanbolat18/parcel/deliveries/internal/entities"
+	"github.com/zhanbolat18/parcel/deliveries/internal/repositories"
+	"sync"
+)
+
+type proof struct {
+	mu         sync.RWMutex
+	lastFileId uint
+	proofs     map[uint]entities.Proof
+}
+
+func NewProofsRepository() repositories.ProofsRepository {
+	return &proof{proofs: make(map[uint]entities.Proof)}
+}
+
+func (p *proof) Store(ctx context.Context, proof *entities.Proof) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	previous, existed := p.proofs[proof.DeliveryId]
+	deliveryId := proof.DeliveryId
+	onRollback(ctx, func() {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		if existed {
+			p.proofs[deliveryId] = previous
+			return
+		}
+		delete(p.proofs, deliveryId)
+	})
+	for i := range proof.Files {
+		p.lastFileId++
+		proof.Files[i].Id = p.lastFileId
+	}
+	stored := *proof
+	stored.Files = append([]entities.ProofFile(nil), proof.Files...)
+	p.proofs[proof.DeliveryId] = stored
+	return nil
+}
+
+func (p *proof) GetByDelivery(_ context.Context, deliveryId uint) (*entities.Proof, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	found, ok := p.proofs[deliveryId]
+	if !ok {
+		return nil, repositories.ErrProofNotFound
+	}
+	found.Files = append([]entities.ProofFile(nil), found.Files...)
+	return &found, nil
+}
@@ -0,0 +1,108 @@
+package memory
+
+import (
+	"context"
+	"github.com/zhanbolat18/parcel/deliveries/internal/entities"
+	"github.com/zhanbolat18/parcel/deliveries/internal/repositories"
+	"sort"
+	"sync"
+)
+
+type shift struct {
+	mu     sync.RWMutex
+	lastId uint
+	shifts map[uint]entities.Shift
+}
+
+func NewShiftsRepository() repositories.ShiftsRepository {
+	return &shift{shifts: make(map[uint]entities.Shift)}
+}
+
+func (s *shift) GetById(_ context.Context, id uint) (*entities.Shift, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	found, ok := s.shifts[id]
+	if !ok {
+		return nil, repositories.ErrShiftNotFound
+	}
+	return &found, nil
+}
+
+func (s *shift) GetAll(_ context.Context, filter repositories.ShiftFilter) ([]*entities.Shift, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	shifts := make([]*entities.Shift, 0)
+	for _, found := range s.shifts {
+		found := found
+		if filter.CourierId != 0 && found.CourierId != filter.CourierId {
+			continue
+		}
+		if !filter.From.IsZero() && !found.EndsAt.After(filter.From) {
+			continue
+		}
+		if !filter.To.IsZero() && !found.StartsAt.Before(filter.To) {
+			continue
+		}
+		shifts = append(shifts, &found)
+	}
+	sort.Slice(shifts, func(i, j int) bool {
+		if shifts[i].StartsAt.Equal(shifts[j].StartsAt) {
+			return shifts[i].Id < shifts[j].Id
+		}
+		return shifts[i].StartsAt.Before(shifts[j].StartsAt)
+	})
+	return shifts, nil
+}
+
+// LockCourier does nothing, the memory TxManager already serializes units of
+// work.
+func (s *shift) LockCourier(_ context.Context, _ uint) error {
+	return nil
+}
+
+func (s *shift) Store(ctx context.Context, shift *entities.Shift) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lastId++
+	shift.Id = s.lastId
+	s.shifts[shift.Id] = *shift
+	s.restoreOnRollback(ctx, shift.Id, nil)
+	return nil
+}
+
+func (s *shift) Update(ctx context.Context, shift *entities.Shift) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	previous, ok := s.shifts[shift.Id]
+	if !ok {
+		return repositories.ErrShiftNotFound
+	}
+	s.shifts[shift.Id] = *shift
+	s.restoreOnRollback(ctx, shift.Id, &previous)
+	return nil
+}
+
+func (s *shift) Delete(ctx context.Context, id uint) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	previous, ok := s.shifts[id]
+	if !ok {
+		return repositories.ErrShiftNotFound
+	}
+	delete(s.shifts, id)
+	s.restoreOnRollback(ctx, id, &previous)
+	return nil
+}
+
+// restoreOnRollback puts back the shift as it was, nil removes it.
+func (s *shift) restoreOnRollback(ctx context.Context, id uint, previous *entities.Shift) {
+	onRollback(ctx, func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if previous == nil {
+			delete(s.shifts, id)
+			return
+		}
+		s.shifts[id] = *previous
+	})
+}
@@ -0,0 +1,65 @@
+package memory
+
+import (
+	"context"
+	"github.com/zhanbolat18/parcel/deliveries/internal/repositories"
+	"sync"
+	"time"
+)
+
+type slotKey struct {
+	zone  string
+	start int64
+}
+
+type slotBookings struct {
+	mu     sync.Mutex
+	booked map[slotKey]uint
+}
+
+func NewSlotBookingsRepository() repositories.SlotBookingsRepository {
+	return &slotBookings{booked: make(map[slotKey]uint)}
+}
+
+func (s *slotBookings) Book(ctx context.Context, zone string, slotStart time.Time, capacity uint) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := slotKey{zone: zone, start: slotStart.Unix()}
+	if s.booked[key] >= capacity {
+		return repositories.ErrSlotFull
+	}
+	s.booked[key]++
+	onRollback(ctx, func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.booked[key]--
+	})
+	return nil
+}
+
+func (s *slotBookings) Release(ctx context.Context, zone string, slotStart time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := slotKey{zone: zone, start: slotStart.Unix()}
+	if s.booked[key] > 0 {
+		s.booked[key]--
+		onRollback(ctx, func() {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			s.booked[key]++
+		})
+	}
+	return nil
+}
+
+func (s *slotBookings) Booked(_ context.Context, zone string, from, to time.Time) (map[int64]uint, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	booked := make(map[int64]uint)
+	for key, n := range s.booked {
+		if key.zone == zone && key.start >= from.Unix() && key.start < to.Unix() && n > 0 {
+			booked[key.start] = n
+		}
+	}
+	return booked, nil
+}
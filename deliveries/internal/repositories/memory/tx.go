@@ -0,0 +1,50 @@
+package memory
+
+import (
+	"context"
+	"github.com/zhanbolat18/parcel/deliveries/internal/repositories"
+	"sync"
+)
+
+type txKey struct{}
+
+// journal collects how to undo the changes made within a transaction.
+type journal struct {
+	undo []func()
+}
+
+type txManager struct {
+	mu sync.Mutex
+}
+
+// NewTxManager serializes units of work, which is what row locks give the
+// postgres implementation. The changes of a unit of work returning an error
+// are undone in reverse order.
+func NewTxManager() repositories.TxManager {
+	return &txManager{}
+}
+
+func (t *txManager) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if ctx.Value(txKey{}) != nil {
+		return fn(ctx)
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	j := &journal{}
+	err := fn(context.WithValue(ctx, txKey{}, j))
+	if err != nil {
+		for i := len(j.undo) - 1; i >= 0; i-- {
+			j.undo[i]()
+		}
+	}
+	return err
+}
+
+// onRollback registers the undo of a change, changes made outside of a
+// transaction stay. undo runs after the change returned and takes the locks
+// it needs itself.
+func onRollback(ctx context.Context, undo func()) {
+	if j, ok := ctx.Value(txKey{}).(*journal); ok {
+		j.undo = append(j.undo, undo)
+	}
+}
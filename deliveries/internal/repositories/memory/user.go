@@ -0,0 +1,65 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"github.com/zhanbolat18/parcel/deliveries/internal/entities"
+	"github.com/zhanbolat18/parcel/deliveries/internal/repositories"
+	"sort"
+	"sync"
+)
+
+type UsersRepository struct {
+	mu    sync.RWMutex
+	users map[uint]*entities.User
+}
+
+// NewUsersRepository serves the given users instead of asking the users
+// service.
+func NewUsersRepository(users ...*entities.User) *UsersRepository {
+	r := &UsersRepository{users: make(map[uint]*entities.User)}
+	for _, u := range users {
+		r.Add(u)
+	}
+	return r
+}
+
+func (u *UsersRepository) Add(user *entities.User) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	c := *user
+	u.users[user.Id] = &c
+}
+
+func (u *UsersRepository) GetCourier(_ context.Context, id uint) (*entities.User, error) {
+	return u.get(id, "courier")
+}
+
+func (u *UsersRepository) GetCouriers(_ context.Context) ([]*entities.User, error) {
+	u.mu.RLock()
+	defer u.mu.RUnlock()
+	couriers := make([]*entities.User, 0)
+	for _, user := range u.users {
+		if user.Role == "courier" {
+			c := *user
+			couriers = append(couriers, &c)
+		}
+	}
+	sort.Slice(couriers, func(i, j int) bool { return couriers[i].Id < couriers[j].Id })
+	return couriers, nil
+}
+
+func (u *UsersRepository) GetRecipient(_ context.Context, id uint) (*entities.User, error) {
+	return u.get(id, "user")
+}
+
+func (u *UsersRepository) get(id uint, role string) (*entities.User, error) {
+	u.mu.RLock()
+	defer u.mu.RUnlock()
+	user, ok := u.users[id]
+	if !ok || user.Role != role {
+		return nil, fmt.Errorf("%s \"%d\": %w", role, id, repositories.ErrUserNotFound)
+	}
+	c := *user
+	return &c, nil
+}
@@ -0,0 +1,99 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"github.com/jmoiron/sqlx"
+	"github.com/zhanbolat18/parcel/deliveries/internal/entities"
+	"github.com/zhanbolat18/parcel/deliveries/internal/repositories"
+	"github.com/zhanbolat18/parcel/deliveries/internal/valueobjects"
+	"time"
+)
+
+type attempt struct {
+	db *sqlx.DB
+}
+
+func NewAttemptsRepository(db *sqlx.DB) repositories.AttemptsRepository {
+	return &attempt{db: db}
+}
+
+type attemptModel struct {
+	Id               int64          `db:"id"`
+	DeliveryId       int64          `db:"delivery_id"`
+	CourierId        int64          `db:"courier_id"`
+	Reason           string         `db:"reason"`
+	Note             string         `db:"note"`
+	PhotoContentType sql.NullString `db:"photo_content_type"`
+	PhotoSize        sql.NullInt64  `db:"photo_size"`
+	PhotoKey         sql.NullString `db:"photo_key"`
+	CreatedAt        time.Time      `db:"created_at"`
+}
+
+func (a *attempt) Store(ctx context.Context, attempt *entities.Attempt) error {
+	am := attemptModel{
+		DeliveryId: int64(attempt.DeliveryId),
+		CourierId:  int64(attempt.CourierId),
+		Reason:     string(attempt.Reason),
+		Note:       attempt.Note,
+		CreatedAt:  attempt.CreatedAt,
+	}
+	if attempt.Photo != nil {
+		am.PhotoContentType = sql.NullString{String: attempt.Photo.ContentType, Valid: true}
+		am.PhotoSize = sql.NullInt64{Int64: attempt.Photo.Size, Valid: true}
+		am.PhotoKey = sql.NullString{String: attempt.Photo.Key, Valid: true}
+	}
+	q := `INSERT INTO delivery_attempts(delivery_id, courier_id, reason, note, photo_content_type, photo_size, photo_key, created_at)
+			VALUES(:delivery_id, :courier_id, :reason, :note, :photo_content_type, :photo_size, :photo_key, :created_at)
+			RETURNING id`
+	rows, err := sqlx.NamedQueryContext(ctx, executor(ctx, a.db), q, am)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	if !rows.Next() {
+		if err = rows.Err(); err != nil {
+			return err
+		}
+		return sql.ErrNoRows
+	}
+	var id int64
+	if err = rows.Scan(&id); err != nil {
+		return err
+	}
+	attempt.Id = uint(id)
+	if attempt.Photo != nil {
+		attempt.Photo.Id = attempt.Id
+	}
+	return nil
+}
+
+func (a *attempt) GetByDelivery(ctx context.Context, deliveryId uint) ([]*entities.Attempt, error) {
+	ams := make([]attemptModel, 0)
+	q := "SELECT * FROM delivery_attempts WHERE delivery_id=$1 ORDER BY id"
+	if err := sqlx.SelectContext(ctx, executor(ctx, a.db), &ams, q, deliveryId); err != nil {
+		return nil, err
+	}
+	attempts := make([]*entities.Attempt, 0, len(ams))
+	for _, am := range ams {
+		attempt := &entities.Attempt{
+			Id:         uint(am.Id),
+			DeliveryId: uint(am.DeliveryId),
+			CourierId:  uint(am.CourierId),
+			Reason:     valueobjects.AttemptReason(am.Reason),
+			Note:       am.Note,
+			CreatedAt:  am.CreatedAt,
+		}
+		if am.PhotoKey.Valid {
+			attempt.Photo = &entities.ProofFile{
+				Id:          attempt.Id,
+				Kind:        entities.ProofFilePhoto,
+				ContentType: am.PhotoContentType.String,
+				Size:        am.PhotoSize.Int64,
+				Key:         am.PhotoKey.String,
+			}
+		}
+		attempts = append(attempts, attempt)
+	}
+	return attempts, nil
+}
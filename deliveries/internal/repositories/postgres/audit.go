@@ -0,0 +1,66 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"github.com/jmoiron/sqlx"
+	"github.com/zhanbolat18/parcel/deliveries/internal/entities"
+	"github.com/zhanbolat18/parcel/deliveries/internal/repositories"
+	"time"
+)
+
+type audit struct {
+	db *sqlx.DB
+}
+
+func NewAuditRepository(db *sqlx.DB) repositories.AuditRepository {
+	return &audit{db: db}
+}
+
+type auditModel struct {
+	Id         int64         `db:"id"`
+	DeliveryId int64         `db:"delivery_id"`
+	ActorId    sql.NullInt64 `db:"actor_id"`
+	Action     string        `db:"action"`
+	Details    string        `db:"details"`
+	CreatedAt  time.Time     `db:"created_at"`
+}
+
+func (a *audit) Record(ctx context.Context, entry *entities.AuditEntry) error {
+	var actorId sql.NullInt64
+	if entry.ActorId != nil {
+		actorId = sql.NullInt64{Int64: int64(*entry.ActorId), Valid: true}
+	}
+	q := "INSERT INTO delivery_audit(delivery_id, actor_id, action, details, created_at) VALUES($1, $2, $3, $4, $5) RETURNING id"
+	var id int64
+	err := executor(ctx, a.db).QueryRowxContext(ctx, q, entry.DeliveryId, actorId, entry.Action, entry.Details, entry.CreatedAt).Scan(&id)
+	if err != nil {
+		return err
+	}
+	entry.Id = uint(id)
+	return nil
+}
+
+func (a *audit) GetByDelivery(ctx context.Context, deliveryId uint) ([]*entities.AuditEntry, error) {
+	ams := make([]auditModel, 0)
+	q := "SELECT * FROM delivery_audit WHERE delivery_id=$1 ORDER BY id"
+	if err := sqlx.SelectContext(ctx, executor(ctx, a.db), &ams, q, deliveryId); err != nil {
+		return nil, err
+	}
+	entries := make([]*entities.AuditEntry, 0, len(ams))
+	for _, am := range ams {
+		entry := &entities.AuditEntry{
+			Id:         uint(am.Id),
+			DeliveryId: uint(am.DeliveryId),
+			Action:     am.Action,
+			Details:    am.Details,
+			CreatedAt:  am.CreatedAt,
+		}
+		if am.ActorId.Valid {
+			id := uint(am.ActorId.Int64)
+			entry.ActorId = &id
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
@@ -0,0 +1,87 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/zhanbolat18/parcel/deliveries/internal/entities"
+	"github.com/zhanbolat18/parcel/deliveries/internal/repositories"
+	"github.com/zhanbolat18/parcel/deliveries/internal/valueobjects"
+	"time"
+)
+
+type availability struct {
+	db *sqlx.DB
+}
+
+func NewAvailabilityRepository(db *sqlx.DB) repositories.AvailabilityRepository {
+	return &availability{db: db}
+}
+
+type availabilityModel struct {
+	CourierId           int64         `db:"courier_id"`
+	Status              string        `db:"status"`
+	MaxActiveDeliveries sql.NullInt64 `db:"max_active_deliveries"`
+	UpdatedAt           time.Time     `db:"updated_at"`
+}
+
+func (a *availability) GetByCourier(ctx context.Context, courierId uint) (*entities.CourierAvailability, error) {
+	am := &availabilityModel{}
+	q := "SELECT * FROM courier_availability WHERE courier_id=$1"
+	if _, ok := txFromContext(ctx); ok {
+		q += " FOR UPDATE"
+	}
+	err := sqlx.GetContext(ctx, executor(ctx, a.db), am, q, courierId)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, repositories.ErrAvailabilityNotFound
+		}
+		return nil, err
+	}
+	return a.hydrateToEntity(am), nil
+}
+
+func (a *availability) GetByCouriers(ctx context.Context, courierIds ...uint) (map[uint]*entities.CourierAvailability, error) {
+	ids := make([]int64, 0, len(courierIds))
+	for _, id := range courierIds {
+		ids = append(ids, int64(id))
+	}
+	ams := make([]availabilityModel, 0)
+	q := "SELECT * FROM courier_availability WHERE courier_id = ANY($1)"
+	err := sqlx.SelectContext(ctx, executor(ctx, a.db), &ams, q, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	found := make(map[uint]*entities.CourierAvailability, len(ams))
+	for _, am := range ams {
+		am := am
+		found[uint(am.CourierId)] = a.hydrateToEntity(&am)
+	}
+	return found, nil
+}
+
+func (a *availability) Save(ctx context.Context, availability *entities.CourierAvailability) error {
+	var max sql.NullInt64
+	if availability.MaxActiveDeliveries != 0 {
+		max = sql.NullInt64{Int64: int64(availability.MaxActiveDeliveries), Valid: true}
+	}
+	q := `INSERT INTO courier_availability(courier_id, status, max_active_deliveries, updated_at)
+			VALUES($1, $2, $3, $4)
+			ON CONFLICT (courier_id) DO UPDATE SET
+				status=EXCLUDED.status,
+				max_active_deliveries=EXCLUDED.max_active_deliveries,
+				updated_at=EXCLUDED.updated_at`
+	_, err := executor(ctx, a.db).ExecContext(ctx, q,
+		availability.CourierId, availability.Status, max, availability.UpdatedAt)
+	return err
+}
+
+func (a *availability) hydrateToEntity(model *availabilityModel) *entities.CourierAvailability {
+	return &entities.CourierAvailability{
+		CourierId:           uint(model.CourierId),
+		Status:              valueobjects.Availability(model.Status),
+		MaxActiveDeliveries: uint(model.MaxActiveDeliveries.Int64),
+		UpdatedAt:           model.UpdatedAt,
+	}
+}
@@ -0,0 +1,114 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/zhanbolat18/parcel/deliveries/internal/entities"
+	"github.com/zhanbolat18/parcel/deliveries/internal/repositories"
+	"github.com/zhanbolat18/parcel/deliveries/internal/valueobjects"
+	"time"
+)
+
+// cashLockSpace keeps courier ledger advisory locks apart from other ones.
+const cashLockSpace = 2
+
+type cashLedger struct {
+	db *sqlx.DB
+}
+
+func NewCashLedgerRepository(db *sqlx.DB) repositories.CashLedgerRepository {
+	return &cashLedger{db: db}
+}
+
+type cashEntryModel struct {
+	Id         int64          `db:"id"`
+	CourierId  int64          `db:"courier_id"`
+	Seq        int64          `db:"seq"`
+	Kind       string         `db:"kind"`
+	DeliveryId sql.NullInt64  `db:"delivery_id"`
+	Amount     int64          `db:"amount"`
+	Balance    int64          `db:"balance"`
+	Currency   string         `db:"currency"`
+	Note       sql.NullString `db:"note"`
+	ActorId    int64          `db:"actor_id"`
+	CreatedAt  time.Time      `db:"created_at"`
+}
+
+// Append takes the next seq of the courier, concurrent appends collide on the
+// unique (courier_id, seq) and all but one fail unless the courier is locked.
+func (c *cashLedger) Append(ctx context.Context, entry *entities.CashEntry) error {
+	var deliveryId sql.NullInt64
+	if entry.DeliveryId != nil {
+		deliveryId = sql.NullInt64{Int64: int64(*entry.DeliveryId), Valid: true}
+	}
+	q := `INSERT INTO cash_ledger(courier_id, seq, kind, delivery_id, amount, balance, currency, note, actor_id, created_at)
+			SELECT $1, COALESCE(last.seq, 0) + 1, $2, $3, $4, COALESCE(last.balance, 0) + $4, $5, $6, $7, $8
+			FROM (SELECT 1) AS one
+			LEFT JOIN (SELECT seq, balance FROM cash_ledger WHERE courier_id=$1 ORDER BY seq DESC LIMIT 1) AS last ON true
+			RETURNING id, seq, balance`
+	var id, seq, balance int64
+	err := executor(ctx, c.db).QueryRowxContext(ctx, q, entry.CourierId, entry.Kind, deliveryId, int64(entry.Amount),
+		entry.Currency, sql.NullString{String: entry.Note, Valid: entry.Note != ""}, entry.ActorId, entry.CreatedAt).
+		Scan(&id, &seq, &balance)
+	if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+		return repositories.ErrConcurrentModification
+	}
+	if err != nil {
+		return err
+	}
+	entry.Id, entry.Seq, entry.Balance = uint(id), uint(seq), valueobjects.Money(balance)
+	return nil
+}
+
+func (c *cashLedger) GetByCourier(ctx context.Context, courierId uint) ([]*entities.CashEntry, error) {
+	return c.selectEntries(ctx, "SELECT * FROM cash_ledger WHERE courier_id=$1 ORDER BY seq", courierId)
+}
+
+func (c *cashLedger) GetDiscrepancies(ctx context.Context, courierId uint) ([]*entities.CashEntry, error) {
+	q := "SELECT * FROM cash_ledger WHERE kind=$1 AND amount <> 0"
+	args := []interface{}{entities.CashReconciliation}
+	if courierId != 0 {
+		q += " AND courier_id=$2"
+		args = append(args, courierId)
+	}
+	return c.selectEntries(ctx, q+" ORDER BY id", args...)
+}
+
+func (c *cashLedger) LockCourier(ctx context.Context, courierId uint) error {
+	tx, ok := txFromContext(ctx)
+	if !ok {
+		return nil
+	}
+	_, err := tx.ExecContext(ctx, "SELECT pg_advisory_xact_lock($1, $2)", cashLockSpace, int32(courierId))
+	return err
+}
+
+func (c *cashLedger) selectEntries(ctx context.Context, q string, args ...interface{}) ([]*entities.CashEntry, error) {
+	cms := make([]cashEntryModel, 0)
+	if err := sqlx.SelectContext(ctx, executor(ctx, c.db), &cms, q, args...); err != nil {
+		return nil, err
+	}
+	entries := make([]*entities.CashEntry, 0, len(cms))
+	for _, cm := range cms {
+		entry := &entities.CashEntry{
+			Id:        uint(cm.Id),
+			CourierId: uint(cm.CourierId),
+			Seq:       uint(cm.Seq),
+			Kind:      cm.Kind,
+			Amount:    valueobjects.Money(cm.Amount),
+			Balance:   valueobjects.Money(cm.Balance),
+			Currency:  cm.Currency,
+			Note:      cm.Note.String,
+			ActorId:   uint(cm.ActorId),
+			CreatedAt: cm.CreatedAt,
+		}
+		if cm.DeliveryId.Valid {
+			id := uint(cm.DeliveryId.Int64)
+			entry.DeliveryId = &id
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
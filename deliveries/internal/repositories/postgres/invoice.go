@@ -0,0 +1,162 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/zhanbolat18/parcel/deliveries/internal/entities"
+	"github.com/zhanbolat18/parcel/deliveries/internal/repositories"
+	"github.com/zhanbolat18/parcel/deliveries/internal/valueobjects"
+	"strings"
+	"time"
+)
+
+type invoice struct {
+	db *sqlx.DB
+}
+
+func NewInvoicesRepository(db *sqlx.DB) repositories.InvoicesRepository {
+	return &invoice{db: db}
+}
+
+type invoiceModel struct {
+	Id             int64         `db:"id"`
+	MerchantId     int64         `db:"merchant_id"`
+	OrganizationId sql.NullInt64 `db:"organization_id"`
+	Period         string        `db:"period"`
+	Currency       string        `db:"currency"`
+	Total          int64         `db:"total"`
+	Deliveries     int64         `db:"deliveries"`
+	CreatedAt      time.Time     `db:"created_at"`
+}
+
+type invoiceLineModel struct {
+	InvoiceId    int64     `db:"invoice_id"`
+	DeliveryId   int64     `db:"delivery_id"`
+	CompletedAt  time.Time `db:"completed_at"`
+	Destination  string    `db:"destination"`
+	ServiceLevel string    `db:"service_level"`
+	Amount       int64     `db:"amount"`
+}
+
+func (i *invoice) Store(ctx context.Context, invoice *entities.Invoice) error {
+	q := `INSERT INTO invoices(merchant_id, organization_id, period, currency, total, deliveries, created_at)
+			VALUES($1, $2, $3, $4, $5, $6, $7) RETURNING id`
+	var organizationId sql.NullInt64
+	if invoice.OrganizationId != nil {
+		organizationId = sql.NullInt64{Int64: int64(*invoice.OrganizationId), Valid: true}
+	}
+	var id int64
+	err := executor(ctx, i.db).QueryRowxContext(ctx, q, invoice.MerchantId, organizationId, invoice.Period,
+		invoice.Currency, int64(invoice.Total), invoice.Deliveries, invoice.CreatedAt).Scan(&id)
+	if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+		return repositories.ErrInvoiceExists
+	}
+	if err != nil {
+		return err
+	}
+	q = `INSERT INTO invoice_lines(invoice_id, delivery_id, completed_at, destination, service_level, amount)
+			VALUES($1, $2, $3, $4, $5, $6)`
+	for _, line := range invoice.Lines {
+		_, err = executor(ctx, i.db).ExecContext(ctx, q, id, line.DeliveryId, line.CompletedAt, line.Destination,
+			line.ServiceLevel, int64(line.Amount))
+		if err != nil {
+			return err
+		}
+	}
+	invoice.Id = uint(id)
+	return nil
+}
+
+func (i *invoice) GetById(ctx context.Context, id uint) (*entities.Invoice, error) {
+	scope, args := tenantCondition(ctx, "merchant_id", []interface{}{id})
+	return i.getOne(ctx, "SELECT * FROM invoices WHERE id=$1"+scope, args...)
+}
+
+func (i *invoice) GetByPeriod(ctx context.Context, merchant entities.Tenant, period string) (*entities.Invoice, error) {
+	scope, args := tenantCondition(ctx, "merchant_id", []interface{}{period})
+	q := "SELECT * FROM invoices WHERE period=$1" + scope
+	if merchant.OrganizationId != 0 {
+		args = append(args, merchant.OrganizationId)
+		q += fmt.Sprintf(" AND organization_id=$%d", len(args))
+	} else {
+		args = append(args, merchant.UserId)
+		q += fmt.Sprintf(" AND organization_id IS NULL AND merchant_id=$%d", len(args))
+	}
+	return i.getOne(ctx, q, args...)
+}
+
+func (i *invoice) GetAll(ctx context.Context, filter repositories.InvoiceFilter) ([]*entities.Invoice, error) {
+	conditions := []string{"TRUE"}
+	args := make([]interface{}, 0, 2)
+	if filter.MerchantId != 0 {
+		args = append(args, filter.MerchantId)
+		conditions = append(conditions, fmt.Sprintf("merchant_id=$%d", len(args)))
+	}
+	if filter.OrganizationId != 0 {
+		args = append(args, filter.OrganizationId)
+		conditions = append(conditions, fmt.Sprintf("organization_id=$%d", len(args)))
+	}
+	if filter.Period != "" {
+		args = append(args, filter.Period)
+		conditions = append(conditions, fmt.Sprintf("period=$%d", len(args)))
+	}
+	scope, args := tenantCondition(ctx, "merchant_id", args)
+	ims := make([]invoiceModel, 0)
+	q := fmt.Sprintf("SELECT * FROM invoices WHERE %s%s ORDER BY period DESC, id", strings.Join(conditions, " AND "), scope)
+	if err := sqlx.SelectContext(ctx, executor(ctx, i.db), &ims, q, args...); err != nil {
+		return nil, err
+	}
+	invoices := make([]*entities.Invoice, 0, len(ims))
+	for _, im := range ims {
+		im := im
+		invoices = append(invoices, i.hydrateToEntity(&im))
+	}
+	return invoices, nil
+}
+
+func (i *invoice) getOne(ctx context.Context, q string, args ...interface{}) (*entities.Invoice, error) {
+	im := &invoiceModel{}
+	if err := sqlx.GetContext(ctx, executor(ctx, i.db), im, q, args...); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, repositories.ErrInvoiceNotFound
+		}
+		return nil, err
+	}
+	lms := make([]invoiceLineModel, 0)
+	q = "SELECT * FROM invoice_lines WHERE invoice_id=$1 ORDER BY completed_at, delivery_id"
+	if err := sqlx.SelectContext(ctx, executor(ctx, i.db), &lms, q, im.Id); err != nil {
+		return nil, err
+	}
+	invoice := i.hydrateToEntity(im)
+	invoice.Lines = make([]entities.InvoiceLine, 0, len(lms))
+	for _, lm := range lms {
+		invoice.Lines = append(invoice.Lines, entities.InvoiceLine{
+			DeliveryId:   uint(lm.DeliveryId),
+			CompletedAt:  lm.CompletedAt,
+			Destination:  lm.Destination,
+			ServiceLevel: valueobjects.ServiceLevel(lm.ServiceLevel),
+			Amount:       valueobjects.Money(lm.Amount),
+		})
+	}
+	return invoice, nil
+}
+
+func (i *invoice) hydrateToEntity(im *invoiceModel) *entities.Invoice {
+	invoice := &entities.Invoice{
+		Id:         uint(im.Id),
+		MerchantId: uint(im.MerchantId),
+		Period:     im.Period,
+		Currency:   im.Currency,
+		Total:      valueobjects.Money(im.Total),
+		Deliveries: uint(im.Deliveries),
+		CreatedAt:  im.CreatedAt,
+	}
+	if im.OrganizationId.Valid {
+		id := uint(im.OrganizationId.Int64)
+		invoice.OrganizationId = &id
+	}
+	return invoice
+}
@@ -0,0 +1,150 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"github.com/jmoiron/sqlx"
+	"github.com/zhanbolat18/parcel/deliveries/internal/entities"
+	"github.com/zhanbolat18/parcel/deliveries/internal/repositories"
+	"strings"
+	"time"
+)
+
+const (
+	locationsTable           = "courier_locations"
+	locationsPartitionPrefix = locationsTable + "_p"
+	locationsDefaultTable    = locationsTable + "_default"
+	locationsPartitionLayout = "20060102"
+	locationsPartitionSpan   = 24 * time.Hour
+)
+
+type locationHistory struct {
+	db *sqlx.DB
+}
+
+// NewLocationHistoryRepository stores fixes in daily partitions of the
+// courier_locations table, so expired days are dropped instead of deleted.
+func NewLocationHistoryRepository(db *sqlx.DB) repositories.LocationHistoryRepository {
+	return &locationHistory{db: db}
+}
+
+type locationFixModel struct {
+	CourierId  int64     `db:"courier_id"`
+	Latitude   float64   `db:"latitude"`
+	Longitude  float64   `db:"longitude"`
+	Accuracy   float64   `db:"accuracy"`
+	RecordedAt time.Time `db:"recorded_at"`
+}
+
+func (l *locationHistory) Store(ctx context.Context, fixes ...*entities.LocationFix) error {
+	if len(fixes) == 0 {
+		return nil
+	}
+	models := make([]locationFixModel, 0, len(fixes))
+	for _, fix := range fixes {
+		models = append(models, locationFixModel{
+			CourierId:  int64(fix.CourierId),
+			Latitude:   fix.Latitude,
+			Longitude:  fix.Longitude,
+			Accuracy:   fix.Accuracy,
+			RecordedAt: fix.RecordedAt,
+		})
+	}
+	q := "INSERT INTO courier_locations(courier_id, latitude, longitude, accuracy, recorded_at) " +
+		"VALUES(:courier_id, :latitude, :longitude, :accuracy, :recorded_at)"
+	_, err := sqlx.NamedExecContext(ctx, executor(ctx, l.db), q, models)
+	return err
+}
+
+func (l *locationHistory) GetByCourier(ctx context.Context, courierId uint, from, to time.Time) ([]*entities.LocationFix, error) {
+	models := make([]locationFixModel, 0)
+	q := "SELECT * FROM courier_locations WHERE courier_id=$1 AND recorded_at >= $2 AND recorded_at < $3 ORDER BY recorded_at"
+	if err := sqlx.SelectContext(ctx, executor(ctx, l.db), &models, q, courierId, from, to); err != nil {
+		return nil, err
+	}
+	fixes := make([]*entities.LocationFix, 0, len(models))
+	for _, m := range models {
+		fixes = append(fixes, &entities.LocationFix{
+			CourierId:  uint(m.CourierId),
+			Latitude:   m.Latitude,
+			Longitude:  m.Longitude,
+			Accuracy:   m.Accuracy,
+			RecordedAt: m.RecordedAt,
+		})
+	}
+	return fixes, nil
+}
+
+// PreparePartitions creates the missing daily partitions, in UTC, covering the
+// period. Fixes of a day that already landed in the default partition are
+// moved into the day's partition.
+func (l *locationHistory) PreparePartitions(ctx context.Context, from, to time.Time) error {
+	for start := from.UTC().Truncate(locationsPartitionSpan); start.Before(to); start = start.Add(locationsPartitionSpan) {
+		if err := l.preparePartition(ctx, start); err != nil {
+			return fmt.Errorf("create partition for %s: %w", start.Format(time.DateOnly), err)
+		}
+	}
+	return nil
+}
+
+func (l *locationHistory) preparePartition(ctx context.Context, start time.Time) error {
+	name := locationsPartitionPrefix + start.Format(locationsPartitionLayout)
+	var exists bool
+	if err := l.db.GetContext(ctx, &exists, "SELECT to_regclass($1) IS NOT NULL", name); err != nil {
+		return err
+	}
+	if exists {
+		return nil
+	}
+	end := start.Add(locationsPartitionSpan)
+	tx, err := l.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	// postgres refuses a partition whose range has rows in the default one,
+	// so they are parked aside while it is created. The lock holds back
+	// fixes that would land there in the meantime.
+	statements := []struct {
+		q    string
+		args []interface{}
+	}{
+		{q: fmt.Sprintf("LOCK TABLE %s IN ACCESS EXCLUSIVE MODE", locationsTable)},
+		{q: fmt.Sprintf("CREATE TEMP TABLE courier_locations_moved (LIKE %s) ON COMMIT DROP", locationsTable)},
+		{
+			q: fmt.Sprintf("WITH moved AS (DELETE FROM %s WHERE recorded_at >= $1 AND recorded_at < $2 RETURNING *) "+
+				"INSERT INTO courier_locations_moved SELECT * FROM moved", locationsDefaultTable),
+			args: []interface{}{start, end},
+		},
+		{q: fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s PARTITION OF %s FOR VALUES FROM ('%s') TO ('%s')",
+			name, locationsTable, start.Format(time.RFC3339), end.Format(time.RFC3339))},
+		{q: fmt.Sprintf("INSERT INTO %s SELECT * FROM courier_locations_moved", locationsTable)},
+	}
+	for _, s := range statements {
+		if _, err := tx.ExecContext(ctx, s.q, s.args...); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// DeleteBefore drops the partitions ending before the given moment and
+// deletes the remaining older fixes.
+func (l *locationHistory) DeleteBefore(ctx context.Context, before time.Time) error {
+	partitions := make([]string, 0)
+	q := "SELECT c.relname FROM pg_inherits i JOIN pg_class c ON c.oid = i.inhrelid WHERE i.inhparent = $1::regclass"
+	if err := sqlx.SelectContext(ctx, l.db, &partitions, q, locationsTable); err != nil {
+		return fmt.Errorf("list partitions: %w", err)
+	}
+	for _, name := range partitions {
+		start, err := time.Parse(locationsPartitionLayout, strings.TrimPrefix(name, locationsPartitionPrefix))
+		if err != nil || !strings.HasPrefix(name, locationsPartitionPrefix) || start.Add(locationsPartitionSpan).After(before) {
+			continue
+		}
+		if _, err := l.db.ExecContext(ctx, fmt.Sprintf("DROP TABLE %s", name)); err != nil {
+			return fmt.Errorf("drop partition %s: %w", name, err)
+		}
+	}
+	_, err := l.db.ExecContext(ctx, "DELETE FROM courier_locations WHERE recorded_at < $1", before)
+	return err
+}
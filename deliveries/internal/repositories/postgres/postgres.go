@@ -2,6 +2,8 @@ package postgres
 
 import (
 	"context"
+	"database/sql"
+	"fmt"
 	"github.com/jmoiron/sqlx"
 	_ "github.com/lib/pq"
 	"github.com/zhanbolat18/parcel/deliveries/internal/entities"
@@ -10,8 +12,6 @@ import (
 	"time"
 )
 
-const DateFormat = time.RFC3339
-
 type delivery struct {
 	db *sqlx.DB
 }
@@ -20,20 +20,93 @@ func NewDeliveryRepository(db *sqlx.DB) repositories.DeliveriesRepository {
 	return &delivery{db: db}
 }
 
+// dateLayout is the layout of the scheduled_for date of deliveries.
+const dateLayout = "2006-01-02"
+
 type deliveryModel struct {
-	Id          uint   `db:"id" json:"id"`
-	Status      string `db:"status" json:"status"`
-	Destination string `db:"destination" json:"destination"`
-	RecipientId uint   `db:"recipient_id" json:"recipientId"`
-	CourierId   *uint  `db:"courier_id" json:"courierId,omitempty"`
-	CreatedAt   string `db:"created_at" json:"createdAt"`
-	UpdatedAt   string `db:"updated_at" json:"updatedAt"`
+	Id          uint            `db:"id" json:"id"`
+	Type        string          `db:"type" json:"type"`
+	Status      string          `db:"status" json:"status"`
+	OriginalId  sql.NullInt64   `db:"original_id" json:"originalId"`
+	Origin      sql.NullString  `db:"origin" json:"origin"`
+	OriginLat   sql.NullFloat64 `db:"origin_lat" json:"originLat"`
+	OriginLng   sql.NullFloat64 `db:"origin_lng" json:"originLng"`
+	Destination string          `db:"destination" json:"destination"`
+	DestLat     sql.NullFloat64 `db:"destination_lat" json:"destinationLat"`
+	DestLng     sql.NullFloat64 `db:"destination_lng" json:"destinationLng"`
+	WindowStart sql.NullTime    `db:"window_start" json:"windowStart"`
+	WindowEnd   sql.NullTime    `db:"window_end" json:"windowEnd"`
+	Zone        sql.NullString  `db:"zone" json:"zone"`
+	Scheduled   sql.NullTime    `db:"scheduled_for" json:"scheduledFor"`
+	SlotStart   sql.NullTime    `db:"slot_start" json:"slotStart"`
+	SlotEnd     sql.NullTime    `db:"slot_end" json:"slotEnd"`
+	Level       string          `db:"service_level" json:"serviceLevel"`
+	DueAt       sql.NullTime    `db:"due_at" json:"dueAt"`
+	SLA         string          `db:"sla_status" json:"slaStatus"`
+	PriceAmount sql.NullInt64   `db:"price_amount" json:"priceAmount"`
+	Currency    sql.NullString  `db:"price_currency" json:"priceCurrency"`
+	CodAmount   sql.NullInt64   `db:"cod_amount" json:"codAmount"`
+	CodCurrency sql.NullString  `db:"cod_currency" json:"codCurrency"`
+	Collected   sql.NullInt64   `db:"collected_amount" json:"collectedAmount"`
+	Method      sql.NullString  `db:"collection_method" json:"collectionMethod"`
+	RecipientId int64           `db:"recipient_id" json:"recipientId"`
+	OrgId       sql.NullInt64   `db:"organization_id" json:"organizationId"`
+	CourierId   sql.NullInt64   `db:"courier_id" json:"courierId,omitempty"`
+	CreatedAt   time.Time       `db:"created_at" json:"createdAt"`
+	UpdatedAt   time.Time       `db:"updated_at" json:"updatedAt"`
+	CompletedAt sql.NullTime    `db:"completed_at" json:"completedAt"`
+	Version     uint            `db:"version" json:"version"`
+	Attempts    int             `db:"attempts" json:"attempts"`
+
+	HandoverNonce    sql.NullString `db:"handover_nonce" json:"-"`
+	HandoverCodeHash sql.NullString `db:"handover_code_hash" json:"-"`
+	HandoverAttempts int            `db:"handover_attempts" json:"-"`
+	HandoverLocked   bool           `db:"handover_locked" json:"-"`
+}
+
+func (d *delivery) GetAll(ctx context.Context, filter repositories.DeliveryFilter) ([]*entities.Delivery, error) {
+	dm := make([]deliveryModel, 0)
+	conditions, args := filterCondition(filter, nil)
+	scope, args := tenantCondition(ctx, "recipient_id", args)
+	q := "SELECT * FROM deliveries WHERE TRUE" + conditions + scope + " ORDER BY id"
+	err := sqlx.SelectContext(ctx, executor(ctx, d.db), &dm, q, args...)
+	if err != nil {
+		return nil, err
+	}
+	dls := make([]*entities.Delivery, 0, len(dm))
+	for _, model := range dm {
+		model := model
+		dls = append(dls, d.hydrateToEntity(&model))
+	}
+	return dls, nil
+}
+
+func (d *delivery) GetAllByCourier(
+	ctx context.Context,
+	courierId uint,
+	filter repositories.DeliveryFilter,
+) ([]*entities.Delivery, error) {
+	dm := make([]deliveryModel, 0)
+	conditions, args := filterCondition(filter, []interface{}{courierId})
+	scope, args := tenantCondition(ctx, "recipient_id", args)
+	q := "SELECT * FROM deliveries WHERE courier_id=$1" + conditions + scope + " ORDER BY id"
+	err := sqlx.SelectContext(ctx, executor(ctx, d.db), &dm, q, args...)
+	if err != nil {
+		return nil, err
+	}
+	dls := make([]*entities.Delivery, 0, len(dm))
+	for _, model := range dm {
+		model := model
+		dls = append(dls, d.hydrateToEntity(&model))
+	}
+	return dls, nil
 }
 
-func (d *delivery) GetAll(ctx context.Context) ([]*entities.Delivery, error) {
+func (d *delivery) GetAllByRecipient(ctx context.Context, recipientId uint) ([]*entities.Delivery, error) {
 	dm := make([]deliveryModel, 0)
-	q := "SELECT * FROM deliveries"
-	err := d.db.SelectContext(ctx, &dm, q)
+	scope, args := tenantCondition(ctx, "recipient_id", []interface{}{recipientId})
+	q := "SELECT * FROM deliveries WHERE recipient_id=$1" + scope + " ORDER BY id"
+	err := sqlx.SelectContext(ctx, executor(ctx, d.db), &dm, q, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -45,10 +118,11 @@ func (d *delivery) GetAll(ctx context.Context) ([]*entities.Delivery, error) {
 	return dls, nil
 }
 
-func (d *delivery) GetAllByCourier(ctx context.Context, courierId uint) ([]*entities.Delivery, error) {
+func (d *delivery) GetAllByStatus(ctx context.Context, status valueobjects.Status) ([]*entities.Delivery, error) {
 	dm := make([]deliveryModel, 0)
-	q := "SELECT * FROM deliveries WHERE courier_id=$1"
-	err := d.db.SelectContext(ctx, &dm, q, courierId)
+	scope, args := tenantCondition(ctx, "recipient_id", []interface{}{status})
+	q := "SELECT * FROM deliveries WHERE status=$1" + scope + " ORDER BY id"
+	err := sqlx.SelectContext(ctx, executor(ctx, d.db), &dm, q, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -60,85 +134,354 @@ func (d *delivery) GetAllByCourier(ctx context.Context, courierId uint) ([]*enti
 	return dls, nil
 }
 
+func (d *delivery) CountByCourier(ctx context.Context, courierId uint, status valueobjects.Status) (int, error) {
+	var count int
+	scope, args := tenantCondition(ctx, "recipient_id", []interface{}{courierId, status})
+	q := "SELECT count(*) FROM deliveries WHERE courier_id=$1 AND status=$2" + scope
+	err := sqlx.GetContext(ctx, executor(ctx, d.db), &count, q, args...)
+	return count, err
+}
+
 func (d *delivery) GetById(ctx context.Context, id uint) (*entities.Delivery, error) {
 	dm := &deliveryModel{}
-	q := "SELECT * FROM deliveries WHERE id=$1"
-	err := d.db.GetContext(ctx, dm, q, id)
+	scope, args := tenantCondition(ctx, "recipient_id", []interface{}{id})
+	q := "SELECT * FROM deliveries WHERE id=$1" + scope
+	if _, ok := txFromContext(ctx); ok {
+		// the row stays locked until the transaction ends
+		q += " FOR UPDATE"
+	}
+	err := sqlx.GetContext(ctx, executor(ctx, d.db), dm, q, args...)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, repositories.ErrDeliveryNotFound
+		}
+		return nil, err
+	}
+	return d.hydrateToEntity(dm), nil
+}
+
+func (d *delivery) GetReturnOf(ctx context.Context, originalId uint) (*entities.Delivery, error) {
+	dm := &deliveryModel{}
+	scope, args := tenantCondition(ctx, "recipient_id", []interface{}{originalId})
+	q := "SELECT * FROM deliveries WHERE original_id=$1" + scope
+	err := sqlx.GetContext(ctx, executor(ctx, d.db), dm, q, args...)
 	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, repositories.ErrDeliveryNotFound
+		}
 		return nil, err
 	}
 	return d.hydrateToEntity(dm), nil
 }
 
+func (d *delivery) GetAllDueBefore(ctx context.Context, before time.Time) ([]*entities.Delivery, error) {
+	dm := make([]deliveryModel, 0)
+	scope, args := tenantCondition(ctx, "recipient_id", []interface{}{before, valueobjects.Breached,
+		valueobjects.Created, valueobjects.Delivers, valueobjects.AttemptFailed})
+	q := `SELECT * FROM deliveries WHERE due_at < $1 AND sla_status <> $2 AND status IN ($3, $4, $5)` + scope +
+		" ORDER BY due_at, id"
+	err := sqlx.SelectContext(ctx, executor(ctx, d.db), &dm, q, args...)
+	if err != nil {
+		return nil, err
+	}
+	dls := make([]*entities.Delivery, 0, len(dm))
+	for _, model := range dm {
+		model := model
+		dls = append(dls, d.hydrateToEntity(&model))
+	}
+	return dls, nil
+}
+
+func (d *delivery) GetAllCompletedBetween(ctx context.Context, from, to time.Time) ([]*entities.Delivery, error) {
+	dm := make([]deliveryModel, 0)
+	scope, args := tenantCondition(ctx, "recipient_id", []interface{}{valueobjects.Completed, from, to})
+	q := "SELECT * FROM deliveries WHERE status=$1 AND completed_at >= $2 AND completed_at < $3" + scope +
+		" ORDER BY completed_at, id"
+	if err := sqlx.SelectContext(ctx, executor(ctx, d.db), &dm, q, args...); err != nil {
+		return nil, err
+	}
+	dls := make([]*entities.Delivery, 0, len(dm))
+	for _, model := range dm {
+		model := model
+		dls = append(dls, d.hydrateToEntity(&model))
+	}
+	return dls, nil
+}
+
 func (d *delivery) Store(ctx context.Context, delivery *entities.Delivery) error {
-	q := `INSERT INTO deliveries(status, destination, recipient_id, courier_id, created_at, updated_at) 
-			VALUES($1, $2, $3, $4, $5, $6)
-			RETURNING id;`
-	dm := d.hydrateFromEntity(delivery)
-	var id int
-	stmt, err := d.db.PrepareContext(ctx, q)
+	q := `INSERT INTO deliveries(type, status, original_id, origin, origin_lat, origin_lng,
+				destination, destination_lat, destination_lng, window_start, window_end,
+				zone, scheduled_for, slot_start, slot_end, service_level, due_at, sla_status,
+				price_amount, price_currency, cod_amount, cod_currency, collected_amount, collection_method, recipient_id, organization_id, courier_id, created_at, updated_at, completed_at, attempts,
+				handover_nonce, handover_code_hash, handover_attempts, handover_locked) 
+			VALUES(:type, :status, :original_id, :origin, :origin_lat, :origin_lng,
+				:destination, :destination_lat, :destination_lng, :window_start, :window_end,
+				:zone, :scheduled_for, :slot_start, :slot_end, :service_level, :due_at, :sla_status,
+				:price_amount, :price_currency, :cod_amount, :cod_currency, :collected_amount, :collection_method, :recipient_id, :organization_id, :courier_id, :created_at, :updated_at, :completed_at, :attempts,
+				:handover_nonce, :handover_code_hash, :handover_attempts, :handover_locked)
+			RETURNING id, version;`
+	rows, err := sqlx.NamedQueryContext(ctx, executor(ctx, d.db), q, d.hydrateFromEntity(delivery))
 	if err != nil {
 		return err
 	}
-	err = stmt.QueryRowContext(ctx, dm.Status, dm.Destination, dm.RecipientId, dm.CourierId, dm.CreatedAt, dm.UpdatedAt).
-		Scan(&id)
-	if err != nil {
+	defer rows.Close()
+	var id int
+	var version uint
+	if !rows.Next() {
+		if err = rows.Err(); err != nil {
+			return err
+		}
+		return sql.ErrNoRows
+	}
+	if err = rows.Scan(&id, &version); err != nil {
 		return err
 	}
 	delivery.Id = uint(id)
+	delivery.Version = version
 	return nil
 }
 
 func (d *delivery) Update(ctx context.Context, delivery *entities.Delivery) error {
 	q := `UPDATE deliveries SET 
+			type=:type,
 			status=:status, 
+			original_id=:original_id,
+			origin=:origin,
+			origin_lat=:origin_lat,
+			origin_lng=:origin_lng,
 			destination=:destination,
+			destination_lat=:destination_lat,
+			destination_lng=:destination_lng,
+			window_start=:window_start,
+			window_end=:window_end,
+			zone=:zone,
+			scheduled_for=:scheduled_for,
+			slot_start=:slot_start,
+			slot_end=:slot_end,
+			service_level=:service_level,
+			due_at=:due_at,
+			sla_status=:sla_status,
+			price_amount=:price_amount,
+			price_currency=:price_currency,
+			cod_amount=:cod_amount,
+			cod_currency=:cod_currency,
+			collected_amount=:collected_amount,
+			collection_method=:collection_method,
 			recipient_id=:recipient_id,
 			courier_id=:courier_id,
 			created_at=:created_at,
-			updated_at=:updated_at
-		WHERE id=:id`
-	_, err := d.db.NamedExecContext(ctx, q, d.hydrateFromEntity(delivery))
-	return err
+			updated_at=:updated_at,
+			completed_at=:completed_at,
+			attempts=:attempts,
+			handover_nonce=:handover_nonce,
+			handover_code_hash=:handover_code_hash,
+			handover_attempts=:handover_attempts,
+			handover_locked=:handover_locked,
+			version=version+1
+		WHERE id=:id AND version=:version`
+	q, args, err := sqlx.Named(q, d.hydrateFromEntity(delivery))
+	if err != nil {
+		return err
+	}
+	scope, args := tenantCondition(ctx, "recipient_id", args)
+	res, err := executor(ctx, d.db).ExecContext(ctx, sqlx.Rebind(sqlx.DOLLAR, q)+scope, args...)
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return repositories.ErrConcurrentModification
+	}
+	delivery.Version++
+	return nil
 }
 
-func (d *delivery) hydrateFromEntity(delivery *entities.Delivery) *deliveryModel {
-	var c, u string
+func (d *delivery) UpdateSLA(ctx context.Context, id uint, expected, status valueobjects.SLAStatus) (bool, error) {
+	scope, args := tenantCondition(ctx, "recipient_id", []interface{}{status, id, expected})
+	q := "UPDATE deliveries SET sla_status=$1 WHERE id=$2 AND sla_status=$3" + scope
+	res, err := executor(ctx, d.db).ExecContext(ctx, q, args...)
+	if err != nil {
+		return false, err
+	}
+	affected, err := res.RowsAffected()
+	return affected > 0, err
+}
+
+// filterCondition narrows a listing to the filter, its placeholders follow
+// the given arguments.
+func filterCondition(filter repositories.DeliveryFilter, args []interface{}) (string, []interface{}) {
+	if filter.SLA == "" {
+		return "", args
+	}
+	args = append(args, filter.SLA)
+	return fmt.Sprintf(" AND sla_status=$%d", len(args)), args
+}
 
-	if !delivery.CreatedAt.IsZero() {
-		c = delivery.CreatedAt.Format(DateFormat)
+func (d *delivery) hydrateFromEntity(delivery *entities.Delivery) *deliveryModel {
+	var courierId sql.NullInt64
+	if delivery.CourierId != nil {
+		courierId = sql.NullInt64{Int64: int64(*delivery.CourierId), Valid: true}
 	}
-	if !delivery.UpdatedAt.IsZero() {
-		u = delivery.UpdatedAt.Format(DateFormat)
+	var lat, lng sql.NullFloat64
+	if delivery.DestinationLocation != nil {
+		lat = sql.NullFloat64{Float64: delivery.DestinationLocation.Latitude, Valid: true}
+		lng = sql.NullFloat64{Float64: delivery.DestinationLocation.Longitude, Valid: true}
+	}
+	var windowStart, windowEnd sql.NullTime
+	if delivery.Window != nil {
+		windowStart = sql.NullTime{Time: delivery.Window.Start, Valid: true}
+		windowEnd = sql.NullTime{Time: delivery.Window.End, Valid: true}
 	}
 
-	return &deliveryModel{
+	model := &deliveryModel{
 		Id:          delivery.Id,
+		Type:        string(delivery.Type),
 		Status:      string(delivery.Status),
+		Origin:      sql.NullString{String: delivery.Origin, Valid: delivery.Origin != ""},
+		Zone:        sql.NullString{String: delivery.Zone, Valid: delivery.Zone != ""},
+		Level:       string(delivery.ServiceLevel),
+		SLA:         string(delivery.SLA),
 		Destination: delivery.Destination,
-		RecipientId: delivery.RecipientId,
-		CourierId:   delivery.CourierId,
-		CreatedAt:   c,
-		UpdatedAt:   u,
+		DestLat:     lat,
+		DestLng:     lng,
+		WindowStart: windowStart,
+		WindowEnd:   windowEnd,
+		RecipientId: int64(delivery.RecipientId),
+		CourierId:   courierId,
+		CreatedAt:   delivery.CreatedAt,
+		UpdatedAt:   delivery.UpdatedAt,
+		Version:     delivery.Version,
+		Attempts:    int(delivery.Attempts),
+	}
+	if delivery.OriginalId != nil {
+		model.OriginalId = sql.NullInt64{Int64: int64(*delivery.OriginalId), Valid: true}
+	}
+	if delivery.OrganizationId != nil {
+		model.OrgId = sql.NullInt64{Int64: int64(*delivery.OrganizationId), Valid: true}
+	}
+	if delivery.OriginLocation != nil {
+		model.OriginLat = sql.NullFloat64{Float64: delivery.OriginLocation.Latitude, Valid: true}
+		model.OriginLng = sql.NullFloat64{Float64: delivery.OriginLocation.Longitude, Valid: true}
+	}
+	if delivery.ScheduledFor != "" {
+		if day, err := time.Parse(dateLayout, delivery.ScheduledFor); err == nil {
+			model.Scheduled = sql.NullTime{Time: day, Valid: true}
+		}
+	}
+	if delivery.Slot != nil {
+		model.SlotStart = sql.NullTime{Time: delivery.Slot.Start, Valid: true}
+		model.SlotEnd = sql.NullTime{Time: delivery.Slot.End, Valid: true}
+	}
+	if delivery.Price != nil {
+		model.PriceAmount = sql.NullInt64{Int64: int64(delivery.Price.Amount), Valid: true}
+		model.Currency = sql.NullString{String: delivery.Price.Currency, Valid: true}
+	}
+	if delivery.COD != nil {
+		model.CodAmount = sql.NullInt64{Int64: int64(delivery.COD.Amount), Valid: true}
+		model.CodCurrency = sql.NullString{String: delivery.COD.Currency, Valid: true}
+	}
+	if delivery.Collection != nil {
+		model.Collected = sql.NullInt64{Int64: int64(delivery.Collection.Amount), Valid: true}
+		model.Method = sql.NullString{String: string(delivery.Collection.Method), Valid: true}
+	}
+	if delivery.DueAt != nil {
+		model.DueAt = sql.NullTime{Time: *delivery.DueAt, Valid: true}
+	}
+	if delivery.CompletedAt != nil {
+		model.CompletedAt = sql.NullTime{Time: *delivery.CompletedAt, Valid: true}
+	}
+	if delivery.Handover != nil {
+		model.HandoverNonce = sql.NullString{String: delivery.Handover.Nonce, Valid: true}
+		model.HandoverCodeHash = sql.NullString{String: delivery.Handover.CodeHash, Valid: true}
+		model.HandoverAttempts = int(delivery.Handover.Attempts)
+		model.HandoverLocked = delivery.Handover.Locked
 	}
+	return model
 }
 
 func (d *delivery) hydrateToEntity(model *deliveryModel) *entities.Delivery {
-	var c, u time.Time
-	if len(model.CreatedAt) > 0 {
-		c, _ = time.Parse(DateFormat, model.CreatedAt)
+	var courierId *uint
+	if model.CourierId.Valid {
+		id := uint(model.CourierId.Int64)
+		courierId = &id
 	}
-	if len(model.UpdatedAt) > 0 {
-		u, _ = time.Parse(DateFormat, model.UpdatedAt)
+	var location *valueobjects.Location
+	if model.DestLat.Valid && model.DestLng.Valid {
+		location = &valueobjects.Location{Latitude: model.DestLat.Float64, Longitude: model.DestLng.Float64}
+	}
+	var window *valueobjects.TimeWindow
+	if model.WindowStart.Valid && model.WindowEnd.Valid {
+		window = &valueobjects.TimeWindow{Start: model.WindowStart.Time, End: model.WindowEnd.Time}
+	}
+
+	var handover *valueobjects.Handover
+	if model.HandoverNonce.Valid && model.HandoverCodeHash.Valid {
+		handover = &valueobjects.Handover{
+			Nonce:    model.HandoverNonce.String,
+			CodeHash: model.HandoverCodeHash.String,
+			Attempts: uint(model.HandoverAttempts),
+			Locked:   model.HandoverLocked,
+		}
 	}
 
-	return &entities.Delivery{
-		Id:          model.Id,
-		Status:      valueobjects.Status(model.Status),
-		Destination: model.Destination,
-		RecipientId: model.RecipientId,
-		CourierId:   model.CourierId,
-		CreatedAt:   c,
-		UpdatedAt:   u,
+	delivery := &entities.Delivery{
+		Id:                  model.Id,
+		Type:                valueobjects.DeliveryType(model.Type),
+		Status:              valueobjects.Status(model.Status),
+		Origin:              model.Origin.String,
+		Zone:                model.Zone.String,
+		ServiceLevel:        valueobjects.ServiceLevel(model.Level),
+		SLA:                 valueobjects.SLAStatus(model.SLA),
+		Destination:         model.Destination,
+		DestinationLocation: location,
+		Window:              window,
+		RecipientId:         uint(model.RecipientId),
+		CourierId:           courierId,
+		CreatedAt:           model.CreatedAt,
+		UpdatedAt:           model.UpdatedAt,
+		Version:             model.Version,
+		Attempts:            uint(model.Attempts),
+		Handover:            handover,
+	}
+	if model.OriginalId.Valid {
+		id := uint(model.OriginalId.Int64)
+		delivery.OriginalId = &id
+	}
+	if model.OrgId.Valid {
+		id := uint(model.OrgId.Int64)
+		delivery.OrganizationId = &id
+	}
+	if model.OriginLat.Valid && model.OriginLng.Valid {
+		delivery.OriginLocation = &valueobjects.Location{Latitude: model.OriginLat.Float64, Longitude: model.OriginLng.Float64}
+	}
+	if model.Scheduled.Valid {
+		delivery.ScheduledFor = model.Scheduled.Time.Format(dateLayout)
+	}
+	if model.SlotStart.Valid && model.SlotEnd.Valid {
+		delivery.Slot = &valueobjects.TimeWindow{Start: model.SlotStart.Time, End: model.SlotEnd.Time}
+	}
+	if model.PriceAmount.Valid {
+		delivery.Price = &valueobjects.Price{Amount: valueobjects.Money(model.PriceAmount.Int64), Currency: model.Currency.String}
+	}
+	if model.CodAmount.Valid {
+		delivery.COD = &valueobjects.Price{Amount: valueobjects.Money(model.CodAmount.Int64), Currency: model.CodCurrency.String}
+	}
+	if model.Collected.Valid {
+		delivery.Collection = &valueobjects.Collection{
+			Amount: valueobjects.Money(model.Collected.Int64),
+			Method: valueobjects.PaymentMethod(model.Method.String),
+		}
+	}
+	if model.DueAt.Valid {
+		dueAt := model.DueAt.Time
+		delivery.DueAt = &dueAt
+	}
+	if model.CompletedAt.Valid {
+		completedAt := model.CompletedAt.Time
+		delivery.CompletedAt = &completedAt
 	}
+	return delivery
 }
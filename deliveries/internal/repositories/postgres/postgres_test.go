@@ -0,0 +1,146 @@
+package postgres_test
+
+import (
+	"context"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/require"
+	"github.com/zhanbolat18/parcel/deliveries/database/postgres/migrations"
+	"github.com/zhanbolat18/parcel/deliveries/internal/entities"
+	"github.com/zhanbolat18/parcel/deliveries/internal/repositories"
+	"github.com/zhanbolat18/parcel/deliveries/internal/repositories/postgres"
+	"github.com/zhanbolat18/parcel/deliveries/internal/repositories/repositorytest"
+	"github.com/zhanbolat18/parcel/libs/migrate"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+// TEST_PG_DSN points to a disposable database, its tables are truncated.
+const dsnEnv = "TEST_PG_DSN"
+
+func connect(t *testing.T) *sqlx.DB {
+	dsn := os.Getenv(dsnEnv)
+	if dsn == "" {
+		t.Skipf("%s is not set", dsnEnv)
+	}
+	db, err := sqlx.Connect("postgres", dsn)
+	require.Nil(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+	migrator, err := migrate.NewMigrator(db.DB, migrations.FS, ioutil.Discard)
+	require.Nil(t, err)
+	require.Nil(t, migrator.Up(context.Background()))
+	return db
+}
+
+func TestDeliveryRepository(t *testing.T) {
+	db := connect(t)
+	repositorytest.DeliveriesRepository(t, func(t *testing.T) repositories.DeliveriesRepository {
+		_, err := db.Exec("TRUNCATE deliveries RESTART IDENTITY CASCADE")
+		require.Nil(t, err)
+		return postgres.NewDeliveryRepository(db)
+	})
+}
+
+func TestAvailabilityRepository(t *testing.T) {
+	db := connect(t)
+	repositorytest.AvailabilityRepository(t, func(t *testing.T) repositories.AvailabilityRepository {
+		_, err := db.Exec("TRUNCATE courier_availability")
+		require.Nil(t, err)
+		return postgres.NewAvailabilityRepository(db)
+	})
+}
+
+func TestShiftsRepository(t *testing.T) {
+	db := connect(t)
+	repositorytest.ShiftsRepository(t, func(t *testing.T) repositories.ShiftsRepository {
+		_, err := db.Exec("TRUNCATE shifts RESTART IDENTITY")
+		require.Nil(t, err)
+		return postgres.NewShiftsRepository(db)
+	})
+}
+
+func TestLocationHistoryRepository(t *testing.T) {
+	db := connect(t)
+	repositorytest.LocationHistoryRepository(t, func(t *testing.T) repositories.LocationHistoryRepository {
+		_, err := db.Exec("TRUNCATE courier_locations")
+		require.Nil(t, err)
+		return postgres.NewLocationHistoryRepository(db)
+	})
+}
+
+func TestProofsRepository(t *testing.T) {
+	db := connect(t)
+	repositorytest.ProofsRepository(t, func(t *testing.T) repositories.ProofsRepository {
+		_, err := db.Exec("TRUNCATE deliveries RESTART IDENTITY CASCADE")
+		require.Nil(t, err)
+		d := entities.NewDelivery("Some Address 1, 14", nil, &entities.User{Id: 1})
+		require.Nil(t, postgres.NewDeliveryRepository(db).Store(context.Background(), d))
+		return postgres.NewProofsRepository(db)
+	})
+}
+
+func TestAuditRepository(t *testing.T) {
+	db := connect(t)
+	repositorytest.AuditRepository(t, func(t *testing.T) repositories.AuditRepository {
+		_, err := db.Exec("TRUNCATE deliveries RESTART IDENTITY CASCADE")
+		require.Nil(t, err)
+		deliveries := postgres.NewDeliveryRepository(db)
+		for i := 0; i < 2; i++ {
+			d := entities.NewDelivery("Some Address 1, 14", nil, &entities.User{Id: 1})
+			require.Nil(t, deliveries.Store(context.Background(), d))
+		}
+		return postgres.NewAuditRepository(db)
+	})
+}
+
+func TestAttemptsRepository(t *testing.T) {
+	db := connect(t)
+	repositorytest.AttemptsRepository(t, func(t *testing.T) repositories.AttemptsRepository {
+		_, err := db.Exec("TRUNCATE deliveries RESTART IDENTITY CASCADE")
+		require.Nil(t, err)
+		d := entities.NewDelivery("Some Address 1, 14", nil, &entities.User{Id: 1})
+		require.Nil(t, postgres.NewDeliveryRepository(db).Store(context.Background(), d))
+		return postgres.NewAttemptsRepository(db)
+	})
+}
+
+func TestSlotBookingsRepository(t *testing.T) {
+	db := connect(t)
+	repositorytest.SlotBookingsRepository(t, func(t *testing.T) repositories.SlotBookingsRepository {
+		_, err := db.Exec("TRUNCATE slot_bookings")
+		require.Nil(t, err)
+		return postgres.NewSlotBookingsRepository(db)
+	})
+}
+
+func TestCashLedgerRepository(t *testing.T) {
+	db := connect(t)
+	repositorytest.CashLedgerRepository(t, func(t *testing.T) repositories.CashLedgerRepository {
+		_, err := db.Exec("TRUNCATE cash_ledger")
+		require.Nil(t, err)
+		return postgres.NewCashLedgerRepository(db)
+	})
+}
+
+func TestInvoicesRepository(t *testing.T) {
+	db := connect(t)
+	repositorytest.InvoicesRepository(t, func(t *testing.T) repositories.InvoicesRepository {
+		_, err := db.Exec("TRUNCATE deliveries, invoices RESTART IDENTITY CASCADE")
+		require.Nil(t, err)
+		deliveries := postgres.NewDeliveryRepository(db)
+		for i := 0; i < 3; i++ {
+			d := entities.NewDelivery("Some Address 1, 14", nil, &entities.User{Id: 1})
+			require.Nil(t, deliveries.Store(context.Background(), d))
+		}
+		return postgres.NewInvoicesRepository(db)
+	})
+}
+
+func TestTxManager(t *testing.T) {
+	db := connect(t)
+	repositorytest.TxManager(t, func(t *testing.T) (repositories.TxManager, repositories.DeliveriesRepository, repositories.SlotBookingsRepository) {
+		_, err := db.Exec("TRUNCATE deliveries, slot_bookings RESTART IDENTITY CASCADE")
+		require.Nil(t, err)
+		return postgres.NewTxManager(db), postgres.NewDeliveryRepository(db), postgres.NewSlotBookingsRepository(db)
+	})
+}
@@ -0,0 +1,105 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"github.com/jmoiron/sqlx"
+	"github.com/zhanbolat18/parcel/deliveries/internal/entities"
+	"github.com/zhanbolat18/parcel/deliveries/internal/repositories"
+	"github.com/zhanbolat18/parcel/deliveries/internal/valueobjects"
+	"time"
+)
+
+type proof struct {
+	db *sqlx.DB
+}
+
+func NewProofsRepository(db *sqlx.DB) repositories.ProofsRepository {
+	return &proof{db: db}
+}
+
+type proofModel struct {
+	DeliveryId int64           `db:"delivery_id"`
+	ReceivedBy string          `db:"received_by"`
+	Latitude   sql.NullFloat64 `db:"latitude"`
+	Longitude  sql.NullFloat64 `db:"longitude"`
+	CreatedAt  time.Time       `db:"created_at"`
+}
+
+type proofFileModel struct {
+	Id          int64  `db:"id"`
+	DeliveryId  int64  `db:"delivery_id"`
+	Kind        string `db:"kind"`
+	ContentType string `db:"content_type"`
+	Size        int64  `db:"size"`
+	BlobKey     string `db:"blob_key"`
+}
+
+// Store expects to run within a transaction, otherwise a failure may leave
+// the proof without some of its files.
+func (p *proof) Store(ctx context.Context, proof *entities.Proof) error {
+	pm := proofModel{
+		DeliveryId: int64(proof.DeliveryId),
+		ReceivedBy: proof.ReceivedBy,
+		CreatedAt:  proof.CreatedAt,
+	}
+	if proof.Location != nil {
+		pm.Latitude = sql.NullFloat64{Float64: proof.Location.Latitude, Valid: true}
+		pm.Longitude = sql.NullFloat64{Float64: proof.Location.Longitude, Valid: true}
+	}
+	q := "INSERT INTO delivery_proofs(delivery_id, received_by, latitude, longitude, created_at) " +
+		"VALUES(:delivery_id, :received_by, :latitude, :longitude, :created_at)"
+	if _, err := sqlx.NamedExecContext(ctx, executor(ctx, p.db), q, pm); err != nil {
+		return err
+	}
+	q = "INSERT INTO delivery_proof_files(delivery_id, kind, content_type, size, blob_key) VALUES($1, $2, $3, $4, $5) RETURNING id"
+	for i := range proof.Files {
+		f := &proof.Files[i]
+		var id int64
+		err := executor(ctx, p.db).QueryRowxContext(ctx, q, proof.DeliveryId, f.Kind, f.ContentType, f.Size, f.Key).Scan(&id)
+		if err != nil {
+			return err
+		}
+		f.Id = uint(id)
+	}
+	return nil
+}
+
+func (p *proof) GetByDelivery(ctx context.Context, deliveryId uint) (*entities.Proof, error) {
+	pm := &proofModel{}
+	err := sqlx.GetContext(ctx, executor(ctx, p.db), pm, "SELECT * FROM delivery_proofs WHERE delivery_id=$1", deliveryId)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, repositories.ErrProofNotFound
+		}
+		return nil, err
+	}
+	fms := make([]proofFileModel, 0)
+	q := "SELECT * FROM delivery_proof_files WHERE delivery_id=$1 ORDER BY id"
+	if err = sqlx.SelectContext(ctx, executor(ctx, p.db), &fms, q, deliveryId); err != nil {
+		return nil, err
+	}
+	return p.hydrateToEntity(pm, fms), nil
+}
+
+func (p *proof) hydrateToEntity(pm *proofModel, fms []proofFileModel) *entities.Proof {
+	proof := &entities.Proof{
+		DeliveryId: uint(pm.DeliveryId),
+		ReceivedBy: pm.ReceivedBy,
+		Files:      make([]entities.ProofFile, 0, len(fms)),
+		CreatedAt:  pm.CreatedAt,
+	}
+	if pm.Latitude.Valid && pm.Longitude.Valid {
+		proof.Location = &valueobjects.Location{Latitude: pm.Latitude.Float64, Longitude: pm.Longitude.Float64}
+	}
+	for _, fm := range fms {
+		proof.Files = append(proof.Files, entities.ProofFile{
+			Id:          uint(fm.Id),
+			Kind:        fm.Kind,
+			ContentType: fm.ContentType,
+			Size:        fm.Size,
+			Key:         fm.BlobKey,
+		})
+	}
+	return proof
+}
@@ -0,0 +1,129 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/jmoiron/sqlx"
+	"github.com/zhanbolat18/parcel/deliveries/internal/entities"
+	"github.com/zhanbolat18/parcel/deliveries/internal/repositories"
+	"strings"
+	"time"
+)
+
+// shiftsLockSpace keeps courier advisory locks apart from other ones.
+const shiftsLockSpace = 1
+
+type shift struct {
+	db *sqlx.DB
+}
+
+func NewShiftsRepository(db *sqlx.DB) repositories.ShiftsRepository {
+	return &shift{db: db}
+}
+
+type shiftModel struct {
+	Id        uint      `db:"id"`
+	CourierId int64     `db:"courier_id"`
+	StartsAt  time.Time `db:"starts_at"`
+	EndsAt    time.Time `db:"ends_at"`
+	Zone      string    `db:"zone"`
+}
+
+func (s *shift) GetById(ctx context.Context, id uint) (*entities.Shift, error) {
+	sm := &shiftModel{}
+	err := sqlx.GetContext(ctx, executor(ctx, s.db), sm, "SELECT * FROM shifts WHERE id=$1", id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, repositories.ErrShiftNotFound
+		}
+		return nil, err
+	}
+	return s.hydrateToEntity(sm), nil
+}
+
+func (s *shift) GetAll(ctx context.Context, filter repositories.ShiftFilter) ([]*entities.Shift, error) {
+	conditions := []string{"TRUE"}
+	args := make([]interface{}, 0, 3)
+	if filter.CourierId != 0 {
+		args = append(args, filter.CourierId)
+		conditions = append(conditions, fmt.Sprintf("courier_id=$%d", len(args)))
+	}
+	if !filter.From.IsZero() {
+		args = append(args, filter.From)
+		conditions = append(conditions, fmt.Sprintf("ends_at > $%d", len(args)))
+	}
+	if !filter.To.IsZero() {
+		args = append(args, filter.To)
+		conditions = append(conditions, fmt.Sprintf("starts_at < $%d", len(args)))
+	}
+	sms := make([]shiftModel, 0)
+	q := fmt.Sprintf("SELECT * FROM shifts WHERE %s ORDER BY starts_at, id", strings.Join(conditions, " AND "))
+	if err := sqlx.SelectContext(ctx, executor(ctx, s.db), &sms, q, args...); err != nil {
+		return nil, err
+	}
+	shifts := make([]*entities.Shift, 0, len(sms))
+	for _, sm := range sms {
+		sm := sm
+		shifts = append(shifts, s.hydrateToEntity(&sm))
+	}
+	return shifts, nil
+}
+
+func (s *shift) LockCourier(ctx context.Context, courierId uint) error {
+	tx, ok := txFromContext(ctx)
+	if !ok {
+		return nil
+	}
+	_, err := tx.ExecContext(ctx, "SELECT pg_advisory_xact_lock($1, $2)", shiftsLockSpace, int32(courierId))
+	return err
+}
+
+func (s *shift) Store(ctx context.Context, shift *entities.Shift) error {
+	q := "INSERT INTO shifts(courier_id, starts_at, ends_at, zone) VALUES($1, $2, $3, $4) RETURNING id"
+	var id int
+	err := executor(ctx, s.db).QueryRowxContext(ctx, q, shift.CourierId, shift.StartsAt, shift.EndsAt, shift.Zone).Scan(&id)
+	if err != nil {
+		return err
+	}
+	shift.Id = uint(id)
+	return nil
+}
+
+func (s *shift) Update(ctx context.Context, shift *entities.Shift) error {
+	q := "UPDATE shifts SET courier_id=$2, starts_at=$3, ends_at=$4, zone=$5 WHERE id=$1"
+	res, err := executor(ctx, s.db).ExecContext(ctx, q, shift.Id, shift.CourierId, shift.StartsAt, shift.EndsAt, shift.Zone)
+	if err != nil {
+		return err
+	}
+	return s.ensureAffected(res)
+}
+
+func (s *shift) Delete(ctx context.Context, id uint) error {
+	res, err := executor(ctx, s.db).ExecContext(ctx, "DELETE FROM shifts WHERE id=$1", id)
+	if err != nil {
+		return err
+	}
+	return s.ensureAffected(res)
+}
+
+func (s *shift) ensureAffected(res sql.Result) error {
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return repositories.ErrShiftNotFound
+	}
+	return nil
+}
+
+func (s *shift) hydrateToEntity(model *shiftModel) *entities.Shift {
+	return &entities.Shift{
+		Id:        model.Id,
+		CourierId: uint(model.CourierId),
+		StartsAt:  model.StartsAt,
+		EndsAt:    model.EndsAt,
+		Zone:      model.Zone,
+	}
+}
@@ -0,0 +1,57 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"github.com/jmoiron/sqlx"
+	"github.com/zhanbolat18/parcel/deliveries/internal/repositories"
+	"time"
+)
+
+type slotBookings struct {
+	db *sqlx.DB
+}
+
+func NewSlotBookingsRepository(db *sqlx.DB) repositories.SlotBookingsRepository {
+	return &slotBookings{db: db}
+}
+
+type slotBookingModel struct {
+	Zone      string    `db:"zone"`
+	SlotStart time.Time `db:"slot_start"`
+	Booked    uint      `db:"booked"`
+}
+
+// Book relies on the conditional upsert being atomic, concurrent bookings of
+// the last place wait for each other on the row and only one succeeds.
+func (s *slotBookings) Book(ctx context.Context, zone string, slotStart time.Time, capacity uint) error {
+	q := `INSERT INTO slot_bookings(zone, slot_start, booked) VALUES($1, $2, 1)
+			ON CONFLICT (zone, slot_start) DO UPDATE SET booked = slot_bookings.booked + 1
+			WHERE slot_bookings.booked < $3
+			RETURNING booked`
+	var booked uint
+	err := executor(ctx, s.db).QueryRowxContext(ctx, q, zone, slotStart, capacity).Scan(&booked)
+	if err == sql.ErrNoRows {
+		return repositories.ErrSlotFull
+	}
+	return err
+}
+
+func (s *slotBookings) Release(ctx context.Context, zone string, slotStart time.Time) error {
+	q := "UPDATE slot_bookings SET booked = booked - 1 WHERE zone=$1 AND slot_start=$2 AND booked > 0"
+	_, err := executor(ctx, s.db).ExecContext(ctx, q, zone, slotStart)
+	return err
+}
+
+func (s *slotBookings) Booked(ctx context.Context, zone string, from, to time.Time) (map[int64]uint, error) {
+	sbms := make([]slotBookingModel, 0)
+	q := "SELECT * FROM slot_bookings WHERE zone=$1 AND slot_start >= $2 AND slot_start < $3 AND booked > 0"
+	if err := sqlx.SelectContext(ctx, executor(ctx, s.db), &sbms, q, zone, from, to); err != nil {
+		return nil, err
+	}
+	booked := make(map[int64]uint, len(sbms))
+	for _, sbm := range sbms {
+		booked[sbm.SlotStart.Unix()] = sbm.Booked
+	}
+	return booked, nil
+}
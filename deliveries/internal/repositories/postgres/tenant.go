@@ -0,0 +1,23 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"github.com/zhanbolat18/parcel/deliveries/internal/repositories"
+)
+
+// tenantCondition limits a query to the rows of the tenant the context is
+// scoped to, userColumn holds the user owning rows outside of organizations.
+// The placeholder of the condition follows the given arguments.
+func tenantCondition(ctx context.Context, userColumn string, args []interface{}) (string, []interface{}) {
+	tenant, ok := repositories.TenantFromContext(ctx)
+	if !ok {
+		return "", args
+	}
+	if tenant.OrganizationId != 0 {
+		args = append(args, tenant.OrganizationId)
+		return fmt.Sprintf(" AND organization_id=$%d", len(args)), args
+	}
+	args = append(args, tenant.UserId)
+	return fmt.Sprintf(" AND organization_id IS NULL AND %s=$%d", userColumn, len(args)), args
+}
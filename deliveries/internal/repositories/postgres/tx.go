@@ -0,0 +1,55 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"github.com/jmoiron/sqlx"
+	"github.com/zhanbolat18/parcel/deliveries/internal/repositories"
+)
+
+type txKey struct{}
+
+type txManager struct {
+	db *sqlx.DB
+}
+
+func NewTxManager(db *sqlx.DB) repositories.TxManager {
+	return &txManager{db: db}
+}
+
+func (t *txManager) WithinTx(ctx context.Context, fn func(ctx context.Context) error) (err error) {
+	if _, ok := txFromContext(ctx); ok {
+		return fn(ctx)
+	}
+	tx, err := t.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			_ = tx.Rollback()
+			panic(p)
+		}
+		if err != nil {
+			_ = tx.Rollback()
+			return
+		}
+		if err = tx.Commit(); err != nil {
+			err = fmt.Errorf("commit transaction: %w", err)
+		}
+	}()
+	return fn(context.WithValue(ctx, txKey{}, tx))
+}
+
+func txFromContext(ctx context.Context) (*sqlx.Tx, bool) {
+	tx, ok := ctx.Value(txKey{}).(*sqlx.Tx)
+	return tx, ok
+}
+
+// executor returns the transaction started by WithinTx or the db itself.
+func executor(ctx context.Context, db *sqlx.DB) sqlx.ExtContext {
+	if tx, ok := txFromContext(ctx); ok {
+		return tx
+	}
+	return db
+}
@@ -0,0 +1,15 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"github.com/zhanbolat18/parcel/deliveries/internal/entities"
+)
+
+var ErrProofNotFound = errors.New("proof of delivery not found")
+
+type ProofsRepository interface {
+	// Store saves the proof with its files, assigning the file ids.
+	Store(ctx context.Context, proof *entities.Proof) error
+	GetByDelivery(ctx context.Context, deliveryId uint) (*entities.Proof, error)
+}
@@ -0,0 +1,53 @@
+package repositorytest
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zhanbolat18/parcel/deliveries/internal/entities"
+	"github.com/zhanbolat18/parcel/deliveries/internal/repositories"
+	"github.com/zhanbolat18/parcel/deliveries/internal/valueobjects"
+	"testing"
+	"time"
+)
+
+// AttemptsRepository expects a delivery with id 1 to exist.
+func AttemptsRepository(t *testing.T, newRepo func(t *testing.T) repositories.AttemptsRepository) {
+	ctx := context.Background()
+
+	t.Run("store and get", func(t *testing.T) {
+		repo := newRepo(t)
+		attempts, err := repo.GetByDelivery(ctx, 1)
+		require.Nil(t, err)
+		assert.Empty(t, attempts)
+
+		now := time.Now().Truncate(time.Millisecond)
+		first := &entities.Attempt{DeliveryId: 1, CourierId: 5, Reason: valueobjects.RecipientAbsent, CreatedAt: now}
+		second := &entities.Attempt{
+			DeliveryId: 1,
+			CourierId:  5,
+			Reason:     valueobjects.WrongAddress,
+			Note:       "no such house",
+			Photo:      &entities.ProofFile{Kind: entities.ProofFilePhoto, ContentType: "image/png", Size: 10, Key: "deliveries/1/a"},
+			CreatedAt:  now.Add(time.Hour),
+		}
+		require.Nil(t, repo.Store(ctx, first))
+		require.Nil(t, repo.Store(ctx, second))
+		assert.NotZero(t, first.Id)
+		assert.Equal(t, second.Id, second.Photo.Id)
+
+		attempts, err = repo.GetByDelivery(ctx, 1)
+		require.Nil(t, err)
+		require.Len(t, attempts, 2)
+		assert.Equal(t, first.Id, attempts[0].Id)
+		assert.Equal(t, valueobjects.RecipientAbsent, attempts[0].Reason)
+		assert.Nil(t, attempts[0].Photo)
+		assert.WithinDuration(t, now, attempts[0].CreatedAt, time.Millisecond)
+		assert.Equal(t, "no such house", attempts[1].Note)
+		assert.Equal(t, second.Photo, attempts[1].Photo)
+
+		attempts, err = repo.GetByDelivery(ctx, 2)
+		require.Nil(t, err)
+		assert.Empty(t, attempts)
+	})
+}
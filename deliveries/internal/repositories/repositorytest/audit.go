@@ -0,0 +1,48 @@
+package repositorytest
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zhanbolat18/parcel/deliveries/internal/entities"
+	"github.com/zhanbolat18/parcel/deliveries/internal/repositories"
+	"testing"
+	"time"
+)
+
+// AuditRepository expects deliveries with ids 1 and 2 to exist.
+func AuditRepository(t *testing.T, newRepo func(t *testing.T) repositories.AuditRepository) {
+	ctx := context.Background()
+
+	t.Run("record and get", func(t *testing.T) {
+		repo := newRepo(t)
+		entries, err := repo.GetByDelivery(ctx, 1)
+		require.Nil(t, err)
+		assert.Empty(t, entries)
+
+		actor := uint(5)
+		now := time.Now().Truncate(time.Millisecond)
+		for _, entry := range []*entities.AuditEntry{
+			{DeliveryId: 1, ActorId: &actor, Action: entities.AuditHandoverFailed, Details: "attempt 1", CreatedAt: now},
+			{DeliveryId: 2, Action: entities.AuditHandoverFailed, CreatedAt: now},
+			{DeliveryId: 1, ActorId: &actor, Action: entities.AuditHandoverOverride, Details: "lost phone", CreatedAt: now.Add(time.Second)},
+		} {
+			require.Nil(t, repo.Record(ctx, entry))
+			assert.NotZero(t, entry.Id)
+		}
+
+		entries, err = repo.GetByDelivery(ctx, 1)
+		require.Nil(t, err)
+		require.Len(t, entries, 2)
+		assert.Equal(t, entities.AuditHandoverFailed, entries[0].Action)
+		assert.Equal(t, "attempt 1", entries[0].Details)
+		assert.Equal(t, actor, *entries[0].ActorId)
+		assert.WithinDuration(t, now, entries[0].CreatedAt, time.Millisecond)
+		assert.Equal(t, entities.AuditHandoverOverride, entries[1].Action)
+
+		entries, err = repo.GetByDelivery(ctx, 2)
+		require.Nil(t, err)
+		require.Len(t, entries, 1)
+		assert.Nil(t, entries[0].ActorId)
+	})
+}
@@ -0,0 +1,50 @@
+package repositorytest
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zhanbolat18/parcel/deliveries/internal/entities"
+	"github.com/zhanbolat18/parcel/deliveries/internal/repositories"
+	"github.com/zhanbolat18/parcel/deliveries/internal/valueobjects"
+	"testing"
+	"time"
+)
+
+func AvailabilityRepository(t *testing.T, newRepo func(t *testing.T) repositories.AvailabilityRepository) {
+	ctx := context.Background()
+
+	t.Run("save and get", func(t *testing.T) {
+		repo := newRepo(t)
+		_, err := repo.GetByCourier(ctx, 1)
+		assert.True(t, errors.Is(err, repositories.ErrAvailabilityNotFound))
+
+		a := &entities.CourierAvailability{CourierId: 1, Status: valueobjects.Online, UpdatedAt: time.Now()}
+		require.Nil(t, repo.Save(ctx, a))
+		got, err := repo.GetByCourier(ctx, 1)
+		require.Nil(t, err)
+		assert.Equal(t, valueobjects.Online, got.Status)
+		assert.Zero(t, got.MaxActiveDeliveries)
+
+		a.Status = valueobjects.OnBreak
+		a.MaxActiveDeliveries = 3
+		require.Nil(t, repo.Save(ctx, a))
+		got, err = repo.GetByCourier(ctx, 1)
+		require.Nil(t, err)
+		assert.Equal(t, valueobjects.OnBreak, got.Status)
+		assert.Equal(t, uint(3), got.MaxActiveDeliveries)
+	})
+
+	t.Run("get by couriers", func(t *testing.T) {
+		repo := newRepo(t)
+		for _, id := range []uint{1, 2} {
+			a := &entities.CourierAvailability{CourierId: id, Status: valueobjects.Online, UpdatedAt: time.Now()}
+			require.Nil(t, repo.Save(ctx, a))
+		}
+		found, err := repo.GetByCouriers(ctx, 2, 3)
+		require.Nil(t, err)
+		require.Len(t, found, 1)
+		assert.Equal(t, uint(2), found[2].CourierId)
+	})
+}
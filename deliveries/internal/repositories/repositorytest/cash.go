@@ -0,0 +1,97 @@
+package repositorytest
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zhanbolat18/parcel/deliveries/internal/entities"
+	"github.com/zhanbolat18/parcel/deliveries/internal/repositories"
+	"github.com/zhanbolat18/parcel/deliveries/internal/valueobjects"
+	"sync"
+	"testing"
+	"time"
+)
+
+func CashLedgerRepository(t *testing.T, newRepo func(t *testing.T) repositories.CashLedgerRepository) {
+	ctx := context.Background()
+	entry := func(courierId uint, kind string, amount valueobjects.Money) *entities.CashEntry {
+		return &entities.CashEntry{
+			CourierId: courierId,
+			Kind:      kind,
+			Amount:    amount,
+			Currency:  "KZT",
+			ActorId:   1,
+			CreatedAt: time.Now().UTC().Truncate(time.Second),
+		}
+	}
+
+	t.Run("append and get", func(t *testing.T) {
+		repo := newRepo(t)
+		collection := entry(2, entities.CashCollection, 125000)
+		require.Nil(t, repo.Append(ctx, collection))
+		assert.NotZero(t, collection.Id)
+		assert.Equal(t, uint(1), collection.Seq)
+		assert.Equal(t, valueobjects.Money(125000), collection.Balance)
+
+		require.Nil(t, repo.Append(ctx, entry(3, entities.CashCollection, 50000)))
+		handover := entry(2, entities.CashHandover, -100000)
+		handover.Note = "evening handover"
+		require.Nil(t, repo.Append(ctx, handover))
+		assert.Equal(t, uint(2), handover.Seq)
+		assert.Equal(t, valueobjects.Money(25000), handover.Balance)
+		reconciliation := entry(2, entities.CashReconciliation, -5000)
+		require.Nil(t, repo.Append(ctx, reconciliation))
+
+		entries, err := repo.GetByCourier(ctx, 2)
+		require.Nil(t, err)
+		require.Len(t, entries, 3)
+		assert.Equal(t, handover.Note, entries[1].Note)
+		assert.Equal(t, valueobjects.Money(20000), entries[2].Balance)
+		for i, e := range entries {
+			assert.Equal(t, uint(i+1), e.Seq)
+			assert.Equal(t, uint(2), e.CourierId)
+			assert.True(t, collection.CreatedAt.Equal(e.CreatedAt))
+		}
+
+		require.Nil(t, repo.Append(ctx, entry(2, entities.CashReconciliation, 0)))
+		other := entry(3, entities.CashReconciliation, 1000)
+		require.Nil(t, repo.Append(ctx, other))
+		discrepancies, err := repo.GetDiscrepancies(ctx, 2)
+		require.Nil(t, err)
+		require.Len(t, discrepancies, 1)
+		assert.Equal(t, reconciliation.Id, discrepancies[0].Id)
+		assert.Equal(t, valueobjects.Money(-5000), discrepancies[0].Amount)
+		discrepancies, err = repo.GetDiscrepancies(ctx, 0)
+		require.Nil(t, err)
+		require.Len(t, discrepancies, 2)
+		assert.Equal(t, other.Id, discrepancies[1].Id)
+	})
+
+	t.Run("concurrent appends", func(t *testing.T) {
+		repo := newRepo(t)
+		var wg sync.WaitGroup
+		var mu sync.Mutex
+		appended := 0
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				err := repo.Append(ctx, entry(2, entities.CashCollection, 1000))
+				if err == nil {
+					mu.Lock()
+					appended++
+					mu.Unlock()
+					return
+				}
+				assert.True(t, errors.Is(err, repositories.ErrConcurrentModification))
+			}()
+		}
+		wg.Wait()
+
+		entries, err := repo.GetByCourier(ctx, 2)
+		require.Nil(t, err)
+		require.Len(t, entries, appended)
+		assert.Equal(t, valueobjects.Money(appended*1000), entries[len(entries)-1].Balance, "no append is lost")
+	})
+}
@@ -0,0 +1,455 @@
+// Package repositorytest holds conformance tests every repository
+// implementation has to pass.
+package repositorytest
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zhanbolat18/parcel/deliveries/internal/entities"
+	"github.com/zhanbolat18/parcel/deliveries/internal/repositories"
+	"github.com/zhanbolat18/parcel/deliveries/internal/valueobjects"
+	"sync"
+	"testing"
+	"time"
+)
+
+// DeliveriesRepository runs the suite, newRepo must return an empty
+// repository on every call.
+func DeliveriesRepository(t *testing.T, newRepo func(t *testing.T) repositories.DeliveriesRepository) {
+	ctx := context.Background()
+	recipient := &entities.User{Id: 10, Role: "user"}
+
+	t.Run("store and get", func(t *testing.T) {
+		repo := newRepo(t)
+		d := entities.NewDelivery("Some Address 1, 14", nil, recipient)
+		require.Nil(t, repo.Store(ctx, d))
+		assert.NotZero(t, d.Id)
+		assert.Equal(t, uint(1), d.Version)
+
+		got, err := repo.GetById(ctx, d.Id)
+		require.Nil(t, err)
+		assert.Equal(t, d.Id, got.Id)
+		assert.Equal(t, valueobjects.Created, got.Status)
+		assert.Equal(t, d.Destination, got.Destination)
+		assert.Equal(t, recipient.Id, got.RecipientId)
+		assert.Nil(t, got.CourierId)
+		assert.WithinDuration(t, d.CreatedAt, got.CreatedAt, time.Millisecond)
+		assert.Equal(t, uint(1), got.Version)
+	})
+
+	t.Run("store with location and window", func(t *testing.T) {
+		repo := newRepo(t)
+		location := &valueobjects.Location{Latitude: 43.238949, Longitude: 76.889709}
+		d := entities.NewDelivery("Some Address 1, 14", location, recipient)
+		start := time.Now().Add(time.Hour).Truncate(time.Second)
+		d.Window = &valueobjects.TimeWindow{Start: start, End: start.Add(2 * time.Hour)}
+		require.Nil(t, repo.Store(ctx, d))
+
+		got, err := repo.GetById(ctx, d.Id)
+		require.Nil(t, err)
+		assert.Equal(t, location, got.DestinationLocation)
+		require.NotNil(t, got.Window)
+		assert.True(t, d.Window.Start.Equal(got.Window.Start))
+		assert.True(t, d.Window.End.Equal(got.Window.End))
+	})
+
+	t.Run("store return", func(t *testing.T) {
+		repo := newRepo(t)
+		original := entities.NewDelivery("Some Address 1, 14", nil, recipient)
+		original.Origin = "Shop 5"
+		original.OriginLocation = &valueobjects.Location{Latitude: 43.2, Longitude: 76.9}
+		completedAt := time.Now().Truncate(time.Second)
+		original.CompletedAt = &completedAt
+		require.Nil(t, repo.Store(ctx, original))
+		_, err := repo.GetReturnOf(ctx, original.Id)
+		assert.True(t, errors.Is(err, repositories.ErrDeliveryNotFound))
+
+		ret := entities.NewDelivery("Shop 5", nil, recipient)
+		ret.Type = valueobjects.Return
+		ret.OriginalId = &original.Id
+		require.Nil(t, repo.Store(ctx, ret))
+
+		got, err := repo.GetById(ctx, original.Id)
+		require.Nil(t, err)
+		assert.Equal(t, valueobjects.Forward, got.Type)
+		assert.Nil(t, got.OriginalId)
+		assert.Equal(t, "Shop 5", got.Origin)
+		assert.Equal(t, original.OriginLocation, got.OriginLocation)
+		require.NotNil(t, got.CompletedAt)
+		assert.True(t, completedAt.Equal(*got.CompletedAt))
+
+		got, err = repo.GetReturnOf(ctx, original.Id)
+		require.Nil(t, err)
+		assert.Equal(t, ret.Id, got.Id)
+		assert.Equal(t, valueobjects.Return, got.Type)
+		assert.Equal(t, original.Id, *got.OriginalId)
+	})
+
+	t.Run("store with price", func(t *testing.T) {
+		repo := newRepo(t)
+		d := entities.NewDelivery("Some Address 1, 14", nil, recipient)
+		d.Price = &valueobjects.Price{Amount: 290050, Currency: "KZT"}
+		require.Nil(t, repo.Store(ctx, d))
+
+		got, err := repo.GetById(ctx, d.Id)
+		require.Nil(t, err)
+		assert.Equal(t, d.Price, got.Price)
+	})
+
+	t.Run("cash on delivery", func(t *testing.T) {
+		repo := newRepo(t)
+		d := entities.NewDelivery("Some Address 1, 14", nil, recipient)
+		d.COD = &valueobjects.Price{Amount: 125000, Currency: "KZT"}
+		require.Nil(t, repo.Store(ctx, d))
+		got, err := repo.GetById(ctx, d.Id)
+		require.Nil(t, err)
+		assert.Equal(t, d.COD, got.COD)
+		assert.Nil(t, got.Collection)
+
+		got.Collection = &valueobjects.Collection{Amount: 125000, Method: valueobjects.Cash}
+		require.Nil(t, repo.Update(ctx, got))
+		got, err = repo.GetById(ctx, d.Id)
+		require.Nil(t, err)
+		assert.Equal(t, &valueobjects.Collection{Amount: 125000, Method: valueobjects.Cash}, got.Collection)
+	})
+
+	t.Run("completed between", func(t *testing.T) {
+		repo := newRepo(t)
+		from := time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC)
+		to := from.AddDate(0, 1, 0)
+		completions := []time.Time{to.Add(-time.Second), from.Add(-time.Second), from, to}
+		dls := make([]*entities.Delivery, 0, len(completions))
+		for _, completedAt := range completions {
+			completedAt := completedAt
+			d := entities.NewDelivery("Some Address 1, 14", nil, recipient)
+			require.Nil(t, repo.Store(ctx, d))
+			d.Status, d.CompletedAt = valueobjects.Completed, &completedAt
+			require.Nil(t, repo.Update(ctx, d))
+			dls = append(dls, d)
+		}
+		open := entities.NewDelivery("Some Address 1, 14", nil, recipient)
+		require.Nil(t, repo.Store(ctx, open))
+
+		completed, err := repo.GetAllCompletedBetween(ctx, from, to)
+		require.Nil(t, err)
+		require.Len(t, completed, 2)
+		assert.Equal(t, dls[2].Id, completed[0].Id)
+		assert.Equal(t, dls[0].Id, completed[1].Id)
+		assert.True(t, from.Equal(*completed[0].CompletedAt))
+	})
+
+	t.Run("due before and update sla", func(t *testing.T) {
+		repo := newRepo(t)
+		now := time.Now().Truncate(time.Second)
+		dues := []time.Duration{2 * time.Hour, -time.Hour, time.Hour, 30 * time.Minute, -2 * time.Hour}
+		dls := make([]*entities.Delivery, 0, len(dues))
+		for _, due := range dues {
+			d := entities.NewDelivery("Some Address 1, 14", nil, recipient)
+			d.ServiceLevel = valueobjects.Express
+			dueAt := now.Add(due)
+			d.DueAt = &dueAt
+			require.Nil(t, repo.Store(ctx, d))
+			dls = append(dls, d)
+		}
+		dls[3].Status = valueobjects.Completed
+		require.Nil(t, repo.Update(ctx, dls[3]))
+		ok, err := repo.UpdateSLA(ctx, dls[4].Id, valueobjects.OnTrack, valueobjects.Breached)
+		require.Nil(t, err)
+		assert.True(t, ok)
+
+		due, err := repo.GetAllDueBefore(ctx, now.Add(90*time.Minute))
+		require.Nil(t, err)
+		require.Len(t, due, 2, "completed, breached and later deliveries are left out")
+		assert.Equal(t, dls[1].Id, due[0].Id)
+		assert.Equal(t, dls[2].Id, due[1].Id)
+		assert.Equal(t, valueobjects.Express, due[0].ServiceLevel)
+		assert.True(t, dls[1].DueAt.Equal(*due[0].DueAt))
+
+		ok, err = repo.UpdateSLA(ctx, dls[2].Id, valueobjects.AtRisk, valueobjects.Breached)
+		require.Nil(t, err)
+		assert.False(t, ok, "the sla status is not the expected one")
+		ok, err = repo.UpdateSLA(ctx, dls[2].Id, valueobjects.OnTrack, valueobjects.AtRisk)
+		require.Nil(t, err)
+		assert.True(t, ok)
+		got, err := repo.GetById(ctx, dls[2].Id)
+		require.Nil(t, err)
+		assert.Equal(t, valueobjects.AtRisk, got.SLA)
+		assert.Equal(t, dls[2].Version, got.Version, "the version stays")
+	})
+
+	t.Run("get unknown", func(t *testing.T) {
+		repo := newRepo(t)
+		d, err := repo.GetById(ctx, 404)
+		assert.Nil(t, d)
+		assert.True(t, errors.Is(err, repositories.ErrDeliveryNotFound))
+	})
+
+	t.Run("filter by sla", func(t *testing.T) {
+		repo := newRepo(t)
+		courier := uint(20)
+		onTrack := entities.NewDelivery("on track", nil, recipient)
+		breached := entities.NewDelivery("breached", nil, recipient)
+		onTrack.CourierId, breached.CourierId = &courier, &courier
+		require.Nil(t, repo.Store(ctx, onTrack))
+		require.Nil(t, repo.Store(ctx, breached))
+		ok, err := repo.UpdateSLA(ctx, breached.Id, valueobjects.OnTrack, valueobjects.Breached)
+		require.Nil(t, err)
+		require.True(t, ok)
+
+		all, err := repo.GetAll(ctx, repositories.DeliveryFilter{SLA: valueobjects.Breached})
+		require.Nil(t, err)
+		require.Len(t, all, 1)
+		assert.Equal(t, breached.Id, all[0].Id)
+		byCourier, err := repo.GetAllByCourier(ctx, courier, repositories.DeliveryFilter{SLA: valueobjects.OnTrack})
+		require.Nil(t, err)
+		require.Len(t, byCourier, 1)
+		assert.Equal(t, onTrack.Id, byCourier[0].Id)
+		all, err = repo.GetAll(ctx, repositories.DeliveryFilter{SLA: valueobjects.AtRisk})
+		require.Nil(t, err)
+		assert.Empty(t, all)
+	})
+
+	t.Run("get all", func(t *testing.T) {
+		repo := newRepo(t)
+		all, err := repo.GetAll(ctx, repositories.DeliveryFilter{})
+		require.Nil(t, err)
+		assert.Empty(t, all)
+
+		courier := uint(20)
+		first := entities.NewDelivery("first", nil, recipient)
+		second := entities.NewDelivery("second", nil, recipient)
+		second.CourierId = &courier
+		require.Nil(t, repo.Store(ctx, first))
+		require.Nil(t, repo.Store(ctx, second))
+
+		all, err = repo.GetAll(ctx, repositories.DeliveryFilter{})
+		require.Nil(t, err)
+		require.Len(t, all, 2)
+		assert.Equal(t, first.Id, all[0].Id)
+		assert.Equal(t, second.Id, all[1].Id)
+
+		byCourier, err := repo.GetAllByCourier(ctx, courier, repositories.DeliveryFilter{})
+		require.Nil(t, err)
+		require.Len(t, byCourier, 1)
+		assert.Equal(t, second.Id, byCourier[0].Id)
+		assert.Equal(t, courier, *byCourier[0].CourierId)
+
+		byCourier, err = repo.GetAllByCourier(ctx, 404, repositories.DeliveryFilter{})
+		require.Nil(t, err)
+		assert.Empty(t, byCourier)
+
+		byRecipient, err := repo.GetAllByRecipient(ctx, recipient.Id)
+		require.Nil(t, err)
+		assert.Len(t, byRecipient, 2)
+		byRecipient, err = repo.GetAllByRecipient(ctx, 404)
+		require.Nil(t, err)
+		assert.Empty(t, byRecipient)
+
+		second.Status = valueobjects.Delivers
+		require.Nil(t, repo.Update(ctx, second))
+		created, err := repo.GetAllByStatus(ctx, valueobjects.Created)
+		require.Nil(t, err)
+		require.Len(t, created, 1)
+		assert.Equal(t, first.Id, created[0].Id)
+		completed, err := repo.GetAllByStatus(ctx, valueobjects.Completed)
+		require.Nil(t, err)
+		assert.Empty(t, completed)
+
+		count, err := repo.CountByCourier(ctx, courier, valueobjects.Delivers)
+		require.Nil(t, err)
+		assert.Equal(t, 1, count)
+		count, err = repo.CountByCourier(ctx, courier, valueobjects.Created)
+		require.Nil(t, err)
+		assert.Zero(t, count)
+	})
+
+	t.Run("tenant scope", func(t *testing.T) {
+		repo := newRepo(t)
+		acmeId, globexId := uint(7), uint(8)
+		acmeOwner := &entities.User{Id: 20, Role: "user", OrganizationId: &acmeId, OrgRole: "owner"}
+		acmeStaff := &entities.User{Id: 21, Role: "user", OrganizationId: &acmeId, OrgRole: "staff"}
+		globexStaff := &entities.User{Id: 30, Role: "user", OrganizationId: &globexId, OrgRole: "staff"}
+		courier := uint(40)
+		due := time.Now().Add(time.Hour)
+		var acme, globex, personal []uint
+		for _, c := range []struct {
+			user *entities.User
+			ids  *[]uint
+		}{
+			{acmeOwner, &acme}, {globexStaff, &globex}, {recipient, &personal}, {acmeStaff, &acme},
+		} {
+			d := entities.NewDelivery("Some Address 1, 14", nil, c.user)
+			d.CourierId, d.DueAt = &courier, &due
+			require.Nil(t, repo.Store(ctx, d))
+			*c.ids = append(*c.ids, d.Id)
+		}
+		ret := entities.NewDelivery("Shop 5", nil, globexStaff)
+		ret.OriginalId, ret.CourierId, ret.DueAt = &globex[0], &courier, &due
+		require.Nil(t, repo.Store(ctx, ret))
+		globex = append(globex, ret.Id)
+
+		idsOf := func(dls []*entities.Delivery) []uint {
+			result := make([]uint, 0, len(dls))
+			for _, d := range dls {
+				result = append(result, d.Id)
+			}
+			return result
+		}
+		for _, c := range []struct {
+			tenant entities.Tenant
+			sees   []uint
+		}{
+			{entities.TenantOf(acmeStaff), acme},
+			{entities.TenantOf(globexStaff), globex},
+			{entities.TenantOf(recipient), personal},
+			{entities.Tenant{UserId: acmeOwner.Id}, []uint{}},
+		} {
+			scoped := repositories.WithTenant(ctx, c.tenant)
+			all, err := repo.GetAll(scoped, repositories.DeliveryFilter{})
+			require.Nil(t, err)
+			assert.Equal(t, c.sees, idsOf(all))
+			byCourier, err := repo.GetAllByCourier(scoped, courier, repositories.DeliveryFilter{})
+			require.Nil(t, err)
+			assert.Equal(t, c.sees, idsOf(byCourier))
+			byStatus, err := repo.GetAllByStatus(scoped, valueobjects.Created)
+			require.Nil(t, err)
+			assert.Equal(t, c.sees, idsOf(byStatus))
+			dueBefore, err := repo.GetAllDueBefore(scoped, due.Add(time.Minute))
+			require.Nil(t, err)
+			assert.ElementsMatch(t, c.sees, idsOf(dueBefore))
+			count, err := repo.CountByCourier(scoped, courier, valueobjects.Created)
+			require.Nil(t, err)
+			assert.Equal(t, len(c.sees), count)
+		}
+
+		scoped := repositories.WithTenant(ctx, entities.TenantOf(acmeOwner))
+		byRecipient, err := repo.GetAllByRecipient(scoped, recipient.Id)
+		require.Nil(t, err)
+		assert.Empty(t, byRecipient)
+		got, err := repo.GetById(scoped, acme[1])
+		require.Nil(t, err)
+		assert.Equal(t, &acmeId, got.OrganizationId)
+		assert.Equal(t, acmeStaff.Id, got.RecipientId)
+		_, err = repo.GetReturnOf(scoped, globex[0])
+		assert.True(t, errors.Is(err, repositories.ErrDeliveryNotFound))
+
+		other, err := repo.GetById(ctx, globex[0])
+		require.Nil(t, err)
+		for _, id := range []uint{globex[0], personal[0]} {
+			_, err = repo.GetById(scoped, id)
+			assert.True(t, errors.Is(err, repositories.ErrDeliveryNotFound))
+		}
+		other.Status = valueobjects.Canceled
+		assert.True(t, errors.Is(repo.Update(scoped, other), repositories.ErrConcurrentModification))
+		updated, err := repo.UpdateSLA(scoped, other.Id, valueobjects.OnTrack, valueobjects.Breached)
+		require.Nil(t, err)
+		assert.False(t, updated)
+		got, err = repo.GetById(ctx, other.Id)
+		require.Nil(t, err)
+		assert.Equal(t, valueobjects.Created, got.Status)
+		assert.Equal(t, valueobjects.OnTrack, got.SLA)
+
+		got, err = repo.GetById(scoped, acme[0])
+		require.Nil(t, err)
+		got.Status = valueobjects.Canceled
+		require.Nil(t, repo.Update(scoped, got))
+	})
+
+	t.Run("update", func(t *testing.T) {
+		repo := newRepo(t)
+		d := entities.NewDelivery("Some Address 1, 14", nil, recipient)
+		require.Nil(t, repo.Store(ctx, d))
+
+		courier := uint(20)
+		d.CourierId = &courier
+		d.Status = valueobjects.Delivers
+		d.Attempts = 2
+		require.Nil(t, repo.Update(ctx, d))
+		assert.Equal(t, uint(2), d.Version)
+
+		got, err := repo.GetById(ctx, d.Id)
+		require.Nil(t, err)
+		assert.Equal(t, valueobjects.Delivers, got.Status)
+		assert.Equal(t, courier, *got.CourierId)
+		assert.Equal(t, uint(2), got.Version)
+		assert.Equal(t, uint(2), got.Attempts)
+	})
+
+	t.Run("update handover", func(t *testing.T) {
+		repo := newRepo(t)
+		d := entities.NewDelivery("Some Address 1, 14", nil, recipient)
+		require.Nil(t, repo.Store(ctx, d))
+		d.Handover = &valueobjects.Handover{Nonce: "nonce", CodeHash: "hash"}
+		require.Nil(t, repo.Update(ctx, d))
+		d.Handover.Attempts, d.Handover.Locked = 3, true
+		require.Nil(t, repo.Update(ctx, d))
+
+		got, err := repo.GetById(ctx, d.Id)
+		require.Nil(t, err)
+		assert.Equal(t, &valueobjects.Handover{Nonce: "nonce", CodeHash: "hash", Attempts: 3, Locked: true}, got.Handover)
+	})
+
+	t.Run("update stale or unknown", func(t *testing.T) {
+		repo := newRepo(t)
+		d := entities.NewDelivery("Some Address 1, 14", nil, recipient)
+		require.Nil(t, repo.Store(ctx, d))
+		stale := *d
+		require.Nil(t, repo.Update(ctx, d))
+
+		stale.Status = valueobjects.Canceled
+		assert.True(t, errors.Is(repo.Update(ctx, &stale), repositories.ErrConcurrentModification))
+		got, err := repo.GetById(ctx, d.Id)
+		require.Nil(t, err)
+		assert.Equal(t, valueobjects.Created, got.Status)
+
+		unknown := entities.NewDelivery("unknown", nil, recipient)
+		unknown.Id = 404
+		assert.True(t, errors.Is(repo.Update(ctx, unknown), repositories.ErrConcurrentModification))
+	})
+
+	t.Run("returned deliveries are copies", func(t *testing.T) {
+		repo := newRepo(t)
+		d := entities.NewDelivery("Some Address 1, 14", nil, recipient)
+		require.Nil(t, repo.Store(ctx, d))
+		d.Status = valueobjects.Canceled
+
+		got, err := repo.GetById(ctx, d.Id)
+		require.Nil(t, err)
+		got.Destination = "changed"
+		got, err = repo.GetById(ctx, d.Id)
+		require.Nil(t, err)
+		assert.Equal(t, valueobjects.Created, got.Status)
+		assert.Equal(t, "Some Address 1, 14", got.Destination)
+	})
+
+	t.Run("concurrent updates", func(t *testing.T) {
+		repo := newRepo(t)
+		d := entities.NewDelivery("Some Address 1, 14", nil, recipient)
+		require.Nil(t, repo.Store(ctx, d))
+
+		const writers = 10
+		var wg sync.WaitGroup
+		errs := make(chan error, writers)
+		for i := 0; i < writers; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				c := *d
+				errs <- repo.Update(ctx, &c)
+			}()
+		}
+		wg.Wait()
+		close(errs)
+		succeeded := 0
+		for err := range errs {
+			if err == nil {
+				succeeded++
+				continue
+			}
+			assert.True(t, errors.Is(err, repositories.ErrConcurrentModification))
+		}
+		assert.Equal(t, 1, succeeded)
+	})
+}
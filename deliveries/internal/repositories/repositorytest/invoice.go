@@ -0,0 +1,141 @@
+package repositorytest
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zhanbolat18/parcel/deliveries/internal/entities"
+	"github.com/zhanbolat18/parcel/deliveries/internal/repositories"
+	"github.com/zhanbolat18/parcel/deliveries/internal/valueobjects"
+	"testing"
+	"time"
+)
+
+// InvoicesRepository expects deliveries with ids 1 to 3 to exist.
+func InvoicesRepository(t *testing.T, newRepo func(t *testing.T) repositories.InvoicesRepository) {
+	ctx := context.Background()
+	now := time.Now().UTC().Truncate(time.Second)
+	invoiceOf := func(merchantId uint, period string, deliveryIds ...uint) *entities.Invoice {
+		invoice := &entities.Invoice{MerchantId: merchantId, Period: period, Currency: "KZT", CreatedAt: now}
+		for _, id := range deliveryIds {
+			invoice.Lines = append(invoice.Lines, entities.InvoiceLine{
+				DeliveryId:   id,
+				CompletedAt:  now.Add(time.Duration(id) * time.Minute),
+				Destination:  "Some Address 1, 14",
+				ServiceLevel: valueobjects.Express,
+				Amount:       valueobjects.Money(id * 100000),
+			})
+			invoice.Total += valueobjects.Money(id * 100000)
+			invoice.Deliveries++
+		}
+		return invoice
+	}
+
+	t.Run("store and get", func(t *testing.T) {
+		repo := newRepo(t)
+		_, err := repo.GetById(ctx, 1)
+		assert.True(t, errors.Is(err, repositories.ErrInvoiceNotFound))
+
+		invoice := invoiceOf(10, "2026-09", 1, 2)
+		require.Nil(t, repo.Store(ctx, invoice))
+		assert.NotZero(t, invoice.Id)
+		err = repo.Store(ctx, invoiceOf(10, "2026-09", 3))
+		assert.True(t, errors.Is(err, repositories.ErrInvoiceExists))
+
+		got, err := repo.GetById(ctx, invoice.Id)
+		require.Nil(t, err)
+		assert.Equal(t, invoice.Total, got.Total)
+		assert.Equal(t, uint(2), got.Deliveries)
+		assert.True(t, now.Equal(got.CreatedAt))
+		require.Len(t, got.Lines, 2)
+		assert.Equal(t, invoice.Lines[1].Amount, got.Lines[1].Amount)
+		assert.Equal(t, valueobjects.Express, got.Lines[1].ServiceLevel)
+		assert.True(t, invoice.Lines[1].CompletedAt.Equal(got.Lines[1].CompletedAt))
+
+		got, err = repo.GetByPeriod(ctx, entities.Tenant{UserId: 10}, "2026-09")
+		require.Nil(t, err)
+		assert.Equal(t, invoice.Id, got.Id)
+		assert.Len(t, got.Lines, 2)
+		_, err = repo.GetByPeriod(ctx, entities.Tenant{UserId: 10}, "2026-08")
+		assert.True(t, errors.Is(err, repositories.ErrInvoiceNotFound))
+	})
+
+	t.Run("get all", func(t *testing.T) {
+		repo := newRepo(t)
+		august := invoiceOf(10, "2026-08", 1)
+		september := invoiceOf(10, "2026-09", 2)
+		other := invoiceOf(11, "2026-09", 3)
+		for _, invoice := range []*entities.Invoice{august, september, other} {
+			require.Nil(t, repo.Store(ctx, invoice))
+		}
+
+		invoices, err := repo.GetAll(ctx, repositories.InvoiceFilter{})
+		require.Nil(t, err)
+		require.Len(t, invoices, 3)
+		assert.Equal(t, []uint{september.Id, other.Id, august.Id},
+			[]uint{invoices[0].Id, invoices[1].Id, invoices[2].Id})
+		assert.Empty(t, invoices[0].Lines, "lists leave the lines out")
+
+		invoices, err = repo.GetAll(ctx, repositories.InvoiceFilter{MerchantId: 10})
+		require.Nil(t, err)
+		assert.Len(t, invoices, 2)
+		invoices, err = repo.GetAll(ctx, repositories.InvoiceFilter{MerchantId: 10, Period: "2026-08"})
+		require.Nil(t, err)
+		require.Len(t, invoices, 1)
+		assert.Equal(t, august.Id, invoices[0].Id)
+	})
+	t.Run("organizations", func(t *testing.T) {
+		repo := newRepo(t)
+		acmeId, otherId := uint(7), uint(8)
+		mine := invoiceOf(10, "2026-09", 1)
+		acme := invoiceOf(0, "2026-09", 2)
+		acme.OrganizationId = &acmeId
+		other := invoiceOf(0, "2026-09", 3)
+		other.OrganizationId = &otherId
+		for _, invoice := range []*entities.Invoice{mine, acme, other} {
+			require.Nil(t, repo.Store(ctx, invoice))
+		}
+		twice := invoiceOf(0, "2026-09")
+		twice.OrganizationId = &acmeId
+		assert.True(t, errors.Is(repo.Store(ctx, twice), repositories.ErrInvoiceExists))
+
+		got, err := repo.GetByPeriod(ctx, entities.Tenant{OrganizationId: 7}, "2026-09")
+		require.Nil(t, err)
+		assert.Equal(t, acme.Id, got.Id)
+		assert.Equal(t, entities.Tenant{OrganizationId: 7}, got.Merchant())
+		invoices, err := repo.GetAll(ctx, repositories.InvoiceFilter{OrganizationId: 8})
+		require.Nil(t, err)
+		require.Len(t, invoices, 1)
+		assert.Equal(t, other.Id, invoices[0].Id)
+	})
+
+	t.Run("tenant scope", func(t *testing.T) {
+		repo := newRepo(t)
+		acmeId := uint(7)
+		mine := invoiceOf(10, "2026-09", 1)
+		acme := invoiceOf(0, "2026-09", 2)
+		acme.OrganizationId = &acmeId
+		for _, invoice := range []*entities.Invoice{mine, acme} {
+			require.Nil(t, repo.Store(ctx, invoice))
+		}
+
+		scoped := repositories.WithTenant(ctx, entities.Tenant{OrganizationId: 7})
+		invoices, err := repo.GetAll(scoped, repositories.InvoiceFilter{})
+		require.Nil(t, err)
+		require.Len(t, invoices, 1)
+		assert.Equal(t, acme.Id, invoices[0].Id)
+		_, err = repo.GetById(scoped, mine.Id)
+		assert.True(t, errors.Is(err, repositories.ErrInvoiceNotFound))
+		_, err = repo.GetByPeriod(scoped, entities.Tenant{UserId: 10}, "2026-09")
+		assert.True(t, errors.Is(err, repositories.ErrInvoiceNotFound))
+
+		scoped = repositories.WithTenant(ctx, entities.Tenant{UserId: 10})
+		invoices, err = repo.GetAll(scoped, repositories.InvoiceFilter{})
+		require.Nil(t, err)
+		require.Len(t, invoices, 1)
+		assert.Equal(t, mine.Id, invoices[0].Id)
+		_, err = repo.GetById(scoped, acme.Id)
+		assert.True(t, errors.Is(err, repositories.ErrInvoiceNotFound))
+	})
+}
@@ -0,0 +1,63 @@
+package repositorytest
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zhanbolat18/parcel/deliveries/internal/entities"
+	"github.com/zhanbolat18/parcel/deliveries/internal/repositories"
+	"testing"
+	"time"
+)
+
+func LocationHistoryRepository(t *testing.T, newRepo func(t *testing.T) repositories.LocationHistoryRepository) {
+	ctx := context.Background()
+	now := time.Now().UTC().Truncate(time.Second)
+	fix := func(courierId uint, ago time.Duration) *entities.LocationFix {
+		return &entities.LocationFix{CourierId: courierId, Latitude: 43.238, Longitude: 76.945, Accuracy: 5, RecordedAt: now.Add(-ago)}
+	}
+
+	t.Run("store and get by courier", func(t *testing.T) {
+		repo := newRepo(t)
+		require.Nil(t, repo.PreparePartitions(ctx, now.Add(-time.Hour), now.Add(time.Hour)))
+		require.Nil(t, repo.Store(ctx, fix(1, time.Minute), fix(1, 3*time.Minute), fix(2, time.Minute)))
+		require.Nil(t, repo.Store(ctx))
+
+		found, err := repo.GetByCourier(ctx, 1, now.Add(-time.Hour), now)
+		require.Nil(t, err)
+		require.Len(t, found, 2)
+		assert.True(t, now.Add(-3*time.Minute).Equal(found[0].RecordedAt))
+		assert.Equal(t, uint(1), found[1].CourierId)
+		assert.Equal(t, 76.945, found[1].Longitude)
+	})
+
+	t.Run("delete before", func(t *testing.T) {
+		repo := newRepo(t)
+		require.Nil(t, repo.PreparePartitions(ctx, now.Add(-72*time.Hour), now.Add(time.Hour)))
+		require.Nil(t, repo.Store(ctx, fix(1, 48*time.Hour), fix(1, 2*time.Hour), fix(1, time.Minute)))
+
+		require.Nil(t, repo.DeleteBefore(ctx, now.Add(-time.Hour)))
+		found, err := repo.GetByCourier(ctx, 1, now.Add(-72*time.Hour), now.Add(time.Hour))
+		require.Nil(t, err)
+		require.Len(t, found, 1)
+		assert.True(t, now.Add(-time.Minute).Equal(found[0].RecordedAt))
+	})
+
+	t.Run("prepare a day with stored fixes", func(t *testing.T) {
+		repo := newRepo(t)
+		require.Nil(t, repo.PreparePartitions(ctx, now, now.Add(time.Hour)))
+		// no partition covers these days yet
+		require.Nil(t, repo.Store(ctx, fix(1, 10*24*time.Hour), fix(1, 9*24*time.Hour)))
+
+		require.Nil(t, repo.PreparePartitions(ctx, now.Add(-10*24*time.Hour), now.Add(time.Hour)))
+		found, err := repo.GetByCourier(ctx, 1, now.Add(-11*24*time.Hour), now)
+		require.Nil(t, err)
+		assert.Len(t, found, 2)
+
+		require.Nil(t, repo.DeleteBefore(ctx, now.Add(-9*24*time.Hour)))
+		found, err = repo.GetByCourier(ctx, 1, now.Add(-11*24*time.Hour), now)
+		require.Nil(t, err)
+		require.Len(t, found, 1)
+		assert.True(t, now.Add(-9*24*time.Hour).Equal(found[0].RecordedAt))
+	})
+}
@@ -0,0 +1,60 @@
+package repositorytest
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zhanbolat18/parcel/deliveries/internal/entities"
+	"github.com/zhanbolat18/parcel/deliveries/internal/repositories"
+	"github.com/zhanbolat18/parcel/deliveries/internal/valueobjects"
+	"testing"
+	"time"
+)
+
+// ProofsRepository expects a delivery with id 1 to exist.
+func ProofsRepository(t *testing.T, newRepo func(t *testing.T) repositories.ProofsRepository) {
+	ctx := context.Background()
+
+	t.Run("store and get", func(t *testing.T) {
+		repo := newRepo(t)
+		_, err := repo.GetByDelivery(ctx, 1)
+		assert.True(t, errors.Is(err, repositories.ErrProofNotFound))
+
+		proof := &entities.Proof{
+			DeliveryId: 1,
+			ReceivedBy: "John Doe",
+			Location:   &valueobjects.Location{Latitude: 43.238, Longitude: 76.945},
+			Files: []entities.ProofFile{
+				{Kind: entities.ProofFileSignature, ContentType: "image/png", Size: 10, Key: "deliveries/1/a"},
+				{Kind: entities.ProofFilePhoto, ContentType: "image/jpeg", Size: 20, Key: "deliveries/1/b"},
+			},
+			CreatedAt: time.Now().UTC().Truncate(time.Second),
+		}
+		require.Nil(t, repo.Store(ctx, proof))
+		assert.NotZero(t, proof.Files[0].Id)
+		assert.NotEqual(t, proof.Files[0].Id, proof.Files[1].Id)
+
+		got, err := repo.GetByDelivery(ctx, 1)
+		require.Nil(t, err)
+		assert.Equal(t, "John Doe", got.ReceivedBy)
+		assert.Equal(t, proof.Location, got.Location)
+		assert.True(t, proof.CreatedAt.Equal(got.CreatedAt))
+		assert.Equal(t, proof.Files, got.Files)
+	})
+
+	t.Run("without location and photos", func(t *testing.T) {
+		repo := newRepo(t)
+		proof := &entities.Proof{
+			DeliveryId: 1,
+			ReceivedBy: "John Doe",
+			Files:      []entities.ProofFile{{Kind: entities.ProofFileSignature, ContentType: "image/png", Size: 10, Key: "deliveries/1/a"}},
+			CreatedAt:  time.Now(),
+		}
+		require.Nil(t, repo.Store(ctx, proof))
+		got, err := repo.GetByDelivery(ctx, 1)
+		require.Nil(t, err)
+		assert.Nil(t, got.Location)
+		assert.Len(t, got.Files, 1)
+	})
+}
@@ -0,0 +1,66 @@
+package repositorytest
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zhanbolat18/parcel/deliveries/internal/entities"
+	"github.com/zhanbolat18/parcel/deliveries/internal/repositories"
+	"testing"
+	"time"
+)
+
+func ShiftsRepository(t *testing.T, newRepo func(t *testing.T) repositories.ShiftsRepository) {
+	ctx := context.Background()
+	day := time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)
+
+	t.Run("store, update and delete", func(t *testing.T) {
+		repo := newRepo(t)
+		s := &entities.Shift{CourierId: 1, StartsAt: day.Add(9 * time.Hour), EndsAt: day.Add(17 * time.Hour), Zone: "center"}
+		require.Nil(t, repo.Store(ctx, s))
+		assert.NotZero(t, s.Id)
+
+		got, err := repo.GetById(ctx, s.Id)
+		require.Nil(t, err)
+		assert.Equal(t, "center", got.Zone)
+		assert.True(t, s.StartsAt.Equal(got.StartsAt))
+
+		s.Zone = "north"
+		require.Nil(t, repo.Update(ctx, s))
+		got, err = repo.GetById(ctx, s.Id)
+		require.Nil(t, err)
+		assert.Equal(t, "north", got.Zone)
+
+		require.Nil(t, repo.Delete(ctx, s.Id))
+		_, err = repo.GetById(ctx, s.Id)
+		assert.True(t, errors.Is(err, repositories.ErrShiftNotFound))
+		assert.True(t, errors.Is(repo.Delete(ctx, s.Id), repositories.ErrShiftNotFound))
+	})
+
+	t.Run("get all by period", func(t *testing.T) {
+		repo := newRepo(t)
+		for _, s := range []*entities.Shift{
+			{CourierId: 1, StartsAt: day.Add(13 * time.Hour), EndsAt: day.Add(17 * time.Hour), Zone: "center"},
+			{CourierId: 1, StartsAt: day.Add(8 * time.Hour), EndsAt: day.Add(12 * time.Hour), Zone: "center"},
+			{CourierId: 2, StartsAt: day.Add(8 * time.Hour), EndsAt: day.Add(12 * time.Hour), Zone: "north"},
+		} {
+			require.Nil(t, repo.Store(ctx, s))
+		}
+
+		all, err := repo.GetAll(ctx, repositories.ShiftFilter{})
+		require.Nil(t, err)
+		assert.Len(t, all, 3)
+
+		mine, err := repo.GetAll(ctx, repositories.ShiftFilter{CourierId: 1})
+		require.Nil(t, err)
+		require.Len(t, mine, 2)
+		assert.True(t, mine[0].StartsAt.Before(mine[1].StartsAt))
+
+		// the period is half-open, a shift ending at From does not overlap it
+		afternoon, err := repo.GetAll(ctx, repositories.ShiftFilter{CourierId: 1, From: day.Add(12 * time.Hour), To: day.Add(14 * time.Hour)})
+		require.Nil(t, err)
+		require.Len(t, afternoon, 1)
+		assert.True(t, day.Add(13*time.Hour).Equal(afternoon[0].StartsAt))
+	})
+}
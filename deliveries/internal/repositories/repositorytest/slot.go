@@ -0,0 +1,62 @@
+package repositorytest
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zhanbolat18/parcel/deliveries/internal/repositories"
+	"sync"
+	"testing"
+	"time"
+)
+
+func SlotBookingsRepository(t *testing.T, newRepo func(t *testing.T) repositories.SlotBookingsRepository) {
+	ctx := context.Background()
+	start := time.Date(2030, 1, 2, 10, 0, 0, 0, time.UTC)
+
+	t.Run("book and release", func(t *testing.T) {
+		repo := newRepo(t)
+		require.Nil(t, repo.Book(ctx, "center", start, 2))
+		require.Nil(t, repo.Book(ctx, "center", start, 2))
+		assert.True(t, errors.Is(repo.Book(ctx, "center", start, 2), repositories.ErrSlotFull))
+		require.Nil(t, repo.Book(ctx, "north", start, 2), "zones are booked apart")
+		require.Nil(t, repo.Book(ctx, "center", start.Add(2*time.Hour), 2))
+
+		booked, err := repo.Booked(ctx, "center", start, start.Add(2*time.Hour))
+		require.Nil(t, err)
+		assert.Equal(t, map[int64]uint{start.Unix(): 2}, booked)
+
+		require.Nil(t, repo.Release(ctx, "center", start))
+		require.Nil(t, repo.Book(ctx, "center", start, 2))
+		require.Nil(t, repo.Release(ctx, "center", start))
+		require.Nil(t, repo.Release(ctx, "center", start))
+		require.Nil(t, repo.Release(ctx, "center", start), "releasing a free slot is a no-op")
+		booked, err = repo.Booked(ctx, "center", start, start.Add(4*time.Hour))
+		require.Nil(t, err)
+		assert.Equal(t, map[int64]uint{start.Add(2 * time.Hour).Unix(): 1}, booked)
+	})
+
+	t.Run("no overbooking", func(t *testing.T) {
+		repo := newRepo(t)
+		var wg sync.WaitGroup
+		var mu sync.Mutex
+		booked := 0
+		for i := 0; i < 20; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				err := repo.Book(ctx, "center", start, 5)
+				if err == nil {
+					mu.Lock()
+					booked++
+					mu.Unlock()
+					return
+				}
+				assert.True(t, errors.Is(err, repositories.ErrSlotFull))
+			}()
+		}
+		wg.Wait()
+		assert.Equal(t, 5, booked)
+	})
+}
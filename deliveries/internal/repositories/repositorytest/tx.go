@@ -0,0 +1,78 @@
+package repositorytest
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zhanbolat18/parcel/deliveries/internal/entities"
+	"github.com/zhanbolat18/parcel/deliveries/internal/repositories"
+	"testing"
+	"time"
+)
+
+// TxManager runs the suite, newRepos must return repositories sharing the
+// returned transaction manager and holding no deliveries or bookings.
+func TxManager(t *testing.T, newRepos func(t *testing.T) (repositories.TxManager, repositories.DeliveriesRepository, repositories.SlotBookingsRepository)) {
+	ctx := context.Background()
+	recipient := &entities.User{Id: 10, Role: "user"}
+	start := time.Date(2030, 1, 2, 10, 0, 0, 0, time.UTC)
+
+	t.Run("commit", func(t *testing.T) {
+		tx, deliveries, slots := newRepos(t)
+		d := entities.NewDelivery("Some Address 1, 14", nil, recipient)
+		require.Nil(t, tx.WithinTx(ctx, func(ctx context.Context) error {
+			if err := deliveries.Store(ctx, d); err != nil {
+				return err
+			}
+			return slots.Book(ctx, "center", start, 1)
+		}))
+
+		_, err := deliveries.GetById(ctx, d.Id)
+		assert.Nil(t, err)
+		booked, err := slots.Booked(ctx, "center", start, start.Add(time.Hour))
+		require.Nil(t, err)
+		assert.Equal(t, map[int64]uint{start.Unix(): 1}, booked)
+	})
+
+	t.Run("rollback", func(t *testing.T) {
+		tx, deliveries, slots := newRepos(t)
+		kept := entities.NewDelivery("Some Address 1, 14", nil, recipient)
+		require.Nil(t, deliveries.Store(ctx, kept))
+		require.Nil(t, slots.Book(ctx, "center", start, 2))
+
+		failed := errors.New("failed")
+		created := entities.NewDelivery("Some Address 2, 7", nil, recipient)
+		err := tx.WithinTx(ctx, func(ctx context.Context) error {
+			if err := deliveries.Store(ctx, created); err != nil {
+				return err
+			}
+			update := *kept
+			update.Destination = "Other Address 3, 1"
+			if err := deliveries.Update(ctx, &update); err != nil {
+				return err
+			}
+			if err := slots.Release(ctx, "center", start); err != nil {
+				return err
+			}
+			if err := slots.Book(ctx, "north", start, 2); err != nil {
+				return err
+			}
+			return failed
+		})
+		assert.True(t, errors.Is(err, failed))
+
+		_, err = deliveries.GetById(ctx, created.Id)
+		assert.True(t, errors.Is(err, repositories.ErrDeliveryNotFound))
+		got, err := deliveries.GetById(ctx, kept.Id)
+		require.Nil(t, err)
+		assert.Equal(t, kept.Destination, got.Destination)
+		assert.Equal(t, kept.Version, got.Version)
+		booked, err := slots.Booked(ctx, "center", start, start.Add(time.Hour))
+		require.Nil(t, err)
+		assert.Equal(t, map[int64]uint{start.Unix(): 1}, booked)
+		booked, err = slots.Booked(ctx, "north", start, start.Add(time.Hour))
+		require.Nil(t, err)
+		assert.Empty(t, booked)
+	})
+}
@@ -0,0 +1,29 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"github.com/zhanbolat18/parcel/deliveries/internal/entities"
+	"time"
+)
+
+var ErrShiftNotFound = errors.New("shift not found")
+
+// ShiftFilter selects shifts overlapping [From, To), zero values match all.
+type ShiftFilter struct {
+	CourierId uint
+	From      time.Time
+	To        time.Time
+}
+
+type ShiftsRepository interface {
+	GetById(ctx context.Context, id uint) (*entities.Shift, error)
+	// GetAll returns matching shifts ordered by start.
+	GetAll(ctx context.Context, filter ShiftFilter) ([]*entities.Shift, error)
+	// LockCourier serializes changes to the courier shifts until the end of
+	// the current transaction, outside a transaction it does nothing.
+	LockCourier(ctx context.Context, courierId uint) error
+	Store(ctx context.Context, shift *entities.Shift) error
+	Update(ctx context.Context, shift *entities.Shift) error
+	Delete(ctx context.Context, id uint) error
+}
@@ -0,0 +1,19 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var ErrSlotFull = errors.New("slot is fully booked")
+
+type SlotBookingsRepository interface {
+	// Book takes a place of the zone slot in one step, it returns ErrSlotFull
+	// when capacity places are taken already.
+	Book(ctx context.Context, zone string, slotStart time.Time, capacity uint) error
+	Release(ctx context.Context, zone string, slotStart time.Time) error
+	// Booked counts the taken places of the zone slots starting within
+	// [from, to), keyed by the unix time of the slot start.
+	Booked(ctx context.Context, zone string, from, to time.Time) (map[int64]uint, error)
+}
@@ -0,0 +1,22 @@
+package repositories
+
+import (
+	"context"
+	"github.com/zhanbolat18/parcel/deliveries/internal/entities"
+)
+
+type tenantKey struct{}
+
+// WithTenant scopes the repositories called with the returned context to the
+// data of the tenant: reads and updates miss whatever belongs to others, as if
+// it did not exist.
+func WithTenant(ctx context.Context, tenant entities.Tenant) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenant)
+}
+
+// TenantFromContext returns the tenant set by WithTenant, requests of admins
+// and couriers are not scoped.
+func TenantFromContext(ctx context.Context) (entities.Tenant, bool) {
+	tenant, ok := ctx.Value(tenantKey{}).(entities.Tenant)
+	return tenant, ok
+}
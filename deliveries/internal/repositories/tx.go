@@ -0,0 +1,10 @@
+package repositories
+
+import "context"
+
+// TxManager runs a unit of work in a single transaction. Repositories called
+// with the context passed to fn take part in that transaction, fn returning
+// an error rolls it back. Nested calls join the outer transaction.
+type TxManager interface {
+	WithinTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
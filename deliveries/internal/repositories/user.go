@@ -2,10 +2,14 @@ package repositories
 
 import (
 	"context"
+	"errors"
 	"github.com/zhanbolat18/parcel/deliveries/internal/entities"
 )
 
+var ErrUserNotFound = errors.New("user not found")
+
 type UsersRepository interface {
 	GetCourier(ctx context.Context, id uint) (*entities.User, error)
+	GetCouriers(ctx context.Context) ([]*entities.User, error)
 	GetRecipient(ctx context.Context, id uint) (*entities.User, error)
 }
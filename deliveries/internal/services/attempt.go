@@ -0,0 +1,148 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/zhanbolat18/parcel/deliveries/internal/entities"
+	"github.com/zhanbolat18/parcel/deliveries/internal/valueobjects"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidAttempt   = errors.New("invalid delivery attempt")
+	ErrNotAssigned      = errors.New("delivery is assigned to another courier")
+	ErrNotReschedulable = errors.New("delivery can not be rescheduled")
+	ErrInvalidWindow    = errors.New("invalid time window")
+)
+
+// FailedAttempt is what the courier reports when the delivery can not be
+// handed over. A non-zero ExpectedVersion must match the stored delivery
+// version, Photo is optional.
+type FailedAttempt struct {
+	ExpectedVersion uint
+	Reason          valueobjects.AttemptReason
+	Note            string
+	Photo           *entities.ProofFile
+}
+
+// FailAttempt records a failed attempt of the assigned courier. The delivery
+// waits for the recipient to reschedule it, or is returned to the sender once
+// the maximum number of attempts is reached.
+func (m *ManageDelivery) FailAttempt(ctx context.Context, deliveryId uint, courier *entities.User, req FailedAttempt) (*entities.Delivery, error) {
+	if !req.Reason.Valid() {
+		return nil, fmt.Errorf("%w: unknown reason \"%s\"", ErrInvalidAttempt, req.Reason)
+	}
+	var delivery *entities.Delivery
+	err := m.txManager.WithinTx(ctx, func(ctx context.Context) (err error) {
+		delivery, err = m.deliveryRepo.GetById(ctx, deliveryId)
+		if err != nil {
+			return fmt.Errorf("get delivery by id \"%d\": %w", deliveryId, err)
+		}
+		if !m.versionMatches(delivery, req.ExpectedVersion) {
+			return ErrPreconditionFailed
+		}
+		if delivery.CourierId == nil || *delivery.CourierId != courier.Id {
+			return ErrNotAssigned
+		}
+		if delivery.Status != valueobjects.Delivers {
+			return fmt.Errorf("%w: delivery is %s", ErrNotInDelivery, delivery.Status)
+		}
+		delivery.Attempts++
+		delivery.Status = valueobjects.AttemptFailed
+		if delivery.Attempts >= m.maxAttempts {
+			delivery.Status = valueobjects.ReturnedToSender
+		}
+		delivery.UpdatedAt = time.Now()
+		if err = m.deliveryRepo.Update(ctx, delivery); err != nil {
+			return fmt.Errorf("update delivery: %w", err)
+		}
+		attempt := &entities.Attempt{
+			DeliveryId: delivery.Id,
+			CourierId:  courier.Id,
+			Reason:     req.Reason,
+			Note:       strings.TrimSpace(req.Note),
+			Photo:      req.Photo,
+			CreatedAt:  delivery.UpdatedAt,
+		}
+		if err = m.attemptsRepo.Store(ctx, attempt); err != nil {
+			return fmt.Errorf("store delivery attempt: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	m.notify(EventAttemptFailed, delivery)
+	if delivery.Status == valueobjects.ReturnedToSender {
+		m.notify(EventReturned, delivery)
+	}
+	return delivery, nil
+}
+
+// Reschedule moves the delivery of the recipient to a new time window in the
+// zone, booking the new slot. An empty zone keeps the one of the delivery, a
+// delivery priced by a quote stays in the quoted zone. A delivery with a
+// failed attempt goes back to dispatch.
+func (m *ManageDelivery) Reschedule(
+	ctx context.Context,
+	deliveryId uint,
+	recipient *entities.User,
+	zone string,
+	window *valueobjects.TimeWindow,
+	expectedVersion uint,
+) (*entities.Delivery, error) {
+	if !window.Start.After(time.Now()) {
+		return nil, fmt.Errorf("%w: window must start in the future", ErrInvalidWindow)
+	}
+	var delivery *entities.Delivery
+	err := m.txManager.WithinTx(ctx, func(ctx context.Context) (err error) {
+		delivery, err = m.deliveryRepo.GetById(ctx, deliveryId)
+		if err != nil {
+			return fmt.Errorf("get delivery by id \"%d\": %w", deliveryId, err)
+		}
+		if !entities.TenantOf(recipient).Owns(delivery) {
+			return ErrNotRecipient
+		}
+		if !m.versionMatches(delivery, expectedVersion) {
+			return ErrPreconditionFailed
+		}
+		switch delivery.Status {
+		case valueobjects.Created:
+		case valueobjects.AttemptFailed:
+			delivery.Status = valueobjects.Created
+			delivery.CourierId = nil
+		default:
+			return fmt.Errorf("%w: delivery is %s", ErrNotReschedulable, delivery.Status)
+		}
+		if zone == "" {
+			zone = delivery.Zone
+		}
+		if delivery.Price != nil && delivery.Zone != "" && zone != delivery.Zone {
+			return fmt.Errorf("%w: delivery is priced for zone \"%s\"", ErrNotReschedulable, delivery.Zone)
+		}
+		if err = m.schedule.Apply(ctx, delivery, Schedule{Zone: zone, Window: window}); err != nil {
+			return err
+		}
+		delivery.UpdatedAt = time.Now()
+		if err = m.deliveryRepo.Update(ctx, delivery); err != nil {
+			return fmt.Errorf("update delivery: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	m.notify(EventRescheduled, delivery)
+	return delivery, nil
+}
+
+// GetAttempts returns the failed attempts of the delivery, oldest first.
+func (m *ManageDelivery) GetAttempts(ctx context.Context, deliveryId uint) ([]*entities.Attempt, error) {
+	attempts, err := m.attemptsRepo.GetByDelivery(ctx, deliveryId)
+	if err != nil {
+		return nil, fmt.Errorf("fetch delivery attempts: %w", err)
+	}
+	return attempts, nil
+}
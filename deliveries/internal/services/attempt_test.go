@@ -0,0 +1,121 @@
+package services_test
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zhanbolat18/parcel/deliveries/internal/entities"
+	"github.com/zhanbolat18/parcel/deliveries/internal/services"
+	"github.com/zhanbolat18/parcel/deliveries/internal/valueobjects"
+	"testing"
+	"time"
+)
+
+func TestManageDelivery_FailAttempt(t *testing.T) {
+	srv, _ := newService(t, valueobjects.Created, valueobjects.Delivers)
+	asrt := assert.New(t)
+
+	_, err := srv.FailAttempt(ctx, 2, courier, services.FailedAttempt{Reason: "lost"})
+	asrt.True(errors.Is(err, services.ErrInvalidAttempt))
+	_, err = srv.FailAttempt(ctx, 1, courier, services.FailedAttempt{Reason: valueobjects.RecipientAbsent})
+	asrt.NotNil(err, "only assigned deliveries fail")
+	_, err = srv.FailAttempt(ctx, 2, other, services.FailedAttempt{Reason: valueobjects.RecipientAbsent})
+	asrt.True(errors.Is(err, services.ErrNotAssigned), "only the assigned courier reports")
+
+	photo := &entities.ProofFile{Kind: entities.ProofFilePhoto, ContentType: "image/png", Size: 10, Key: "deliveries/2/a"}
+	d, err := srv.FailAttempt(ctx, 2, courier, services.FailedAttempt{
+		Reason: valueobjects.RecipientAbsent,
+		Note:   " nobody home ",
+		Photo:  photo,
+	})
+	require.Nil(t, err)
+	asrt.Equal(valueobjects.AttemptFailed, d.Status)
+	asrt.Equal(uint(1), d.Attempts)
+	_, err = srv.Complete(ctx, 2, courier, services.CompleteDelivery{})
+	asrt.NotNil(err, "a failed attempt is not completable")
+
+	attempts, err := srv.GetAttempts(ctx, 2)
+	require.Nil(t, err)
+	require.Len(t, attempts, 1)
+	asrt.Equal(valueobjects.RecipientAbsent, attempts[0].Reason)
+	asrt.Equal("nobody home", attempts[0].Note)
+	asrt.Equal(courier.Id, attempts[0].CourierId)
+	if asrt.NotNil(attempts[0].Photo) {
+		asrt.Equal(attempts[0].Id, attempts[0].Photo.Id)
+		asrt.Equal(photo.Key, attempts[0].Photo.Key)
+	}
+}
+
+func TestManageDelivery_Reschedule(t *testing.T) {
+	srv, _ := newService(t, valueobjects.Delivers, valueobjects.Completed)
+	asrt := assert.New(t)
+	window := slotWindow(1)
+
+	_, err := srv.FailAttempt(ctx, 1, courier, services.FailedAttempt{Reason: valueobjects.WrongAddress})
+	require.Nil(t, err)
+
+	_, err = srv.Reschedule(ctx, 1, courier, "", window, 0)
+	asrt.True(errors.Is(err, services.ErrNotRecipient))
+	past := &valueobjects.TimeWindow{Start: time.Now().Add(-time.Hour), End: time.Now().Add(time.Hour)}
+	_, err = srv.Reschedule(ctx, 1, recipient, "", past, 0)
+	asrt.True(errors.Is(err, services.ErrInvalidWindow))
+	_, err = srv.Reschedule(ctx, 2, recipient, "", window, 0)
+	asrt.True(errors.Is(err, services.ErrNotReschedulable))
+	_, err = srv.Reschedule(ctx, 1, recipient, "north", window, 0)
+	asrt.True(errors.Is(err, services.ErrInvalidSchedule), "the zone is booked in")
+
+	d, err := srv.Reschedule(ctx, 1, recipient, "center", window, 0)
+	require.Nil(t, err)
+	asrt.Equal(valueobjects.Created, d.Status)
+	asrt.Nil(d.CourierId)
+	asrt.Equal(window, d.Window)
+	asrt.Equal(window, d.Slot)
+	asrt.Equal("center", d.Zone)
+
+	// the delivery is dispatched again and keeps its attempts
+	d, err = srv.AssignToCourier(ctx, 1, other.Id, 0)
+	require.Nil(t, err)
+	asrt.Equal(valueobjects.Delivers, d.Status)
+	asrt.Equal(uint(1), d.Attempts)
+}
+
+func TestManageDelivery_RescheduleKeepsQuotedZone(t *testing.T) {
+	srv, deliveries := newService(t, valueobjects.Created)
+	d, err := deliveries.GetById(ctx, 1)
+	require.Nil(t, err)
+	d.Zone = "center"
+	d.Price = &valueobjects.Price{Amount: 100000, Currency: "KZT"}
+	require.Nil(t, deliveries.Update(ctx, d))
+
+	_, err = srv.Reschedule(ctx, 1, recipient, "north", slotWindow(1), 0)
+	assert.True(t, errors.Is(err, services.ErrNotReschedulable))
+	d, err = srv.Reschedule(ctx, 1, recipient, "", slotWindow(1), 0)
+	require.Nil(t, err)
+	assert.Equal(t, "center", d.Zone)
+}
+
+func TestManageDelivery_ReturnedToSender(t *testing.T) {
+	srv, _ := newService(t, valueobjects.Delivers)
+	asrt := assert.New(t)
+	window := slotWindow(1)
+
+	var d *entities.Delivery
+	var err error
+	for i := 0; i < 3; i++ {
+		if i > 0 {
+			_, err = srv.Reschedule(ctx, 1, recipient, "", window, 0)
+			require.Nil(t, err)
+			_, err = srv.AssignToCourier(ctx, 1, courier.Id, 0)
+			require.Nil(t, err)
+		}
+		d, err = srv.FailAttempt(ctx, 1, courier, services.FailedAttempt{Reason: valueobjects.RecipientAbsent})
+		require.Nil(t, err)
+	}
+	asrt.Equal(valueobjects.ReturnedToSender, d.Status)
+	asrt.Equal(uint(3), d.Attempts)
+
+	_, err = srv.Reschedule(ctx, 1, recipient, "", window, 0)
+	asrt.True(errors.Is(err, services.ErrNotReschedulable))
+	_, err = srv.AssignToCourier(ctx, 1, courier.Id, 0)
+	asrt.NotNil(err)
+}
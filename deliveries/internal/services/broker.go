@@ -0,0 +1,135 @@
+package services
+
+import (
+	"github.com/zhanbolat18/parcel/deliveries/internal/entities"
+	"sync"
+	"time"
+)
+
+// subscriptionBuffer is how many events a subscriber may lag behind before it
+// is dropped, the client then resumes with Last-Event-ID.
+const subscriptionBuffer = 64
+
+type DeliveryEvent struct {
+	Id       uint64
+	Type     string
+	Delivery entities.Delivery
+	At       time.Time
+}
+
+type Publisher interface {
+	Publish(eventType string, delivery *entities.Delivery)
+}
+
+// Broker fans delivery events out to the subscribers of this instance and
+// keeps the latest ones, so that reconnecting clients can resume.
+type Broker struct {
+	mu          sync.Mutex
+	lastId      uint64
+	history     []DeliveryEvent
+	historySize int
+	subscribers map[*Subscription]struct{}
+	closed      bool
+}
+
+func NewBroker(historySize int) *Broker {
+	return &Broker{
+		// ids continue from the start time, so a Last-Event-ID received after a
+		// restart replays everything this instance has seen
+		lastId:      uint64(time.Now().UnixMicro()),
+		history:     make([]DeliveryEvent, 0, historySize),
+		historySize: historySize,
+		subscribers: make(map[*Subscription]struct{}),
+	}
+}
+
+type Subscription struct {
+	C      <-chan DeliveryEvent
+	events chan DeliveryEvent
+	filter func(*DeliveryEvent) bool
+	broker *Broker
+}
+
+// Subscribe registers a subscriber for the events accepted by filter and
+// returns the kept ones newer than lastEventId. The filter runs under the
+// broker lock and must not block.
+func (b *Broker) Subscribe(lastEventId uint64, filter func(*DeliveryEvent) bool) (*Subscription, []DeliveryEvent) {
+	events := make(chan DeliveryEvent, subscriptionBuffer)
+	sub := &Subscription{C: events, events: events, filter: filter, broker: b}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		close(events)
+		return sub, nil
+	}
+	b.subscribers[sub] = struct{}{}
+	if lastEventId == 0 {
+		return sub, nil
+	}
+	backlog := make([]DeliveryEvent, 0)
+	for i := range b.history {
+		if b.history[i].Id > lastEventId && filter(&b.history[i]) {
+			backlog = append(backlog, b.history[i])
+		}
+	}
+	return sub, backlog
+}
+
+func (b *Broker) Publish(eventType string, delivery *entities.Delivery) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.lastId++
+	event := DeliveryEvent{Id: b.lastId, Type: eventType, Delivery: *delivery, At: time.Now()}
+	if b.historySize > 0 {
+		if len(b.history) == b.historySize {
+			copy(b.history, b.history[1:])
+			b.history = b.history[:len(b.history)-1]
+		}
+		b.history = append(b.history, event)
+	}
+	for sub := range b.subscribers {
+		if !sub.filter(&event) {
+			continue
+		}
+		select {
+		case sub.events <- event:
+		default:
+			b.remove(sub)
+		}
+	}
+}
+
+// Subscribers returns how many subscribers are registered.
+func (b *Broker) Subscribers() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.subscribers)
+}
+
+// Close ends every subscription, so that streaming handlers return and the
+// server can shut down.
+func (b *Broker) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.closed = true
+	for sub := range b.subscribers {
+		b.remove(sub)
+	}
+}
+
+// Close unregisters the subscription, it is safe to call more than once.
+func (s *Subscription) Close() {
+	s.broker.mu.Lock()
+	defer s.broker.mu.Unlock()
+	s.broker.remove(s)
+}
+
+// remove closes the channel of a registered subscriber, the caller must hold
+// the lock.
+func (b *Broker) remove(sub *Subscription) {
+	if _, ok := b.subscribers[sub]; !ok {
+		return
+	}
+	delete(b.subscribers, sub)
+	close(sub.events)
+}
@@ -0,0 +1,128 @@
+package services_test
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zhanbolat18/parcel/deliveries/internal/entities"
+	"github.com/zhanbolat18/parcel/deliveries/internal/repositories/memory"
+	"github.com/zhanbolat18/parcel/deliveries/internal/services"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func all(*services.DeliveryEvent) bool { return true }
+
+func TestBroker_Resume(t *testing.T) {
+	broker := services.NewBroker(2)
+	asrt := assert.New(t)
+	for id := uint(1); id <= 3; id++ {
+		broker.Publish(services.EventCreated, &entities.Delivery{Id: id})
+	}
+
+	sub, backlog := broker.Subscribe(0, all)
+	asrt.Empty(backlog)
+	sub.Close()
+
+	// only the latest two events are kept
+	sub, backlog = broker.Subscribe(1, all)
+	defer sub.Close()
+	require.Len(t, backlog, 2)
+	asrt.Equal(uint(2), backlog[0].Delivery.Id)
+	asrt.Equal(uint(3), backlog[1].Delivery.Id)
+
+	sub2, backlog := broker.Subscribe(backlog[0].Id, all)
+	defer sub2.Close()
+	require.Len(t, backlog, 1)
+	asrt.Equal(uint(3), backlog[0].Delivery.Id)
+}
+
+func TestBroker_Filter(t *testing.T) {
+	broker := services.NewBroker(10)
+	sub, _ := broker.Subscribe(0, func(e *services.DeliveryEvent) bool {
+		return e.Delivery.Id == 2
+	})
+	defer sub.Close()
+	broker.Publish(services.EventCreated, &entities.Delivery{Id: 1})
+	broker.Publish(services.EventAssigned, &entities.Delivery{Id: 2})
+
+	event := <-sub.C
+	assert.Equal(t, uint(2), event.Delivery.Id)
+	assert.Equal(t, services.EventAssigned, event.Type)
+	assert.Empty(t, sub.C)
+}
+
+func TestBroker_DropsSlowSubscriber(t *testing.T) {
+	broker := services.NewBroker(0)
+	sub, _ := broker.Subscribe(0, all)
+	for i := 0; i < 100; i++ {
+		broker.Publish(services.EventCreated, &entities.Delivery{Id: uint(i)})
+	}
+	assert.Zero(t, broker.Subscribers())
+
+	received := 0
+	for range sub.C {
+		received++
+	}
+	assert.Less(t, received, 100)
+	sub.Close()
+}
+
+func TestBroker_CloseDoesNotLeak(t *testing.T) {
+	broker := services.NewBroker(0)
+	before := runtime.NumGoroutine()
+	subs := make([]*services.Subscription, 0)
+	for i := 0; i < 10; i++ {
+		sub, _ := broker.Subscribe(0, all)
+		subs = append(subs, sub)
+	}
+	assert.Equal(t, 10, broker.Subscribers())
+	for _, sub := range subs[:5] {
+		sub.Close()
+		sub.Close()
+	}
+	assert.Equal(t, 5, broker.Subscribers())
+
+	broker.Close()
+	assert.Zero(t, broker.Subscribers())
+	for _, sub := range subs {
+		_, ok := <-sub.C
+		assert.False(t, ok)
+	}
+	sub, _ := broker.Subscribe(0, all)
+	_, ok := <-sub.C
+	assert.False(t, ok)
+	assert.LessOrEqual(t, runtime.NumGoroutine(), before)
+}
+
+func TestManageDelivery_PublishesStatusChanges(t *testing.T) {
+	deliveries := memory.NewDeliveryRepository()
+	broker := services.NewBroker(10)
+	deps := newDeps(t, deliveries, memory.NewUsersRepository(courier, other, recipient), newCouriers(t, deliveries, courier))
+	deps.Publisher = broker
+	srv := services.NewManageDelivery(deps, policy)
+	sub, _ := broker.Subscribe(0, all)
+	defer sub.Close()
+
+	d, err := srv.Create(ctx, recipient, services.CreateDelivery{Destination: "Some Address 1, 14"})
+	require.Nil(t, err)
+	assigned, err := srv.AssignToCourier(ctx, d.Id, courier.Id, 0)
+	require.Nil(t, err)
+	srv.RevealHandoverCode(assigned)
+	_, err = srv.Complete(ctx, d.Id, courier, services.CompleteDelivery{HandoverCode: assigned.HandoverCode})
+	require.Nil(t, err)
+	// failed changes are not published
+	_, err = srv.Complete(ctx, d.Id, courier, services.CompleteDelivery{HandoverCode: assigned.HandoverCode})
+	require.NotNil(t, err)
+
+	for _, expected := range []string{services.EventCreated, services.EventAssigned, services.EventCompleted} {
+		select {
+		case event := <-sub.C:
+			assert.Equal(t, expected, event.Type)
+			assert.Equal(t, d.Id, event.Delivery.Id)
+		case <-time.After(time.Second):
+			t.Fatalf("no %s event", expected)
+		}
+	}
+	assert.Empty(t, sub.C)
+}
@@ -0,0 +1,223 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"github.com/zhanbolat18/parcel/deliveries/internal/valueobjects"
+	"strings"
+	"time"
+)
+
+// DateLayout is the layout of scheduled_for dates.
+const DateLayout = "2006-01-02"
+
+// MonthLayout is the layout of invoice periods.
+const MonthLayout = "2006-01"
+
+var ErrInvalidSchedule = errors.New("invalid delivery schedule")
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// CalendarRules configure a Calendar. WorkingHours look like "09:00-21:00",
+// WorkingDays like "mon" and Holidays like "2026-12-31".
+type CalendarRules struct {
+	TimeZone     string
+	WorkingHours string
+	WorkingDays  []string
+	Holidays     []string
+	MinLeadTime  time.Duration
+	SlotDuration time.Duration
+}
+
+// Calendar tells when deliveries can be scheduled. Working hours of every
+// working day, holidays aside, are split into slots of equal duration.
+type Calendar struct {
+	location     *time.Location
+	opens        time.Duration
+	closes       time.Duration
+	workingDays  map[time.Weekday]bool
+	holidays     map[string]bool
+	minLeadTime  time.Duration
+	slotDuration time.Duration
+	now          func() time.Time
+}
+
+func NewCalendar(rules CalendarRules) (*Calendar, error) {
+	location, err := time.LoadLocation(rules.TimeZone)
+	if err != nil {
+		return nil, fmt.Errorf("calendar time zone: %w", err)
+	}
+	c := &Calendar{
+		location:     location,
+		workingDays:  make(map[time.Weekday]bool),
+		holidays:     make(map[string]bool),
+		minLeadTime:  rules.MinLeadTime,
+		slotDuration: rules.SlotDuration,
+		now:          time.Now,
+	}
+	if c.opens, c.closes, err = parseWorkingHours(rules.WorkingHours); err != nil {
+		return nil, err
+	}
+	if c.slotDuration <= 0 || c.slotDuration > c.closes-c.opens {
+		return nil, fmt.Errorf("slot duration %s does not fit the working hours", c.slotDuration)
+	}
+	for _, day := range rules.WorkingDays {
+		weekday, ok := weekdays[strings.ToLower(strings.TrimSpace(day))]
+		if !ok {
+			return nil, fmt.Errorf("unknown working day \"%s\"", day)
+		}
+		c.workingDays[weekday] = true
+	}
+	for _, holiday := range rules.Holidays {
+		date, err := time.Parse(DateLayout, strings.TrimSpace(holiday))
+		if err != nil {
+			return nil, fmt.Errorf("holiday \"%s\": %w", holiday, err)
+		}
+		c.holidays[date.Format(DateLayout)] = true
+	}
+	return c, nil
+}
+
+// Day parses a scheduled_for date, which must be a working day with time
+// left to deliver.
+func (c *Calendar) Day(date string) (time.Time, error) {
+	day, err := c.Parse(date)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if err = c.ensureWorkingDay(day); err != nil {
+		return time.Time{}, err
+	}
+	if c.at(day, c.closes).Before(c.earliest()) {
+		return time.Time{}, fmt.Errorf("%w: %s is too soon", ErrInvalidSchedule, date)
+	}
+	return day, nil
+}
+
+// Parse returns the midnight of the date in the calendar time zone.
+func (c *Calendar) Parse(date string) (time.Time, error) {
+	day, err := time.ParseInLocation(DateLayout, date, c.location)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: date \"%s\" must look like %s", ErrInvalidSchedule, date, DateLayout)
+	}
+	return day, nil
+}
+
+// Month returns the bounds [from, to) of the month in the calendar time zone.
+func (c *Calendar) Month(period string) (time.Time, time.Time, error) {
+	from, err := time.ParseInLocation(MonthLayout, period, c.location)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("%w: period \"%s\" must look like %s", ErrInvalidPeriod, period, MonthLayout)
+	}
+	return from, from.AddDate(0, 1, 0), nil
+}
+
+// Today returns the midnight of the current day in the calendar time zone.
+func (c *Calendar) Today() time.Time {
+	return c.dayOf(c.now())
+}
+
+// SlotOf returns the slot the window lies within.
+func (c *Calendar) SlotOf(window *valueobjects.TimeWindow) (*valueobjects.TimeWindow, error) {
+	if window.Start.Before(c.earliest()) {
+		return nil, fmt.Errorf("%w: window must start at least %s from now", ErrInvalidSchedule, c.minLeadTime)
+	}
+	day := c.dayOf(window.Start)
+	if err := c.ensureWorkingDay(day); err != nil {
+		return nil, err
+	}
+	for _, slot := range c.daySlots(day) {
+		if window.Within(slot.Start, slot.End) {
+			return &slot, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: window must lie within one slot of the working hours", ErrInvalidSchedule)
+}
+
+// Slots returns the bookable slots of the days from and to, inclusive.
+func (c *Calendar) Slots(from, to time.Time) []valueobjects.TimeWindow {
+	slots := make([]valueobjects.TimeWindow, 0)
+	earliest := c.earliest()
+	for day := c.dayOf(from); !day.After(c.dayOf(to)); day = day.AddDate(0, 0, 1) {
+		if c.ensureWorkingDay(day) != nil {
+			continue
+		}
+		for _, slot := range c.daySlots(day) {
+			if !slot.Start.Before(earliest) {
+				slots = append(slots, slot)
+			}
+		}
+	}
+	return slots
+}
+
+// Date formats the day of t in the calendar time zone.
+func (c *Calendar) Date(t time.Time) string {
+	return t.In(c.location).Format(DateLayout)
+}
+
+func (c *Calendar) ensureWorkingDay(day time.Time) error {
+	switch {
+	case c.holidays[day.Format(DateLayout)]:
+		return fmt.Errorf("%w: %s is a holiday", ErrInvalidSchedule, day.Format(DateLayout))
+	case !c.workingDays[day.Weekday()]:
+		return fmt.Errorf("%w: %s is not a working day", ErrInvalidSchedule, day.Format(DateLayout))
+	}
+	return nil
+}
+
+func (c *Calendar) daySlots(day time.Time) []valueobjects.TimeWindow {
+	slots := make([]valueobjects.TimeWindow, 0)
+	for offset := c.opens; offset+c.slotDuration <= c.closes; offset += c.slotDuration {
+		slots = append(slots, valueobjects.TimeWindow{Start: c.at(day, offset), End: c.at(day, offset+c.slotDuration)})
+	}
+	return slots
+}
+
+func (c *Calendar) dayOf(t time.Time) time.Time {
+	t = t.In(c.location)
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, c.location)
+}
+
+// at returns the time the clock shows offset on the day, which differs from
+// midnight plus offset on days of daylight saving changes.
+func (c *Calendar) at(day time.Time, offset time.Duration) time.Time {
+	return time.Date(day.Year(), day.Month(), day.Day(), 0, int(offset/time.Minute), 0, 0, c.location)
+}
+
+func (c *Calendar) earliest() time.Time {
+	return c.now().Add(c.minLeadTime)
+}
+
+func parseWorkingHours(hours string) (opens, closes time.Duration, err error) {
+	parts := strings.Split(hours, "-")
+	if len(parts) != 2 {
+		return 0, 0, fmt.Errorf("working hours \"%s\" must look like 09:00-21:00", hours)
+	}
+	if opens, err = parseClock(parts[0]); err != nil {
+		return 0, 0, err
+	}
+	if closes, err = parseClock(parts[1]); err != nil {
+		return 0, 0, err
+	}
+	if closes <= opens {
+		return 0, 0, fmt.Errorf("working hours \"%s\" must end after they start", hours)
+	}
+	return opens, closes, nil
+}
+
+func parseClock(clock string) (time.Duration, error) {
+	var h, m int
+	if _, err := fmt.Sscanf(strings.TrimSpace(clock), "%d:%d", &h, &m); err != nil || h < 0 || m < 0 || m > 59 || h*60+m > 24*60 {
+		return 0, fmt.Errorf("invalid time of day \"%s\"", clock)
+	}
+	return time.Duration(h)*time.Hour + time.Duration(m)*time.Minute, nil
+}
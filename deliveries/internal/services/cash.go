@@ -0,0 +1,163 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/zhanbolat18/parcel/deliveries/internal/entities"
+	"github.com/zhanbolat18/parcel/deliveries/internal/repositories"
+	"github.com/zhanbolat18/parcel/deliveries/internal/valueobjects"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidCollection = errors.New("invalid collection")
+	ErrInvalidCashAmount = errors.New("invalid cash amount")
+	ErrInsufficientCash  = errors.New("courier does not hold that much cash")
+)
+
+// ManageCash keeps the cash ledger of the couriers. Cash collected on
+// delivery adds to the cash a courier holds, handovers to the office subtract
+// from it and reconciliations bring it to the counted cash.
+type ManageCash struct {
+	ledgerRepo repositories.CashLedgerRepository
+	txManager  repositories.TxManager
+	currency   string
+}
+
+func NewManageCash(ledgerRepo repositories.CashLedgerRepository, txManager repositories.TxManager, currency string) *ManageCash {
+	return &ManageCash{ledgerRepo: ledgerRepo, txManager: txManager, currency: currency}
+}
+
+// Currency is the currency of cash on delivery amounts.
+func (c *ManageCash) Currency() string {
+	return c.currency
+}
+
+// Collect records the cash collected on the completed delivery. It is called
+// within the transaction of the completion, card payments are not recorded.
+func (c *ManageCash) Collect(ctx context.Context, delivery *entities.Delivery, courierId uint, actor *entities.User) error {
+	if delivery.Collection == nil || delivery.Collection.Method != valueobjects.Cash {
+		return nil
+	}
+	if err := c.lock(ctx, courierId); err != nil {
+		return err
+	}
+	deliveryId := delivery.Id
+	return c.append(ctx, &entities.CashEntry{
+		CourierId:  courierId,
+		Kind:       entities.CashCollection,
+		DeliveryId: &deliveryId,
+		Amount:     delivery.Collection.Amount,
+		ActorId:    actor.Id,
+	})
+}
+
+// Handover records the cash the courier handed over to the admin.
+func (c *ManageCash) Handover(ctx context.Context, courierId uint, admin *entities.User, amount valueobjects.Money, note string) (*entities.CashEntry, error) {
+	if amount <= 0 {
+		return nil, fmt.Errorf("%w: handover must be positive", ErrInvalidCashAmount)
+	}
+	entry := &entities.CashEntry{
+		CourierId: courierId,
+		Kind:      entities.CashHandover,
+		Amount:    -amount,
+		Note:      strings.TrimSpace(note),
+		ActorId:   admin.Id,
+	}
+	err := c.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		if err := c.lock(ctx, courierId); err != nil {
+			return err
+		}
+		balance, err := c.balance(ctx, courierId)
+		if err != nil {
+			return err
+		}
+		if amount > balance {
+			return fmt.Errorf("%w: holds %s", ErrInsufficientCash, balance)
+		}
+		return c.append(ctx, entry)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+// Reconcile records the cash counted at the courier. The entry brings the
+// balance to the counted cash, a non-zero amount is a discrepancy.
+func (c *ManageCash) Reconcile(ctx context.Context, courierId uint, admin *entities.User, counted valueobjects.Money, note string) (*entities.CashEntry, error) {
+	if counted < 0 {
+		return nil, fmt.Errorf("%w: counted cash can not be negative", ErrInvalidCashAmount)
+	}
+	entry := &entities.CashEntry{
+		CourierId: courierId,
+		Kind:      entities.CashReconciliation,
+		Note:      strings.TrimSpace(note),
+		ActorId:   admin.Id,
+	}
+	err := c.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		if err := c.lock(ctx, courierId); err != nil {
+			return err
+		}
+		balance, err := c.balance(ctx, courierId)
+		if err != nil {
+			return err
+		}
+		entry.Amount = counted - balance
+		return c.append(ctx, entry)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+func (c *ManageCash) Ledger(ctx context.Context, courierId uint) (*entities.CashLedger, error) {
+	entries, err := c.ledgerRepo.GetByCourier(ctx, courierId)
+	if err != nil {
+		return nil, fmt.Errorf("fetch cash ledger: %w", err)
+	}
+	ledger := &entities.CashLedger{CourierId: courierId, Currency: c.currency, Entries: entries}
+	if len(entries) > 0 {
+		ledger.Balance = entries[len(entries)-1].Balance
+	}
+	return ledger, nil
+}
+
+// Discrepancies returns the reconciliations that found other cash than the
+// ledger balance, of the courier when courierId is not zero.
+func (c *ManageCash) Discrepancies(ctx context.Context, courierId uint) ([]*entities.CashEntry, error) {
+	discrepancies, err := c.ledgerRepo.GetDiscrepancies(ctx, courierId)
+	if err != nil {
+		return nil, fmt.Errorf("fetch discrepancies: %w", err)
+	}
+	return discrepancies, nil
+}
+
+// lock serializes the ledger of the courier until the end of the
+// transaction, so that its balance does not change under the next entry.
+func (c *ManageCash) lock(ctx context.Context, courierId uint) error {
+	if err := c.ledgerRepo.LockCourier(ctx, courierId); err != nil {
+		return fmt.Errorf("lock cash ledger: %w", err)
+	}
+	return nil
+}
+
+func (c *ManageCash) balance(ctx context.Context, courierId uint) (valueobjects.Money, error) {
+	ledger, err := c.Ledger(ctx, courierId)
+	if err != nil {
+		return 0, err
+	}
+	return ledger.Balance, nil
+}
+
+func (c *ManageCash) append(ctx context.Context, entry *entities.CashEntry) error {
+	entry.Currency = c.currency
+	entry.CreatedAt = time.Now()
+	if err := c.ledgerRepo.Append(ctx, entry); err != nil {
+		return fmt.Errorf("append cash entry: %w", err)
+	}
+	return nil
+}
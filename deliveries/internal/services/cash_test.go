@@ -0,0 +1,118 @@
+package services_test
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zhanbolat18/parcel/deliveries/internal/entities"
+	"github.com/zhanbolat18/parcel/deliveries/internal/repositories"
+	"github.com/zhanbolat18/parcel/deliveries/internal/repositories/memory"
+	"github.com/zhanbolat18/parcel/deliveries/internal/services"
+	"github.com/zhanbolat18/parcel/deliveries/internal/valueobjects"
+	"testing"
+)
+
+func TestManageDelivery_CashOnDelivery(t *testing.T) {
+	asrt := assert.New(t)
+	deliveries := memory.NewDeliveryRepository()
+	cash := newCash()
+	deps := newDeps(t, deliveries, memory.NewUsersRepository(courier, other, recipient), newCouriers(t, deliveries, courier, other))
+	deps.Cash = cash
+	srv := services.NewManageDelivery(deps, policy)
+
+	zero := valueobjects.Money(0)
+	_, err := srv.Create(ctx, recipient, services.CreateDelivery{Destination: "Some Address 1, 14", COD: &zero})
+	asrt.True(errors.Is(err, services.ErrInvalidCashAmount))
+	cod := valueobjects.Money(125000)
+	d, err := srv.Create(ctx, recipient, services.CreateDelivery{Destination: "Some Address 1, 14", COD: &cod})
+	require.Nil(t, err)
+	asrt.Equal(&valueobjects.Price{Amount: 125000, Currency: "KZT"}, d.COD)
+	plain, err := srv.Create(ctx, recipient, services.CreateDelivery{Destination: "Some Address 2, 7"})
+	require.Nil(t, err)
+
+	for _, id := range []uint{d.Id, plain.Id} {
+		_, err = srv.AssignToCourier(ctx, id, courier.Id, 0)
+		require.Nil(t, err)
+	}
+	codes := map[uint]string{}
+	mine, err := srv.GetAllByRecipient(ctx, recipient, repositories.DeliveryFilter{})
+	require.Nil(t, err)
+	for _, delivery := range mine {
+		codes[delivery.Id] = delivery.HandoverCode
+	}
+
+	for _, collection := range []*valueobjects.Collection{
+		nil,
+		{Amount: 100000, Method: valueobjects.Cash},
+		{Amount: 125000, Method: "cheque"},
+	} {
+		_, err = srv.Complete(ctx, d.Id, courier, services.CompleteDelivery{HandoverCode: codes[d.Id], Collection: collection})
+		asrt.True(errors.Is(err, services.ErrInvalidCollection), "%v", collection)
+	}
+	_, err = srv.Complete(ctx, plain.Id, courier, services.CompleteDelivery{
+		HandoverCode: codes[plain.Id],
+		Collection:   &valueobjects.Collection{Amount: 100, Method: valueobjects.Cash},
+	})
+	asrt.True(errors.Is(err, services.ErrInvalidCollection), "nothing is collected without cash on delivery")
+
+	completed, err := srv.Complete(ctx, d.Id, courier, services.CompleteDelivery{
+		HandoverCode: codes[d.Id],
+		Collection:   &valueobjects.Collection{Amount: 125000, Method: valueobjects.Cash},
+	})
+	require.Nil(t, err)
+	asrt.Equal(valueobjects.Completed, completed.Status)
+	stored, err := deliveries.GetById(ctx, d.Id)
+	require.Nil(t, err)
+	asrt.Equal(valueobjects.Cash, stored.Collection.Method)
+
+	ledger, err := cash.Ledger(ctx, courier.Id)
+	require.Nil(t, err)
+	asrt.Equal(valueobjects.Money(125000), ledger.Balance)
+	require.Len(t, ledger.Entries, 1)
+	asrt.Equal(entities.CashCollection, ledger.Entries[0].Kind)
+	asrt.Equal(d.Id, *ledger.Entries[0].DeliveryId)
+}
+
+func TestManageCash(t *testing.T) {
+	asrt := assert.New(t)
+	cash := newCash()
+	admin := &entities.User{Id: 3, Role: "admin"}
+	for _, amount := range []valueobjects.Money{125000, 50000} {
+		d := &entities.Delivery{Id: 1, Collection: &valueobjects.Collection{Amount: amount, Method: valueobjects.Cash}}
+		require.Nil(t, cash.Collect(ctx, d, courier.Id, courier))
+	}
+	card := &entities.Delivery{Id: 2, Collection: &valueobjects.Collection{Amount: 70000, Method: valueobjects.Card}}
+	require.Nil(t, cash.Collect(ctx, card, courier.Id, courier))
+
+	_, err := cash.Handover(ctx, courier.Id, admin, 0, "")
+	asrt.True(errors.Is(err, services.ErrInvalidCashAmount))
+	_, err = cash.Handover(ctx, courier.Id, admin, 175001, "")
+	asrt.True(errors.Is(err, services.ErrInsufficientCash))
+	entry, err := cash.Handover(ctx, courier.Id, admin, 150000, " evening ")
+	require.Nil(t, err)
+	asrt.Equal(valueobjects.Money(-150000), entry.Amount)
+	asrt.Equal(valueobjects.Money(25000), entry.Balance)
+	asrt.Equal("evening", entry.Note)
+
+	matched, err := cash.Reconcile(ctx, courier.Id, admin, 25000, "")
+	require.Nil(t, err)
+	asrt.Zero(matched.Amount)
+	short, err := cash.Reconcile(ctx, courier.Id, admin, 20000, "short")
+	require.Nil(t, err)
+	asrt.Equal(valueobjects.Money(-5000), short.Amount)
+	_, err = cash.Reconcile(ctx, other.Id, admin, 1000, "")
+	require.Nil(t, err)
+
+	discrepancies, err := cash.Discrepancies(ctx, courier.Id)
+	require.Nil(t, err)
+	require.Len(t, discrepancies, 1)
+	asrt.Equal(short.Id, discrepancies[0].Id)
+	discrepancies, err = cash.Discrepancies(ctx, 0)
+	require.Nil(t, err)
+	asrt.Len(discrepancies, 2)
+
+	ledger, err := cash.Ledger(ctx, courier.Id)
+	require.Nil(t, err)
+	asrt.Equal(valueobjects.Money(20000), ledger.Balance)
+	asrt.Len(ledger.Entries, 5)
+}
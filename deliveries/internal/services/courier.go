@@ -0,0 +1,160 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/zhanbolat18/parcel/deliveries/internal/entities"
+	"github.com/zhanbolat18/parcel/deliveries/internal/repositories"
+	"github.com/zhanbolat18/parcel/deliveries/internal/valueobjects"
+	"time"
+)
+
+var (
+	ErrCourierUnavailable = errors.New("courier is not available")
+	ErrCourierAtCapacity  = errors.New("courier is at capacity")
+)
+
+// AssignmentGuard decides whether a courier may take the delivery. It is
+// called within the assignment transaction.
+type AssignmentGuard interface {
+	EnsureAssignable(ctx context.Context, courierId uint, delivery *entities.Delivery) error
+}
+
+type guards []AssignmentGuard
+
+// Guards combines guards, the first rejection wins.
+func Guards(g ...AssignmentGuard) AssignmentGuard {
+	return guards(g)
+}
+
+func (g guards) EnsureAssignable(ctx context.Context, courierId uint, delivery *entities.Delivery) error {
+	for _, guard := range g {
+		if err := guard.EnsureAssignable(ctx, courierId, delivery); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type ManageCourier struct {
+	availabilityRepo    repositories.AvailabilityRepository
+	deliveryRepo        repositories.DeliveriesRepository
+	maxActiveDeliveries uint
+}
+
+func NewManageCourier(
+	availabilityRepo repositories.AvailabilityRepository,
+	deliveryRepo repositories.DeliveriesRepository,
+	maxActiveDeliveries uint,
+) *ManageCourier {
+	if maxActiveDeliveries == 0 {
+		panic("max active deliveries must be set")
+	}
+	return &ManageCourier{
+		availabilityRepo:    availabilityRepo,
+		deliveryRepo:        deliveryRepo,
+		maxActiveDeliveries: maxActiveDeliveries,
+	}
+}
+
+// Availability returns the courier availability with the default capacity
+// applied. Couriers who never reported one are offline.
+func (m *ManageCourier) Availability(ctx context.Context, courierId uint) (*entities.CourierAvailability, error) {
+	a, err := m.get(ctx, courierId)
+	if err != nil {
+		return nil, err
+	}
+	return m.withDefaults(a), nil
+}
+
+func (m *ManageCourier) Availabilities(ctx context.Context, courierIds ...uint) (map[uint]*entities.CourierAvailability, error) {
+	found, err := m.availabilityRepo.GetByCouriers(ctx, courierIds...)
+	if err != nil {
+		return nil, fmt.Errorf("get couriers availability: %w", err)
+	}
+	availabilities := make(map[uint]*entities.CourierAvailability, len(courierIds))
+	for _, id := range courierIds {
+		a, ok := found[id]
+		if !ok {
+			a = m.offline(id)
+		}
+		availabilities[id] = m.withDefaults(a)
+	}
+	return availabilities, nil
+}
+
+func (m *ManageCourier) SetStatus(
+	ctx context.Context,
+	courierId uint,
+	status valueobjects.Availability,
+) (*entities.CourierAvailability, error) {
+	return m.update(ctx, courierId, func(a *entities.CourierAvailability) {
+		a.Status = status
+	})
+}
+
+func (m *ManageCourier) SetMaxActiveDeliveries(ctx context.Context, courierId, max uint) (*entities.CourierAvailability, error) {
+	return m.update(ctx, courierId, func(a *entities.CourierAvailability) {
+		a.MaxActiveDeliveries = max
+	})
+}
+
+func (m *ManageCourier) EnsureAssignable(ctx context.Context, courierId uint, _ *entities.Delivery) error {
+	a, err := m.Availability(ctx, courierId)
+	if err != nil {
+		return err
+	}
+	if a.Status != valueobjects.Online {
+		return fmt.Errorf("%w: courier \"%d\" is %s", ErrCourierUnavailable, courierId, a.Status)
+	}
+	active, err := m.deliveryRepo.CountByCourier(ctx, courierId, valueobjects.Delivers)
+	if err != nil {
+		return fmt.Errorf("count active deliveries: %w", err)
+	}
+	if uint(active) >= a.MaxActiveDeliveries {
+		return fmt.Errorf("%w: courier \"%d\" already delivers %d of %d deliveries",
+			ErrCourierAtCapacity, courierId, active, a.MaxActiveDeliveries)
+	}
+	return nil
+}
+
+func (m *ManageCourier) update(
+	ctx context.Context,
+	courierId uint,
+	change func(a *entities.CourierAvailability),
+) (*entities.CourierAvailability, error) {
+	a, err := m.get(ctx, courierId)
+	if err != nil {
+		return nil, err
+	}
+	change(a)
+	a.UpdatedAt = time.Now()
+	if err = m.availabilityRepo.Save(ctx, a); err != nil {
+		return nil, fmt.Errorf("save courier availability: %w", err)
+	}
+	return m.withDefaults(a), nil
+}
+
+func (m *ManageCourier) get(ctx context.Context, courierId uint) (*entities.CourierAvailability, error) {
+	a, err := m.availabilityRepo.GetByCourier(ctx, courierId)
+	if errors.Is(err, repositories.ErrAvailabilityNotFound) {
+		return m.offline(courierId), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get courier \"%d\" availability: %w", courierId, err)
+	}
+	return a, nil
+}
+
+func (m *ManageCourier) offline(courierId uint) *entities.CourierAvailability {
+	return &entities.CourierAvailability{CourierId: courierId, Status: valueobjects.Offline}
+}
+
+func (m *ManageCourier) withDefaults(a *entities.CourierAvailability) *entities.CourierAvailability {
+	c := *a
+	if c.MaxActiveDeliveries == 0 {
+		c.MaxActiveDeliveries = m.maxActiveDeliveries
+	}
+	return &c
+}
@@ -0,0 +1,57 @@
+package services_test
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zhanbolat18/parcel/deliveries/internal/entities"
+	"github.com/zhanbolat18/parcel/deliveries/internal/repositories/memory"
+	"github.com/zhanbolat18/parcel/deliveries/internal/services"
+	"github.com/zhanbolat18/parcel/deliveries/internal/valueobjects"
+	"testing"
+)
+
+func TestManageCourier_Availability(t *testing.T) {
+	asrt := assert.New(t)
+	couriers := services.NewManageCourier(memory.NewAvailabilityRepository(), memory.NewDeliveryRepository(), 3)
+
+	a, err := couriers.Availability(ctx, courier.Id)
+	require.Nil(t, err)
+	asrt.Equal(valueobjects.Offline, a.Status)
+	asrt.Equal(uint(3), a.MaxActiveDeliveries)
+
+	_, err = couriers.SetMaxActiveDeliveries(ctx, courier.Id, 1)
+	require.Nil(t, err)
+	a, err = couriers.SetStatus(ctx, courier.Id, valueobjects.OnBreak)
+	require.Nil(t, err)
+	asrt.Equal(valueobjects.OnBreak, a.Status)
+	asrt.Equal(uint(1), a.MaxActiveDeliveries)
+}
+
+func TestManageDelivery_AssignRespectsAvailability(t *testing.T) {
+	asrt := assert.New(t)
+	deliveries := memory.NewDeliveryRepository()
+	for i := 0; i < 3; i++ {
+		require.Nil(t, deliveries.Store(ctx, entities.NewDelivery("Some Address 1, 14", nil, recipient)))
+	}
+	couriers := services.NewManageCourier(memory.NewAvailabilityRepository(), deliveries, 2)
+	srv := services.NewManageDelivery(newDeps(t, deliveries, memory.NewUsersRepository(courier), couriers), policy)
+
+	_, err := srv.AssignToCourier(ctx, 1, courier.Id, 0)
+	asrt.True(errors.Is(err, services.ErrCourierUnavailable))
+	asrt.Contains(err.Error(), "offline")
+
+	_, err = couriers.SetStatus(ctx, courier.Id, valueobjects.Online)
+	require.Nil(t, err)
+	_, err = srv.AssignToCourier(ctx, 1, courier.Id, 0)
+	asrt.Nil(err)
+	_, err = srv.AssignToCourier(ctx, 2, courier.Id, 0)
+	asrt.Nil(err)
+	_, err = srv.AssignToCourier(ctx, 3, courier.Id, 0)
+	asrt.True(errors.Is(err, services.ErrCourierAtCapacity))
+	asrt.Contains(err.Error(), "2 of 2")
+
+	// reassigning to the same courier does not count twice
+	_, err = srv.AssignToCourier(ctx, 1, courier.Id, 0)
+	asrt.Nil(err)
+}
@@ -7,29 +7,152 @@ import (
 	"github.com/zhanbolat18/parcel/deliveries/internal/entities"
 	"github.com/zhanbolat18/parcel/deliveries/internal/repositories"
 	"github.com/zhanbolat18/parcel/deliveries/internal/valueobjects"
+	"github.com/zhanbolat18/parcel/libs/metrics"
+	"strings"
 	"time"
 )
 
+const (
+	EventCreated   = "created"
+	EventAssigned  = "assigned"
+	EventCompleted = "completed"
+	// EventCanceled is registered ahead of a cancel flow so dashboards can
+	// reference it, it stays at zero until then.
+	EventCanceled = "canceled"
+	// EventHandoverLocked asks admins to look into a delivery whose handover
+	// attempts are exhausted.
+	EventHandoverLocked = "handover_locked"
+	EventAttemptFailed  = "attempt_failed"
+	EventRescheduled    = "rescheduled"
+	EventReturned       = "returned_to_sender"
+	EventReturnCreated  = "return_created"
+)
+
+// ErrPreconditionFailed is returned when the caller expects a delivery version
+// other than the stored one.
+var ErrPreconditionFailed = errors.New("delivery version does not match")
+
+var ErrOverrideReason = errors.New("override reason must be set")
+
 type ManageDelivery struct {
 	deliveryRepo repositories.DeliveriesRepository
 	usersRepo    repositories.UsersRepository
+	proofsRepo   repositories.ProofsRepository
+	auditRepo    repositories.AuditRepository
+	attemptsRepo repositories.AttemptsRepository
+	txManager    repositories.TxManager
+	guard        AssignmentGuard
+	handover     *HandoverCodes
+	schedule     *ManageSchedule
+	pricing      *Pricing
+	cash         *ManageCash
+	maxAttempts  uint
+	returns      ReturnPolicy
+	sla          SLAPolicy
+	events       metrics.Counter
+	publisher    Publisher
 }
 
-func NewManageDelivery(deliveryRepo repositories.DeliveriesRepository, usersRepo repositories.UsersRepository) *ManageDelivery {
-	return &ManageDelivery{deliveryRepo: deliveryRepo, usersRepo: usersRepo}
+// DeliveryDeps are the collaborators of ManageDelivery, every one is required.
+type DeliveryDeps struct {
+	Deliveries repositories.DeliveriesRepository
+	Users      repositories.UsersRepository
+	Proofs     repositories.ProofsRepository
+	Audit      repositories.AuditRepository
+	Attempts   repositories.AttemptsRepository
+	TxManager  repositories.TxManager
+	Guard      AssignmentGuard
+	Handover   *HandoverCodes
+	Schedule   *ManageSchedule
+	Pricing    *Pricing
+	Cash       *ManageCash
+	Events     metrics.Counter
+	Publisher  Publisher
 }
 
-func (m *ManageDelivery) Create(ctx context.Context, recipient *entities.User, destination string) (*entities.Delivery, error) {
-	delivery := entities.NewDelivery(destination, recipient)
-	err := m.deliveryRepo.Store(ctx, delivery)
+// DeliveryPolicy configures the lifecycle of deliveries. A delivery returns to
+// its sender after MaxAttempts failed attempts.
+type DeliveryPolicy struct {
+	MaxAttempts uint
+	Returns     ReturnPolicy
+	SLA         SLAPolicy
+}
+
+func NewManageDelivery(deps DeliveryDeps, policy DeliveryPolicy) *ManageDelivery {
+	return &ManageDelivery{
+		deliveryRepo: deps.Deliveries,
+		usersRepo:    deps.Users,
+		proofsRepo:   deps.Proofs,
+		auditRepo:    deps.Audit,
+		attemptsRepo: deps.Attempts,
+		txManager:    deps.TxManager,
+		guard:        deps.Guard,
+		handover:     deps.Handover,
+		schedule:     deps.Schedule,
+		pricing:      deps.Pricing,
+		cash:         deps.Cash,
+		maxAttempts:  policy.MaxAttempts,
+		returns:      policy.Returns,
+		sla:          policy.SLA,
+		events:       deps.Events,
+		publisher:    deps.Publisher,
+	}
+}
+
+// CreateDelivery holds what a recipient provides for a new delivery.
+type CreateDelivery struct {
+	Origin         string
+	OriginLocation *valueobjects.Location
+	Destination    string
+	Location       *valueobjects.Location
+	// ServiceLevel defaults to the standard one.
+	ServiceLevel valueobjects.ServiceLevel
+	// QuoteId optionally locks the quoted price onto the delivery.
+	QuoteId string
+	// COD is the optional amount the courier collects on handover.
+	COD *valueobjects.Money
+	Schedule
+}
+
+// Create stores the delivery, booking the slot of its window.
+func (m *ManageDelivery) Create(ctx context.Context, recipient *entities.User, req CreateDelivery) (*entities.Delivery, error) {
+	delivery := entities.NewDelivery(req.Destination, req.Location, recipient)
+	delivery.Origin, delivery.OriginLocation = req.Origin, req.OriginLocation
+	if req.QuoteId != "" {
+		if err := m.pricing.Lock(req.QuoteId, recipient, delivery, &req); err != nil {
+			return nil, err
+		}
+	}
+	if req.ServiceLevel != "" {
+		delivery.ServiceLevel = req.ServiceLevel
+	}
+	if req.COD != nil {
+		if *req.COD <= 0 {
+			return nil, fmt.Errorf("%w: cash on delivery must be positive", ErrInvalidCashAmount)
+		}
+		delivery.COD = &valueobjects.Price{Amount: *req.COD, Currency: m.cash.Currency()}
+	}
+	if err := m.setDueAt(delivery); err != nil {
+		return nil, err
+	}
+	err := m.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		if err := m.schedule.Apply(ctx, delivery, req.Schedule); err != nil {
+			return err
+		}
+		if err := m.deliveryRepo.Store(ctx, delivery); err != nil {
+			return fmt.Errorf("store delivery %w", err)
+		}
+		return nil
+	})
 	if err != nil {
-		return nil, fmt.Errorf("store delivery %w", err)
+		return nil, err
 	}
+	m.notify(EventCreated, delivery)
 	return delivery, nil
 }
 
-func (m *ManageDelivery) GetAll(ctx context.Context) ([]*entities.Delivery, error) {
-	deliveries, err := m.deliveryRepo.GetAll(ctx)
+func (m *ManageDelivery) GetAll(ctx context.Context, filter repositories.DeliveryFilter) ([]*entities.Delivery, error) {
+	deliveries, err := m.deliveryRepo.GetAll(ctx, filter)
 	if err != nil {
 		return nil, fmt.Errorf("fetch all deliveries %w", err)
 	}
@@ -44,61 +167,298 @@ func (m *ManageDelivery) GetOne(ctx context.Context, id uint) (*entities.Deliver
 	return delivery, nil
 }
 
-func (m *ManageDelivery) GetAllByCourier(ctx context.Context, courier *entities.User) ([]*entities.Delivery, error) {
-	deliveries, err := m.deliveryRepo.GetAllByCourier(ctx, courier.Id)
+func (m *ManageDelivery) GetAllByCourier(
+	ctx context.Context,
+	courier *entities.User,
+	filter repositories.DeliveryFilter,
+) ([]*entities.Delivery, error) {
+	deliveries, err := m.deliveryRepo.GetAllByCourier(ctx, courier.Id, filter)
 	if err != nil {
 		return nil, fmt.Errorf("fetch all deliveries %w", err)
 	}
 	return deliveries, nil
 }
 
-func (m *ManageDelivery) AssignToCourier(ctx context.Context, deliveryId, courierId uint) (*entities.Delivery, error) {
+// GetAllByRecipient returns the deliveries of the tenant of the recipient
+// together with the handover codes of those being delivered.
+func (m *ManageDelivery) GetAllByRecipient(
+	ctx context.Context,
+	recipient *entities.User,
+	filter repositories.DeliveryFilter,
+) ([]*entities.Delivery, error) {
+	deliveries, err := m.deliveryRepo.GetAll(repositories.WithTenant(ctx, entities.TenantOf(recipient)), filter)
+	if err != nil {
+		return nil, fmt.Errorf("fetch all deliveries %w", err)
+	}
+	for _, delivery := range deliveries {
+		m.RevealHandoverCode(delivery)
+	}
+	return deliveries, nil
+}
+
+// RevealHandoverCode fills in the handover code of a delivery being
+// delivered, it must only be called for responses to the recipient.
+func (m *ManageDelivery) RevealHandoverCode(delivery *entities.Delivery) {
+	if delivery.Status == valueobjects.Delivers {
+		delivery.HandoverCode = m.handover.Code(delivery)
+	}
+}
+
+// AssignToCourier assigns the delivery to the courier. A non-zero
+// expectedVersion must match the stored delivery version.
+func (m *ManageDelivery) AssignToCourier(ctx context.Context, deliveryId, courierId, expectedVersion uint) (*entities.Delivery, error) {
 	courier, err := m.usersRepo.GetCourier(ctx, courierId)
 	if err != nil {
 		return nil, fmt.Errorf("get courier by id \"%d\": %w", courierId, err)
 	}
-	delivery, err := m.deliveryRepo.GetById(ctx, deliveryId)
+	// the courier is fetched over http before the transaction so that the row
+	// lock is not held during the request
+	var delivery *entities.Delivery
+	err = m.txManager.WithinTx(ctx, func(ctx context.Context) (err error) {
+		delivery, err = m.deliveryRepo.GetById(ctx, deliveryId)
+		if err != nil {
+			return fmt.Errorf("get delivery by id \"%d\": %w", deliveryId, err)
+		}
+		if !m.versionMatches(delivery, expectedVersion) {
+			return ErrPreconditionFailed
+		}
+		if !m.isCourierAssignable(delivery) {
+			return errors.New("delivery is not assignable")
+		}
+		// a reassignment to the same courier does not add to their load
+		if delivery.CourierId == nil || *delivery.CourierId != courier.Id {
+			if err = m.guard.EnsureAssignable(ctx, courier.Id, delivery); err != nil {
+				return err
+			}
+		}
+		// a reassigned delivery keeps the code the recipient may already know
+		if delivery.Handover == nil {
+			if err = m.handover.Issue(delivery); err != nil {
+				return err
+			}
+		}
+		delivery.Status = valueobjects.Delivers
+		delivery.UpdatedAt = time.Now()
+		delivery.CourierId = &courier.Id
+		if err = m.deliveryRepo.Update(ctx, delivery); err != nil {
+			return fmt.Errorf("assign delivery: %w", err)
+		}
+		return nil
+	})
 	if err != nil {
-		return nil, fmt.Errorf("get delivery by id \"%d\": %w", deliveryId, err)
+		return nil, err
 	}
-	if !m.isCourierAssignable(delivery) {
-		return nil, errors.New("delivery is not assignable")
+	m.notify(EventAssigned, delivery)
+	return delivery, nil
+}
+
+// CompleteDelivery holds what the courier provides on completion. A non-zero
+// ExpectedVersion must match the stored delivery version, Proof is optional
+// and Collection is required for cash on delivery only.
+type CompleteDelivery struct {
+	ExpectedVersion uint
+	HandoverCode    string
+	Proof           *entities.Proof
+	Collection      *valueobjects.Collection
+}
+
+// Complete marks the delivery as completed by the courier, provided the
+// handover code is right, and saves the proof of delivery along. Wrong codes
+// are counted and recorded in the audit trail.
+func (m *ManageDelivery) Complete(ctx context.Context, deliveryId uint, courier *entities.User, req CompleteDelivery) (*entities.Delivery, error) {
+	var delivery *entities.Delivery
+	var rejected error
+	locked := false
+	err := m.txManager.WithinTx(ctx, func(ctx context.Context) (err error) {
+		delivery, err = m.deliveryRepo.GetById(ctx, deliveryId)
+		if err != nil {
+			return fmt.Errorf("get delivery by id \"%d\": %w", deliveryId, err)
+		}
+		if !m.versionMatches(delivery, req.ExpectedVersion) {
+			return ErrPreconditionFailed
+		}
+		if delivery.CourierId != nil && *delivery.CourierId != courier.Id {
+			return fmt.Errorf("forbidden")
+		}
+		if !m.isCompletable(delivery) {
+			return errors.New("delivery is not completable")
+		}
+		wasLocked := delivery.Handover != nil && delivery.Handover.Locked
+		if rejected = m.handover.Verify(delivery, req.HandoverCode); rejected != nil {
+			if wasLocked {
+				return nil
+			}
+			// the failed attempt is committed, the rejection is returned after
+			locked = delivery.Handover.Locked
+			return m.recordHandoverFailure(ctx, delivery, courier)
+		}
+		if err = m.collect(delivery, req.Collection); err != nil {
+			return err
+		}
+		delivery.Status = valueobjects.Completed
+		delivery.UpdatedAt = time.Now()
+		delivery.CompletedAt = &delivery.UpdatedAt
+		if err = m.deliveryRepo.Update(ctx, delivery); err != nil {
+			return fmt.Errorf("update delivery: %w", err)
+		}
+		if err = m.cash.Collect(ctx, delivery, courier.Id, courier); err != nil {
+			return err
+		}
+		return m.storeProof(ctx, delivery, req.Proof)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if rejected != nil {
+		if locked {
+			m.notify(EventHandoverLocked, delivery)
+		}
+		return nil, rejected
+	}
+	m.notify(EventCompleted, delivery)
+	return delivery, nil
+}
+
+// CompleteByAdmin completes a delivery without the handover code, for
+// example once the handover is locked. The override is recorded in the audit
+// trail with its reason. The collection of a cash on delivery is credited to
+// the assigned courier.
+func (m *ManageDelivery) CompleteByAdmin(
+	ctx context.Context,
+	deliveryId uint,
+	admin *entities.User,
+	reason string,
+	collection *valueobjects.Collection,
+	expectedVersion uint,
+) (*entities.Delivery, error) {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return nil, ErrOverrideReason
 	}
-	delivery.Status = valueobjects.Delivers
-	delivery.UpdatedAt = time.Now()
-	delivery.CourierId = &courier.Id
-	err = m.deliveryRepo.Update(ctx, delivery)
+	var delivery *entities.Delivery
+	err := m.txManager.WithinTx(ctx, func(ctx context.Context) (err error) {
+		delivery, err = m.deliveryRepo.GetById(ctx, deliveryId)
+		if err != nil {
+			return fmt.Errorf("get delivery by id \"%d\": %w", deliveryId, err)
+		}
+		if !m.versionMatches(delivery, expectedVersion) {
+			return ErrPreconditionFailed
+		}
+		if !m.isCompletable(delivery) {
+			return errors.New("delivery is not completable")
+		}
+		if err = m.collect(delivery, collection); err != nil {
+			return err
+		}
+		delivery.Status = valueobjects.Completed
+		delivery.UpdatedAt = time.Now()
+		delivery.CompletedAt = &delivery.UpdatedAt
+		if err = m.deliveryRepo.Update(ctx, delivery); err != nil {
+			return fmt.Errorf("update delivery: %w", err)
+		}
+		if delivery.CourierId != nil {
+			if err = m.cash.Collect(ctx, delivery, *delivery.CourierId, admin); err != nil {
+				return err
+			}
+		}
+		return m.audit(ctx, delivery, admin, entities.AuditHandoverOverride, reason)
+	})
 	if err != nil {
-		return nil, fmt.Errorf("assign delivery: %w", err)
+		return nil, err
 	}
+	m.notify(EventCompleted, delivery)
 	return delivery, nil
 }
 
-func (m *ManageDelivery) Complete(ctx context.Context, deliveryId uint, courier *entities.User) (*entities.Delivery, error) {
-	delivery, err := m.deliveryRepo.GetById(ctx, deliveryId)
+// GetAudit returns the audit trail of the delivery.
+func (m *ManageDelivery) GetAudit(ctx context.Context, deliveryId uint) ([]*entities.AuditEntry, error) {
+	if _, err := m.GetOne(ctx, deliveryId); err != nil {
+		return nil, err
+	}
+	entries, err := m.auditRepo.GetByDelivery(ctx, deliveryId)
 	if err != nil {
-		return nil, fmt.Errorf("get delivery by id \"%d\": %w", deliveryId, err)
+		return nil, fmt.Errorf("fetch audit trail: %w", err)
+	}
+	return entries, nil
+}
+
+func (m *ManageDelivery) recordHandoverFailure(ctx context.Context, delivery *entities.Delivery, courier *entities.User) error {
+	if err := m.deliveryRepo.Update(ctx, delivery); err != nil {
+		return fmt.Errorf("update delivery: %w", err)
+	}
+	action := entities.AuditHandoverFailed
+	if delivery.Handover.Locked {
+		action = entities.AuditHandoverLocked
+	}
+	return m.audit(ctx, delivery, courier, action, fmt.Sprintf("attempt %d", delivery.Handover.Attempts))
+}
+
+// collect puts the collection onto the delivery. A cash on delivery has to be
+// collected in full, other deliveries collect nothing.
+func (m *ManageDelivery) collect(delivery *entities.Delivery, collection *valueobjects.Collection) error {
+	switch {
+	case delivery.COD == nil && collection == nil:
+		return nil
+	case delivery.COD == nil:
+		return fmt.Errorf("%w: delivery is not cash on delivery", ErrInvalidCollection)
+	case collection == nil:
+		return fmt.Errorf("%w: cash on delivery of %s must be collected", ErrInvalidCollection, delivery.COD.Amount)
+	case !collection.Method.Valid():
+		return fmt.Errorf("%w: unknown payment method \"%s\"", ErrInvalidCollection, collection.Method)
+	case collection.Amount != delivery.COD.Amount:
+		return fmt.Errorf("%w: collected %s instead of %s", ErrInvalidCollection, collection.Amount, delivery.COD.Amount)
+	}
+	delivery.Collection = collection
+	return nil
+}
+
+func (m *ManageDelivery) storeProof(ctx context.Context, delivery *entities.Delivery, proof *entities.Proof) error {
+	if proof == nil {
+		return nil
 	}
-	if delivery.CourierId != nil && *delivery.CourierId != courier.Id {
-		return nil, fmt.Errorf("forbidden")
+	proof.DeliveryId = delivery.Id
+	proof.CreatedAt = delivery.UpdatedAt
+	if err := m.proofsRepo.Store(ctx, proof); err != nil {
+		return fmt.Errorf("store proof of delivery: %w", err)
+	}
+	return nil
+}
+
+func (m *ManageDelivery) audit(ctx context.Context, delivery *entities.Delivery, actor *entities.User, action, details string) error {
+	entry := &entities.AuditEntry{
+		DeliveryId: delivery.Id,
+		ActorId:    &actor.Id,
+		Action:     action,
+		Details:    details,
+		CreatedAt:  time.Now(),
 	}
-	if !m.isCompletable(delivery) {
-		return nil, errors.New("delivery is not completable")
+	if err := m.auditRepo.Record(ctx, entry); err != nil {
+		return fmt.Errorf("record %s: %w", action, err)
 	}
-	delivery.Status = valueobjects.Completed
-	delivery.UpdatedAt = time.Now()
-	err = m.deliveryRepo.Update(ctx, delivery)
+	return nil
+}
+
+// setDueAt sets the SLA deadline from the service level and creation time.
+func (m *ManageDelivery) setDueAt(delivery *entities.Delivery) error {
+	dueAt, err := m.sla.DueAt(delivery.ServiceLevel, delivery.CreatedAt)
 	if err != nil {
-		return nil, fmt.Errorf("update delivery: %w", err)
+		return err
 	}
-	return delivery, nil
+	delivery.DueAt = dueAt
+	return nil
+}
+
+// notify is called once the change is committed.
+func (m *ManageDelivery) notify(event string, delivery *entities.Delivery) {
+	m.events.Inc(event)
+	m.publisher.Publish(event, delivery)
 }
 
 func (m *ManageDelivery) isCourierAssignable(delivery *entities.Delivery) bool {
 	switch delivery.Status {
 	case valueobjects.Created, valueobjects.Delivers:
 		return true
-	case valueobjects.Completed, valueobjects.Canceled:
+	case valueobjects.Completed, valueobjects.Canceled, valueobjects.AttemptFailed, valueobjects.ReturnedToSender:
 		return false
 	default:
 		return false
@@ -108,3 +468,7 @@ func (m *ManageDelivery) isCourierAssignable(delivery *entities.Delivery) bool {
 func (m *ManageDelivery) isCompletable(delivery *entities.Delivery) bool {
 	return delivery.Status == valueobjects.Delivers
 }
+
+func (m *ManageDelivery) versionMatches(delivery *entities.Delivery, expectedVersion uint) bool {
+	return expectedVersion == 0 || delivery.Version == expectedVersion
+}
@@ -4,145 +4,258 @@ import (
 	"context"
 	"errors"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/zhanbolat18/parcel/deliveries/internal/entities"
+	"github.com/zhanbolat18/parcel/deliveries/internal/repositories"
+	"github.com/zhanbolat18/parcel/deliveries/internal/repositories/memory"
 	"github.com/zhanbolat18/parcel/deliveries/internal/services"
 	"github.com/zhanbolat18/parcel/deliveries/internal/valueobjects"
+	"github.com/zhanbolat18/parcel/libs/metrics"
+	"strconv"
+	"sync"
 	"testing"
 	"time"
 )
 
-type mockRepos struct {
-	deliveries map[uint]*entities.Delivery
-	users      map[uint]*entities.User
+var ctx = context.Background()
+
+var (
+	courier   = &entities.User{Id: 1, Email: "courier@mail.com", Role: "courier"}
+	other     = &entities.User{Id: 2, Email: "other@mail.com", Role: "courier"}
+	recipient = &entities.User{Id: 4, Email: "user@mail.com", Role: "user"}
+)
+
+var returns = services.ReturnPolicy{Window: 24 * time.Hour, Address: "Warehouse 1"}
+
+var slas = services.SLAPolicy{
+	Deadlines: map[valueobjects.ServiceLevel]time.Duration{
+		valueobjects.Standard: 72 * time.Hour,
+		valueobjects.Express:  24 * time.Hour,
+		valueobjects.SameDay:  4 * time.Hour,
+	},
+	AtRisk: time.Hour,
 }
 
-func (m *mockRepos) GetCourier(id uint) (*entities.User, error) {
-	if u, ok := m.users[id]; ok {
-		if u.Role == "courier" {
-			return u, nil
+func newService(t *testing.T, statuses ...valueobjects.Status) (*services.ManageDelivery, repositories.DeliveriesRepository) {
+	deliveries := memory.NewDeliveryRepository()
+	for _, status := range statuses {
+		d := entities.NewDelivery("Some Address 1, 14", nil, recipient)
+		require.Nil(t, deliveries.Store(ctx, d))
+		if status == valueobjects.Created {
+			continue
 		}
-		return nil, errors.New("user is not courier")
+		d.Status = status
+		if status != valueobjects.Canceled {
+			d.CourierId = &courier.Id
+		}
+		require.Nil(t, deliveries.Update(ctx, d))
 	}
-	return nil, errors.New("courier not found")
+	users := memory.NewUsersRepository(courier, other, recipient)
+	couriers := newCouriers(t, deliveries, courier, other)
+	return services.NewManageDelivery(newDeps(t, deliveries, users, couriers), policy), deliveries
 }
 
-func (m *mockRepos) GetRecipient(id uint) (*entities.User, error) {
-	if u, ok := m.users[id]; ok {
-		if u.Role == "user" {
-			return u, nil
-		}
-		return nil, errors.New("user is not courier")
+var policy = services.DeliveryPolicy{MaxAttempts: 3, Returns: returns, SLA: slas}
+
+// newDeps returns in-memory collaborators of ManageDelivery, tests replace the
+// ones they look into.
+func newDeps(t *testing.T, deliveries repositories.DeliveriesRepository, users repositories.UsersRepository, guard services.AssignmentGuard) services.DeliveryDeps {
+	return services.DeliveryDeps{
+		Deliveries: deliveries,
+		Users:      users,
+		Proofs:     memory.NewProofsRepository(),
+		Audit:      memory.NewAuditRepository(),
+		Attempts:   memory.NewAttemptsRepository(),
+		TxManager:  memory.NewTxManager(),
+		Guard:      guard,
+		Handover:   newHandover(t),
+		Schedule:   newSchedule(t),
+		Pricing:    newPricing(t),
+		Cash:       newCash(),
+		Events:     metrics.NopCounter(),
+		Publisher:  services.NewBroker(0),
 	}
-	return nil, errors.New("courier not found")
 }
 
-func (m *mockRepos) GetById(_ context.Context, id uint) (*entities.Delivery, error) {
-	return m.deliveries[id], nil
+func newHandover(t *testing.T) *services.HandoverCodes {
+	handover, err := services.NewHandoverCodes("secret", 6, 3)
+	require.Nil(t, err)
+	return handover
 }
 
-func (m *mockRepos) GetAll(_ context.Context) ([]entities.Delivery, error) {
-	return nil, nil
+func newSchedule(t *testing.T) *services.ManageSchedule {
+	schedule, err := services.NewManageSchedule(newCalendar(t, services.CalendarRules{}), memory.NewSlotBookingsRepository(),
+		[]string{"center"}, 2)
+	require.Nil(t, err)
+	return schedule
 }
 
-func (m *mockRepos) AssignToCourier(_ context.Context, d *entities.Delivery, u *entities.User) error {
-	if dd, ok := m.deliveries[d.Id]; ok {
-		dd.CourierId = u
-	}
-	return nil
+func newPricing(t *testing.T) *services.Pricing {
+	pricing, err := services.NewPricing(services.PricingRules{
+		Currency: "KZT",
+		Rates: map[valueobjects.ServiceLevel]services.Rate{
+			valueobjects.Standard: {Base: 100000, PerKm: 10000, PerKg: 20000, Zone: 150000},
+			valueobjects.Express:  {Base: 200000, PerKm: 15000, PerKg: 25000, Zone: 250000},
+		},
+		Surcharges:        services.Surcharges{Fragile: 50000, Oversize: 150000, Remote: 100000},
+		RemoteZones:       []string{"north"},
+		OversizeCm:        120,
+		OversizeKg:        30,
+		VolumetricDivisor: 5000,
+	}, "secret", time.Hour)
+	require.Nil(t, err)
+	return pricing
+}
+
+func newCash() *services.ManageCash {
+	return services.NewManageCash(memory.NewCashLedgerRepository(), memory.NewTxManager(), "KZT")
 }
 
-func (m *mockRepos) Store(_ context.Context, _ *entities.Delivery) error { return nil }
+// newCalendar fills the unset rules with UTC 08:00-20:00 every day in slots
+// of two hours.
+func newCalendar(t *testing.T, rules services.CalendarRules) *services.Calendar {
+	if rules.TimeZone == "" {
+		rules.TimeZone = "UTC"
+	}
+	if rules.WorkingHours == "" {
+		rules.WorkingHours = "08:00-20:00"
+	}
+	if rules.WorkingDays == nil {
+		rules.WorkingDays = []string{"mon", "tue", "wed", "thu", "fri", "sat", "sun"}
+	}
+	if rules.SlotDuration == 0 {
+		rules.SlotDuration = 2 * time.Hour
+	}
+	calendar, err := services.NewCalendar(rules)
+	require.Nil(t, err)
+	return calendar
+}
 
-func (m *mockRepos) Update(_ context.Context, _ *entities.Delivery) error { return nil }
+// slotWindow is the 10:00-12:00 UTC slot days from today.
+func slotWindow(days int) *valueobjects.TimeWindow {
+	start := time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, days).Add(10 * time.Hour)
+	return &valueobjects.TimeWindow{Start: start, End: start.Add(2 * time.Hour)}
+}
 
-var ctx = context.Background()
+// newCouriers puts the given couriers online.
+func newCouriers(t *testing.T, deliveries repositories.DeliveriesRepository, online ...*entities.User) *services.ManageCourier {
+	couriers := services.NewManageCourier(memory.NewAvailabilityRepository(), deliveries, 10)
+	for _, c := range online {
+		_, err := couriers.SetStatus(ctx, c.Id, valueobjects.Online)
+		require.Nil(t, err)
+	}
+	return couriers
+}
 
 func TestManageDelivery_Create(t *testing.T) {
-	repo := &mockRepos{}
-	srv := services.NewManageDelivery(repo, repo)
+	srv, repo := newService(t)
 	asrt := assert.New(t)
-	recip := &entities.User{
-		Id:    1,
-		Email: "email@mail.com",
-		Role:  "user",
-	}
-	d, err := srv.Create(ctx, recip, "Some Address 1, 14")
+	d, err := srv.Create(ctx, recipient, services.CreateDelivery{Destination: "Some Address 1, 14"})
 	asrt.Nil(err)
 	asrt.NotNil(d)
-	asrt.Equal(d.RecipientId.Id, recip.Id)
+	asrt.Equal(recipient.Id, d.RecipientId)
 	asrt.Nil(d.CourierId)
-	asrt.LessOrEqual(time.Now().Sub(d.CreatedAt).Milliseconds(), int64(1))
-	asrt.LessOrEqual(time.Now().Sub(d.UpdatedAt).Milliseconds(), int64(1))
+	asrt.Equal(valueobjects.Created, d.Status)
+	asrt.WithinDuration(time.Now(), d.CreatedAt, time.Second)
+
+	stored, err := repo.GetById(ctx, d.Id)
+	asrt.Nil(err)
+	asrt.Equal(d.Destination, stored.Destination)
 }
 
 func TestManageDelivery_AssignToCourier(t *testing.T) {
-	users := map[uint]*entities.User{
-		1: {Id: 1, Email: "custom1@mail.com", Role: "courier"},
-		4: {Id: 4, Email: "custom4@mail.com", Role: "user"},
-	}
-	deliveries := map[uint]*entities.Delivery{
-		1: {Id: 1, Status: valueobjects.Created, RecipientId: users[1]},
-		2: {Id: 2, Status: valueobjects.Canceled, RecipientId: users[1]},
-		3: {Id: 3, Status: valueobjects.Delivers, RecipientId: users[1]},
-		4: {Id: 4, Status: valueobjects.Completed, RecipientId: users[1]},
-	}
-	repo := &mockRepos{users: users, deliveries: deliveries}
-	srv := services.NewManageDelivery(repo, repo)
 	testCases := []struct {
-		userId, deliveryId int
-		success            bool
+		courierId, deliveryId uint
+		success               bool
 	}{
-		{userId: 1, deliveryId: 1, success: true},
-		{userId: 1, deliveryId: 2, success: false},
-		{userId: 1, deliveryId: 3, success: true},
-		{userId: 1, deliveryId: 4, success: false},
-		{userId: 4, deliveryId: 4, success: false},
-		{userId: 4, deliveryId: 1, success: false},
+		{courierId: 1, deliveryId: 1, success: true},
+		{courierId: 1, deliveryId: 2, success: false},
+		{courierId: 2, deliveryId: 3, success: true},
+		{courierId: 1, deliveryId: 4, success: false},
+		{courierId: 4, deliveryId: 1, success: false},
+		{courierId: 1, deliveryId: 404, success: false},
 	}
-	asrt := assert.New(t)
 	for i, testCase := range testCases {
-		t.Logf("case %d \n", i)
-		d, e := srv.AssignToCourier(ctx, uint(testCase.userId), uint(testCase.deliveryId))
-		if testCase.success {
-			asrt.Nil(e)
-			asrt.NotNil(d)
-			asrt.Equal(d.CourierId.Id, uint(testCase.userId))
-		} else {
-			asrt.NotNil(e)
-			asrt.True(d == nil || d.CourierId == nil || d.CourierId.Id != uint(testCase.userId))
-		}
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			srv, repo := newService(t,
+				valueobjects.Created, valueobjects.Canceled, valueobjects.Delivers, valueobjects.Completed)
+			asrt := assert.New(t)
+			d, err := srv.AssignToCourier(ctx, testCase.deliveryId, testCase.courierId, 0)
+			if !testCase.success {
+				asrt.NotNil(err)
+				asrt.Nil(d)
+				return
+			}
+			asrt.Nil(err)
+			asrt.Equal(testCase.courierId, *d.CourierId)
+			asrt.Equal(valueobjects.Delivers, d.Status)
+			stored, err := repo.GetById(ctx, testCase.deliveryId)
+			asrt.Nil(err)
+			asrt.Equal(d.Version, stored.Version)
+			asrt.Equal(testCase.courierId, *stored.CourierId)
+		})
 	}
 }
 
 func TestManageDelivery_Complete(t *testing.T) {
-	deliveries := map[uint]*entities.Delivery{
-		1: {Id: 1, Status: valueobjects.Created},
-		2: {Id: 2, Status: valueobjects.Canceled},
-		3: {Id: 3, Status: valueobjects.Delivers},
-		4: {Id: 4, Status: valueobjects.Completed},
-	}
-	repo := &mockRepos{deliveries: deliveries}
-	srv := services.NewManageDelivery(repo, repo)
 	testCases := []struct {
 		deliveryId uint
+		courier    *entities.User
 		success    bool
 	}{
-		{deliveryId: 1, success: false},
-		{deliveryId: 2, success: false},
-		{deliveryId: 3, success: true},
-		{deliveryId: 4, success: false},
+		{deliveryId: 1, courier: courier, success: false},
+		{deliveryId: 2, courier: courier, success: false},
+		{deliveryId: 3, courier: courier, success: true},
+		{deliveryId: 3, courier: other, success: false},
+		{deliveryId: 4, courier: courier, success: false},
 	}
-	asrt := assert.New(t)
 	for i, testCase := range testCases {
-		t.Logf("case %d", i)
-		d, err := srv.Complete(ctx, testCase.deliveryId)
-		if testCase.success {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			srv, _ := newService(t,
+				valueobjects.Created, valueobjects.Canceled, valueobjects.Delivers, valueobjects.Completed)
+			asrt := assert.New(t)
+			d, err := srv.Complete(ctx, testCase.deliveryId, testCase.courier, services.CompleteDelivery{})
+			if !testCase.success {
+				asrt.NotNil(err)
+				return
+			}
 			asrt.Nil(err)
-			asrt.Equal(d.Status, valueobjects.Completed)
-			asrt.LessOrEqual(time.Now().Sub(d.UpdatedAt).Milliseconds(), int64(1))
-		} else {
-			asrt.NotNil(err)
-		}
+			asrt.Equal(valueobjects.Completed, d.Status)
+			asrt.WithinDuration(time.Now(), d.UpdatedAt, time.Second)
+		})
 	}
+}
+
+func TestManageDelivery_ExpectedVersion(t *testing.T) {
+	srv, _ := newService(t, valueobjects.Created)
+	asrt := assert.New(t)
 
+	_, err := srv.AssignToCourier(ctx, 1, courier.Id, 2)
+	asrt.True(errors.Is(err, services.ErrPreconditionFailed))
+	d, err := srv.AssignToCourier(ctx, 1, courier.Id, 1)
+	asrt.Nil(err)
+	srv.RevealHandoverCode(d)
+	_, err = srv.Complete(ctx, 1, courier, services.CompleteDelivery{ExpectedVersion: 1, HandoverCode: d.HandoverCode})
+	asrt.True(errors.Is(err, services.ErrPreconditionFailed))
+	_, err = srv.Complete(ctx, 1, courier, services.CompleteDelivery{ExpectedVersion: d.Version, HandoverCode: d.HandoverCode})
+	asrt.Nil(err)
+}
+
+func TestManageDelivery_ConcurrentAssign(t *testing.T) {
+	srv, repo := newService(t, valueobjects.Created)
+	var wg sync.WaitGroup
+	for _, c := range []*entities.User{courier, other} {
+		wg.Add(1)
+		go func(c *entities.User) {
+			defer wg.Done()
+			_, err := srv.AssignToCourier(ctx, 1, c.Id, 1)
+			if err != nil {
+				assert.True(t, errors.Is(err, services.ErrPreconditionFailed))
+			}
+		}(c)
+	}
+	wg.Wait()
+	d, err := repo.GetById(ctx, 1)
+	assert.Nil(t, err)
+	assert.Equal(t, uint(2), d.Version)
 }
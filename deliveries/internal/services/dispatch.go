@@ -0,0 +1,227 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/zhanbolat18/parcel/deliveries/internal/entities"
+	"github.com/zhanbolat18/parcel/deliveries/internal/repositories"
+	"github.com/zhanbolat18/parcel/deliveries/internal/valueobjects"
+)
+
+var ErrUnknownStrategy = errors.New("unknown dispatch strategy")
+
+type Assignment struct {
+	DeliveryId uint   `json:"delivery_id"`
+	CourierId  uint   `json:"courier_id,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+type Plan struct {
+	Strategy    string        `json:"strategy"`
+	DryRun      bool          `json:"dry_run"`
+	Assignments []*Assignment `json:"assignments"`
+}
+
+type Dispatcher struct {
+	deliveryRepo    repositories.DeliveriesRepository
+	usersRepo       repositories.UsersRepository
+	locationsRepo   repositories.LocationsRepository
+	manage          *ManageDelivery
+	couriers        *ManageCourier
+	strategies      map[string]Strategy
+	defaultStrategy string
+}
+
+func NewDispatcher(
+	deliveryRepo repositories.DeliveriesRepository,
+	usersRepo repositories.UsersRepository,
+	locationsRepo repositories.LocationsRepository,
+	manage *ManageDelivery,
+	couriers *ManageCourier,
+	defaultStrategy string,
+) *Dispatcher {
+	d := &Dispatcher{
+		deliveryRepo:  deliveryRepo,
+		usersRepo:     usersRepo,
+		locationsRepo: locationsRepo,
+		manage:        manage,
+		couriers:      couriers,
+		strategies: map[string]Strategy{
+			StrategyRoundRobin:  NewRoundRobinStrategy(),
+			StrategyLeastActive: NewLeastActiveStrategy(),
+			StrategyNearest:     NewNearestStrategy(),
+		},
+		defaultStrategy: defaultStrategy,
+	}
+	if _, ok := d.strategies[defaultStrategy]; !ok {
+		panic(fmt.Sprintf("unknown default dispatch strategy \"%s\"", defaultStrategy))
+	}
+	return d
+}
+
+// Dispatch assigns one created delivery to the courier chosen by the named
+// strategy, the default strategy is used when name is empty.
+func (d *Dispatcher) Dispatch(ctx context.Context, deliveryId uint, name string) (*entities.Delivery, error) {
+	strategy, err := d.strategy(name)
+	if err != nil {
+		return nil, err
+	}
+	delivery, err := d.deliveryRepo.GetById(ctx, deliveryId)
+	if err != nil {
+		return nil, fmt.Errorf("get delivery by id \"%d\": %w", deliveryId, err)
+	}
+	if delivery.Status != valueobjects.Created {
+		return nil, errors.New("only created deliveries can be dispatched")
+	}
+	candidates, err := d.candidates(ctx)
+	if err != nil {
+		return nil, err
+	}
+	selected, err := d.selectCourier(fork(strategy), delivery, candidates)
+	if err != nil {
+		return nil, err
+	}
+	delivery, err = d.manage.AssignToCourier(ctx, delivery.Id, selected.Courier.Id, delivery.Version)
+	if err != nil {
+		return nil, err
+	}
+	advance(strategy)
+	return delivery, nil
+}
+
+// DispatchAll plans assignments for every created delivery, oldest first,
+// and applies them unless dryRun is set. Deliveries that cannot be assigned
+// are reported in the plan and do not stop the others.
+func (d *Dispatcher) DispatchAll(ctx context.Context, name string, dryRun bool) (*Plan, error) {
+	if name == "" {
+		name = d.defaultStrategy
+	}
+	strategy, err := d.strategy(name)
+	if err != nil {
+		return nil, err
+	}
+	deliveries, err := d.deliveryRepo.GetAllByStatus(ctx, valueobjects.Created)
+	if err != nil {
+		return nil, fmt.Errorf("fetch created deliveries: %w", err)
+	}
+	candidates, err := d.candidates(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := &Plan{Strategy: name, DryRun: dryRun, Assignments: make([]*Assignment, 0, len(deliveries))}
+	planner := fork(strategy)
+	for _, delivery := range deliveries {
+		assignment := &Assignment{DeliveryId: delivery.Id}
+		plan.Assignments = append(plan.Assignments, assignment)
+		selected, err := d.selectCourier(planner, delivery, candidates)
+		if err != nil {
+			assignment.Error = err.Error()
+			continue
+		}
+		assignment.CourierId = selected.Courier.Id
+		if !dryRun {
+			_, err = d.manage.AssignToCourier(ctx, delivery.Id, selected.Courier.Id, delivery.Version)
+			if err != nil {
+				assignment.Error = err.Error()
+				continue
+			}
+			advance(strategy)
+		}
+		// following deliveries of the same run see the planned load
+		selected.Active++
+	}
+	return plan, nil
+}
+
+func (d *Dispatcher) strategy(name string) (Strategy, error) {
+	if name == "" {
+		name = d.defaultStrategy
+	}
+	strategy, ok := d.strategies[name]
+	if !ok {
+		return nil, fmt.Errorf("%w \"%s\"", ErrUnknownStrategy, name)
+	}
+	return strategy, nil
+}
+
+// fork returns the strategy to pick with, so that picks change a stateful
+// strategy only once they are assigned.
+func fork(strategy Strategy) Strategy {
+	if s, ok := strategy.(statefulStrategy); ok {
+		return s.Fork()
+	}
+	return strategy
+}
+
+func advance(strategy Strategy) {
+	if s, ok := strategy.(statefulStrategy); ok {
+		s.Advance()
+	}
+}
+
+func (d *Dispatcher) selectCourier(strategy Strategy, delivery *entities.Delivery, candidates []*Candidate) (*Candidate, error) {
+	eligible := make([]*Candidate, 0, len(candidates))
+	for _, c := range candidates {
+		if c.Active < c.Capacity {
+			eligible = append(eligible, c)
+		}
+	}
+	if len(eligible) == 0 {
+		return nil, ErrNoCourier
+	}
+	selected, err := strategy.Select(delivery, eligible)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrNoCourier, err.Error())
+	}
+	return selected, nil
+}
+
+func (d *Dispatcher) candidates(ctx context.Context) ([]*Candidate, error) {
+	couriers, err := d.usersRepo.GetCouriers(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("fetch couriers: %w", err)
+	}
+	active, err := d.deliveryRepo.GetAllByStatus(ctx, valueobjects.Delivers)
+	if err != nil {
+		return nil, fmt.Errorf("fetch active deliveries: %w", err)
+	}
+	ids := make([]uint, 0, len(couriers))
+	for _, c := range couriers {
+		ids = append(ids, c.Id)
+	}
+	locations, err := d.locationsRepo.LastKnown(ctx, ids...)
+	if err != nil {
+		return nil, fmt.Errorf("fetch courier locations: %w", err)
+	}
+	availabilities, err := d.couriers.Availabilities(ctx, ids...)
+	if err != nil {
+		return nil, err
+	}
+
+	candidates := make([]*Candidate, 0, len(couriers))
+	byId := make(map[uint]*Candidate, len(couriers))
+	for _, c := range couriers {
+		availability := availabilities[c.Id]
+		if availability.Status != valueobjects.Online {
+			continue
+		}
+		candidate := &Candidate{Courier: c, Capacity: int(availability.MaxActiveDeliveries)}
+		if location, ok := locations[c.Id]; ok {
+			candidate.Location = &location
+		}
+		candidates = append(candidates, candidate)
+		byId[c.Id] = candidate
+	}
+	for _, dl := range active {
+		if dl.CourierId == nil {
+			continue
+		}
+		if c, ok := byId[*dl.CourierId]; ok {
+			c.Active++
+		}
+	}
+	sortCandidates(candidates)
+	return candidates, nil
+}
@@ -0,0 +1,182 @@
+package services_test
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zhanbolat18/parcel/deliveries/internal/entities"
+	"github.com/zhanbolat18/parcel/deliveries/internal/repositories/memory"
+	"github.com/zhanbolat18/parcel/deliveries/internal/services"
+	"github.com/zhanbolat18/parcel/deliveries/internal/valueobjects"
+	"strings"
+	"testing"
+)
+
+func candidates() []*services.Candidate {
+	return []*services.Candidate{
+		{Courier: courier, Active: 2, Location: &valueobjects.Location{Latitude: 43.25, Longitude: 76.95}},
+		{Courier: other, Active: 1},
+	}
+}
+
+func TestRoundRobinStrategy(t *testing.T) {
+	s := services.NewRoundRobinStrategy()
+	var selected []uint
+	for i := 0; i < 3; i++ {
+		c, err := s.Select(&entities.Delivery{}, candidates())
+		require.Nil(t, err)
+		selected = append(selected, c.Courier.Id)
+	}
+	assert.Equal(t, []uint{courier.Id, other.Id, courier.Id}, selected)
+}
+
+func TestLeastActiveStrategy(t *testing.T) {
+	c, err := services.NewLeastActiveStrategy().Select(&entities.Delivery{}, candidates())
+	assert.Nil(t, err)
+	assert.Equal(t, other.Id, c.Courier.Id)
+}
+
+func TestNearestStrategy(t *testing.T) {
+	s := services.NewNearestStrategy()
+	_, err := s.Select(&entities.Delivery{}, candidates())
+	assert.NotNil(t, err)
+
+	cs := candidates()
+	cs[1].Location = &valueobjects.Location{Latitude: 43.24, Longitude: 76.89}
+	c, err := s.Select(&entities.Delivery{
+		DestinationLocation: &valueobjects.Location{Latitude: 43.238949, Longitude: 76.889709},
+	}, cs)
+	assert.Nil(t, err)
+	assert.Equal(t, other.Id, c.Courier.Id)
+}
+
+func TestDispatcher_DispatchAll(t *testing.T) {
+	asrt := assert.New(t)
+	deliveries := memory.NewDeliveryRepository()
+	for i := 0; i < 3; i++ {
+		require.Nil(t, deliveries.Store(ctx, entities.NewDelivery("Some Address 1, 14", nil, recipient)))
+	}
+	busy := entities.NewDelivery("busy", nil, recipient)
+	require.Nil(t, deliveries.Store(ctx, busy))
+	busy.Status, busy.CourierId = valueobjects.Delivers, &courier.Id
+	require.Nil(t, deliveries.Update(ctx, busy))
+
+	users := memory.NewUsersRepository(courier, other, recipient)
+	couriers := newCouriers(t, deliveries, courier, other)
+	manage := services.NewManageDelivery(newDeps(t, deliveries, users, couriers), policy)
+	dispatcher := services.NewDispatcher(deliveries, users, memory.NewLocationsRepository(), manage, couriers,
+		services.StrategyLeastActive)
+
+	plan, err := dispatcher.DispatchAll(ctx, "", true)
+	require.Nil(t, err)
+	asrt.True(plan.DryRun)
+	asrt.Equal(services.StrategyLeastActive, plan.Strategy)
+	require.Len(t, plan.Assignments, 3)
+	asrt.Equal([]uint{other.Id, courier.Id, other.Id}, []uint{
+		plan.Assignments[0].CourierId, plan.Assignments[1].CourierId, plan.Assignments[2].CourierId,
+	})
+	created, err := deliveries.GetAllByStatus(ctx, valueobjects.Created)
+	require.Nil(t, err)
+	asrt.Len(created, 3)
+
+	plan, err = dispatcher.DispatchAll(ctx, services.StrategyNearest, false)
+	require.Nil(t, err)
+	for _, a := range plan.Assignments {
+		asrt.NotEmpty(a.Error)
+	}
+
+	plan, err = dispatcher.DispatchAll(ctx, services.StrategyLeastActive, false)
+	require.Nil(t, err)
+	for _, a := range plan.Assignments {
+		asrt.Empty(a.Error)
+		d, err := deliveries.GetById(ctx, a.DeliveryId)
+		require.Nil(t, err)
+		asrt.Equal(valueobjects.Delivers, d.Status)
+		asrt.Equal(a.CourierId, *d.CourierId)
+	}
+
+	_, err = dispatcher.DispatchAll(ctx, "random", true)
+	asrt.True(errors.Is(err, services.ErrUnknownStrategy))
+}
+
+func TestDispatcher_SkipsUnavailableCouriers(t *testing.T) {
+	asrt := assert.New(t)
+	deliveries := memory.NewDeliveryRepository()
+	for i := 0; i < 3; i++ {
+		require.Nil(t, deliveries.Store(ctx, entities.NewDelivery("Some Address 1, 14", nil, recipient)))
+	}
+	users := memory.NewUsersRepository(courier, other, recipient)
+	couriers := newCouriers(t, deliveries, courier)
+	_, err := couriers.SetMaxActiveDeliveries(ctx, courier.Id, 2)
+	require.Nil(t, err)
+	manage := services.NewManageDelivery(newDeps(t, deliveries, users, couriers), policy)
+	dispatcher := services.NewDispatcher(deliveries, users, memory.NewLocationsRepository(), manage, couriers,
+		services.StrategyRoundRobin)
+
+	plan, err := dispatcher.DispatchAll(ctx, "", false)
+	require.Nil(t, err)
+	require.Len(t, plan.Assignments, 3)
+	asrt.Equal(courier.Id, plan.Assignments[0].CourierId)
+	asrt.Equal(courier.Id, plan.Assignments[1].CourierId)
+	asrt.True(strings.Contains(plan.Assignments[2].Error, services.ErrNoCourier.Error()))
+}
+
+func TestDispatcher_DryRunKeepsRoundRobin(t *testing.T) {
+	asrt := assert.New(t)
+	deliveries := memory.NewDeliveryRepository()
+	for i := 0; i < 3; i++ {
+		require.Nil(t, deliveries.Store(ctx, entities.NewDelivery("Some Address 1, 14", nil, recipient)))
+	}
+	users := memory.NewUsersRepository(courier, other, recipient)
+	couriers := newCouriers(t, deliveries, courier, other)
+	manage := services.NewManageDelivery(newDeps(t, deliveries, users, couriers), policy)
+	dispatcher := services.NewDispatcher(deliveries, users, memory.NewLocationsRepository(), manage, couriers,
+		services.StrategyRoundRobin)
+
+	picks := func(plan *services.Plan) []uint {
+		ids := make([]uint, 0, len(plan.Assignments))
+		for _, a := range plan.Assignments {
+			asrt.Empty(a.Error)
+			ids = append(ids, a.CourierId)
+		}
+		return ids
+	}
+	dry, err := dispatcher.DispatchAll(ctx, "", true)
+	require.Nil(t, err)
+	again, err := dispatcher.DispatchAll(ctx, "", true)
+	require.Nil(t, err)
+	asrt.Equal(picks(dry), picks(again))
+	applied, err := dispatcher.DispatchAll(ctx, "", false)
+	require.Nil(t, err)
+	asrt.Equal(picks(dry), picks(applied))
+	asrt.Equal([]uint{courier.Id, other.Id, courier.Id}, picks(applied))
+
+	d := entities.NewDelivery("Some Address 1, 14", nil, recipient)
+	require.Nil(t, deliveries.Store(ctx, d))
+	assigned, err := dispatcher.Dispatch(ctx, d.Id, "")
+	require.Nil(t, err)
+	asrt.Equal(other.Id, *assigned.CourierId, "the applied run advanced the rotation")
+}
+
+func TestDispatcher_Dispatch(t *testing.T) {
+	asrt := assert.New(t)
+	deliveries := memory.NewDeliveryRepository()
+	d := entities.NewDelivery("Some Address 1, 14", nil, recipient)
+	require.Nil(t, deliveries.Store(ctx, d))
+
+	users := memory.NewUsersRepository(recipient)
+	couriers := newCouriers(t, deliveries, courier)
+	manage := services.NewManageDelivery(newDeps(t, deliveries, users, couriers), policy)
+	dispatcher := services.NewDispatcher(deliveries, users, memory.NewLocationsRepository(), manage, couriers,
+		services.StrategyRoundRobin)
+	_, err := dispatcher.Dispatch(ctx, d.Id, "")
+	asrt.True(errors.Is(err, services.ErrNoCourier))
+
+	users.Add(courier)
+	assigned, err := dispatcher.Dispatch(ctx, d.Id, "")
+	require.Nil(t, err)
+	asrt.Equal(courier.Id, *assigned.CourierId)
+
+	_, err = dispatcher.Dispatch(ctx, d.Id, "")
+	asrt.NotNil(err)
+}
@@ -0,0 +1,94 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/zhanbolat18/parcel/deliveries/internal/entities"
+	"github.com/zhanbolat18/parcel/deliveries/internal/valueobjects"
+)
+
+var (
+	ErrWrongHandoverCode = errors.New("wrong handover code")
+	ErrHandoverLocked    = errors.New("handover is locked after too many wrong codes, an admin has to complete the delivery")
+)
+
+// HandoverCodes issues and verifies the codes recipients give couriers on
+// handover. A code is derived from the secret and a random nonce, so it can
+// be shown to the recipient again without being stored.
+type HandoverCodes struct {
+	secret      []byte
+	digits      int
+	maxAttempts uint
+}
+
+func NewHandoverCodes(secret string, digits int, maxAttempts uint) (*HandoverCodes, error) {
+	switch {
+	case secret == "":
+		return nil, errors.New("handover secret must be set")
+	case digits < 4 || digits > 6:
+		return nil, fmt.Errorf("handover code must have 4 to 6 digits, got %d", digits)
+	case maxAttempts == 0:
+		return nil, errors.New("handover attempts must be positive")
+	}
+	return &HandoverCodes{secret: []byte(secret), digits: digits, maxAttempts: maxAttempts}, nil
+}
+
+// Issue protects the delivery with a new code.
+func (h *HandoverCodes) Issue(delivery *entities.Delivery) error {
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return fmt.Errorf("generate handover nonce: %w", err)
+	}
+	delivery.Handover = &valueobjects.Handover{Nonce: hex.EncodeToString(nonce)}
+	delivery.Handover.CodeHash = h.hash(delivery, h.Code(delivery))
+	return nil
+}
+
+// Code returns the code of the delivery, empty when it has none.
+func (h *HandoverCodes) Code(delivery *entities.Delivery) string {
+	if delivery.Handover == nil {
+		return ""
+	}
+	mac := h.mac("code", delivery.Id, delivery.Handover.Nonce)
+	modulo := uint64(1)
+	for i := 0; i < h.digits; i++ {
+		modulo *= 10
+	}
+	return fmt.Sprintf("%0*d", h.digits, binary.BigEndian.Uint64(mac)%modulo)
+}
+
+// Verify checks the code, counting wrong ones and locking the handover once
+// the attempts are exhausted. The caller stores the changed delivery.
+func (h *HandoverCodes) Verify(delivery *entities.Delivery, code string) error {
+	handover := delivery.Handover
+	if handover == nil {
+		return nil
+	}
+	if handover.Locked {
+		return ErrHandoverLocked
+	}
+	if hmac.Equal([]byte(h.hash(delivery, code)), []byte(handover.CodeHash)) {
+		return nil
+	}
+	handover.Attempts++
+	if handover.Attempts >= h.maxAttempts {
+		handover.Locked = true
+		return ErrHandoverLocked
+	}
+	return fmt.Errorf("%w, %d attempts left", ErrWrongHandoverCode, h.maxAttempts-handover.Attempts)
+}
+
+func (h *HandoverCodes) hash(delivery *entities.Delivery, code string) string {
+	return hex.EncodeToString(h.mac("hash", delivery.Id, code))
+}
+
+func (h *HandoverCodes) mac(purpose string, deliveryId uint, value string) []byte {
+	mac := hmac.New(sha256.New, h.secret)
+	_, _ = fmt.Fprintf(mac, "%s:%d:%s", purpose, deliveryId, value)
+	return mac.Sum(nil)
+}
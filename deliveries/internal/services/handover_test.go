@@ -0,0 +1,121 @@
+package services_test
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zhanbolat18/parcel/deliveries/internal/entities"
+	"github.com/zhanbolat18/parcel/deliveries/internal/repositories"
+	"github.com/zhanbolat18/parcel/deliveries/internal/services"
+	"github.com/zhanbolat18/parcel/deliveries/internal/valueobjects"
+	"testing"
+)
+
+func TestHandoverCodes(t *testing.T) {
+	_, err := services.NewHandoverCodes("", 6, 3)
+	assert.NotNil(t, err)
+	_, err = services.NewHandoverCodes("secret", 3, 3)
+	assert.NotNil(t, err)
+
+	codes, err := services.NewHandoverCodes("secret", 4, 3)
+	require.Nil(t, err)
+	d := &entities.Delivery{Id: 1}
+	assert.Empty(t, codes.Code(d))
+	assert.Nil(t, codes.Verify(d, ""))
+
+	require.Nil(t, codes.Issue(d))
+	code := codes.Code(d)
+	assert.Len(t, code, 4)
+	assert.Equal(t, code, codes.Code(d))
+	assert.NotContains(t, d.Handover.CodeHash, code)
+	assert.Nil(t, codes.Verify(d, code))
+
+	other, err := services.NewHandoverCodes("other", 4, 3)
+	require.Nil(t, err)
+	assert.True(t, errors.Is(other.Verify(d, code), services.ErrWrongHandoverCode))
+}
+
+func TestManageDelivery_Handover(t *testing.T) {
+	srv, repo := newService(t, valueobjects.Created)
+	asrt := assert.New(t)
+
+	assigned, err := srv.AssignToCourier(ctx, 1, courier.Id, 0)
+	require.Nil(t, err)
+	asrt.Empty(assigned.HandoverCode)
+
+	// only the recipient sees the code
+	deliveries, err := srv.GetAllByRecipient(ctx, recipient, repositories.DeliveryFilter{})
+	require.Nil(t, err)
+	require.Len(t, deliveries, 1)
+	code := deliveries[0].HandoverCode
+	asrt.Len(code, 6)
+	reassigned, err := srv.AssignToCourier(ctx, 1, other.Id, 0)
+	require.Nil(t, err)
+	srv.RevealHandoverCode(reassigned)
+	asrt.Equal(code, reassigned.HandoverCode, "reassignment keeps the code")
+
+	_, err = srv.Complete(ctx, 1, courier, services.CompleteDelivery{HandoverCode: code})
+	asrt.NotNil(err, "only the assigned courier completes")
+	_, err = srv.Complete(ctx, 1, other, services.CompleteDelivery{HandoverCode: "000000" + code})
+	asrt.True(errors.Is(err, services.ErrWrongHandoverCode))
+	d, err := srv.Complete(ctx, 1, other, services.CompleteDelivery{HandoverCode: code})
+	require.Nil(t, err)
+	asrt.Equal(valueobjects.Completed, d.Status)
+
+	stored, err := repo.GetById(ctx, 1)
+	require.Nil(t, err)
+	asrt.Equal(uint(1), stored.Handover.Attempts)
+	entries, err := srv.GetAudit(ctx, 1)
+	require.Nil(t, err)
+	require.Len(t, entries, 1)
+	asrt.Equal(entities.AuditHandoverFailed, entries[0].Action)
+	asrt.Equal(other.Id, *entries[0].ActorId)
+}
+
+func TestManageDelivery_HandoverLockout(t *testing.T) {
+	srv, repo := newService(t, valueobjects.Created)
+	asrt := assert.New(t)
+	admin := &entities.User{Id: 3, Role: "admin"}
+
+	_, err := srv.AssignToCourier(ctx, 1, courier.Id, 0)
+	require.Nil(t, err)
+	deliveries, err := srv.GetAllByRecipient(ctx, recipient, repositories.DeliveryFilter{})
+	require.Nil(t, err)
+	code := deliveries[0].HandoverCode
+
+	for i := 0; i < 2; i++ {
+		_, err = srv.Complete(ctx, 1, courier, services.CompleteDelivery{HandoverCode: "wrong"})
+		asrt.True(errors.Is(err, services.ErrWrongHandoverCode))
+	}
+	_, err = srv.Complete(ctx, 1, courier, services.CompleteDelivery{HandoverCode: "wrong"})
+	asrt.True(errors.Is(err, services.ErrHandoverLocked))
+	// a locked handover rejects even the right code and stops counting
+	_, err = srv.Complete(ctx, 1, courier, services.CompleteDelivery{HandoverCode: code})
+	asrt.True(errors.Is(err, services.ErrHandoverLocked))
+	stored, err := repo.GetById(ctx, 1)
+	require.Nil(t, err)
+	asrt.Equal(valueobjects.Delivers, stored.Status)
+	asrt.Equal(uint(3), stored.Handover.Attempts)
+	asrt.True(stored.Handover.Locked)
+
+	_, err = srv.CompleteByAdmin(ctx, 1, admin, " ", nil, 0)
+	asrt.True(errors.Is(err, services.ErrOverrideReason))
+	d, err := srv.CompleteByAdmin(ctx, 1, admin, "recipient lost the code", nil, 0)
+	require.Nil(t, err)
+	asrt.Equal(valueobjects.Completed, d.Status)
+
+	entries, err := srv.GetAudit(ctx, 1)
+	require.Nil(t, err)
+	actions := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		actions = append(actions, entry.Action)
+	}
+	asrt.Equal([]string{
+		entities.AuditHandoverFailed,
+		entities.AuditHandoverFailed,
+		entities.AuditHandoverLocked,
+		entities.AuditHandoverOverride,
+	}, actions)
+	asrt.Equal("recipient lost the code", entries[3].Details)
+	asrt.Equal(admin.Id, *entries[3].ActorId)
+}
@@ -0,0 +1,138 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/zhanbolat18/parcel/deliveries/internal/entities"
+	"github.com/zhanbolat18/parcel/deliveries/internal/repositories"
+	"sort"
+	"time"
+)
+
+var (
+	ErrInvalidPeriod = errors.New("invalid invoice period")
+	// ErrPeriodOpen is returned for periods that have not ended yet, their
+	// invoices would miss the deliveries still to be completed.
+	ErrPeriodOpen  = errors.New("invoice period has not ended")
+	ErrNotMerchant = errors.New("invoice belongs to another merchant")
+)
+
+// ManageInvoice bills merchants monthly for their completed deliveries at the
+// price stored on each of them. Deliveries without a price are not billed.
+type ManageInvoice struct {
+	invoicesRepo repositories.InvoicesRepository
+	deliveryRepo repositories.DeliveriesRepository
+	txManager    repositories.TxManager
+	calendar     *Calendar
+}
+
+func NewManageInvoice(
+	invoicesRepo repositories.InvoicesRepository,
+	deliveryRepo repositories.DeliveriesRepository,
+	txManager repositories.TxManager,
+	calendar *Calendar,
+) *ManageInvoice {
+	return &ManageInvoice{invoicesRepo: invoicesRepo, deliveryRepo: deliveryRepo, txManager: txManager, calendar: calendar}
+}
+
+// Generate invoices the merchants, or only the given one when merchant is not
+// nil, for the period. A merchant is invoiced once per period, later calls
+// return the invoice generated first.
+func (m *ManageInvoice) Generate(ctx context.Context, period string, merchant *entities.Tenant) ([]*entities.Invoice, error) {
+	from, to, err := m.calendar.Month(period)
+	if err != nil {
+		return nil, err
+	}
+	if to.After(m.calendar.Today()) {
+		return nil, fmt.Errorf("%w: %s", ErrPeriodOpen, period)
+	}
+	deliveries, err := m.deliveryRepo.GetAllCompletedBetween(ctx, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("fetch completed deliveries: %w", err)
+	}
+	drafts := make(map[entities.Tenant]*entities.Invoice)
+	for _, delivery := range deliveries {
+		if delivery.Price == nil || (merchant != nil && !merchant.Owns(delivery)) {
+			continue
+		}
+		draft, ok := drafts[delivery.Tenant()]
+		if !ok {
+			draft = entities.NewInvoice(delivery.Tenant(), period, delivery.Price.Currency)
+			drafts[delivery.Tenant()] = draft
+		}
+		if delivery.Price.Currency != draft.Currency {
+			return nil, fmt.Errorf("delivery \"%d\" is priced in %s, not %s", delivery.Id, delivery.Price.Currency, draft.Currency)
+		}
+		draft.Lines = append(draft.Lines, entities.InvoiceLine{
+			DeliveryId:   delivery.Id,
+			CompletedAt:  *delivery.CompletedAt,
+			Destination:  delivery.Destination,
+			ServiceLevel: delivery.ServiceLevel,
+			Amount:       delivery.Price.Amount,
+		})
+		draft.Total += delivery.Price.Amount
+		draft.Deliveries++
+	}
+
+	invoices := make([]*entities.Invoice, 0, len(drafts))
+	for _, draft := range drafts {
+		invoice, err := m.store(ctx, draft)
+		if err != nil {
+			return nil, err
+		}
+		invoices = append(invoices, invoice)
+	}
+	sort.Slice(invoices, func(i, j int) bool {
+		a, b := invoices[i].Merchant(), invoices[j].Merchant()
+		if a.OrganizationId != b.OrganizationId {
+			return a.OrganizationId < b.OrganizationId
+		}
+		return a.UserId < b.UserId
+	})
+	return invoices, nil
+}
+
+func (m *ManageInvoice) GetAll(ctx context.Context, filter repositories.InvoiceFilter) ([]*entities.Invoice, error) {
+	invoices, err := m.invoicesRepo.GetAll(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("fetch invoices: %w", err)
+	}
+	return invoices, nil
+}
+
+// GetOne returns the invoice with its lines to admins and to the members of
+// the merchant.
+func (m *ManageInvoice) GetOne(ctx context.Context, user *entities.User, id uint) (*entities.Invoice, error) {
+	invoice, err := m.invoicesRepo.GetById(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("get invoice by id \"%d\": %w", id, err)
+	}
+	if user.Role != "admin" && invoice.Merchant() != entities.TenantOf(user) {
+		return nil, ErrNotMerchant
+	}
+	return invoice, nil
+}
+
+// store keeps the invoice generated first when invoices of the merchant for
+// the period are generated concurrently.
+func (m *ManageInvoice) store(ctx context.Context, draft *entities.Invoice) (*entities.Invoice, error) {
+	invoice, err := m.invoicesRepo.GetByPeriod(ctx, draft.Merchant(), draft.Period)
+	if err == nil {
+		return invoice, nil
+	}
+	if !errors.Is(err, repositories.ErrInvoiceNotFound) {
+		return nil, fmt.Errorf("get invoice of %s: %w", draft.Period, err)
+	}
+	draft.CreatedAt = time.Now()
+	err = m.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		return m.invoicesRepo.Store(ctx, draft)
+	})
+	if errors.Is(err, repositories.ErrInvoiceExists) {
+		return m.invoicesRepo.GetByPeriod(ctx, draft.Merchant(), draft.Period)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("store invoice: %w", err)
+	}
+	return draft, nil
+}
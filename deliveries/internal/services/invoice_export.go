@@ -0,0 +1,95 @@
+package services
+
+import (
+	"encoding/csv"
+	"fmt"
+	"github.com/jung-kurt/gofpdf"
+	"github.com/zhanbolat18/parcel/deliveries/internal/entities"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// WriteInvoiceCSV writes a line per delivery followed by the total.
+func WriteInvoiceCSV(w io.Writer, invoice *entities.Invoice) error {
+	cw := csv.NewWriter(w)
+	records := [][]string{{"delivery_id", "completed_at", "destination", "service_level", "amount", "currency"}}
+	for _, line := range invoice.Lines {
+		records = append(records, []string{
+			strconv.FormatUint(uint64(line.DeliveryId), 10),
+			line.CompletedAt.UTC().Format(time.RFC3339),
+			spreadsheetSafe(line.Destination),
+			string(line.ServiceLevel),
+			line.Amount.String(),
+			invoice.Currency,
+		})
+	}
+	records = append(records, []string{"total", "", "", "", invoice.Total.String(), invoice.Currency})
+	if err := cw.WriteAll(records); err != nil {
+		return fmt.Errorf("write invoice csv: %w", err)
+	}
+	return nil
+}
+
+// spreadsheetSafe keeps spreadsheets from evaluating user input as a
+// formula.
+func spreadsheetSafe(s string) string {
+	if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
+
+// WriteInvoicePDF renders the invoice as an A4 document.
+func WriteInvoicePDF(w io.Writer, invoice *entities.Invoice) error {
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	// the core fonts only cover cp1252, other characters of addresses are
+	// printed as dots
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+	pdf.SetTitle(fmt.Sprintf("Invoice %d", invoice.Id), true)
+	pdf.AddPage()
+
+	pdf.SetFont("Helvetica", "B", 16)
+	pdf.CellFormat(0, 10, fmt.Sprintf("Invoice #%d", invoice.Id), "", 1, "L", false, 0, "")
+	pdf.SetFont("Helvetica", "", 10)
+	merchant := fmt.Sprintf("Merchant: %d", invoice.MerchantId)
+	if invoice.OrganizationId != nil {
+		merchant = fmt.Sprintf("Organization: %d", *invoice.OrganizationId)
+	}
+	pdf.CellFormat(0, 6, merchant, "", 1, "L", false, 0, "")
+	pdf.CellFormat(0, 6, "Period: "+invoice.Period, "", 1, "L", false, 0, "")
+	pdf.CellFormat(0, 6, "Issued: "+invoice.CreatedAt.UTC().Format(DateLayout), "", 1, "L", false, 0, "")
+	pdf.Ln(4)
+
+	widths := []float64{22, 38, 80, 25, 25}
+	pdf.SetFont("Helvetica", "B", 10)
+	for i, title := range []string{"Delivery", "Completed", "Destination", "Service", "Amount"} {
+		pdf.CellFormat(widths[i], 7, title, "B", 0, "L", false, 0, "")
+	}
+	pdf.Ln(-1)
+	pdf.SetFont("Helvetica", "", 9)
+	for _, line := range invoice.Lines {
+		pdf.CellFormat(widths[0], 6, strconv.FormatUint(uint64(line.DeliveryId), 10), "", 0, "L", false, 0, "")
+		pdf.CellFormat(widths[1], 6, line.CompletedAt.UTC().Format("2006-01-02 15:04"), "", 0, "L", false, 0, "")
+		pdf.CellFormat(widths[2], 6, tr(truncate(line.Destination, 48)), "", 0, "L", false, 0, "")
+		pdf.CellFormat(widths[3], 6, string(line.ServiceLevel), "", 0, "L", false, 0, "")
+		pdf.CellFormat(widths[4], 6, line.Amount.String(), "", 1, "R", false, 0, "")
+	}
+	pdf.SetFont("Helvetica", "B", 10)
+	pdf.CellFormat(widths[0]+widths[1]+widths[2]+widths[3], 8, fmt.Sprintf("Total, %d deliveries", invoice.Deliveries), "T", 0, "L", false, 0, "")
+	pdf.CellFormat(widths[4], 8, invoice.Total.String()+" "+invoice.Currency, "T", 1, "R", false, 0, "")
+
+	if err := pdf.Output(w); err != nil {
+		return fmt.Errorf("render invoice pdf: %w", err)
+	}
+	return nil
+}
+
+func truncate(s string, max int) string {
+	runes := []rune(s)
+	if len(runes) <= max {
+		return s
+	}
+	return string(runes[:max-1]) + "…"
+}
@@ -0,0 +1,142 @@
+package services_test
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zhanbolat18/parcel/deliveries/internal/entities"
+	"github.com/zhanbolat18/parcel/deliveries/internal/repositories"
+	"github.com/zhanbolat18/parcel/deliveries/internal/repositories/memory"
+	"github.com/zhanbolat18/parcel/deliveries/internal/services"
+	"github.com/zhanbolat18/parcel/deliveries/internal/valueobjects"
+	"testing"
+	"time"
+)
+
+func TestManageInvoice(t *testing.T) {
+	asrt := assert.New(t)
+	deliveries := memory.NewDeliveryRepository()
+	march := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	for _, c := range []struct {
+		recipient   *entities.User
+		completedAt time.Time
+		amount      valueobjects.Money
+	}{
+		{recipient, march.Add(48 * time.Hour), 250000},
+		{recipient, march.Add(24 * time.Hour), 125050},
+		{recipient, march.Add(72 * time.Hour), 0},
+		{other, march.Add(time.Hour), 90000},
+		{recipient, march.AddDate(0, 1, 0), 70000},
+	} {
+		d := entities.NewDelivery("Some Address 1, 14", nil, c.recipient)
+		if c.amount != 0 {
+			d.Price = &valueobjects.Price{Amount: c.amount, Currency: "KZT"}
+		}
+		require.Nil(t, deliveries.Store(ctx, d))
+		completedAt := c.completedAt
+		d.Status, d.CompletedAt = valueobjects.Completed, &completedAt
+		require.Nil(t, deliveries.Update(ctx, d))
+	}
+	srv := services.NewManageInvoice(memory.NewInvoicesRepository(), deliveries, memory.NewTxManager(), newCalendar(t, services.CalendarRules{}))
+
+	_, err := srv.Generate(ctx, "2025-13", nil)
+	asrt.True(errors.Is(err, services.ErrInvalidPeriod))
+	_, err = srv.Generate(ctx, time.Now().Format(services.MonthLayout), nil)
+	asrt.True(errors.Is(err, services.ErrPeriodOpen))
+
+	invoices, err := srv.Generate(ctx, "2025-03", &entities.Tenant{UserId: recipient.Id})
+	require.Nil(t, err)
+	require.Len(t, invoices, 1)
+	invoice := invoices[0]
+	asrt.Equal(recipient.Id, invoice.MerchantId)
+	asrt.Equal(valueobjects.Money(375050), invoice.Total, "deliveries without a price are not billed")
+	asrt.Equal(uint(2), invoice.Deliveries)
+	require.Len(t, invoice.Lines, 2)
+	asrt.Equal(uint(2), invoice.Lines[0].DeliveryId)
+
+	invoices, err = srv.Generate(ctx, "2025-03", nil)
+	require.Nil(t, err)
+	require.Len(t, invoices, 2)
+	asrt.Equal(other.Id, invoices[0].MerchantId)
+	asrt.Equal(invoice.Id, invoices[1].Id, "a merchant is invoiced once per period")
+	asrt.Equal(invoice.CreatedAt, invoices[1].CreatedAt)
+
+	all, err := srv.GetAll(ctx, repositories.InvoiceFilter{MerchantId: recipient.Id})
+	require.Nil(t, err)
+	asrt.Len(all, 1)
+	_, err = srv.GetOne(ctx, other, invoice.Id)
+	asrt.True(errors.Is(err, services.ErrNotMerchant))
+	got, err := srv.GetOne(ctx, recipient, invoice.Id)
+	require.Nil(t, err)
+
+	buf := &bytes.Buffer{}
+	require.Nil(t, services.WriteInvoiceCSV(buf, got))
+	records, err := csv.NewReader(buf).ReadAll()
+	require.Nil(t, err)
+	require.Len(t, records, 4)
+	asrt.Equal([]string{"2", "2025-03-02T00:00:00Z", "Some Address 1, 14", "standard", "1250.50", "KZT"}, records[1])
+	asrt.Equal([]string{"total", "", "", "", "3750.50", "KZT"}, records[3])
+
+	buf.Reset()
+	require.Nil(t, services.WriteInvoicePDF(buf, got))
+	asrt.True(bytes.HasPrefix(buf.Bytes(), []byte("%PDF-")))
+}
+
+func TestManageInvoice_Organizations(t *testing.T) {
+	asrt := assert.New(t)
+	deliveries := memory.NewDeliveryRepository()
+	completedAt := time.Date(2025, 3, 2, 0, 0, 0, 0, time.UTC)
+	for _, u := range []*entities.User{acmeOwner, acmeViewer, globexStaff, recipient} {
+		d := entities.NewDelivery("Some Address 1, 14", nil, u)
+		d.Price = &valueobjects.Price{Amount: 100000, Currency: "KZT"}
+		require.Nil(t, deliveries.Store(ctx, d))
+		d.Status, d.CompletedAt = valueobjects.Completed, &completedAt
+		require.Nil(t, deliveries.Update(ctx, d))
+	}
+	srv := services.NewManageInvoice(memory.NewInvoicesRepository(), deliveries, memory.NewTxManager(), newCalendar(t, services.CalendarRules{}))
+
+	invoices, err := srv.Generate(ctx, "2025-03", nil)
+	require.Nil(t, err)
+	require.Len(t, invoices, 3)
+	asrt.Equal(recipient.Id, invoices[0].MerchantId)
+	acme := invoices[1]
+	asrt.Equal(entities.Tenant{OrganizationId: acmeId}, acme.Merchant())
+	asrt.Zero(acme.MerchantId)
+	asrt.Equal(uint(2), acme.Deliveries, "members are billed together")
+	asrt.Equal(entities.Tenant{OrganizationId: globexId}, invoices[2].Merchant())
+
+	got, err := srv.GetOne(ctx, acmeViewer, acme.Id)
+	require.Nil(t, err)
+	asrt.Len(got.Lines, 2)
+	for _, outsider := range []*entities.User{globexStaff, recipient} {
+		_, err = srv.GetOne(ctx, outsider, acme.Id)
+		asrt.True(errors.Is(err, services.ErrNotMerchant))
+	}
+	scoped := repositories.WithTenant(ctx, entities.TenantOf(globexStaff))
+	_, err = srv.GetOne(scoped, globexStaff, acme.Id)
+	asrt.True(errors.Is(err, repositories.ErrInvoiceNotFound))
+	all, err := srv.GetAll(scoped, repositories.InvoiceFilter{})
+	require.Nil(t, err)
+	require.Len(t, all, 1)
+	asrt.Equal(invoices[2].Id, all[0].Id)
+}
+
+func TestWriteInvoiceCSV_Formulas(t *testing.T) {
+	invoice := &entities.Invoice{Currency: "KZT", Lines: []entities.InvoiceLine{
+		{DeliveryId: 1, Destination: "=HYPERLINK(\"http://evil\")"},
+		{DeliveryId: 2, Destination: "@SUM(A1)"},
+		{DeliveryId: 3, Destination: "-1+2"},
+		{DeliveryId: 4, Destination: "Abay 10, 3"},
+	}}
+	buf := &bytes.Buffer{}
+	require.Nil(t, services.WriteInvoiceCSV(buf, invoice))
+	records, err := csv.NewReader(buf).ReadAll()
+	require.Nil(t, err)
+	destinations := make([]string, 0)
+	for _, record := range records[1:5] {
+		destinations = append(destinations, record[2])
+	}
+	assert.Equal(t, []string{"'=HYPERLINK(\"http://evil\")", "'@SUM(A1)", "'-1+2", "Abay 10, 3"}, destinations)
+}
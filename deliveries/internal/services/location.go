@@ -0,0 +1,137 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/zhanbolat18/parcel/deliveries/internal/entities"
+	"github.com/zhanbolat18/parcel/deliveries/internal/repositories"
+	"github.com/zhanbolat18/parcel/deliveries/internal/valueobjects"
+	"log"
+	"sort"
+	"time"
+)
+
+const (
+	// maxClockSkew tolerates courier devices whose clock runs slightly ahead.
+	maxClockSkew = time.Minute
+	// partitionsAhead keeps the storage of the next days ready, so fixes
+	// have their partition even when cleanup runs are missed.
+	partitionsAhead = 3 * 24 * time.Hour
+)
+
+var (
+	ErrInvalidLocation = errors.New("invalid location fix")
+	ErrNotRecipient    = errors.New("delivery belongs to another recipient")
+	ErrNotInDelivery   = errors.New("delivery is not being delivered")
+)
+
+type ManageLocation struct {
+	historyRepo   repositories.LocationHistoryRepository
+	locationsRepo repositories.LocationsRepository
+	deliveryRepo  repositories.DeliveriesRepository
+	retention     time.Duration
+	now           func() time.Time
+}
+
+func NewManageLocation(
+	historyRepo repositories.LocationHistoryRepository,
+	locationsRepo repositories.LocationsRepository,
+	deliveryRepo repositories.DeliveriesRepository,
+	retention time.Duration,
+) *ManageLocation {
+	return &ManageLocation{
+		historyRepo:   historyRepo,
+		locationsRepo: locationsRepo,
+		deliveryRepo:  deliveryRepo,
+		retention:     retention,
+		now:           time.Now,
+	}
+}
+
+// Report stores a batch of fixes of the courier and returns how many were
+// accepted. Fixes older than the retention are skipped, since they would be
+// deleted right away, a fix from the future rejects the whole batch.
+func (m *ManageLocation) Report(ctx context.Context, courierId uint, fixes []*entities.LocationFix) (int, error) {
+	now := m.now()
+	accepted := make([]*entities.LocationFix, 0, len(fixes))
+	for _, fix := range fixes {
+		if fix.RecordedAt.After(now.Add(maxClockSkew)) {
+			return 0, fmt.Errorf("%w: recorded at %s is in the future", ErrInvalidLocation, fix.RecordedAt.Format(time.RFC3339))
+		}
+		if fix.RecordedAt.Before(now.Add(-m.retention)) {
+			continue
+		}
+		fix.CourierId = courierId
+		accepted = append(accepted, fix)
+	}
+	if len(accepted) == 0 {
+		return 0, nil
+	}
+	sort.SliceStable(accepted, func(i, j int) bool {
+		return accepted[i].RecordedAt.Before(accepted[j].RecordedAt)
+	})
+	if err := m.historyRepo.Store(ctx, accepted...); err != nil {
+		return 0, fmt.Errorf("store location fixes: %w", err)
+	}
+	if err := m.locationsRepo.Remember(ctx, accepted[len(accepted)-1]); err != nil {
+		return 0, fmt.Errorf("remember last location: %w", err)
+	}
+	return len(accepted), nil
+}
+
+// DeliveryLocation returns the last known position of the courier carrying
+// the delivery of the recipient.
+func (m *ManageLocation) DeliveryLocation(ctx context.Context, recipient *entities.User, deliveryId uint) (*entities.LocationFix, error) {
+	delivery, err := m.deliveryRepo.GetById(ctx, deliveryId)
+	if err != nil {
+		return nil, fmt.Errorf("get delivery by id \"%d\": %w", deliveryId, err)
+	}
+	if !entities.TenantOf(recipient).Owns(delivery) {
+		return nil, ErrNotRecipient
+	}
+	if delivery.Status != valueobjects.Delivers || delivery.CourierId == nil {
+		return nil, fmt.Errorf("%w: delivery is %s", ErrNotInDelivery, delivery.Status)
+	}
+	fix, err := m.locationsRepo.Latest(ctx, *delivery.CourierId)
+	if err != nil {
+		return nil, fmt.Errorf("get courier location: %w", err)
+	}
+	return fix, nil
+}
+
+// PreparePartitions prepares the storage for the fixes of today and the next
+// days, it runs before serving traffic.
+func (m *ManageLocation) PreparePartitions(ctx context.Context) error {
+	now := m.now()
+	if err := m.historyRepo.PreparePartitions(ctx, now, now.Add(partitionsAhead)); err != nil {
+		return fmt.Errorf("prepare location partitions: %w", err)
+	}
+	return nil
+}
+
+// Cleanup prepares the storage for the next days and deletes the fixes older
+// than the retention, a failure of one does not skip the other.
+func (m *ManageLocation) Cleanup(ctx context.Context) error {
+	prepareErr := m.PreparePartitions(ctx)
+	if err := m.historyRepo.DeleteBefore(ctx, m.now().Add(-m.retention)); err != nil {
+		return errors.Join(prepareErr, fmt.Errorf("delete expired locations: %w", err))
+	}
+	return prepareErr
+}
+
+// RunCleanup runs Cleanup right away and then every interval until ctx is done.
+func (m *ManageLocation) RunCleanup(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := m.Cleanup(ctx); err != nil {
+			log.Printf("location cleanup: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package services_test
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zhanbolat18/parcel/deliveries/internal/entities"
+	"github.com/zhanbolat18/parcel/deliveries/internal/repositories"
+	"github.com/zhanbolat18/parcel/deliveries/internal/repositories/memory"
+	"github.com/zhanbolat18/parcel/deliveries/internal/services"
+	"github.com/zhanbolat18/parcel/deliveries/internal/valueobjects"
+	"testing"
+	"time"
+)
+
+func newLocations(t *testing.T, statuses ...valueobjects.Status) (*services.ManageLocation, repositories.LocationHistoryRepository) {
+	_, deliveries := newService(t, statuses...)
+	history := memory.NewLocationHistoryRepository()
+	return services.NewManageLocation(history, memory.NewLocationsRepository(), deliveries, 24*time.Hour), history
+}
+
+func TestManageLocation_Report(t *testing.T) {
+	srv, history := newLocations(t)
+	asrt := assert.New(t)
+	now := time.Now()
+
+	accepted, err := srv.Report(ctx, courier.Id, []*entities.LocationFix{
+		{Latitude: 1, Longitude: 1, RecordedAt: now.Add(-time.Minute)},
+		{Latitude: 2, Longitude: 2, RecordedAt: now.Add(-2 * time.Minute)},
+		{Latitude: 3, Longitude: 3, RecordedAt: now.Add(-48 * time.Hour)},
+	})
+	asrt.Nil(err)
+	asrt.Equal(2, accepted)
+
+	stored, err := history.GetByCourier(ctx, courier.Id, now.Add(-72*time.Hour), now)
+	asrt.Nil(err)
+	asrt.Len(stored, 2)
+
+	_, err = srv.Report(ctx, courier.Id, []*entities.LocationFix{{RecordedAt: now.Add(time.Hour)}})
+	asrt.True(errors.Is(err, services.ErrInvalidLocation))
+}
+
+func TestManageLocation_DeliveryLocation(t *testing.T) {
+	srv, _ := newLocations(t, valueobjects.Delivers, valueobjects.Created, valueobjects.Delivers)
+	asrt := assert.New(t)
+
+	_, err := srv.DeliveryLocation(ctx, recipient, 1)
+	asrt.True(errors.Is(err, repositories.ErrLocationNotFound))
+
+	_, err = srv.Report(ctx, courier.Id, []*entities.LocationFix{
+		{Latitude: 1, Longitude: 1, RecordedAt: time.Now().Add(-time.Minute)},
+		{Latitude: 2, Longitude: 2, RecordedAt: time.Now().Add(-2 * time.Minute)},
+	})
+	require.Nil(t, err)
+	// an older batch does not replace the last known position
+	_, err = srv.Report(ctx, courier.Id, []*entities.LocationFix{{Latitude: 4, Longitude: 4, RecordedAt: time.Now().Add(-time.Hour)}})
+	require.Nil(t, err)
+
+	fix, err := srv.DeliveryLocation(ctx, recipient, 1)
+	asrt.Nil(err)
+	if asrt.NotNil(fix) {
+		asrt.Equal(courier.Id, fix.CourierId)
+		asrt.Equal(1.0, fix.Latitude)
+	}
+
+	_, err = srv.DeliveryLocation(ctx, other, 1)
+	asrt.True(errors.Is(err, services.ErrNotRecipient))
+	_, err = srv.DeliveryLocation(ctx, recipient, 2)
+	asrt.True(errors.Is(err, services.ErrNotInDelivery))
+	_, err = srv.DeliveryLocation(ctx, recipient, 100)
+	asrt.True(errors.Is(err, repositories.ErrDeliveryNotFound))
+}
+
+type failingPartitions struct {
+	repositories.LocationHistoryRepository
+}
+
+func (failingPartitions) PreparePartitions(_ context.Context, _, _ time.Time) error {
+	return errors.New("partition exists")
+}
+
+func TestManageLocation_CleanupDeletesWhenPrepareFails(t *testing.T) {
+	_, deliveries := newService(t)
+	history := memory.NewLocationHistoryRepository()
+	srv := services.NewManageLocation(failingPartitions{history}, memory.NewLocationsRepository(), deliveries, 24*time.Hour)
+	now := time.Now()
+	require.Nil(t, history.Store(ctx, &entities.LocationFix{CourierId: courier.Id, RecordedAt: now.Add(-48 * time.Hour)}))
+
+	assert.NotNil(t, srv.Cleanup(ctx))
+	stored, err := history.GetByCourier(ctx, courier.Id, now.Add(-72*time.Hour), now)
+	require.Nil(t, err)
+	assert.Empty(t, stored)
+}
@@ -0,0 +1,267 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/zhanbolat18/parcel/deliveries/internal/entities"
+	"github.com/zhanbolat18/parcel/deliveries/internal/valueobjects"
+	"math"
+	"strings"
+	"time"
+)
+
+// MaxWeightKg and MaxSideCm bound the parcels Pricing quotes, which keeps
+// their weight in grams and its price within int64.
+const (
+	MaxWeightKg = 1000
+	MaxSideCm   = 1000
+)
+
+var (
+	ErrInvalidParcel = errors.New("invalid parcel")
+	ErrInvalidQuote  = errors.New("invalid quote")
+	ErrQuoteExpired  = errors.New("quote expired")
+)
+
+// Rate is the rate table of a service level. Zone is charged instead of the
+// distance when the pickup or drop-off location is unknown.
+type Rate struct {
+	Base  valueobjects.Money `json:"base"`
+	PerKm valueobjects.Money `json:"per_km"`
+	PerKg valueobjects.Money `json:"per_kg"`
+	Zone  valueobjects.Money `json:"zone"`
+}
+
+type Surcharges struct {
+	Fragile  valueobjects.Money
+	Oversize valueobjects.Money
+	Remote   valueobjects.Money
+}
+
+// PricingRules configure Pricing. Parcels with a side over OversizeCm or
+// heavier than OversizeKg are oversize, the volumetric weight of a parcel is
+// its volume in cubic centimeters over VolumetricDivisor.
+type PricingRules struct {
+	Currency          string
+	Rates             map[valueobjects.ServiceLevel]Rate
+	Surcharges        Surcharges
+	RemoteZones       []string
+	OversizeCm        uint
+	OversizeKg        float64
+	VolumetricDivisor uint
+}
+
+type Parcel struct {
+	WeightKg float64
+	LengthCm uint
+	WidthCm  uint
+	HeightCm uint
+	Fragile  bool
+}
+
+type QuoteRequest struct {
+	ServiceLevel   valueobjects.ServiceLevel
+	Zone           string
+	OriginLocation *valueobjects.Location
+	Location       *valueobjects.Location
+	Parcel
+}
+
+// quoteClaims are signed into the quote id, so quotes need no storage.
+type quoteClaims struct {
+	RecipientId    uint                      `json:"r"`
+	ServiceLevel   valueobjects.ServiceLevel `json:"l"`
+	Zone           string                    `json:"z,omitempty"`
+	OriginLocation *valueobjects.Location    `json:"o,omitempty"`
+	Location       *valueobjects.Location    `json:"d,omitempty"`
+	Amount         valueobjects.Money        `json:"a"`
+	Currency       string                    `json:"c"`
+	ExpiresAt      int64                     `json:"e"`
+}
+
+// Pricing prices deliveries by the rate table of their service level and
+// issues quotes that lock a price for ttl.
+type Pricing struct {
+	rules  PricingRules
+	remote map[string]bool
+	secret []byte
+	ttl    time.Duration
+	now    func() time.Time
+}
+
+func NewPricing(rules PricingRules, secret string, ttl time.Duration) (*Pricing, error) {
+	switch {
+	case secret == "":
+		return nil, errors.New("quote secret must be set")
+	case ttl <= 0:
+		return nil, errors.New("quote ttl must be positive")
+	case rules.Currency == "":
+		return nil, errors.New("pricing currency must be set")
+	case rules.VolumetricDivisor == 0:
+		return nil, errors.New("volumetric divisor must be positive")
+	}
+	for level := range rules.Rates {
+		if !level.Valid() {
+			return nil, fmt.Errorf("rates of unknown service level \"%s\"", level)
+		}
+	}
+	remote := make(map[string]bool, len(rules.RemoteZones))
+	for _, zone := range rules.RemoteZones {
+		remote[zone] = true
+	}
+	return &Pricing{rules: rules, remote: remote, secret: []byte(secret), ttl: ttl, now: time.Now}, nil
+}
+
+// Quote prices the request for the recipient.
+func (p *Pricing) Quote(recipient *entities.User, req QuoteRequest) (*entities.Quote, error) {
+	if req.ServiceLevel == "" {
+		req.ServiceLevel = valueobjects.Standard
+	}
+	rate, ok := p.rules.Rates[req.ServiceLevel]
+	if !ok {
+		return nil, fmt.Errorf("%w \"%s\"", ErrInvalidServiceLevel, req.ServiceLevel)
+	}
+	if req.WeightKg <= 0 || req.LengthCm == 0 || req.WidthCm == 0 || req.HeightCm == 0 {
+		return nil, fmt.Errorf("%w: weight and dimensions must be positive", ErrInvalidParcel)
+	}
+	// written negated so that NaN is rejected too
+	if !(req.WeightKg <= MaxWeightKg) || req.LengthCm > MaxSideCm || req.WidthCm > MaxSideCm || req.HeightCm > MaxSideCm {
+		return nil, fmt.Errorf("%w: weight is limited to %d kg and sides to %d cm", ErrInvalidParcel, MaxWeightKg, MaxSideCm)
+	}
+	quote := &entities.Quote{
+		ServiceLevel: req.ServiceLevel,
+		Zone:         strings.TrimSpace(req.Zone),
+		WeightKg:     p.chargeableWeight(req.Parcel),
+		Lines:        []entities.PriceLine{{Name: "base", Amount: rate.Base}},
+		ExpiresAt:    p.now().Add(p.ttl).Truncate(time.Second),
+	}
+	if req.OriginLocation != nil && req.Location != nil {
+		meters := math.Round(req.OriginLocation.DistanceTo(*req.Location))
+		quote.DistanceKm = meters / 1000
+		quote.Lines = append(quote.Lines, entities.PriceLine{Name: "distance", Amount: rate.PerKm.Mul(int64(meters), 1000)})
+	} else {
+		quote.Lines = append(quote.Lines, entities.PriceLine{Name: "zone", Amount: rate.Zone})
+	}
+	grams := int64(math.Round(quote.WeightKg * 1000))
+	quote.Lines = append(quote.Lines, entities.PriceLine{Name: "weight", Amount: rate.PerKg.Mul(grams, 1000)})
+	if req.Fragile {
+		quote.Lines = append(quote.Lines, entities.PriceLine{Name: "fragile", Amount: p.rules.Surcharges.Fragile})
+	}
+	if p.oversize(req.Parcel) {
+		quote.Lines = append(quote.Lines, entities.PriceLine{Name: "oversize", Amount: p.rules.Surcharges.Oversize})
+	}
+	if p.remote[quote.Zone] {
+		quote.Lines = append(quote.Lines, entities.PriceLine{Name: "remote_zone", Amount: p.rules.Surcharges.Remote})
+	}
+	quote.Price.Currency = p.rules.Currency
+	for _, line := range quote.Lines {
+		quote.Price.Amount += line.Amount
+	}
+
+	id, err := p.sign(quoteClaims{
+		RecipientId:    recipient.Id,
+		ServiceLevel:   quote.ServiceLevel,
+		Zone:           quote.Zone,
+		OriginLocation: req.OriginLocation,
+		Location:       req.Location,
+		Amount:         quote.Price.Amount,
+		Currency:       quote.Price.Currency,
+		ExpiresAt:      quote.ExpiresAt.Unix(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	quote.Id = id
+	return quote, nil
+}
+
+// Lock puts the price of the quote onto the delivery of the recipient. The
+// delivery takes the service level and zone of the quote and has to go the
+// way that was quoted, a quote without a zone does not cover a zoned delivery
+// that may be charged more.
+func (p *Pricing) Lock(quoteId string, recipient *entities.User, delivery *entities.Delivery, req *CreateDelivery) error {
+	claims, err := p.verify(quoteId)
+	if err != nil {
+		return err
+	}
+	switch {
+	case claims.RecipientId != recipient.Id:
+		return fmt.Errorf("%w: quoted for another user", ErrInvalidQuote)
+	case req.ServiceLevel != "" && req.ServiceLevel != claims.ServiceLevel:
+		return fmt.Errorf("%w: quoted for the %s service level", ErrInvalidQuote, claims.ServiceLevel)
+	case req.Zone != "" && req.Zone != claims.Zone:
+		return fmt.Errorf("%w: quoted for zone \"%s\"", ErrInvalidQuote, claims.Zone)
+	case !sameLocation(claims.OriginLocation, req.OriginLocation) || !sameLocation(claims.Location, req.Location):
+		return fmt.Errorf("%w: quoted for other locations", ErrInvalidQuote)
+	}
+	req.ServiceLevel = claims.ServiceLevel
+	req.Zone = claims.Zone
+	delivery.Price = &valueobjects.Price{Amount: claims.Amount, Currency: claims.Currency}
+	return nil
+}
+
+func (p *Pricing) chargeableWeight(parcel Parcel) float64 {
+	volume := float64(parcel.LengthCm) * float64(parcel.WidthCm) * float64(parcel.HeightCm)
+	return math.Max(parcel.WeightKg, volume/float64(p.rules.VolumetricDivisor))
+}
+
+func (p *Pricing) oversize(parcel Parcel) bool {
+	if p.rules.OversizeKg > 0 && parcel.WeightKg > p.rules.OversizeKg {
+		return true
+	}
+	for _, side := range []uint{parcel.LengthCm, parcel.WidthCm, parcel.HeightCm} {
+		if p.rules.OversizeCm > 0 && side > p.rules.OversizeCm {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *Pricing) sign(claims quoteClaims) (string, error) {
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", fmt.Errorf("encode quote: %w", err)
+	}
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	return encoded + "." + base64.RawURLEncoding.EncodeToString(p.mac(encoded)), nil
+}
+
+func (p *Pricing) verify(quoteId string) (*quoteClaims, error) {
+	encoded, signature, ok := strings.Cut(quoteId, ".")
+	if !ok {
+		return nil, ErrInvalidQuote
+	}
+	mac, err := base64.RawURLEncoding.DecodeString(signature)
+	if err != nil || !hmac.Equal(mac, p.mac(encoded)) {
+		return nil, ErrInvalidQuote
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, ErrInvalidQuote
+	}
+	claims := &quoteClaims{}
+	if err = json.Unmarshal(payload, claims); err != nil {
+		return nil, ErrInvalidQuote
+	}
+	if !p.now().Before(time.Unix(claims.ExpiresAt, 0)) {
+		return nil, ErrQuoteExpired
+	}
+	return claims, nil
+}
+
+func (p *Pricing) mac(payload string) []byte {
+	mac := hmac.New(sha256.New, p.secret)
+	mac.Write([]byte("quote:" + payload))
+	return mac.Sum(nil)
+}
+
+func sameLocation(a, b *valueobjects.Location) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
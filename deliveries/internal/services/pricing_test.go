@@ -0,0 +1,139 @@
+package services_test
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zhanbolat18/parcel/deliveries/internal/entities"
+	"github.com/zhanbolat18/parcel/deliveries/internal/services"
+	"github.com/zhanbolat18/parcel/deliveries/internal/valueobjects"
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMoney(t *testing.T) {
+	asrt := assert.New(t)
+	for s, expected := range map[string]valueobjects.Money{"12": 1200, "12.5": 1250, "0.05": 5, " 1000.00 ": 100000} {
+		m, err := valueobjects.ParseMoney(s)
+		require.Nil(t, err, s)
+		asrt.Equal(expected, m, s)
+	}
+	max, err := valueobjects.ParseMoney("92233720368547757.99")
+	require.Nil(t, err)
+	asrt.Equal(valueobjects.Money(math.MaxInt64-8), max)
+	for _, s := range []string{"", "-1.00", "1.005", "1,00", ".5", "1.-5", "92233720368547758", "9223372036854775807"} {
+		_, err := valueobjects.ParseMoney(s)
+		asrt.NotNil(err, s)
+	}
+	asrt.Equal("0.10", valueobjects.Money(10).String())
+	asrt.Equal(valueobjects.Money(1235), valueobjects.Money(10000).Mul(12345, 100000), "half up")
+	asrt.Equal(valueobjects.Money(1234), valueobjects.Money(10000).Mul(12344, 100000))
+}
+
+func TestPricing_Quote(t *testing.T) {
+	pricing := newPricing(t)
+	asrt := assert.New(t)
+	parcel := services.Parcel{WeightKg: 2, LengthCm: 10, WidthCm: 10, HeightCm: 10}
+
+	quote, err := pricing.Quote(recipient, services.QuoteRequest{Zone: "center", Parcel: parcel})
+	require.Nil(t, err)
+	asrt.Equal(valueobjects.Standard, quote.ServiceLevel)
+	asrt.Equal([]entities.PriceLine{
+		{Name: "base", Amount: 100000},
+		{Name: "zone", Amount: 150000},
+		{Name: "weight", Amount: 40000},
+	}, quote.Lines)
+	asrt.Equal(valueobjects.Price{Amount: 290000, Currency: "KZT"}, quote.Price)
+	asrt.NotEmpty(quote.Id)
+	asrt.WithinDuration(time.Now().Add(time.Hour), quote.ExpiresAt, time.Second)
+
+	origin := &valueobjects.Location{Latitude: 43.238949, Longitude: 76.889709}
+	destination := &valueobjects.Location{Latitude: 43.2567, Longitude: 76.9286}
+	quote, err = pricing.Quote(recipient, services.QuoteRequest{
+		ServiceLevel:   valueobjects.Express,
+		Zone:           "north",
+		OriginLocation: origin,
+		Location:       destination,
+		Parcel:         services.Parcel{WeightKg: 10, LengthCm: 130, WidthCm: 50, HeightCm: 50, Fragile: true},
+	})
+	require.Nil(t, err)
+	meters := origin.DistanceTo(*destination)
+	asrt.InDelta(meters/1000, quote.DistanceKm, 0.001)
+	asrt.Equal(65.0, quote.WeightKg, "volumetric weight")
+	names := make([]string, 0, len(quote.Lines))
+	var total valueobjects.Money
+	for _, line := range quote.Lines {
+		names = append(names, line.Name)
+		total += line.Amount
+	}
+	asrt.Equal([]string{"base", "distance", "weight", "fragile", "oversize", "remote_zone"}, names)
+	asrt.Equal(valueobjects.Money(15000).Mul(int64(quote.DistanceKm*1000+0.5), 1000), quote.Lines[1].Amount)
+	asrt.Equal(valueobjects.Money(65*25000), quote.Lines[2].Amount)
+	asrt.Equal(total, quote.Price.Amount)
+
+	_, err = pricing.Quote(recipient, services.QuoteRequest{ServiceLevel: valueobjects.SameDay, Parcel: parcel})
+	asrt.True(errors.Is(err, services.ErrInvalidServiceLevel), "same day has no rates")
+	_, err = pricing.Quote(recipient, services.QuoteRequest{Parcel: services.Parcel{WeightKg: 1}})
+	asrt.True(errors.Is(err, services.ErrInvalidParcel))
+	for _, p := range []services.Parcel{
+		{WeightKg: 1e19, LengthCm: 10, WidthCm: 10, HeightCm: 10},
+		{WeightKg: math.NaN(), LengthCm: 10, WidthCm: 10, HeightCm: 10},
+		{WeightKg: 1, LengthCm: 1 << 30, WidthCm: 1 << 30, HeightCm: 1 << 30},
+	} {
+		_, err = pricing.Quote(recipient, services.QuoteRequest{Parcel: p})
+		asrt.True(errors.Is(err, services.ErrInvalidParcel), "%+v", p)
+	}
+}
+
+func TestManageDelivery_CreateQuoted(t *testing.T) {
+	srv, _ := newService(t)
+	pricing := newPricing(t)
+	asrt := assert.New(t)
+	location := &valueobjects.Location{Latitude: 43.2567, Longitude: 76.9286}
+	quote, err := pricing.Quote(recipient, services.QuoteRequest{
+		ServiceLevel: valueobjects.Express,
+		Location:     location,
+		Parcel:       services.Parcel{WeightKg: 2, LengthCm: 10, WidthCm: 10, HeightCm: 10},
+	})
+	require.Nil(t, err)
+	create := func(change func(req *services.CreateDelivery)) (*entities.Delivery, error) {
+		req := services.CreateDelivery{Destination: "Some Address 1, 14", Location: location, QuoteId: quote.Id}
+		change(&req)
+		return srv.Create(ctx, recipient, req)
+	}
+
+	for name, change := range map[string]func(req *services.CreateDelivery){
+		"tampered":       func(req *services.CreateDelivery) { req.QuoteId = strings.Replace(req.QuoteId, ".", "x.", 1) },
+		"service level":  func(req *services.CreateDelivery) { req.ServiceLevel = valueobjects.Standard },
+		"other location": func(req *services.CreateDelivery) { req.Location = &valueobjects.Location{Latitude: 43, Longitude: 76} },
+		"no location":    func(req *services.CreateDelivery) { req.Location = nil },
+		// the quote has no zone, so it left out the remote zone surcharge
+		"remote zone": func(req *services.CreateDelivery) { req.Zone = "north" },
+	} {
+		_, err = create(change)
+		asrt.True(errors.Is(err, services.ErrInvalidQuote), name)
+	}
+	_, err = srv.Create(ctx, &entities.User{Id: 5, Role: "user"}, services.CreateDelivery{
+		Destination: "Some Address 1, 14", Location: location, QuoteId: quote.Id,
+	})
+	asrt.True(errors.Is(err, services.ErrInvalidQuote), "quoted for another user")
+
+	d, err := create(func(*services.CreateDelivery) {})
+	require.Nil(t, err)
+	asrt.Equal(valueobjects.Express, d.ServiceLevel)
+	asrt.Equal(&quote.Price, d.Price)
+	asrt.Equal(d.CreatedAt.Add(24*time.Hour), *d.DueAt)
+
+	expiring, err := services.NewPricing(services.PricingRules{
+		Currency:          "KZT",
+		Rates:             map[valueobjects.ServiceLevel]services.Rate{valueobjects.Standard: {Base: 100}},
+		VolumetricDivisor: 5000,
+	}, "secret", time.Nanosecond)
+	require.Nil(t, err)
+	quote, err = expiring.Quote(recipient, services.QuoteRequest{Parcel: services.Parcel{WeightKg: 1, LengthCm: 1, WidthCm: 1, HeightCm: 1}})
+	require.Nil(t, err)
+	_, err = srv.Create(ctx, recipient, services.CreateDelivery{Destination: "Some Address 1, 14", QuoteId: quote.Id})
+	asrt.True(errors.Is(err, services.ErrQuoteExpired))
+}
@@ -0,0 +1,204 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/zhanbolat18/parcel/deliveries/internal/entities"
+	"github.com/zhanbolat18/parcel/deliveries/internal/repositories"
+	"github.com/zhanbolat18/parcel/deliveries/internal/valueobjects"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+)
+
+var ErrInvalidProof = errors.New("invalid proof of delivery")
+
+var proofContentTypes = map[string]bool{
+	"image/png":  true,
+	"image/jpeg": true,
+	"image/webp": true,
+}
+
+// ProofUpload is the evidence sent by the courier on completion.
+type ProofUpload struct {
+	ReceivedBy string
+	Location   *valueobjects.Location
+	Signature  io.Reader
+	Photos     []io.Reader
+}
+
+type ManageProof struct {
+	blobStore    repositories.BlobStore
+	proofsRepo   repositories.ProofsRepository
+	deliveryRepo repositories.DeliveriesRepository
+	maxPhotos    int
+	maxFileSize  int64
+}
+
+func NewManageProof(
+	blobStore repositories.BlobStore,
+	proofsRepo repositories.ProofsRepository,
+	deliveryRepo repositories.DeliveriesRepository,
+	maxPhotos int,
+	maxFileSize int64,
+) *ManageProof {
+	return &ManageProof{
+		blobStore:    blobStore,
+		proofsRepo:   proofsRepo,
+		deliveryRepo: deliveryRepo,
+		maxPhotos:    maxPhotos,
+		maxFileSize:  maxFileSize,
+	}
+}
+
+// MaxUploadSize bounds a whole completion request carrying a proof.
+func (m *ManageProof) MaxUploadSize() int64 {
+	return int64(m.maxPhotos+1)*m.maxFileSize + 1<<20
+}
+
+// Upload validates the evidence and stores its files. The returned proof is
+// saved by ManageDelivery.Complete, when that fails the files must be removed
+// with Discard.
+func (m *ManageProof) Upload(ctx context.Context, deliveryId uint, upload *ProofUpload) (*entities.Proof, error) {
+	switch {
+	case strings.TrimSpace(upload.ReceivedBy) == "":
+		return nil, fmt.Errorf("%w: name of the receiver must be set", ErrInvalidProof)
+	case upload.Signature == nil:
+		return nil, fmt.Errorf("%w: signature must be set", ErrInvalidProof)
+	case len(upload.Photos) > m.maxPhotos:
+		return nil, fmt.Errorf("%w: at most %d photos are allowed", ErrInvalidProof, m.maxPhotos)
+	}
+	proof := &entities.Proof{
+		DeliveryId: deliveryId,
+		ReceivedBy: strings.TrimSpace(upload.ReceivedBy),
+		Location:   upload.Location,
+		Files:      make([]entities.ProofFile, 0, len(upload.Photos)+1),
+	}
+	contents := append([]io.Reader{upload.Signature}, upload.Photos...)
+	for i, content := range contents {
+		kind := entities.ProofFilePhoto
+		if i == 0 {
+			kind = entities.ProofFileSignature
+		}
+		file, err := m.put(ctx, deliveryId, kind, content)
+		if err != nil {
+			m.Discard(ctx, proof)
+			return nil, err
+		}
+		proof.Files = append(proof.Files, *file)
+	}
+	return proof, nil
+}
+
+// UploadPhoto stores a single photo, e.g. of a failed attempt. When the
+// photo is not saved it must be removed with DiscardFile.
+func (m *ManageProof) UploadPhoto(ctx context.Context, deliveryId uint, content io.Reader) (*entities.ProofFile, error) {
+	return m.put(ctx, deliveryId, entities.ProofFilePhoto, content)
+}
+
+// Discard removes the stored files of a proof that was not saved.
+func (m *ManageProof) Discard(ctx context.Context, proof *entities.Proof) {
+	for i := range proof.Files {
+		m.DiscardFile(ctx, &proof.Files[i])
+	}
+}
+
+func (m *ManageProof) DiscardFile(ctx context.Context, file *entities.ProofFile) {
+	if err := m.blobStore.Delete(ctx, file.Key); err != nil && !errors.Is(err, repositories.ErrBlobNotFound) {
+		log.Printf("discard proof file \"%s\": %v", file.Key, err)
+	}
+}
+
+// Get returns the proof of the delivery to admins and to its recipient.
+func (m *ManageProof) Get(ctx context.Context, user *entities.User, deliveryId uint) (*entities.Proof, error) {
+	delivery, err := m.deliveryRepo.GetById(ctx, deliveryId)
+	if err != nil {
+		return nil, fmt.Errorf("get delivery by id \"%d\": %w", deliveryId, err)
+	}
+	if user.Role != "admin" && !entities.TenantOf(user).Owns(delivery) {
+		return nil, ErrNotRecipient
+	}
+	proof, err := m.proofsRepo.GetByDelivery(ctx, deliveryId)
+	if err != nil {
+		return nil, fmt.Errorf("get proof of delivery \"%d\": %w", deliveryId, err)
+	}
+	return proof, nil
+}
+
+// OpenFile returns a file of the proof with its content, the caller must close
+// the content.
+func (m *ManageProof) OpenFile(ctx context.Context, user *entities.User, deliveryId, fileId uint) (*entities.ProofFile, io.ReadCloser, error) {
+	proof, err := m.Get(ctx, user, deliveryId)
+	if err != nil {
+		return nil, nil, err
+	}
+	file, ok := proof.File(fileId)
+	if !ok {
+		return nil, nil, fmt.Errorf("proof file \"%d\": %w", fileId, repositories.ErrBlobNotFound)
+	}
+	content, err := m.Open(ctx, file)
+	if err != nil {
+		return nil, nil, err
+	}
+	return file, content, nil
+}
+
+// Open returns the content of a stored file, the caller checks the access to
+// it and closes the content.
+func (m *ManageProof) Open(ctx context.Context, file *entities.ProofFile) (io.ReadCloser, error) {
+	content, err := m.blobStore.Open(ctx, file.Key)
+	if err != nil {
+		return nil, fmt.Errorf("open file \"%d\": %w", file.Id, err)
+	}
+	return content, nil
+}
+
+// put stores an image, its content type is sniffed rather than trusted from
+// the client.
+func (m *ManageProof) put(ctx context.Context, deliveryId uint, kind string, content io.Reader) (*entities.ProofFile, error) {
+	head := make([]byte, 512)
+	n, err := io.ReadFull(content, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("read %s: %w", kind, err)
+	}
+	contentType := http.DetectContentType(head[:n])
+	if n == 0 || !proofContentTypes[contentType] {
+		return nil, fmt.Errorf("%w: %s must be a png, jpeg or webp image", ErrInvalidProof, kind)
+	}
+
+	id := make([]byte, 16)
+	if _, err = rand.Read(id); err != nil {
+		return nil, fmt.Errorf("generate blob key: %w", err)
+	}
+	file := &entities.ProofFile{
+		Kind:        kind,
+		ContentType: contentType,
+		Key:         fmt.Sprintf("deliveries/%d/%s", deliveryId, hex.EncodeToString(id)),
+	}
+	counter := &countingReader{r: io.LimitReader(io.MultiReader(bytes.NewReader(head[:n]), content), m.maxFileSize+1)}
+	if err = m.blobStore.Put(ctx, file.Key, counter); err != nil {
+		return nil, fmt.Errorf("store %s: %w", kind, err)
+	}
+	if counter.n > m.maxFileSize {
+		_ = m.blobStore.Delete(ctx, file.Key)
+		return nil, fmt.Errorf("%w: %s exceeds %d bytes", ErrInvalidProof, kind, m.maxFileSize)
+	}
+	file.Size = counter.n
+	return file, nil
+}
+
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
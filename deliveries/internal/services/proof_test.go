@@ -0,0 +1,109 @@
+package services_test
+
+import (
+	"bytes"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zhanbolat18/parcel/deliveries/internal/entities"
+	"github.com/zhanbolat18/parcel/deliveries/internal/repositories"
+	"github.com/zhanbolat18/parcel/deliveries/internal/repositories/memory"
+	"github.com/zhanbolat18/parcel/deliveries/internal/services"
+	"github.com/zhanbolat18/parcel/deliveries/internal/valueobjects"
+	"io"
+	"testing"
+)
+
+var admin = &entities.User{Id: 5, Email: "admin@mail.com", Role: "admin"}
+
+// png returns a minimal content sniffed as image/png.
+func png(size int) io.Reader {
+	header := []byte("\x89PNG\x0D\x0A\x1A\x0A")
+	return bytes.NewReader(append(header, make([]byte, size-len(header))...))
+}
+
+func newProofs(t *testing.T) (*services.ManageDelivery, *services.ManageProof, repositories.BlobStore) {
+	deliveries := memory.NewDeliveryRepository()
+	d := entities.NewDelivery("Some Address 1, 14", nil, recipient)
+	require.Nil(t, deliveries.Store(ctx, d))
+	d.Status, d.CourierId = valueobjects.Delivers, &courier.Id
+	require.Nil(t, deliveries.Update(ctx, d))
+
+	proofsRepo, blobs := memory.NewProofsRepository(), memory.NewBlobStore()
+	deps := newDeps(t, deliveries, memory.NewUsersRepository(courier, other, recipient), newCouriers(t, deliveries, courier))
+	deps.Proofs = proofsRepo
+	srv := services.NewManageDelivery(deps, policy)
+	return srv, services.NewManageProof(blobs, proofsRepo, deliveries, 2, 1024), blobs
+}
+
+func TestManageProof_Upload(t *testing.T) {
+	_, proofs, _ := newProofs(t)
+	testCases := []struct {
+		name   string
+		upload *services.ProofUpload
+		valid  bool
+	}{
+		{name: "signature only", upload: &services.ProofUpload{ReceivedBy: "John", Signature: png(100)}, valid: true},
+		{name: "with photos", upload: &services.ProofUpload{ReceivedBy: "John", Signature: png(100), Photos: []io.Reader{png(1024), png(10)}}, valid: true},
+		{name: "without name", upload: &services.ProofUpload{ReceivedBy: " ", Signature: png(100)}},
+		{name: "without signature", upload: &services.ProofUpload{ReceivedBy: "John"}},
+		{name: "too many photos", upload: &services.ProofUpload{ReceivedBy: "John", Signature: png(100), Photos: []io.Reader{png(10), png(10), png(10)}}},
+		{name: "too large", upload: &services.ProofUpload{ReceivedBy: "John", Signature: png(1025)}},
+		{name: "not an image", upload: &services.ProofUpload{ReceivedBy: "John", Signature: bytes.NewReader([]byte("%PDF-1.4"))}},
+	}
+	for _, tc := range testCases {
+		proof, err := proofs.Upload(ctx, 1, tc.upload)
+		if !tc.valid {
+			assert.True(t, errors.Is(err, services.ErrInvalidProof), "%s: %v", tc.name, err)
+			continue
+		}
+		if assert.Nil(t, err, tc.name) {
+			assert.Equal(t, entities.ProofFileSignature, proof.Files[0].Kind, tc.name)
+			assert.Equal(t, "image/png", proof.Files[0].ContentType, tc.name)
+			assert.Len(t, proof.Files, len(tc.upload.Photos)+1, tc.name)
+		}
+	}
+}
+
+func TestManageProof_CompleteWithProof(t *testing.T) {
+	srv, proofs, blobs := newProofs(t)
+	asrt := assert.New(t)
+
+	proof, err := proofs.Upload(ctx, 1, &services.ProofUpload{
+		ReceivedBy: "John",
+		Location:   &valueobjects.Location{Latitude: 43.238, Longitude: 76.945},
+		Signature:  png(100),
+		Photos:     []io.Reader{png(200)},
+	})
+	require.Nil(t, err)
+	_, err = srv.Complete(ctx, 1, courier, services.CompleteDelivery{Proof: proof})
+	require.Nil(t, err)
+
+	for _, user := range []*entities.User{recipient, admin} {
+		got, err := proofs.Get(ctx, user, 1)
+		if asrt.Nil(err) {
+			asrt.Equal("John", got.ReceivedBy)
+			asrt.Len(got.Files, 2)
+		}
+	}
+	_, err = proofs.Get(ctx, other, 1)
+	asrt.True(errors.Is(err, services.ErrNotRecipient))
+
+	file, content, err := proofs.OpenFile(ctx, recipient, 1, proof.Files[1].Id)
+	require.Nil(t, err)
+	data, err := io.ReadAll(content)
+	asrt.Nil(err)
+	asrt.Nil(content.Close())
+	asrt.Equal(entities.ProofFilePhoto, file.Kind)
+	asrt.Len(data, 200)
+	asrt.EqualValues(200, file.Size)
+
+	// the files of a proof that could not be saved are discarded
+	rejected, err := proofs.Upload(ctx, 1, &services.ProofUpload{ReceivedBy: "John", Signature: png(100)})
+	require.Nil(t, err)
+	_, err = srv.Complete(ctx, 1, courier, services.CompleteDelivery{Proof: rejected})
+	require.NotNil(t, err)
+	proofs.Discard(ctx, rejected)
+	_, err = blobs.Open(ctx, rejected.Files[0].Key)
+	asrt.True(errors.Is(err, repositories.ErrBlobNotFound))
+}
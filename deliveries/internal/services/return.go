@@ -0,0 +1,96 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/zhanbolat18/parcel/deliveries/internal/entities"
+	"github.com/zhanbolat18/parcel/deliveries/internal/repositories"
+	"github.com/zhanbolat18/parcel/deliveries/internal/valueobjects"
+	"time"
+)
+
+var ErrNotReturnable = errors.New("delivery can not be returned")
+
+// ReturnPolicy limits returns to Window after completion. Address receives
+// the returns of deliveries created without an origin, they are not
+// returnable when it is empty.
+type ReturnPolicy struct {
+	Window  time.Duration
+	Address string
+}
+
+// CreateReturn holds what the recipient provides for a return, Window is the
+// optional pickup time.
+type CreateReturn struct {
+	Window *valueobjects.TimeWindow
+}
+
+// Return creates the return of a completed delivery of the recipient. The
+// return is picked up at the destination of the original delivery and
+// dropped off at its origin, it is dispatched, assigned and completed as any
+// other delivery.
+func (m *ManageDelivery) Return(
+	ctx context.Context,
+	originalId uint,
+	recipient *entities.User,
+	req CreateReturn,
+) (*entities.Delivery, error) {
+	var ret *entities.Delivery
+	err := m.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		// the original stays locked, so it is returned at most once
+		original, err := m.deliveryRepo.GetById(ctx, originalId)
+		if err != nil {
+			return fmt.Errorf("get delivery by id \"%d\": %w", originalId, err)
+		}
+		if !entities.TenantOf(recipient).Owns(original) {
+			return ErrNotRecipient
+		}
+		if err = m.ensureReturnable(ctx, original); err != nil {
+			return err
+		}
+		ret = entities.NewDelivery(original.Origin, original.OriginLocation, recipient)
+		if original.Origin == "" {
+			ret.Destination = m.returns.Address
+		}
+		ret.Type = valueobjects.Return
+		ret.OriginalId = &original.Id
+		ret.Origin, ret.OriginLocation = original.Destination, original.DestinationLocation
+		if err = m.setDueAt(ret); err != nil {
+			return err
+		}
+		if err = m.schedule.Apply(ctx, ret, Schedule{Zone: original.Zone, Window: req.Window}); err != nil {
+			return err
+		}
+		if err = m.deliveryRepo.Store(ctx, ret); err != nil {
+			return fmt.Errorf("store return delivery: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	m.notify(EventReturnCreated, ret)
+	return ret, nil
+}
+
+func (m *ManageDelivery) ensureReturnable(ctx context.Context, original *entities.Delivery) error {
+	switch {
+	case original.Type == valueobjects.Return:
+		return fmt.Errorf("%w: it is a return itself", ErrNotReturnable)
+	case original.Status != valueobjects.Completed || original.CompletedAt == nil:
+		return fmt.Errorf("%w: it is not completed", ErrNotReturnable)
+	case time.Since(*original.CompletedAt) > m.returns.Window:
+		return fmt.Errorf("%w: returns are accepted within %s after completion", ErrNotReturnable, m.returns.Window)
+	case original.Origin == "" && m.returns.Address == "":
+		return fmt.Errorf("%w: there is no address to return it to", ErrNotReturnable)
+	}
+	_, err := m.deliveryRepo.GetReturnOf(ctx, original.Id)
+	if err == nil {
+		return fmt.Errorf("%w: it is already returned", ErrNotReturnable)
+	}
+	if !errors.Is(err, repositories.ErrDeliveryNotFound) {
+		return fmt.Errorf("get return of delivery \"%d\": %w", original.Id, err)
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package services_test
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zhanbolat18/parcel/deliveries/internal/services"
+	"github.com/zhanbolat18/parcel/deliveries/internal/valueobjects"
+	"testing"
+	"time"
+)
+
+func TestManageDelivery_Return(t *testing.T) {
+	srv, repo := newService(t, valueobjects.Delivers, valueobjects.Delivers, valueobjects.Created)
+	asrt := assert.New(t)
+
+	origin := &valueobjects.Location{Latitude: 43.2, Longitude: 76.9}
+	stored, err := repo.GetById(ctx, 1)
+	require.Nil(t, err)
+	stored.Origin, stored.OriginLocation = "Shop 5", origin
+	require.Nil(t, repo.Update(ctx, stored))
+	for _, id := range []uint{1, 2} {
+		_, err = srv.Complete(ctx, id, courier, services.CompleteDelivery{})
+		require.Nil(t, err)
+	}
+
+	_, err = srv.Return(ctx, 3, recipient, services.CreateReturn{})
+	asrt.True(errors.Is(err, services.ErrNotReturnable), "not completed")
+	_, err = srv.Return(ctx, 1, other, services.CreateReturn{})
+	asrt.True(errors.Is(err, services.ErrNotRecipient))
+
+	ret, err := srv.Return(ctx, 1, recipient, services.CreateReturn{})
+	require.Nil(t, err)
+	asrt.Equal(valueobjects.Return, ret.Type)
+	asrt.Equal(valueobjects.Created, ret.Status)
+	asrt.Equal(uint(1), *ret.OriginalId)
+	asrt.Equal("Some Address 1, 14", ret.Origin)
+	asrt.Equal("Shop 5", ret.Destination)
+	asrt.Equal(origin, ret.DestinationLocation)
+	asrt.Equal(recipient.Id, ret.RecipientId)
+
+	_, err = srv.Return(ctx, 1, recipient, services.CreateReturn{})
+	asrt.True(errors.Is(err, services.ErrNotReturnable), "returned once")
+	_, err = srv.Return(ctx, ret.Id, recipient, services.CreateReturn{})
+	asrt.True(errors.Is(err, services.ErrNotReturnable), "a return is not returned")
+
+	// deliveries without an origin go back to the configured address
+	ret, err = srv.Return(ctx, 2, recipient, services.CreateReturn{})
+	require.Nil(t, err)
+	asrt.Equal(returns.Address, ret.Destination)
+	asrt.Nil(ret.DestinationLocation)
+
+	// returns go through the normal flow
+	assigned, err := srv.AssignToCourier(ctx, ret.Id, courier.Id, 0)
+	require.Nil(t, err)
+	srv.RevealHandoverCode(assigned)
+	completed, err := srv.Complete(ctx, ret.Id, courier, services.CompleteDelivery{HandoverCode: assigned.HandoverCode})
+	require.Nil(t, err)
+	asrt.Equal(valueobjects.Completed, completed.Status)
+}
+
+func TestManageDelivery_ReturnWindow(t *testing.T) {
+	srv, repo := newService(t, valueobjects.Completed)
+	d, err := repo.GetById(ctx, 1)
+	require.Nil(t, err)
+	completedAt := time.Now().Add(-returns.Window - time.Minute)
+	d.CompletedAt = &completedAt
+	require.Nil(t, repo.Update(ctx, d))
+
+	_, err = srv.Return(ctx, 1, recipient, services.CreateReturn{})
+	assert.True(t, errors.Is(err, services.ErrNotReturnable))
+
+	completedAt = time.Now().Add(-returns.Window + time.Minute)
+	d.CompletedAt = &completedAt
+	require.Nil(t, repo.Update(ctx, d))
+	_, err = srv.Return(ctx, 1, recipient, services.CreateReturn{})
+	assert.Nil(t, err)
+}
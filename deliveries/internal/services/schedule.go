@@ -0,0 +1,178 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/zhanbolat18/parcel/deliveries/internal/entities"
+	"github.com/zhanbolat18/parcel/deliveries/internal/repositories"
+	"github.com/zhanbolat18/parcel/deliveries/internal/valueobjects"
+	"strings"
+	"time"
+)
+
+var ErrSlotFull = errors.New("delivery slot is fully booked")
+
+// MaxSlotDays limits the days listed by ManageSchedule.Slots at once.
+const MaxSlotDays = 31
+
+// Schedule is the requested time of a delivery, every field is optional.
+type Schedule struct {
+	Zone         string
+	ScheduledFor string
+	Window       *valueobjects.TimeWindow
+}
+
+// ManageSchedule books the slots of the calendar, every zone takes capacity
+// deliveries per slot.
+type ManageSchedule struct {
+	calendar     *Calendar
+	bookingsRepo repositories.SlotBookingsRepository
+	zones        []string
+	capacity     uint
+}
+
+func NewManageSchedule(
+	calendar *Calendar,
+	bookingsRepo repositories.SlotBookingsRepository,
+	zones []string,
+	capacity uint,
+) (*ManageSchedule, error) {
+	if len(zones) == 0 || capacity == 0 {
+		return nil, errors.New("slot zones and capacity must be set")
+	}
+	return &ManageSchedule{calendar: calendar, bookingsRepo: bookingsRepo, zones: zones, capacity: capacity}, nil
+}
+
+// Apply validates the schedule against the calendar and books the slot of
+// its window for the delivery. It is meant to run within the transaction
+// storing the delivery, so that a failed store does not keep the booking.
+func (m *ManageSchedule) Apply(ctx context.Context, delivery *entities.Delivery, schedule Schedule) error {
+	zone, err := m.zone(schedule.Zone, schedule.Window != nil)
+	if err != nil {
+		return err
+	}
+	scheduledFor := schedule.ScheduledFor
+	if scheduledFor != "" {
+		if _, err = m.calendar.Day(scheduledFor); err != nil {
+			return err
+		}
+	}
+	var slot *valueobjects.TimeWindow
+	if schedule.Window != nil {
+		if slot, err = m.calendar.SlotOf(schedule.Window); err != nil {
+			return err
+		}
+		day := m.calendar.Date(slot.Start)
+		if scheduledFor != "" && scheduledFor != day {
+			return fmt.Errorf("%w: window is not on %s", ErrInvalidSchedule, scheduledFor)
+		}
+		scheduledFor = day
+	}
+	// a rescheduled delivery may keep its slot
+	if err = m.Release(ctx, delivery); err != nil {
+		return err
+	}
+	if slot != nil {
+		if err = m.bookingsRepo.Book(ctx, zone, slot.Start, m.capacity); err != nil {
+			if errors.Is(err, repositories.ErrSlotFull) {
+				return fmt.Errorf("%w: %s %s", ErrSlotFull, zone, slot.Start.Format(time.RFC3339))
+			}
+			return fmt.Errorf("book slot: %w", err)
+		}
+	}
+	delivery.Zone, delivery.ScheduledFor = zone, scheduledFor
+	delivery.Window, delivery.Slot = schedule.Window, slot
+	return nil
+}
+
+// Release gives the booked slot of the delivery back.
+func (m *ManageSchedule) Release(ctx context.Context, delivery *entities.Delivery) error {
+	if delivery.Slot == nil {
+		return nil
+	}
+	if err := m.bookingsRepo.Release(ctx, delivery.Zone, delivery.Slot.Start); err != nil {
+		return fmt.Errorf("release slot: %w", err)
+	}
+	delivery.Slot = nil
+	return nil
+}
+
+// Slots lists the bookable slots of the zone, or of every zone when it is
+// empty, for the days from and to inclusive. The days default to the next
+// week and span MaxSlotDays at most.
+func (m *ManageSchedule) Slots(ctx context.Context, zone, fromDate, toDate string) ([]*entities.Slot, error) {
+	zones := m.zones
+	if zone != "" {
+		if _, err := m.zone(zone, true); err != nil {
+			return nil, err
+		}
+		zones = []string{zone}
+	}
+	from, to, err := m.days(fromDate, toDate)
+	if err != nil {
+		return nil, err
+	}
+	windows := m.calendar.Slots(from, to)
+	slots := make([]*entities.Slot, 0, len(windows)*len(zones))
+	if len(windows) == 0 {
+		return slots, nil
+	}
+	for _, z := range zones {
+		booked, err := m.bookingsRepo.Booked(ctx, z, windows[0].Start, windows[len(windows)-1].End)
+		if err != nil {
+			return nil, fmt.Errorf("fetch slot bookings: %w", err)
+		}
+		for _, w := range windows {
+			slot := &entities.Slot{Zone: z, Start: w.Start, End: w.End, Capacity: m.capacity}
+			if taken := booked[w.Start.Unix()]; taken < m.capacity {
+				slot.Remaining = m.capacity - taken
+			}
+			slots = append(slots, slot)
+		}
+	}
+	return slots, nil
+}
+
+func (m *ManageSchedule) days(fromDate, toDate string) (from, to time.Time, err error) {
+	from = m.calendar.Today()
+	if fromDate != "" {
+		if from, err = m.calendar.Parse(fromDate); err != nil {
+			return from, to, err
+		}
+	}
+	to = from.AddDate(0, 0, 6)
+	if toDate != "" {
+		if to, err = m.calendar.Parse(toDate); err != nil {
+			return from, to, err
+		}
+	}
+	switch {
+	case to.Before(from):
+		return from, to, fmt.Errorf("%w: to must not be before from", ErrInvalidSchedule)
+	case to.After(from.AddDate(0, 0, MaxSlotDays-1)):
+		return from, to, fmt.Errorf("%w: at most %d days are listed at once", ErrInvalidSchedule, MaxSlotDays)
+	}
+	return from, to, nil
+}
+
+// zone validates the requested zone, a single configured zone is the
+// default one.
+func (m *ManageSchedule) zone(zone string, required bool) (string, error) {
+	zone = strings.TrimSpace(zone)
+	if zone == "" {
+		if len(m.zones) == 1 && required {
+			return m.zones[0], nil
+		}
+		if required {
+			return "", fmt.Errorf("%w: zone must be set", ErrInvalidSchedule)
+		}
+		return "", nil
+	}
+	for _, z := range m.zones {
+		if z == zone {
+			return zone, nil
+		}
+	}
+	return "", fmt.Errorf("%w: unknown zone \"%s\"", ErrInvalidSchedule, zone)
+}
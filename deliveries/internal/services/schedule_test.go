@@ -0,0 +1,159 @@
+package services_test
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zhanbolat18/parcel/deliveries/internal/entities"
+	"github.com/zhanbolat18/parcel/deliveries/internal/repositories/memory"
+	"github.com/zhanbolat18/parcel/deliveries/internal/services"
+	"github.com/zhanbolat18/parcel/deliveries/internal/valueobjects"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestNewCalendar(t *testing.T) {
+	for name, rules := range map[string]services.CalendarRules{
+		"time zone":     {TimeZone: "Mars/Olympus", WorkingHours: "08:00-20:00", SlotDuration: time.Hour},
+		"working hours": {TimeZone: "UTC", WorkingHours: "20:00-08:00", SlotDuration: time.Hour},
+		"clock":         {TimeZone: "UTC", WorkingHours: "8-20", SlotDuration: time.Hour},
+		"working day":   {TimeZone: "UTC", WorkingHours: "08:00-20:00", WorkingDays: []string{"funday"}, SlotDuration: time.Hour},
+		"holiday":       {TimeZone: "UTC", WorkingHours: "08:00-20:00", Holidays: []string{"31.12.2026"}, SlotDuration: time.Hour},
+		"slot duration": {TimeZone: "UTC", WorkingHours: "08:00-10:00", SlotDuration: 3 * time.Hour},
+	} {
+		_, err := services.NewCalendar(rules)
+		assert.NotNil(t, err, name)
+	}
+}
+
+func TestCalendar_SlotOf(t *testing.T) {
+	asrt := assert.New(t)
+	window := slotWindow(3)
+	holiday := slotWindow(4).Start.Format(services.DateLayout)
+	calendar := newCalendar(t, services.CalendarRules{Holidays: []string{holiday}, MinLeadTime: 48 * time.Hour})
+
+	slot, err := calendar.SlotOf(&valueobjects.TimeWindow{Start: window.Start.Add(30 * time.Minute), End: window.End})
+	require.Nil(t, err)
+	asrt.Equal(window, slot)
+
+	for name, w := range map[string]*valueobjects.TimeWindow{
+		"spans two slots": {Start: window.Start.Add(time.Hour), End: window.End.Add(time.Hour)},
+		"after hours":     {Start: window.Start.Add(10 * time.Hour), End: window.End.Add(10 * time.Hour)},
+		"holiday":         slotWindow(4),
+		"lead time":       slotWindow(1),
+	} {
+		_, err = calendar.SlotOf(w)
+		asrt.True(errors.Is(err, services.ErrInvalidSchedule), name)
+	}
+
+	_, err = calendar.Day(holiday)
+	asrt.True(errors.Is(err, services.ErrInvalidSchedule))
+	_, err = calendar.Day("tomorrow")
+	asrt.True(errors.Is(err, services.ErrInvalidSchedule))
+	day, err := calendar.Day(window.Start.Format(services.DateLayout))
+	require.Nil(t, err)
+	asrt.Len(calendar.Slots(day, day), 6)
+	asrt.Empty(calendar.Slots(day.AddDate(0, 0, 1), day.AddDate(0, 0, 1)), "holidays have no slots")
+}
+
+func TestCalendar_WorkingDays(t *testing.T) {
+	calendar := newCalendar(t, services.CalendarRules{WorkingDays: []string{"mon", "tue", "wed", "thu", "fri"}})
+	for days := 1; days <= 7; days++ {
+		window := slotWindow(days)
+		_, err := calendar.SlotOf(window)
+		switch window.Start.Weekday() {
+		case time.Saturday, time.Sunday:
+			assert.True(t, errors.Is(err, services.ErrInvalidSchedule), window.Start.Weekday())
+		default:
+			assert.Nil(t, err, window.Start.Weekday())
+		}
+	}
+}
+
+func TestManageSchedule_Apply(t *testing.T) {
+	asrt := assert.New(t)
+	schedule, err := services.NewManageSchedule(newCalendar(t, services.CalendarRules{}), memory.NewSlotBookingsRepository(),
+		[]string{"center", "north"}, 2)
+	require.Nil(t, err)
+	window := slotWindow(1)
+	day := window.Start.Format(services.DateLayout)
+
+	d := &entities.Delivery{}
+	err = schedule.Apply(ctx, d, services.Schedule{Window: window})
+	asrt.True(errors.Is(err, services.ErrInvalidSchedule), "zone is required with several zones")
+	err = schedule.Apply(ctx, d, services.Schedule{Zone: "south", Window: window})
+	asrt.True(errors.Is(err, services.ErrInvalidSchedule))
+	err = schedule.Apply(ctx, d, services.Schedule{Zone: "center", Window: window, ScheduledFor: slotWindow(2).Start.Format(services.DateLayout)})
+	asrt.True(errors.Is(err, services.ErrInvalidSchedule), "window is not on the scheduled day")
+
+	require.Nil(t, schedule.Apply(ctx, d, services.Schedule{ScheduledFor: day}))
+	asrt.Equal(day, d.ScheduledFor)
+	asrt.Nil(d.Slot)
+
+	require.Nil(t, schedule.Apply(ctx, d, services.Schedule{Zone: "center", Window: window}))
+	asrt.Equal("center", d.Zone)
+	asrt.Equal(day, d.ScheduledFor)
+	asrt.Equal(window, d.Slot)
+	require.Nil(t, schedule.Apply(ctx, &entities.Delivery{}, services.Schedule{Zone: "center", Window: window}))
+	err = schedule.Apply(ctx, &entities.Delivery{}, services.Schedule{Zone: "center", Window: window})
+	asrt.True(errors.Is(err, services.ErrSlotFull))
+	require.Nil(t, schedule.Apply(ctx, &entities.Delivery{}, services.Schedule{Zone: "north", Window: window}))
+	require.Nil(t, schedule.Apply(ctx, d, services.Schedule{Zone: "center", Window: window}), "a delivery keeps its slot")
+
+	slots, err := schedule.Slots(ctx, "center", day, day)
+	require.Nil(t, err)
+	require.Len(t, slots, 6)
+	asrt.Equal(uint(0), slots[1].Remaining)
+	asrt.Equal(uint(2), slots[2].Remaining)
+
+	// moving to another slot frees the old one
+	later := &valueobjects.TimeWindow{Start: window.Start.Add(2 * time.Hour), End: window.End.Add(2 * time.Hour)}
+	require.Nil(t, schedule.Apply(ctx, d, services.Schedule{Zone: "center", Window: later}))
+	slots, err = schedule.Slots(ctx, "", day, day)
+	require.Nil(t, err)
+	require.Len(t, slots, 12)
+	asrt.Equal(uint(1), slots[1].Remaining)
+	asrt.Equal(uint(1), slots[2].Remaining)
+	asrt.Equal("north", slots[7].Zone)
+	asrt.Equal(uint(1), slots[7].Remaining)
+
+	_, err = schedule.Slots(ctx, "center", day, slotWindow(0).Start.Format(services.DateLayout))
+	asrt.True(errors.Is(err, services.ErrInvalidSchedule))
+	_, err = schedule.Slots(ctx, "center", day, slotWindow(1+services.MaxSlotDays).Start.Format(services.DateLayout))
+	asrt.True(errors.Is(err, services.ErrInvalidSchedule))
+}
+
+func TestManageDelivery_CreateScheduled(t *testing.T) {
+	srv, _ := newService(t)
+	window := slotWindow(1)
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	created, full := 0, 0
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := srv.Create(ctx, recipient, services.CreateDelivery{
+				Destination: "Some Address 1, 14",
+				Schedule:    services.Schedule{Window: window},
+			})
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case err == nil:
+				created++
+			case errors.Is(err, services.ErrSlotFull):
+				full++
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, 2, created)
+	assert.Equal(t, 8, full)
+
+	d, err := srv.Create(ctx, recipient, services.CreateDelivery{Destination: "Some Address 1, 14"})
+	require.Nil(t, err)
+	assert.Empty(t, d.Zone, "unscheduled deliveries book no slot")
+}
@@ -0,0 +1,136 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/zhanbolat18/parcel/deliveries/internal/entities"
+	"github.com/zhanbolat18/parcel/deliveries/internal/repositories"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidShift = errors.New("invalid shift")
+	ErrShiftOverlap = errors.New("shift overlaps another shift of the courier")
+	ErrNoShift      = errors.New("courier has no shift covering the delivery")
+)
+
+type ManageShift struct {
+	shiftsRepo repositories.ShiftsRepository
+	txManager  repositories.TxManager
+	now        func() time.Time
+}
+
+func NewManageShift(shiftsRepo repositories.ShiftsRepository, txManager repositories.TxManager) *ManageShift {
+	return &ManageShift{shiftsRepo: shiftsRepo, txManager: txManager, now: time.Now}
+}
+
+func (m *ManageShift) Get(ctx context.Context, id uint) (*entities.Shift, error) {
+	shift, err := m.shiftsRepo.GetById(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("get shift by id \"%d\": %w", id, err)
+	}
+	return shift, nil
+}
+
+func (m *ManageShift) GetAll(ctx context.Context, filter repositories.ShiftFilter) ([]*entities.Shift, error) {
+	shifts, err := m.shiftsRepo.GetAll(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("fetch shifts: %w", err)
+	}
+	return shifts, nil
+}
+
+func (m *ManageShift) Create(ctx context.Context, shift *entities.Shift) error {
+	if err := m.validate(shift); err != nil {
+		return err
+	}
+	return m.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		if err := m.ensureNoOverlap(ctx, shift); err != nil {
+			return err
+		}
+		if err := m.shiftsRepo.Store(ctx, shift); err != nil {
+			return fmt.Errorf("store shift: %w", err)
+		}
+		return nil
+	})
+}
+
+func (m *ManageShift) Update(ctx context.Context, shift *entities.Shift) error {
+	if err := m.validate(shift); err != nil {
+		return err
+	}
+	return m.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		if _, err := m.Get(ctx, shift.Id); err != nil {
+			return err
+		}
+		if err := m.ensureNoOverlap(ctx, shift); err != nil {
+			return err
+		}
+		if err := m.shiftsRepo.Update(ctx, shift); err != nil {
+			return fmt.Errorf("update shift: %w", err)
+		}
+		return nil
+	})
+}
+
+func (m *ManageShift) Delete(ctx context.Context, id uint) error {
+	if err := m.shiftsRepo.Delete(ctx, id); err != nil {
+		return fmt.Errorf("delete shift \"%d\": %w", id, err)
+	}
+	return nil
+}
+
+// EnsureAssignable requires a single shift of the courier to cover the
+// delivery window, or the current moment for deliveries without one.
+func (m *ManageShift) EnsureAssignable(ctx context.Context, courierId uint, delivery *entities.Delivery) error {
+	start, end := m.now(), m.now()
+	if delivery.Window != nil {
+		start, end = delivery.Window.Start, delivery.Window.End
+	}
+	shifts, err := m.shiftsRepo.GetAll(ctx, repositories.ShiftFilter{CourierId: courierId, From: start, To: end.Add(time.Nanosecond)})
+	if err != nil {
+		return fmt.Errorf("fetch courier shifts: %w", err)
+	}
+	for _, s := range shifts {
+		if !s.StartsAt.After(start) && !s.EndsAt.Before(end) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: courier \"%d\", %s - %s", ErrNoShift, courierId,
+		start.Format(time.RFC3339), end.Format(time.RFC3339))
+}
+
+func (m *ManageShift) validate(shift *entities.Shift) error {
+	switch {
+	case shift.CourierId == 0:
+		return fmt.Errorf("%w: courier must be set", ErrInvalidShift)
+	case !shift.EndsAt.After(shift.StartsAt):
+		return fmt.Errorf("%w: shift must end after it starts", ErrInvalidShift)
+	case strings.TrimSpace(shift.Zone) == "":
+		return fmt.Errorf("%w: zone must be set", ErrInvalidShift)
+	}
+	return nil
+}
+
+func (m *ManageShift) ensureNoOverlap(ctx context.Context, shift *entities.Shift) error {
+	if err := m.shiftsRepo.LockCourier(ctx, shift.CourierId); err != nil {
+		return fmt.Errorf("lock courier shifts: %w", err)
+	}
+	overlapping, err := m.shiftsRepo.GetAll(ctx, repositories.ShiftFilter{
+		CourierId: shift.CourierId,
+		From:      shift.StartsAt,
+		To:        shift.EndsAt,
+	})
+	if err != nil {
+		return fmt.Errorf("fetch courier shifts: %w", err)
+	}
+	for _, s := range overlapping {
+		if s.Id != shift.Id {
+			return fmt.Errorf("%w: shift \"%d\" %s - %s", ErrShiftOverlap, s.Id,
+				s.StartsAt.Format(time.RFC3339), s.EndsAt.Format(time.RFC3339))
+		}
+	}
+	return nil
+}
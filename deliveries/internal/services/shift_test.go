@@ -0,0 +1,105 @@
+package services_test
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zhanbolat18/parcel/deliveries/internal/entities"
+	"github.com/zhanbolat18/parcel/deliveries/internal/repositories/memory"
+	"github.com/zhanbolat18/parcel/deliveries/internal/services"
+	"github.com/zhanbolat18/parcel/deliveries/internal/valueobjects"
+	"testing"
+	"time"
+)
+
+var day = time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)
+
+func newShift(courierId uint, from, to int) *entities.Shift {
+	return &entities.Shift{
+		CourierId: courierId,
+		StartsAt:  day.Add(time.Duration(from) * time.Hour),
+		EndsAt:    day.Add(time.Duration(to) * time.Hour),
+		Zone:      "center",
+	}
+}
+
+func TestManageShift_Create(t *testing.T) {
+	srv := services.NewManageShift(memory.NewShiftsRepository(), memory.NewTxManager())
+	asrt := assert.New(t)
+	require.Nil(t, srv.Create(ctx, newShift(courier.Id, 9, 13)))
+
+	testCases := []struct {
+		name  string
+		shift *entities.Shift
+		err   error
+	}{
+		{name: "overlapping", shift: newShift(courier.Id, 12, 18), err: services.ErrShiftOverlap},
+		{name: "inside", shift: newShift(courier.Id, 10, 11), err: services.ErrShiftOverlap},
+		{name: "adjacent", shift: newShift(courier.Id, 13, 18)},
+		{name: "other courier", shift: newShift(other.Id, 9, 13)},
+		{name: "ends before start", shift: newShift(courier.Id, 20, 19), err: services.ErrInvalidShift},
+		{name: "without zone", shift: &entities.Shift{CourierId: courier.Id, StartsAt: day, EndsAt: day.Add(time.Hour)}, err: services.ErrInvalidShift},
+	}
+	for _, tc := range testCases {
+		err := srv.Create(ctx, tc.shift)
+		if tc.err == nil {
+			asrt.Nil(err, tc.name)
+			asrt.NotZero(tc.shift.Id, tc.name)
+		} else {
+			asrt.True(errors.Is(err, tc.err), "%s: %v", tc.name, err)
+		}
+	}
+}
+
+func TestManageShift_Update(t *testing.T) {
+	srv := services.NewManageShift(memory.NewShiftsRepository(), memory.NewTxManager())
+	asrt := assert.New(t)
+	morning, evening := newShift(courier.Id, 9, 13), newShift(courier.Id, 15, 19)
+	require.Nil(t, srv.Create(ctx, morning))
+	require.Nil(t, srv.Create(ctx, evening))
+
+	// a shift does not overlap itself
+	morning.EndsAt = day.Add(14 * time.Hour)
+	asrt.Nil(srv.Update(ctx, morning))
+
+	morning.EndsAt = day.Add(16 * time.Hour)
+	asrt.True(errors.Is(srv.Update(ctx, morning), services.ErrShiftOverlap))
+
+	missing := newShift(courier.Id, 1, 2)
+	missing.Id = 100
+	asrt.NotNil(srv.Update(ctx, missing))
+}
+
+func TestManageShift_EnsureAssignable(t *testing.T) {
+	srv := services.NewManageShift(memory.NewShiftsRepository(), memory.NewTxManager())
+	asrt := assert.New(t)
+	require.Nil(t, srv.Create(ctx, newShift(courier.Id, 9, 13)))
+	require.Nil(t, srv.Create(ctx, newShift(courier.Id, 13, 18)))
+
+	window := func(from, to int) *entities.Delivery {
+		w, err := valueobjects.NewTimeWindow(day.Add(time.Duration(from)*time.Hour), day.Add(time.Duration(to)*time.Hour))
+		require.Nil(t, err)
+		return &entities.Delivery{Window: w}
+	}
+	asrt.Nil(srv.EnsureAssignable(ctx, courier.Id, window(10, 12)))
+	asrt.Nil(srv.EnsureAssignable(ctx, courier.Id, window(9, 13)))
+	// covering the window with two shifts is not enough
+	asrt.True(errors.Is(srv.EnsureAssignable(ctx, courier.Id, window(12, 14)), services.ErrNoShift))
+	asrt.True(errors.Is(srv.EnsureAssignable(ctx, other.Id, window(10, 12)), services.ErrNoShift))
+}
+
+func TestManageDelivery_AssignRequiresShift(t *testing.T) {
+	deliveries := memory.NewDeliveryRepository()
+	shifts := services.NewManageShift(memory.NewShiftsRepository(), memory.NewTxManager())
+	now := time.Now()
+	require.Nil(t, shifts.Create(ctx, &entities.Shift{CourierId: courier.Id, StartsAt: now.Add(-time.Hour), EndsAt: now.Add(time.Hour), Zone: "center"}))
+	guard := services.Guards(newCouriers(t, deliveries, courier, other), shifts)
+	srv := services.NewManageDelivery(newDeps(t, deliveries, memory.NewUsersRepository(courier, other, recipient), guard), policy)
+
+	d, err := srv.Create(ctx, recipient, services.CreateDelivery{Destination: "Some Address 1, 14"})
+	require.Nil(t, err)
+	_, err = srv.AssignToCourier(ctx, d.Id, other.Id, 0)
+	assert.True(t, errors.Is(err, services.ErrNoShift))
+	_, err = srv.AssignToCourier(ctx, d.Id, courier.Id, 0)
+	assert.Nil(t, err)
+}
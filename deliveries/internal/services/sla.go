@@ -0,0 +1,112 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/zhanbolat18/parcel/deliveries/internal/repositories"
+	"github.com/zhanbolat18/parcel/deliveries/internal/valueobjects"
+	"github.com/zhanbolat18/parcel/libs/metrics"
+	"log"
+	"time"
+)
+
+const (
+	EventSLAAtRisk   = "sla_at_risk"
+	EventSLABreached = "sla_breached"
+)
+
+var ErrInvalidServiceLevel = errors.New("invalid service level")
+
+// SLAPolicy sets how long after creation deliveries of every service level
+// are due, and how long before that they are at risk.
+type SLAPolicy struct {
+	Deadlines map[valueobjects.ServiceLevel]time.Duration
+	AtRisk    time.Duration
+}
+
+// DueAt returns when a delivery of the level created at the given time is due.
+func (p SLAPolicy) DueAt(level valueobjects.ServiceLevel, createdAt time.Time) (*time.Time, error) {
+	deadline, ok := p.Deadlines[level]
+	if !level.Valid() || !ok {
+		return nil, fmt.Errorf("%w \"%s\"", ErrInvalidServiceLevel, level)
+	}
+	dueAt := createdAt.Add(deadline)
+	return &dueAt, nil
+}
+
+// Status returns the SLA status of a delivery due at the given time.
+func (p SLAPolicy) Status(dueAt, now time.Time) valueobjects.SLAStatus {
+	switch {
+	case !now.Before(dueAt):
+		return valueobjects.Breached
+	case !now.Before(dueAt.Add(-p.AtRisk)):
+		return valueobjects.AtRisk
+	}
+	return valueobjects.OnTrack
+}
+
+// ManageSLA flags deliveries that are at risk of missing their due time or
+// have missed it, and announces every change.
+type ManageSLA struct {
+	deliveryRepo repositories.DeliveriesRepository
+	policy       SLAPolicy
+	events       metrics.Counter
+	publisher    Publisher
+}
+
+func NewManageSLA(
+	deliveryRepo repositories.DeliveriesRepository,
+	policy SLAPolicy,
+	events metrics.Counter,
+	publisher Publisher,
+) *ManageSLA {
+	return &ManageSLA{deliveryRepo: deliveryRepo, policy: policy, events: events, publisher: publisher}
+}
+
+// Check flags the deliveries due soon. A delivery is only announced by the
+// instance that flags it, so the check may run on several instances.
+func (m *ManageSLA) Check(ctx context.Context) error {
+	now := time.Now()
+	deliveries, err := m.deliveryRepo.GetAllDueBefore(ctx, now.Add(m.policy.AtRisk))
+	if err != nil {
+		return fmt.Errorf("fetch due deliveries: %w", err)
+	}
+	for _, delivery := range deliveries {
+		status := m.policy.Status(*delivery.DueAt, now)
+		if status == delivery.SLA {
+			continue
+		}
+		ok, err := m.deliveryRepo.UpdateSLA(ctx, delivery.Id, delivery.SLA, status)
+		if err != nil {
+			return fmt.Errorf("update sla of delivery %d: %w", delivery.Id, err)
+		}
+		if !ok {
+			continue
+		}
+		delivery.SLA = status
+		event := EventSLAAtRisk
+		if status == valueobjects.Breached {
+			event = EventSLABreached
+		}
+		m.events.Inc(event)
+		m.publisher.Publish(event, delivery)
+	}
+	return nil
+}
+
+// Run runs Check right away and then every interval until ctx is done.
+func (m *ManageSLA) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := m.Check(ctx); err != nil {
+			log.Printf("sla check: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
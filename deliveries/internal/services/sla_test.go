@@ -0,0 +1,75 @@
+package services_test
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zhanbolat18/parcel/deliveries/internal/entities"
+	"github.com/zhanbolat18/parcel/deliveries/internal/repositories/memory"
+	"github.com/zhanbolat18/parcel/deliveries/internal/services"
+	"github.com/zhanbolat18/parcel/deliveries/internal/valueobjects"
+	"github.com/zhanbolat18/parcel/libs/metrics"
+	"testing"
+	"time"
+)
+
+func TestManageDelivery_CreateServiceLevel(t *testing.T) {
+	srv, _ := newService(t)
+	asrt := assert.New(t)
+
+	d, err := srv.Create(ctx, recipient, services.CreateDelivery{Destination: "Some Address 1, 14"})
+	require.Nil(t, err)
+	asrt.Equal(valueobjects.Standard, d.ServiceLevel)
+	asrt.Equal(valueobjects.OnTrack, d.SLA)
+	if asrt.NotNil(d.DueAt) {
+		asrt.Equal(d.CreatedAt.Add(72*time.Hour), *d.DueAt)
+	}
+
+	d, err = srv.Create(ctx, recipient, services.CreateDelivery{Destination: "Some Address 1, 14", ServiceLevel: valueobjects.SameDay})
+	require.Nil(t, err)
+	asrt.Equal(d.CreatedAt.Add(4*time.Hour), *d.DueAt)
+
+	_, err = srv.Create(ctx, recipient, services.CreateDelivery{Destination: "Some Address 1, 14", ServiceLevel: "overnight"})
+	asrt.True(errors.Is(err, services.ErrInvalidServiceLevel))
+}
+
+func TestManageSLA_Check(t *testing.T) {
+	asrt := assert.New(t)
+	deliveries := memory.NewDeliveryRepository()
+	now := time.Now()
+	for _, due := range []time.Duration{-time.Minute, 30 * time.Minute, 2 * time.Hour} {
+		d := entities.NewDelivery("Some Address 1, 14", nil, recipient)
+		dueAt := now.Add(due)
+		d.DueAt = &dueAt
+		require.Nil(t, deliveries.Store(ctx, d))
+	}
+	broker := services.NewBroker(10)
+	sub, _ := broker.Subscribe(0, all)
+	defer sub.Close()
+	sla := services.NewManageSLA(deliveries, slas, metrics.NopCounter(), broker)
+
+	require.Nil(t, sla.Check(ctx))
+	require.Nil(t, sla.Check(ctx), "deliveries are announced once")
+	require.Len(t, sub.C, 2)
+	e := <-sub.C
+	asrt.Equal(services.EventSLABreached, e.Type)
+	asrt.Equal(uint(1), e.Delivery.Id)
+	e = <-sub.C
+	asrt.Equal(services.EventSLAAtRisk, e.Type)
+	asrt.Equal(uint(2), e.Delivery.Id)
+
+	for id, status := range map[uint]valueobjects.SLAStatus{1: valueobjects.Breached, 2: valueobjects.AtRisk, 3: valueobjects.OnTrack} {
+		d, err := deliveries.GetById(ctx, id)
+		require.Nil(t, err)
+		asrt.Equal(status, d.SLA, id)
+	}
+}
+
+func TestSLAPolicy_Status(t *testing.T) {
+	now := time.Now()
+	asrt := assert.New(t)
+	asrt.Equal(valueobjects.OnTrack, slas.Status(now.Add(61*time.Minute), now))
+	asrt.Equal(valueobjects.AtRisk, slas.Status(now.Add(time.Hour), now))
+	asrt.Equal(valueobjects.AtRisk, slas.Status(now.Add(time.Second), now))
+	asrt.Equal(valueobjects.Breached, slas.Status(now, now))
+}
@@ -0,0 +1,119 @@
+package services
+
+import (
+	"errors"
+	"github.com/zhanbolat18/parcel/deliveries/internal/entities"
+	"github.com/zhanbolat18/parcel/deliveries/internal/valueobjects"
+	"sort"
+	"sync"
+)
+
+const (
+	StrategyRoundRobin  = "round_robin"
+	StrategyLeastActive = "least_active"
+	StrategyNearest     = "nearest"
+)
+
+var ErrNoCourier = errors.New("no suitable courier")
+
+// Candidate is a courier the dispatcher may assign a delivery to.
+type Candidate struct {
+	Courier *entities.User
+	// Active is the number of deliveries the courier is delivering now.
+	Active   int
+	Capacity int
+	Location *valueobjects.Location
+}
+
+// Strategy picks a courier for the delivery. Candidates are online couriers
+// below their capacity, ordered by courier id and never empty.
+type Strategy interface {
+	Select(delivery *entities.Delivery, candidates []*Candidate) (*Candidate, error)
+}
+
+// statefulStrategy is implemented by strategies whose picks depend on the
+// earlier ones. Fork returns a strategy picking on from the current state
+// without changing it, Advance records that a pick was assigned.
+type statefulStrategy interface {
+	Strategy
+	Fork() Strategy
+	Advance()
+}
+
+type roundRobin struct {
+	mu   sync.Mutex
+	next int
+}
+
+func NewRoundRobinStrategy() Strategy {
+	return &roundRobin{}
+}
+
+func (r *roundRobin) Select(_ *entities.Delivery, candidates []*Candidate) (*Candidate, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	c := candidates[r.next%len(candidates)]
+	r.next++
+	return c, nil
+}
+
+func (r *roundRobin) Fork() Strategy {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return &roundRobin{next: r.next}
+}
+
+func (r *roundRobin) Advance() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.next++
+}
+
+type leastActive struct {
+}
+
+func NewLeastActiveStrategy() Strategy {
+	return &leastActive{}
+}
+
+func (l *leastActive) Select(_ *entities.Delivery, candidates []*Candidate) (*Candidate, error) {
+	selected := candidates[0]
+	for _, c := range candidates[1:] {
+		if c.Active < selected.Active {
+			selected = c
+		}
+	}
+	return selected, nil
+}
+
+type nearest struct {
+}
+
+func NewNearestStrategy() Strategy {
+	return &nearest{}
+}
+
+func (n *nearest) Select(delivery *entities.Delivery, candidates []*Candidate) (*Candidate, error) {
+	if delivery.DestinationLocation == nil {
+		return nil, errors.New("delivery has no destination location")
+	}
+	var selected *Candidate
+	var selectedDistance float64
+	for _, c := range candidates {
+		if c.Location == nil {
+			continue
+		}
+		distance := c.Location.DistanceTo(*delivery.DestinationLocation)
+		if selected == nil || distance < selectedDistance {
+			selected, selectedDistance = c, distance
+		}
+	}
+	if selected == nil {
+		return nil, errors.New("no courier has reported a location")
+	}
+	return selected, nil
+}
+
+func sortCandidates(candidates []*Candidate) {
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i].Courier.Id < candidates[j].Courier.Id })
+}
@@ -0,0 +1,75 @@
+package services_test
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zhanbolat18/parcel/deliveries/internal/entities"
+	"github.com/zhanbolat18/parcel/deliveries/internal/repositories"
+	"github.com/zhanbolat18/parcel/deliveries/internal/services"
+	"testing"
+)
+
+var acmeId, globexId = uint(7), uint(8)
+
+var (
+	acmeOwner   = &entities.User{Id: 20, Email: "owner@acme.com", Role: "user", OrganizationId: &acmeId, OrgRole: "owner"}
+	acmeViewer  = &entities.User{Id: 21, Email: "viewer@acme.com", Role: "user", OrganizationId: &acmeId, OrgRole: "viewer"}
+	globexStaff = &entities.User{Id: 30, Email: "staff@globex.com", Role: "user", OrganizationId: &globexId, OrgRole: "staff"}
+)
+
+func ids(deliveries []*entities.Delivery) []uint {
+	result := make([]uint, 0, len(deliveries))
+	for _, d := range deliveries {
+		result = append(result, d.Id)
+	}
+	return result
+}
+
+func TestManageDelivery_TenantIsolation(t *testing.T) {
+	srv, _ := newService(t)
+	asrt := assert.New(t)
+	acme, err := srv.Create(ctx, acmeOwner, services.CreateDelivery{Destination: "Acme Street 1"})
+	require.Nil(t, err)
+	globex, err := srv.Create(ctx, globexStaff, services.CreateDelivery{Destination: "Globex Street 1"})
+	require.Nil(t, err)
+	personal, err := srv.Create(ctx, recipient, services.CreateDelivery{Destination: "Some Address 1, 14"})
+	require.Nil(t, err)
+	asrt.Equal(&acmeId, acme.OrganizationId)
+	asrt.Nil(personal.OrganizationId)
+
+	for _, c := range []struct {
+		user *entities.User
+		sees []uint
+	}{
+		{acmeOwner, []uint{acme.Id}},
+		{acmeViewer, []uint{acme.Id}},
+		{globexStaff, []uint{globex.Id}},
+		{recipient, []uint{personal.Id}},
+	} {
+		deliveries, err := srv.GetAllByRecipient(ctx, c.user, repositories.DeliveryFilter{})
+		require.Nil(t, err)
+		asrt.Equal(c.sees, ids(deliveries), c.user.Email)
+	}
+
+	// requests of users are scoped to their tenant, others' deliveries do
+	// not exist for them
+	scoped := repositories.WithTenant(ctx, entities.TenantOf(acmeViewer))
+	got, err := srv.GetOne(scoped, acme.Id)
+	require.Nil(t, err)
+	asrt.Equal(acme.Id, got.Id)
+	for _, id := range []uint{globex.Id, personal.Id} {
+		_, err = srv.GetOne(scoped, id)
+		asrt.True(errors.Is(err, repositories.ErrDeliveryNotFound))
+		_, err = srv.Reschedule(scoped, id, acmeOwner, "", slotWindow(1), 0)
+		asrt.True(errors.Is(err, repositories.ErrDeliveryNotFound))
+	}
+
+	// the services check the tenant without the scope as well
+	_, err = srv.Reschedule(ctx, globex.Id, acmeOwner, "", slotWindow(1), 0)
+	asrt.True(errors.Is(err, services.ErrNotRecipient))
+	_, err = srv.Reschedule(ctx, acme.Id, recipient, "", slotWindow(1), 0)
+	asrt.True(errors.Is(err, services.ErrNotRecipient))
+	_, err = srv.Reschedule(ctx, acme.Id, acmeViewer, "", slotWindow(1), 0)
+	asrt.Nil(err, "members share the deliveries of the organization")
+}
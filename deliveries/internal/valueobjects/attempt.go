@@ -0,0 +1,19 @@
+package valueobjects
+
+type AttemptReason string
+
+const (
+	RecipientAbsent AttemptReason = "recipient_absent"
+	WrongAddress    AttemptReason = "wrong_address"
+	Refused         AttemptReason = "refused"
+	NoAccess        AttemptReason = "no_access"
+	OtherReason     AttemptReason = "other"
+)
+
+func (r AttemptReason) Valid() bool {
+	switch r {
+	case RecipientAbsent, WrongAddress, Refused, NoAccess, OtherReason:
+		return true
+	}
+	return false
+}
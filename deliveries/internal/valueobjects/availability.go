@@ -0,0 +1,9 @@
+package valueobjects
+
+type Availability string
+
+const (
+	Online  Availability = "online"
+	Offline Availability = "offline"
+	OnBreak Availability = "on_break"
+)
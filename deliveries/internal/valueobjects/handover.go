@@ -0,0 +1,13 @@
+package valueobjects
+
+// Handover protects the handover of an assigned delivery with a code the
+// recipient gives the courier. Only the hash of the code is kept, the nonce
+// lets the service derive the code again for the recipient.
+type Handover struct {
+	Nonce    string
+	CodeHash string
+	Attempts uint
+	// Locked is set once the attempts are exhausted, only an admin can
+	// complete the delivery then.
+	Locked bool
+}
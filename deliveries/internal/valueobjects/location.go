@@ -0,0 +1,19 @@
+package valueobjects
+
+import "math"
+
+const earthRadius = 6371000
+
+type Location struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+}
+
+// DistanceTo returns the great-circle distance in meters.
+func (l Location) DistanceTo(other Location) float64 {
+	lat1, lat2 := l.Latitude*math.Pi/180, other.Latitude*math.Pi/180
+	dLat := lat2 - lat1
+	dLng := (other.Longitude - l.Longitude) * math.Pi / 180
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return 2 * earthRadius * math.Asin(math.Sqrt(a))
+}
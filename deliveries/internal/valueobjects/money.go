@@ -0,0 +1,68 @@
+package valueobjects
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Money is an amount in minor units, like cents, so that prices add up
+// exactly. It is written as a decimal string with two fraction digits.
+type Money int64
+
+// ParseMoney parses a non-negative amount like "12", "12.5" or "12.50".
+func ParseMoney(s string) (Money, error) {
+	whole, fraction, _ := strings.Cut(strings.TrimSpace(s), ".")
+	if whole == "" || len(fraction) > 2 || strings.HasPrefix(whole, "-") || strings.HasPrefix(whole, "+") {
+		return 0, fmt.Errorf("invalid amount \"%s\"", s)
+	}
+	units, err := strconv.ParseInt(whole, 10, 64)
+	// larger amounts do not fit in minor units
+	if err != nil || units > (math.MaxInt64-99)/100 {
+		return 0, fmt.Errorf("invalid amount \"%s\"", s)
+	}
+	var minor int64
+	if fraction != "" {
+		if minor, err = strconv.ParseInt(fraction+strings.Repeat("0", 2-len(fraction)), 10, 64); err != nil || minor < 0 {
+			return 0, fmt.Errorf("invalid amount \"%s\"", s)
+		}
+	}
+	return Money(units*100 + minor), nil
+}
+
+// Mul returns the amount multiplied by num/den, rounded half up.
+func (m Money) Mul(num, den int64) Money {
+	return Money((int64(m)*num*2 + den) / (den * 2))
+}
+
+func (m Money) String() string {
+	sign := ""
+	if m < 0 {
+		sign, m = "-", -m
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, m/100, m%100)
+}
+
+func (m Money) MarshalJSON() ([]byte, error) {
+	return json.Marshal(m.String())
+}
+
+func (m *Money) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("amount must be a decimal string: %w", err)
+	}
+	parsed, err := ParseMoney(s)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
+
+type Price struct {
+	Amount   Money  `json:"amount" swaggertype:"string" example:"1250.00"`
+	Currency string `json:"currency"`
+}
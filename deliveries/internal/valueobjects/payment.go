@@ -0,0 +1,23 @@
+package valueobjects
+
+type PaymentMethod string
+
+const (
+	Cash PaymentMethod = "cash"
+	Card PaymentMethod = "card"
+)
+
+func (m PaymentMethod) Valid() bool {
+	switch m {
+	case Cash, Card:
+		return true
+	}
+	return false
+}
+
+// Collection is what the courier collected from the recipient of a cash on
+// delivery.
+type Collection struct {
+	Amount Money         `json:"amount" swaggertype:"string" example:"1250.00"`
+	Method PaymentMethod `json:"method"`
+}
@@ -0,0 +1,35 @@
+package valueobjects
+
+type ServiceLevel string
+
+const (
+	Standard ServiceLevel = "standard"
+	Express  ServiceLevel = "express"
+	SameDay  ServiceLevel = "same_day"
+)
+
+func (l ServiceLevel) Valid() bool {
+	switch l {
+	case Standard, Express, SameDay:
+		return true
+	}
+	return false
+}
+
+// SLAStatus tells whether a delivery is completed in time, it only ever
+// moves from OnTrack to AtRisk to Breached.
+type SLAStatus string
+
+const (
+	OnTrack  SLAStatus = "on_track"
+	AtRisk   SLAStatus = "at_risk"
+	Breached SLAStatus = "breached"
+)
+
+func (s SLAStatus) Valid() bool {
+	switch s {
+	case OnTrack, AtRisk, Breached:
+		return true
+	}
+	return false
+}
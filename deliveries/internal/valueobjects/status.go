@@ -7,4 +7,7 @@ const (
 	Canceled  Status = "canceled"
 	Delivers  Status = "delivers"
 	Completed Status = "completed"
+	// AttemptFailed deliveries wait for the recipient to reschedule them.
+	AttemptFailed    Status = "attempt_failed"
+	ReturnedToSender Status = "returned_to_sender"
 )
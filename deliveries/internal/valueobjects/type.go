@@ -0,0 +1,10 @@
+package valueobjects
+
+type DeliveryType string
+
+const (
+	Forward DeliveryType = "forward"
+	// Return deliveries carry an item back from the recipient of the
+	// original delivery.
+	Return DeliveryType = "return"
+)
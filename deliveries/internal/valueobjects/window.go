@@ -0,0 +1,23 @@
+package valueobjects
+
+import (
+	"errors"
+	"time"
+)
+
+type TimeWindow struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+}
+
+func NewTimeWindow(start, end time.Time) (*TimeWindow, error) {
+	if !end.After(start) {
+		return nil, errors.New("time window must end after it starts")
+	}
+	return &TimeWindow{Start: start, End: end}, nil
+}
+
+// Within reports whether the window lies entirely inside [start, end].
+func (w TimeWindow) Within(start, end time.Time) bool {
+	return !w.Start.Before(start) && !w.End.After(end)
+}
@@ -0,0 +1,57 @@
+package health
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+)
+
+func DBCheck(db *sql.DB) Check {
+	return func(ctx context.Context) error {
+		return db.PingContext(ctx)
+	}
+}
+
+// HttpCheck expects a 2xx response from the given url, usually the liveness
+// endpoint of a dependency.
+func HttpCheck(client *http.Client, url string) Check {
+	return func(ctx context.Context) error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return err
+		}
+		res, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+		defer res.Body.Close()
+		if res.StatusCode < http.StatusOK || res.StatusCode >= http.StatusMultipleChoices {
+			return fmt.Errorf("unexpected status %d", res.StatusCode)
+		}
+		return nil
+	}
+}
+
+// WaitFor runs the check until it succeeds, giving up after the given number
+// of attempts.
+func WaitFor(ctx context.Context, name string, check Check, attempts int, interval time.Duration) error {
+	var err error
+	for i := 1; i <= attempts; i++ {
+		if err = check(ctx); err == nil {
+			return nil
+		}
+		log.Printf("%s is not ready (attempt %d/%d): %v", name, i, attempts, err)
+		if i == attempts {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(interval):
+		}
+	}
+	return fmt.Errorf("%s is not ready after %d attempts: %w", name, attempts, err)
+}
@@ -0,0 +1,132 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	StatusOk          = "ok"
+	StatusFail        = "fail"
+	StatusUnavailable = "unavailable"
+)
+
+var ErrShuttingDown = errors.New("shutting down")
+
+type Check func(ctx context.Context) error
+
+type CheckReport struct {
+	Status   string `json:"status"`
+	Duration string `json:"duration"`
+	Error    string `json:"error,omitempty"`
+}
+
+type Report struct {
+	Status string                 `json:"status"`
+	Error  string                 `json:"error,omitempty"`
+	Checks map[string]CheckReport `json:"checks,omitempty"`
+}
+
+type namedCheck struct {
+	name  string
+	check Check
+}
+
+type Health struct {
+	timeout      time.Duration
+	checks       []namedCheck
+	shuttingDown int32
+}
+
+func NewHealth(timeout time.Duration) *Health {
+	if timeout <= 0 {
+		panic("check timeout must be positive")
+	}
+	return &Health{timeout: timeout}
+}
+
+// Register adds a readiness check. Checks must be registered before the
+// readiness handler starts serving.
+func (h *Health) Register(name string, check Check) {
+	h.checks = append(h.checks, namedCheck{name: name, check: check})
+}
+
+// Shutdown switches readiness to failing, so load balancers stop routing
+// traffic while in-flight requests are drained.
+func (h *Health) Shutdown() {
+	atomic.StoreInt32(&h.shuttingDown, 1)
+}
+
+func (h *Health) Liveness() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		ctx.JSON(http.StatusOK, Report{Status: StatusOk})
+	}
+}
+
+func (h *Health) Readiness() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if atomic.LoadInt32(&h.shuttingDown) == 1 {
+			ctx.JSON(http.StatusServiceUnavailable, Report{Status: StatusUnavailable, Error: ErrShuttingDown.Error()})
+			return
+		}
+		report := h.Check(ctx.Request.Context())
+		status := http.StatusOK
+		if report.Status != StatusOk {
+			status = http.StatusServiceUnavailable
+		}
+		ctx.JSON(status, report)
+	}
+}
+
+// Check runs all registered checks concurrently, each bounded by the
+// configured timeout.
+func (h *Health) Check(ctx context.Context) Report {
+	reports := make([]CheckReport, len(h.checks))
+	wg := sync.WaitGroup{}
+	for i, c := range h.checks {
+		wg.Add(1)
+		go func(i int, c namedCheck) {
+			defer wg.Done()
+			reports[i] = h.run(ctx, c.check)
+		}(i, c)
+	}
+	wg.Wait()
+
+	report := Report{Status: StatusOk, Checks: make(map[string]CheckReport, len(h.checks))}
+	for i, c := range h.checks {
+		if reports[i].Status != StatusOk {
+			report.Status = StatusUnavailable
+		}
+		report.Checks[c.name] = reports[i]
+	}
+	return report
+}
+
+func (h *Health) run(ctx context.Context, check Check) CheckReport {
+	ctx, cf := context.WithTimeout(ctx, h.timeout)
+	defer cf()
+	start := time.Now()
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- check(ctx)
+	}()
+
+	var err error
+	select {
+	case err = <-errCh:
+	case <-ctx.Done():
+		err = fmt.Errorf("check timed out after %s", h.timeout)
+	}
+	report := CheckReport{Status: StatusOk, Duration: time.Since(start).String()}
+	if err != nil {
+		report.Status = StatusFail
+		report.Error = err.Error()
+	}
+	return report
+}
@@ -0,0 +1,81 @@
+package health_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/zhanbolat18/parcel/libs/health"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func serve(h *health.Health) (int, health.Report) {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.GET("/readyz", h.Readiness())
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	report := health.Report{}
+	_ = json.Unmarshal(w.Body.Bytes(), &report)
+	return w.Code, report
+}
+
+func TestHealth_Readiness(t *testing.T) {
+	asrt := assert.New(t)
+	h := health.NewHealth(50 * time.Millisecond)
+	h.Register("database", func(ctx context.Context) error { return nil })
+
+	code, report := serve(h)
+	asrt.Equal(http.StatusOK, code)
+	asrt.Equal(health.StatusOk, report.Status)
+	asrt.Equal(health.StatusOk, report.Checks["database"].Status)
+
+	h.Register("users", func(ctx context.Context) error { return errors.New("connection refused") })
+	h.Register("slow", func(ctx context.Context) error {
+		time.Sleep(time.Second)
+		return nil
+	})
+	start := time.Now()
+	code, report = serve(h)
+	asrt.Less(time.Since(start), 500*time.Millisecond)
+	asrt.Equal(http.StatusServiceUnavailable, code)
+	asrt.Equal(health.StatusUnavailable, report.Status)
+	asrt.Equal(health.StatusOk, report.Checks["database"].Status)
+	asrt.Equal("connection refused", report.Checks["users"].Error)
+	asrt.Equal(health.StatusFail, report.Checks["slow"].Status)
+}
+
+func TestHealth_ReadinessDuringShutdown(t *testing.T) {
+	h := health.NewHealth(time.Second)
+	h.Register("database", func(ctx context.Context) error { return nil })
+	h.Shutdown()
+	code, report := serve(h)
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, health.ErrShuttingDown.Error(), report.Error)
+}
+
+func TestWaitFor(t *testing.T) {
+	asrt := assert.New(t)
+	calls := 0
+	err := health.WaitFor(context.Background(), "database", func(ctx context.Context) error {
+		calls++
+		if calls < 3 {
+			return errors.New("not yet")
+		}
+		return nil
+	}, 5, time.Millisecond)
+	asrt.Nil(err)
+	asrt.Equal(3, calls)
+
+	calls = 0
+	err = health.WaitFor(context.Background(), "database", func(ctx context.Context) error {
+		calls++
+		return errors.New("down")
+	}, 2, time.Millisecond)
+	asrt.NotNil(err)
+	asrt.Equal(2, calls)
+}
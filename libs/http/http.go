@@ -17,6 +17,30 @@ func Forbidden(payload ...interface{}) Resp {
 	return error("forbidden", payload...)
 }
 
+func NotFound(payload ...interface{}) Resp {
+	return error("not found", payload...)
+}
+
+func Conflict(payload ...interface{}) Resp {
+	return error("conflict", payload...)
+}
+
+func UnprocessableEntity(payload ...interface{}) Resp {
+	return error("unprocessable entity", payload...)
+}
+
+func RequestEntityTooLarge(payload ...interface{}) Resp {
+	return error("request entity too large", payload...)
+}
+
+func UnsupportedMediaType(payload ...interface{}) Resp {
+	return error("unsupported media type", payload...)
+}
+
+func PreconditionFailed(payload ...interface{}) Resp {
+	return error("precondition failed", payload...)
+}
+
 func InternalServErr(payload ...interface{}) Resp {
 	return error("internal server error", payload...)
 }
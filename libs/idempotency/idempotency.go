@@ -0,0 +1,245 @@
+package idempotency
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"github.com/gin-gonic/gin"
+	httpLib "github.com/zhanbolat18/parcel/libs/http"
+	"io"
+	"io/ioutil"
+	"log"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	HeaderKey      = "Idempotency-Key"
+	HeaderReplayed = "Idempotent-Replayed"
+	maxKeyLength   = 255
+)
+
+// Record is the stored outcome of a request made with an idempotency key.
+// Until the request finishes the record is not Completed.
+type Record struct {
+	Fingerprint string
+	Completed   bool
+	StatusCode  int
+	ContentType string
+	Body        []byte
+}
+
+type Store interface {
+	// Reserve claims the key for a new request and returns nil. If the key is
+	// already claimed and not expired, the existing record is returned instead.
+	Reserve(ctx context.Context, key, fingerprint string, ttl time.Duration) (*Record, error)
+	Complete(ctx context.Context, key string, record *Record) error
+	Release(ctx context.Context, key string) error
+	DeleteExpired(ctx context.Context) error
+}
+
+type Idempotency struct {
+	store Store
+	ttl   time.Duration
+	// maxBodySize bounds the body read for the fingerprint, it must cover the
+	// largest request of the routes using the middleware. The body is spooled
+	// to a temporary file, so it is not held in memory.
+	maxBodySize int64
+}
+
+func NewIdempotency(store Store, ttl time.Duration, maxBodySize int64) *Idempotency {
+	if store == nil {
+		panic("store must be set")
+	}
+	if maxBodySize <= 0 {
+		panic("max body size must be positive")
+	}
+	return &Idempotency{store: store, ttl: ttl, maxBodySize: maxBodySize}
+}
+
+// Middleware replays the stored response for a repeated Idempotency-Key.
+// Keys are namespaced with the value returned by scope, usually the caller
+// identity, so different clients cannot collide. Requests without the header
+// pass through untouched, server errors are not stored so they can be retried.
+func (i *Idempotency) Middleware(scope func(ctx *gin.Context) string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		key := ctx.GetHeader(HeaderKey)
+		if key == "" {
+			ctx.Next()
+			return
+		}
+		if len(key) > maxKeyLength {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, httpLib.BadRequest("idempotency key is too long"))
+			return
+		}
+		if scope != nil {
+			key = scope(ctx) + ":" + key
+		}
+
+		body, err := ioutil.TempFile("", "idempotency-*")
+		if err != nil {
+			ctx.AbortWithStatusJSON(http.StatusInternalServerError, httpLib.InternalServErr(err.Error()))
+			return
+		}
+		defer func() {
+			_ = body.Close()
+			_ = os.Remove(body.Name())
+		}()
+		fingerprint, err := i.fingerprint(ctx.Request, http.MaxBytesReader(ctx.Writer, ctx.Request.Body, i.maxBodySize), body)
+		if err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				ctx.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, httpLib.RequestEntityTooLarge(err.Error()))
+				return
+			}
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, httpLib.BadRequest(err.Error()))
+			return
+		}
+		ctx.Request.Body = body
+
+		existing, err := i.store.Reserve(ctx, key, fingerprint, i.ttl)
+		if err != nil {
+			ctx.AbortWithStatusJSON(http.StatusInternalServerError, httpLib.InternalServErr(err.Error()))
+			return
+		}
+		if existing != nil {
+			i.replay(ctx, existing, fingerprint)
+			return
+		}
+
+		writer := &recorder{ResponseWriter: ctx.Writer}
+		ctx.Writer = writer
+		defer func() {
+			// a panicking handler must not keep the key reserved until it expires
+			if r := recover(); r != nil {
+				if err := i.store.Release(context.Background(), key); err != nil {
+					log.Printf("release idempotency key: %v", err)
+				}
+				panic(r)
+			}
+		}()
+		ctx.Next()
+
+		// the request context may already be canceled by the client
+		storeCtx := context.Background()
+		if writer.Status() >= http.StatusInternalServerError {
+			if err = i.store.Release(storeCtx, key); err != nil {
+				log.Printf("release idempotency key: %v", err)
+			}
+			return
+		}
+		err = i.store.Complete(storeCtx, key, &Record{
+			Fingerprint: fingerprint,
+			Completed:   true,
+			StatusCode:  writer.Status(),
+			ContentType: writer.Header().Get("Content-Type"),
+			Body:        writer.body.Bytes(),
+		})
+		if err != nil {
+			log.Printf("store idempotent response: %v", err)
+		}
+	}
+}
+
+func (i *Idempotency) replay(ctx *gin.Context, record *Record, fingerprint string) {
+	switch {
+	case record.Fingerprint != fingerprint:
+		ctx.AbortWithStatusJSON(http.StatusUnprocessableEntity,
+			httpLib.UnprocessableEntity("idempotency key was already used with a different request"))
+	case !record.Completed:
+		ctx.AbortWithStatusJSON(http.StatusConflict,
+			httpLib.Conflict("request with the same idempotency key is in progress"))
+	default:
+		ctx.Header(HeaderReplayed, "true")
+		ctx.Data(record.StatusCode, record.ContentType, record.Body)
+		ctx.Abort()
+	}
+}
+
+// fingerprint hashes the request while copying its body into spool, which is
+// left at its start for the handler.
+func (i *Idempotency) fingerprint(req *http.Request, body io.Reader, spool *os.File) (string, error) {
+	line := []byte(req.Method + " " + req.URL.Path + "?" + req.URL.RawQuery + "\n")
+	h := sha256.New()
+	h.Write(line)
+	if _, err := io.Copy(io.MultiWriter(spool, h), body); err != nil {
+		return "", err
+	}
+	if _, err := spool.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+	if parts, ok := multipartDigest(req.Header.Get("Content-Type"), spool); ok {
+		h.Reset()
+		h.Write(line)
+		h.Write(parts)
+	}
+	if _, err := spool.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// multipartDigest describes a multipart body by its fields and the hashes of
+// their content, the boundary is random on every retry.
+func multipartDigest(contentType string, body io.Reader) ([]byte, bool) {
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil || !strings.HasPrefix(mediaType, "multipart/") || params["boundary"] == "" {
+		return nil, false
+	}
+	reader := multipart.NewReader(body, params["boundary"])
+	parts := make([]string, 0)
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, false
+		}
+		content := sha256.New()
+		if _, err = io.Copy(content, part); err != nil {
+			return nil, false
+		}
+		parts = append(parts, part.FormName()+"\x00"+part.FileName()+"\x00"+hex.EncodeToString(content.Sum(nil)))
+	}
+	sort.Strings(parts)
+	return []byte(strings.Join(parts, "\n")), true
+}
+
+// RunCleanup periodically removes expired keys until ctx is done.
+func RunCleanup(ctx context.Context, store Store, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := store.DeleteExpired(ctx); err != nil {
+				log.Printf("delete expired idempotency keys: %v", err)
+			}
+		}
+	}
+}
+
+type recorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (r *recorder) Write(b []byte) (int, error) {
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
+
+func (r *recorder) WriteString(s string) (int, error) {
+	r.body.WriteString(s)
+	return r.ResponseWriter.WriteString(s)
+}
@@ -0,0 +1,195 @@
+package idempotency_test
+
+import (
+	"bytes"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/zhanbolat18/parcel/libs/idempotency"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func engine(handler gin.HandlerFunc) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	idem := idempotency.NewIdempotency(idempotency.NewMemoryStore(), time.Hour, 1<<10)
+	e := gin.New()
+	e.Use(gin.Recovery())
+	e.POST("/deliveries", idem.Middleware(func(ctx *gin.Context) string {
+		return ctx.GetHeader("X-User")
+	}), handler)
+	return e
+}
+
+func post(e *gin.Engine, key, user, body string) *httptest.ResponseRecorder {
+	return postTo(e, "/deliveries", key, user, body)
+}
+
+func postTo(e *gin.Engine, target, key, user, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, target, strings.NewReader(body))
+	if key != "" {
+		req.Header.Set(idempotency.HeaderKey, key)
+	}
+	req.Header.Set("X-User", user)
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, req)
+	return w
+}
+
+func TestIdempotency_Replay(t *testing.T) {
+	asrt := assert.New(t)
+	calls := 0
+	e := engine(func(ctx *gin.Context) {
+		calls++
+		ctx.JSON(http.StatusCreated, gin.H{"id": calls})
+	})
+
+	first := post(e, "abc", "1", `{"a":1}`)
+	second := post(e, "abc", "1", `{"a":1}`)
+	asrt.Equal(1, calls)
+	asrt.Equal(http.StatusCreated, second.Code)
+	asrt.Equal(first.Body.String(), second.Body.String())
+	asrt.Equal("true", second.Header().Get(idempotency.HeaderReplayed))
+	asrt.Equal(first.Header().Get("Content-Type"), second.Header().Get("Content-Type"))
+
+	asrt.Equal(http.StatusUnprocessableEntity, post(e, "abc", "1", `{"a":2}`).Code)
+	asrt.Equal(1, calls)
+
+	asrt.Equal(http.StatusCreated, post(e, "abc", "2", `{"a":1}`).Code)
+	asrt.Equal(http.StatusCreated, post(e, "", "1", `{"a":1}`).Code)
+	asrt.Equal(3, calls)
+}
+
+func TestIdempotency_ServerErrorIsNotStored(t *testing.T) {
+	asrt := assert.New(t)
+	calls := 0
+	e := engine(func(ctx *gin.Context) {
+		calls++
+		if calls == 1 {
+			ctx.Status(http.StatusInternalServerError)
+			return
+		}
+		ctx.Status(http.StatusCreated)
+	})
+
+	asrt.Equal(http.StatusInternalServerError, post(e, "abc", "1", "{}").Code)
+	asrt.Equal(http.StatusCreated, post(e, "abc", "1", "{}").Code)
+	asrt.Equal(2, calls)
+}
+
+func TestIdempotency_InProgress(t *testing.T) {
+	asrt := assert.New(t)
+	started := make(chan struct{})
+	release := make(chan struct{})
+	e := engine(func(ctx *gin.Context) {
+		close(started)
+		<-release
+		ctx.Status(http.StatusCreated)
+	})
+
+	done := make(chan *httptest.ResponseRecorder)
+	go func() { done <- post(e, "abc", "1", "{}") }()
+	<-started
+	asrt.Equal(http.StatusConflict, post(e, "abc", "1", "{}").Code)
+	close(release)
+	asrt.Equal(http.StatusCreated, (<-done).Code)
+}
+
+// postForm sends the fields as multipart, every call gets a new boundary.
+func postForm(e *gin.Engine, key string, fields map[string]string, file string) *httptest.ResponseRecorder {
+	body := &bytes.Buffer{}
+	form := multipart.NewWriter(body)
+	for name, value := range fields {
+		_ = form.WriteField(name, value)
+	}
+	w, _ := form.CreateFormFile("photo", "door.jpg")
+	_, _ = w.Write([]byte(file))
+	_ = form.Close()
+	req := httptest.NewRequest(http.MethodPost, "/deliveries", body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	req.Header.Set(idempotency.HeaderKey, key)
+	req.Header.Set("X-User", "1")
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestIdempotency_Multipart(t *testing.T) {
+	asrt := assert.New(t)
+	calls := 0
+	e := engine(func(ctx *gin.Context) {
+		calls++
+		ctx.Status(http.StatusCreated)
+	})
+	fields := map[string]string{"received_by": "John", "handover_code": "123456"}
+
+	asrt.Equal(http.StatusCreated, postForm(e, "abc", fields, "jpeg").Code)
+	retry := postForm(e, "abc", fields, "jpeg")
+	asrt.Equal(http.StatusCreated, retry.Code)
+	asrt.Equal("true", retry.Header().Get(idempotency.HeaderReplayed))
+	asrt.Equal(http.StatusUnprocessableEntity, postForm(e, "abc", fields, "other jpeg").Code)
+	asrt.Equal(1, calls)
+}
+
+func TestIdempotency_BodyTooLarge(t *testing.T) {
+	calls := 0
+	e := engine(func(ctx *gin.Context) {
+		calls++
+		ctx.Status(http.StatusCreated)
+	})
+
+	assert.Equal(t, http.StatusRequestEntityTooLarge, post(e, "abc", "1", strings.Repeat("a", 1<<10+1)).Code)
+	assert.Equal(t, http.StatusCreated, post(e, "", "1", strings.Repeat("a", 1<<10+1)).Code)
+	assert.Equal(t, 1, calls)
+}
+
+func TestIdempotency_PanicReleasesKey(t *testing.T) {
+	asrt := assert.New(t)
+	calls := 0
+	e := engine(func(ctx *gin.Context) {
+		calls++
+		if calls == 1 {
+			panic("boom")
+		}
+		ctx.Status(http.StatusCreated)
+	})
+
+	asrt.Equal(http.StatusInternalServerError, post(e, "abc", "1", "{}").Code)
+	asrt.Equal(http.StatusCreated, post(e, "abc", "1", "{}").Code)
+	asrt.Equal(2, calls)
+}
+
+func TestIdempotency_QueryIsFingerprinted(t *testing.T) {
+	calls := 0
+	e := engine(func(ctx *gin.Context) {
+		calls++
+		ctx.Status(http.StatusCreated)
+	})
+
+	assert.Equal(t, http.StatusCreated, postTo(e, "/deliveries?dry_run=true", "abc", "1", "{}").Code)
+	assert.Equal(t, http.StatusUnprocessableEntity, postTo(e, "/deliveries?dry_run=false", "abc", "1", "{}").Code)
+	assert.Equal(t, 1, calls)
+}
+
+func TestIdempotency_BodyIsSpooled(t *testing.T) {
+	asrt := assert.New(t)
+	tmp := t.TempDir()
+	t.Setenv("TMPDIR", tmp)
+	var received string
+	e := engine(func(ctx *gin.Context) {
+		body, err := ioutil.ReadAll(ctx.Request.Body)
+		asrt.Nil(err)
+		received = string(body)
+		ctx.Status(http.StatusCreated)
+	})
+
+	asrt.Equal(http.StatusCreated, post(e, "abc", "1", `{"destination":"Some Address 1, 14"}`).Code)
+	asrt.Equal(`{"destination":"Some Address 1, 14"}`, received)
+	left, err := ioutil.ReadDir(tmp)
+	asrt.Nil(err)
+	asrt.Empty(left, "the spooled body is removed")
+}
@@ -0,0 +1,63 @@
+package idempotency
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type memoryEntry struct {
+	record    Record
+	expiresAt time.Time
+}
+
+type memoryStore struct {
+	mu      sync.Mutex
+	entries map[string]*memoryEntry
+}
+
+func NewMemoryStore() Store {
+	return &memoryStore{entries: make(map[string]*memoryEntry)}
+}
+
+func (m *memoryStore) Reserve(_ context.Context, key, fingerprint string, ttl time.Duration) (*Record, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if e, ok := m.entries[key]; ok && e.expiresAt.After(time.Now()) {
+		r := e.record
+		return &r, nil
+	}
+	m.entries[key] = &memoryEntry{
+		record:    Record{Fingerprint: fingerprint},
+		expiresAt: time.Now().Add(ttl),
+	}
+	return nil, nil
+}
+
+func (m *memoryStore) Complete(_ context.Context, key string, record *Record) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if e, ok := m.entries[key]; ok {
+		e.record = *record
+	}
+	return nil
+}
+
+func (m *memoryStore) Release(_ context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.entries, key)
+	return nil
+}
+
+func (m *memoryStore) DeleteExpired(_ context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := time.Now()
+	for k, e := range m.entries {
+		if !e.expiresAt.After(now) {
+			delete(m.entries, k)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package idempotency
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+type postgresStore struct {
+	db    *sql.DB
+	table string
+}
+
+// NewPostgresStore keeps records in the given table, see the
+// idempotency_keys migration of the deliveries service for its schema.
+func NewPostgresStore(db *sql.DB, table string) Store {
+	return &postgresStore{db: db, table: table}
+}
+
+func (p *postgresStore) Reserve(ctx context.Context, key, fingerprint string, ttl time.Duration) (*Record, error) {
+	expiresAt := time.Now().Add(ttl)
+	// an expired key is taken over as if it never existed
+	q := fmt.Sprintf(`INSERT INTO %[1]s(key, fingerprint, completed, expires_at) VALUES($1, $2, FALSE, $3)
+		ON CONFLICT (key) DO UPDATE SET 
+			fingerprint=EXCLUDED.fingerprint,
+			completed=FALSE,
+			status_code=NULL,
+			content_type=NULL,
+			body=NULL,
+			expires_at=EXCLUDED.expires_at
+		WHERE %[1]s.expires_at <= now()
+		RETURNING key`, p.table)
+	var reserved string
+	err := p.db.QueryRowContext(ctx, q, key, fingerprint, expiresAt).Scan(&reserved)
+	if err == nil {
+		return nil, nil
+	}
+	if err != sql.ErrNoRows {
+		return nil, err
+	}
+
+	r := &Record{}
+	var status sql.NullInt64
+	var contentType sql.NullString
+	q = fmt.Sprintf("SELECT fingerprint, completed, status_code, content_type, body FROM %s WHERE key=$1", p.table)
+	err = p.db.QueryRowContext(ctx, q, key).Scan(&r.Fingerprint, &r.Completed, &status, &contentType, &r.Body)
+	if err != nil {
+		return nil, err
+	}
+	r.StatusCode = int(status.Int64)
+	r.ContentType = contentType.String
+	return r, nil
+}
+
+func (p *postgresStore) Complete(ctx context.Context, key string, record *Record) error {
+	q := fmt.Sprintf(`UPDATE %s SET completed=TRUE, status_code=$2, content_type=$3, body=$4 WHERE key=$1`, p.table)
+	_, err := p.db.ExecContext(ctx, q, key, record.StatusCode, record.ContentType, record.Body)
+	return err
+}
+
+func (p *postgresStore) Release(ctx context.Context, key string) error {
+	_, err := p.db.ExecContext(ctx, fmt.Sprintf("DELETE FROM %s WHERE key=$1", p.table), key)
+	return err
+}
+
+func (p *postgresStore) DeleteExpired(ctx context.Context) error {
+	_, err := p.db.ExecContext(ctx, fmt.Sprintf("DELETE FROM %s WHERE expires_at <= now()", p.table))
+	return err
+}
@@ -0,0 +1,25 @@
+package metrics
+
+import "github.com/prometheus/client_golang/prometheus"
+
+type Counter interface {
+	Inc(value string)
+}
+
+type events struct {
+	vec *prometheus.CounterVec
+}
+
+func (e *events) Inc(value string) {
+	e.vec.WithLabelValues(value).Inc()
+}
+
+type nopCounter struct {
+}
+
+func (n *nopCounter) Inc(string) {
+}
+
+func NopCounter() Counter {
+	return &nopCounter{}
+}
@@ -0,0 +1,113 @@
+package metrics
+
+import (
+	"database/sql"
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const unmatchedRoute = "unmatched"
+
+type Metrics struct {
+	namespace       string
+	registry        *prometheus.Registry
+	requestDuration *prometheus.HistogramVec
+	clientDuration  *prometheus.HistogramVec
+}
+
+func NewMetrics(namespace string) *Metrics {
+	if namespace == "" {
+		panic("namespace must be set")
+	}
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+
+	requestDuration := prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "Duration of HTTP requests handled by the service.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+	clientDuration := prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "http_client",
+		Name:      "request_duration_seconds",
+		Help:      "Duration of outgoing HTTP requests made by the service.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"client", "method", "code"})
+	registry.MustRegister(requestDuration, clientDuration)
+
+	return &Metrics{
+		namespace:       namespace,
+		registry:        registry,
+		requestDuration: requestDuration,
+		clientDuration:  clientDuration,
+	}
+}
+
+func (m *Metrics) Registry() *prometheus.Registry {
+	return m.registry
+}
+
+// Middleware records request durations labeled by the route template, so
+// "/deliveries/:id" is a single series no matter which id was requested.
+func (m *Metrics) Middleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		start := time.Now()
+		ctx.Next()
+		route := ctx.FullPath()
+		if route == "" {
+			route = unmatchedRoute
+		}
+		m.requestDuration.
+			WithLabelValues(ctx.Request.Method, route, strconv.Itoa(ctx.Writer.Status())).
+			Observe(time.Since(start).Seconds())
+	}
+}
+
+func (m *Metrics) Handler() gin.HandlerFunc {
+	return gin.WrapH(promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{Registry: m.registry}))
+}
+
+// InstrumentClient wraps the client transport in place, so every component
+// sharing the client is measured under the given name.
+func (m *Metrics) InstrumentClient(client *http.Client, name string) *http.Client {
+	transport := client.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	client.Transport = promhttp.InstrumentRoundTripperDuration(
+		m.clientDuration.MustCurryWith(prometheus.Labels{"client": name}),
+		transport,
+	)
+	return client
+}
+
+func (m *Metrics) RegisterDB(db *sql.DB, name string) {
+	m.registry.MustRegister(collectors.NewDBStatsCollector(db, name))
+}
+
+// Events registers a counter of domain events partitioned by the given label.
+// All known values are initialised with zero so that they are exported before
+// the first occurrence.
+func (m *Metrics) Events(name, help, label string, values ...string) Counter {
+	vec := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: m.namespace,
+		Name:      name,
+		Help:      help,
+	}, []string{label})
+	m.registry.MustRegister(vec)
+	for _, v := range values {
+		vec.WithLabelValues(v)
+	}
+	return &events{vec: vec}
+}
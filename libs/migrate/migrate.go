@@ -0,0 +1,120 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/pressly/goose/v3"
+	"github.com/pressly/goose/v3/lock"
+	"io"
+	"io/fs"
+)
+
+const (
+	CommandUp     = "up"
+	CommandDown   = "down"
+	CommandStatus = "status"
+	CommandRedo   = "redo"
+)
+
+type Migrator struct {
+	provider *goose.Provider
+	out      io.Writer
+}
+
+// NewMigrator creates a migrator for the sql files found in the root of fsys,
+// reporting progress to out. Every run holds a postgres advisory lock, so
+// replicas starting at the same time apply migrations only once.
+func NewMigrator(db *sql.DB, fsys fs.FS, out io.Writer) (*Migrator, error) {
+	locker, err := lock.NewPostgresSessionLocker()
+	if err != nil {
+		return nil, fmt.Errorf("create session locker: %w", err)
+	}
+	provider, err := goose.NewProvider(goose.DialectPostgres, db, fsys, goose.WithSessionLocker(locker))
+	if err != nil {
+		return nil, fmt.Errorf("create migrations provider: %w", err)
+	}
+	return &Migrator{provider: provider, out: out}, nil
+}
+
+func (m *Migrator) Run(ctx context.Context, command string) error {
+	switch command {
+	case CommandUp:
+		return m.Up(ctx)
+	case CommandDown:
+		return m.Down(ctx)
+	case CommandStatus:
+		return m.Status(ctx)
+	case CommandRedo:
+		return m.Redo(ctx)
+	default:
+		return fmt.Errorf("unknown migrate command \"%s\", expected one of: %s, %s, %s, %s",
+			command, CommandUp, CommandDown, CommandStatus, CommandRedo)
+	}
+}
+
+func (m *Migrator) Up(ctx context.Context) error {
+	results, err := m.provider.Up(ctx)
+	for _, r := range results {
+		m.print(r)
+	}
+	if err != nil {
+		return fmt.Errorf("apply migrations: %w", err)
+	}
+	if len(results) == 0 {
+		_, _ = fmt.Fprintln(m.out, "no pending migrations")
+	}
+	return nil
+}
+
+func (m *Migrator) Down(ctx context.Context) error {
+	r, err := m.provider.Down(ctx)
+	if err != nil {
+		return fmt.Errorf("rollback migration: %w", err)
+	}
+	m.print(r)
+	return nil
+}
+
+func (m *Migrator) Redo(ctx context.Context) error {
+	version, err := m.provider.GetDBVersion(ctx)
+	if err != nil {
+		return fmt.Errorf("get db version: %w", err)
+	}
+	if version == 0 {
+		return errors.New("no applied migrations to redo")
+	}
+	r, err := m.provider.ApplyVersion(ctx, version, false)
+	if err != nil {
+		return fmt.Errorf("rollback migration %d: %w", version, err)
+	}
+	m.print(r)
+	r, err = m.provider.ApplyVersion(ctx, version, true)
+	if err != nil {
+		return fmt.Errorf("reapply migration %d: %w", version, err)
+	}
+	m.print(r)
+	return nil
+}
+
+func (m *Migrator) Status(ctx context.Context) error {
+	statuses, err := m.provider.Status(ctx)
+	if err != nil {
+		return fmt.Errorf("get migrations status: %w", err)
+	}
+	for _, s := range statuses {
+		appliedAt := "pending"
+		if s.State == goose.StateApplied {
+			appliedAt = s.AppliedAt.Format("2006-01-02 15:04:05")
+		}
+		_, _ = fmt.Fprintf(m.out, "%-20s %s\n", appliedAt, s.Source.Path)
+	}
+	return nil
+}
+
+func (m *Migrator) print(r *goose.MigrationResult) {
+	if r != nil {
+		_, _ = fmt.Fprintln(m.out, r.String())
+	}
+}
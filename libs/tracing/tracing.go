@@ -0,0 +1,149 @@
+package tracing
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/XSAM/otelsql"
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+	"go.opentelemetry.io/otel/trace"
+	"net/http"
+)
+
+const (
+	ExporterNone   = "none"
+	ExporterStdout = "stdout"
+	ExporterOtlp   = "otlp"
+)
+
+const instrumentationName = "github.com/zhanbolat18/parcel/libs/tracing"
+const unmatchedRoute = "unmatched"
+
+type ExporterConfig struct {
+	Exporter     string
+	OtlpEndpoint string
+	OtlpInsecure bool
+}
+
+// NewTracerProvider builds a provider exporting to the configured backend and
+// installs it, together with the W3C trace context propagator, as the global
+// one. The caller is responsible for shutting it down to flush pending spans.
+func NewTracerProvider(ctx context.Context, serviceName string, cfg ExporterConfig) (*sdktrace.TracerProvider, error) {
+	opts := []sdktrace.TracerProviderOption{
+		sdktrace.WithResource(resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceName(serviceName))),
+	}
+	switch cfg.Exporter {
+	case ExporterNone, "":
+	case ExporterStdout:
+		exp, err := stdouttrace.New(stdouttrace.WithPrettyPrint())
+		if err != nil {
+			return nil, fmt.Errorf("create stdout exporter: %w", err)
+		}
+		opts = append(opts, sdktrace.WithBatcher(exp))
+	case ExporterOtlp:
+		var clientOpts []otlptracehttp.Option
+		if cfg.OtlpEndpoint != "" {
+			clientOpts = append(clientOpts, otlptracehttp.WithEndpoint(cfg.OtlpEndpoint))
+		}
+		if cfg.OtlpInsecure {
+			clientOpts = append(clientOpts, otlptracehttp.WithInsecure())
+		}
+		exp, err := otlptracehttp.New(ctx, clientOpts...)
+		if err != nil {
+			return nil, fmt.Errorf("create otlp exporter: %w", err)
+		}
+		opts = append(opts, sdktrace.WithBatcher(exp))
+	default:
+		return nil, fmt.Errorf("unknown trace exporter \"%s\"", cfg.Exporter)
+	}
+
+	tp := sdktrace.NewTracerProvider(opts...)
+	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(Propagator())
+	return tp, nil
+}
+
+func Propagator() propagation.TextMapPropagator {
+	return propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{})
+}
+
+type Tracing struct {
+	provider   trace.TracerProvider
+	propagator propagation.TextMapPropagator
+	tracer     trace.Tracer
+}
+
+func NewTracing(provider trace.TracerProvider, propagator propagation.TextMapPropagator) *Tracing {
+	if provider == nil || propagator == nil {
+		panic("tracer provider and propagator must be set")
+	}
+	return &Tracing{
+		provider:   provider,
+		propagator: propagator,
+		tracer:     provider.Tracer(instrumentationName),
+	}
+}
+
+// Middleware starts a server span for every request, continuing the trace
+// received in the traceparent header. The span is stored in the request
+// context, so the engine must have ContextWithFallback enabled for handlers
+// passing *gin.Context down as context.Context to see it.
+func (t *Tracing) Middleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		parent := t.propagator.Extract(ctx.Request.Context(), propagation.HeaderCarrier(ctx.Request.Header))
+		route := ctx.FullPath()
+		if route == "" {
+			route = unmatchedRoute
+		}
+		spanCtx, span := t.tracer.Start(parent, fmt.Sprintf("%s %s", ctx.Request.Method, route),
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.HTTPMethod(ctx.Request.Method),
+				semconv.HTTPRoute(route),
+			))
+		defer span.End()
+
+		ctx.Request = ctx.Request.WithContext(spanCtx)
+		ctx.Next()
+
+		status := ctx.Writer.Status()
+		span.SetAttributes(semconv.HTTPStatusCode(status))
+		if status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(status))
+		}
+		for _, err := range ctx.Errors {
+			span.RecordError(err.Err)
+		}
+	}
+}
+
+// InstrumentClient wraps the client transport in place with client spans and
+// injects the trace context into outgoing requests.
+func (t *Tracing) InstrumentClient(client *http.Client) *http.Client {
+	transport := client.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	client.Transport = otelhttp.NewTransport(transport,
+		otelhttp.WithTracerProvider(t.provider),
+		otelhttp.WithPropagators(t.propagator),
+	)
+	return client
+}
+
+// OpenDB opens a database handle whose queries are recorded as spans.
+func (t *Tracing) OpenDB(driver, dsn string) (*sql.DB, error) {
+	return otelsql.Open(driver, dsn,
+		otelsql.WithTracerProvider(t.provider),
+		otelsql.WithAttributes(semconv.DBSystemPostgreSQL),
+	)
+}
@@ -0,0 +1,89 @@
+package tracing_test
+
+import (
+	"context"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/zhanbolat18/parcel/libs/tracing"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestTracing_PropagatesAcrossServices(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	asrt := assert.New(t)
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	tr := tracing.NewTracing(provider, tracing.Propagator())
+
+	var traceparent string
+	users := gin.New()
+	users.Use(tr.Middleware())
+	users.GET("/couriers/:id", func(ctx *gin.Context) {
+		traceparent = ctx.GetHeader("traceparent")
+		ctx.Status(http.StatusOK)
+	})
+	usersSrv := httptest.NewServer(users)
+	defer usersSrv.Close()
+
+	client := tr.InstrumentClient(&http.Client{})
+	deliveries := gin.New()
+	deliveries.ContextWithFallback = true
+	deliveries.Use(tr.Middleware())
+	deliveries.POST("/deliveries/:id/courier/:courierId", func(ctx *gin.Context) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, usersSrv.URL+"/couriers/"+ctx.Param("courierId"), nil)
+		if !asrt.Nil(err) {
+			return
+		}
+		res, err := client.Do(req)
+		if !asrt.Nil(err) {
+			return
+		}
+		_ = res.Body.Close()
+		ctx.Status(res.StatusCode)
+	})
+
+	w := httptest.NewRecorder()
+	deliveries.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/deliveries/1/courier/2", nil))
+	asrt.Equal(http.StatusOK, w.Code)
+	asrt.NotEmpty(traceparent)
+
+	spans := recorder.Ended()
+	if !asrt.Len(spans, 3) {
+		return
+	}
+	byKind := make(map[trace.SpanKind][]sdktrace.ReadOnlySpan)
+	for _, s := range spans {
+		byKind[s.SpanKind()] = append(byKind[s.SpanKind()], s)
+	}
+	asrt.Len(byKind[trace.SpanKindServer], 2)
+	asrt.Len(byKind[trace.SpanKindClient], 1)
+
+	clientSpan := byKind[trace.SpanKindClient][0]
+	var root, downstream sdktrace.ReadOnlySpan
+	for _, s := range byKind[trace.SpanKindServer] {
+		if s.Name() == "POST /deliveries/:id/courier/:courierId" {
+			root = s
+		} else {
+			downstream = s
+		}
+	}
+	if !asrt.NotNil(root) || !asrt.NotNil(downstream) {
+		return
+	}
+	asrt.Equal("GET /couriers/:id", downstream.Name())
+	asrt.Equal(root.SpanContext().TraceID(), clientSpan.SpanContext().TraceID())
+	asrt.Equal(root.SpanContext().TraceID(), downstream.SpanContext().TraceID())
+	asrt.Equal(root.SpanContext().SpanID(), clientSpan.Parent().SpanID())
+	asrt.Equal(clientSpan.SpanContext().SpanID(), downstream.Parent().SpanID())
+	asrt.True(downstream.Parent().IsRemote())
+}
+
+func TestTracing_UnknownExporter(t *testing.T) {
+	_, err := tracing.NewTracerProvider(context.Background(), "test", tracing.ExporterConfig{Exporter: "jaeger"})
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,19 @@
+package dto
+
+import (
+	"github.com/zhanbolat18/parcel/users/internal/entities"
+	"time"
+)
+
+type ApiKeyDto struct {
+	Name string `json:"name" binding:"required"`
+	// Scopes are deliveries:create, deliveries:read, deliveries:update and invoices:read.
+	Scopes    []string   `json:"scopes" binding:"required,min=1" example:"deliveries:create,deliveries:read"`
+	ExpiresAt *time.Time `json:"expires_at" example:"2027-10-19T00:00:00Z"`
+}
+
+// CreatedApiKeyDto carries the plain key, it is shown only once.
+type CreatedApiKeyDto struct {
+	Key    string           `json:"key" example:"pk_3f9a1c2b7d4e.ZkC1lT3..."`
+	ApiKey *entities.ApiKey `json:"api_key"`
+}
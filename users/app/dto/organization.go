@@ -0,0 +1,15 @@
+package dto
+
+type OrganizationDto struct {
+	Name string `json:"name" binding:"required"`
+}
+
+type MemberDto struct {
+	Email string `json:"email" binding:"email,required"`
+	// Role is owner, staff or viewer.
+	Role string `json:"role" binding:"required" example:"staff"`
+}
+
+type MemberRoleDto struct {
+	Role string `json:"role" binding:"required" example:"viewer"`
+}
@@ -0,0 +1,110 @@
+package controllers
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	httpLib "github.com/zhanbolat18/parcel/libs/http"
+	"github.com/zhanbolat18/parcel/users/app/dto"
+	"github.com/zhanbolat18/parcel/users/internal/entities"
+	"github.com/zhanbolat18/parcel/users/internal/repositories"
+	"github.com/zhanbolat18/parcel/users/internal/services"
+	"github.com/zhanbolat18/parcel/users/internal/valueobjects"
+	"net/http"
+	"strconv"
+)
+
+type ApiKeyController struct {
+	srv *services.ManageApiKey
+}
+
+func NewApiKeyController(srv *services.ManageApiKey) *ApiKeyController {
+	return &ApiKeyController{srv: srv}
+}
+
+// Create godoc
+// @Summary      Create API key
+// @Description  Create a key merchant backends send as 'Authorization: ApiKey {key}' to act for the organization
+// @Description  within the scopes. The key is returned only once. Only owners have permission.
+// @Accept 		 json
+// @Produce      json
+// @Param        message  body  dto.ApiKeyDto  true  "api key info"
+// @Param 		 Authorization  header    string  true  "Authentication header. Usage 'Bearer {token}'"
+// @Success      201  {object}  dto.CreatedApiKeyDto
+// @Failure      400  {object}  object{error=string}
+// @Failure      401  {object}  object{error=string}
+// @Failure      403  {object}  object{error=string}
+// @Failure      404  {object}  object{error=string}
+// @Router       /organizations/me/api-keys [post]
+func (a *ApiKeyController) Create(ctx *gin.Context) {
+	keyDto := &dto.ApiKeyDto{}
+	if err := ctx.ShouldBindJSON(keyDto); err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, httpLib.BadRequest(err.Error()))
+		return
+	}
+	scopes := make([]valueobjects.Scope, 0, len(keyDto.Scopes))
+	for _, scope := range keyDto.Scopes {
+		scopes = append(scopes, valueobjects.Scope(scope))
+	}
+	key, plain, err := a.srv.Create(ctx, ctx.MustGet("user").(*entities.User), keyDto.Name, scopes, keyDto.ExpiresAt)
+	if err != nil {
+		a.abort(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusCreated, dto.CreatedApiKeyDto{Key: plain, ApiKey: key})
+}
+
+// GetAll godoc
+// @Summary      Fetch API keys
+// @Description  Fetch the API keys of the organization of the caller, revoked ones included. Only owners have permission.
+// @Produce      json
+// @Param 		 Authorization  header    string  true  "Authentication header. Usage 'Bearer {token}'"
+// @Success      200  {array}  entities.ApiKey
+// @Failure      401  {object}  object{error=string}
+// @Failure      403  {object}  object{error=string}
+// @Failure      404  {object}  object{error=string}
+// @Router       /organizations/me/api-keys [get]
+func (a *ApiKeyController) GetAll(ctx *gin.Context) {
+	keys, err := a.srv.GetAll(ctx, ctx.MustGet("user").(*entities.User))
+	if err != nil {
+		a.abort(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, keys)
+}
+
+// Revoke godoc
+// @Summary      Revoke API key
+// @Description  Revoke an API key of the organization of the caller. Only owners have permission.
+// @Param 		 Authorization  header    string  true  "Authentication header. Usage 'Bearer {token}'"
+// @Param		 id		path	integer	true	"api key id"
+// @Success      204
+// @Failure      400  {object}  object{error=string}
+// @Failure      401  {object}  object{error=string}
+// @Failure      403  {object}  object{error=string}
+// @Failure      404  {object}  object{error=string}
+// @Router       /organizations/me/api-keys/{id} [delete]
+func (a *ApiKeyController) Revoke(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil || id < 1 {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, httpLib.BadRequest("invalid id"))
+		return
+	}
+	if err = a.srv.Revoke(ctx, ctx.MustGet("user").(*entities.User), uint(id)); err != nil {
+		a.abort(ctx, err)
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
+
+func (a *ApiKeyController) abort(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrInvalidScope), errors.Is(err, services.ErrInvalidExpiry):
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, httpLib.BadRequest(err.Error()))
+	case errors.Is(err, services.ErrNotOwner):
+		ctx.AbortWithStatusJSON(http.StatusForbidden, httpLib.Forbidden(err.Error()))
+	case errors.Is(err, services.ErrNotMember), errors.Is(err, repositories.ErrApiKeyNotFound):
+		ctx.AbortWithStatusJSON(http.StatusNotFound, httpLib.NotFound(err.Error()))
+	default:
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, httpLib.InternalServErr(err.Error()))
+	}
+}
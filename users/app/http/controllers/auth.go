@@ -19,10 +19,11 @@ func NewAuthController(srv *services.AuthService) *AuthController {
 
 // Auth godoc
 // @Summary      Authorization
-// @Description  authorization on service with JWT token and return user info
+// @Description  authorization on service with JWT token or API key and return user info
+// @Description  API keys resolve to their creator acting as staff of the organization, with the scopes of the key.
 // @Produce      json
 // @Security 	 ApiKeyAuth
-// @Param 		 Authorization  header    string  true  "Authentication header. Usage 'Bearer {token}'"
+// @Param 		 Authorization  header    string  true  "Authentication header. Usage 'Bearer {token}' or 'ApiKey {key}'"
 // @Success      200  {object}  entities.User
 // @Failure      401  {object}  object{error=string}
 // @Failure      500  {string}  Internal Server Error
@@ -0,0 +1,200 @@
+package controllers
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	httpLib "github.com/zhanbolat18/parcel/libs/http"
+	"github.com/zhanbolat18/parcel/users/app/dto"
+	"github.com/zhanbolat18/parcel/users/internal/entities"
+	"github.com/zhanbolat18/parcel/users/internal/services"
+	"github.com/zhanbolat18/parcel/users/internal/valueobjects"
+	"net/http"
+	"strconv"
+)
+
+type OrganizationController struct {
+	srv *services.ManageOrganization
+}
+
+func NewOrganizationController(srv *services.ManageOrganization) *OrganizationController {
+	return &OrganizationController{srv: srv}
+}
+
+// Create godoc
+// @Summary      Create organization
+// @Description  Create an organization owned by the caller. Members share the deliveries of the organization.
+// @Description  Only users outside of organizations have permission.
+// @Accept 		 json
+// @Produce      json
+// @Param        message  body  dto.OrganizationDto  true  "organization info"
+// @Param 		 Authorization  header    string  true  "Authentication header. Usage 'Bearer {token}'"
+// @Success      201  {object}  entities.Organization
+// @Failure      400  {object}  object{error=string}
+// @Failure      401  {object}  object{error=string}
+// @Failure      403  {object}  object{error=string}
+// @Failure      409  {object}  object{error=string}
+// @Router       /organizations [post]
+func (o *OrganizationController) Create(ctx *gin.Context) {
+	orgDto := &dto.OrganizationDto{}
+	if err := ctx.ShouldBindJSON(orgDto); err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, httpLib.BadRequest(err.Error()))
+		return
+	}
+	org, err := o.srv.Create(ctx, ctx.MustGet("user").(*entities.User), orgDto.Name)
+	if err != nil {
+		o.abort(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusCreated, org)
+}
+
+// Mine godoc
+// @Summary      Fetch own organization
+// @Description  Fetch the organization of the caller.
+// @Produce      json
+// @Param 		 Authorization  header    string  true  "Authentication header. Usage 'Bearer {token}'"
+// @Success      200  {object}  entities.Organization
+// @Failure      401  {object}  object{error=string}
+// @Failure      403  {object}  object{error=string}
+// @Failure      404  {object}  object{error=string}
+// @Router       /organizations/me [get]
+func (o *OrganizationController) Mine(ctx *gin.Context) {
+	org, err := o.srv.Get(ctx, ctx.MustGet("user").(*entities.User))
+	if err != nil {
+		o.abort(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, org)
+}
+
+// Members godoc
+// @Summary      Fetch organization members
+// @Description  Fetch the members of the organization of the caller.
+// @Produce      json
+// @Param 		 Authorization  header    string  true  "Authentication header. Usage 'Bearer {token}'"
+// @Success      200  {array}  entities.User
+// @Failure      401  {object}  object{error=string}
+// @Failure      403  {object}  object{error=string}
+// @Failure      404  {object}  object{error=string}
+// @Router       /organizations/me/members [get]
+func (o *OrganizationController) Members(ctx *gin.Context) {
+	members, err := o.srv.Members(ctx, ctx.MustGet("user").(*entities.User))
+	if err != nil {
+		o.abort(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, members)
+}
+
+// AddMember godoc
+// @Summary      Add organization member
+// @Description  Add a signed up user to the organization of the caller as owner, staff or viewer.
+// @Description  Only owners have permission.
+// @Accept 		 json
+// @Produce      json
+// @Param        message  body  dto.MemberDto  true  "member info"
+// @Param 		 Authorization  header    string  true  "Authentication header. Usage 'Bearer {token}'"
+// @Success      200  {object}  entities.User
+// @Failure      400  {object}  object{error=string}
+// @Failure      401  {object}  object{error=string}
+// @Failure      403  {object}  object{error=string}
+// @Failure      404  {object}  object{error=string}
+// @Failure      409  {object}  object{error=string}
+// @Router       /organizations/me/members [post]
+func (o *OrganizationController) AddMember(ctx *gin.Context) {
+	memberDto := &dto.MemberDto{}
+	if err := ctx.ShouldBindJSON(memberDto); err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, httpLib.BadRequest(err.Error()))
+		return
+	}
+	member, err := o.srv.AddMember(ctx, ctx.MustGet("user").(*entities.User), memberDto.Email,
+		valueobjects.MembershipRole(memberDto.Role))
+	if err != nil {
+		o.abort(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, member)
+}
+
+// ChangeRole godoc
+// @Summary      Change member role
+// @Description  Change the role of a member of the organization of the caller. Only owners have permission.
+// @Accept 		 json
+// @Produce      json
+// @Param        message  body  dto.MemberRoleDto  true  "new role"
+// @Param 		 Authorization  header    string  true  "Authentication header. Usage 'Bearer {token}'"
+// @Param		 id		path	integer	true	"member id"
+// @Success      200  {object}  entities.User
+// @Failure      400  {object}  object{error=string}
+// @Failure      401  {object}  object{error=string}
+// @Failure      403  {object}  object{error=string}
+// @Failure      404  {object}  object{error=string}
+// @Failure      409  {object}  object{error=string}
+// @Router       /organizations/me/members/{id} [put]
+func (o *OrganizationController) ChangeRole(ctx *gin.Context) {
+	id, ok := o.memberId(ctx)
+	if !ok {
+		return
+	}
+	roleDto := &dto.MemberRoleDto{}
+	if err := ctx.ShouldBindJSON(roleDto); err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, httpLib.BadRequest(err.Error()))
+		return
+	}
+	member, err := o.srv.ChangeRole(ctx, ctx.MustGet("user").(*entities.User), id,
+		valueobjects.MembershipRole(roleDto.Role))
+	if err != nil {
+		o.abort(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, member)
+}
+
+// RemoveMember godoc
+// @Summary      Remove organization member
+// @Description  Remove a member from the organization of the caller, the deliveries stay with the organization.
+// @Description  Only owners have permission.
+// @Param 		 Authorization  header    string  true  "Authentication header. Usage 'Bearer {token}'"
+// @Param		 id		path	integer	true	"member id"
+// @Success      204
+// @Failure      400  {object}  object{error=string}
+// @Failure      401  {object}  object{error=string}
+// @Failure      403  {object}  object{error=string}
+// @Failure      404  {object}  object{error=string}
+// @Failure      409  {object}  object{error=string}
+// @Router       /organizations/me/members/{id} [delete]
+func (o *OrganizationController) RemoveMember(ctx *gin.Context) {
+	id, ok := o.memberId(ctx)
+	if !ok {
+		return
+	}
+	if err := o.srv.RemoveMember(ctx, ctx.MustGet("user").(*entities.User), id); err != nil {
+		o.abort(ctx, err)
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
+
+func (o *OrganizationController) memberId(ctx *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil || id < 1 {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, httpLib.BadRequest("invalid id"))
+		return 0, false
+	}
+	return uint(id), true
+}
+
+func (o *OrganizationController) abort(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrInvalidMembershipRole):
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, httpLib.BadRequest(err.Error()))
+	case errors.Is(err, services.ErrNotOwner):
+		ctx.AbortWithStatusJSON(http.StatusForbidden, httpLib.Forbidden(err.Error()))
+	case errors.Is(err, services.ErrNotMember), errors.Is(err, services.ErrMemberNotFound):
+		ctx.AbortWithStatusJSON(http.StatusNotFound, httpLib.NotFound(err.Error()))
+	case errors.Is(err, services.ErrAlreadyMember), errors.Is(err, services.ErrLastOwner):
+		ctx.AbortWithStatusJSON(http.StatusConflict, httpLib.Conflict(err.Error()))
+	default:
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, httpLib.InternalServErr(err.Error()))
+	}
+}
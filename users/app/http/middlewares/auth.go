@@ -6,19 +6,24 @@ import (
 	httpLib "github.com/zhanbolat18/parcel/libs/http"
 	"github.com/zhanbolat18/parcel/users/internal/services"
 	"net/http"
+	"strings"
 )
 
 const BearerSchema = "Bearer "
+const ApiKeySchema = "ApiKey "
 const AuthHeader = "Authorization"
 
 type AuthMiddleware struct {
-	srv *services.AuthService
+	srv  *services.AuthService
+	keys *services.ManageApiKey
 }
 
-func NewAuthMiddleware(srv *services.AuthService) *AuthMiddleware {
-	return &AuthMiddleware{srv: srv}
+func NewAuthMiddleware(srv *services.AuthService, keys *services.ManageApiKey) *AuthMiddleware {
+	return &AuthMiddleware{srv: srv, keys: keys}
 }
 
+// Auth accepts bearer tokens only, the routes of this service are for people.
+// API keys carry no scope for them.
 func (a *AuthMiddleware) Auth() gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		header := ctx.GetHeader(AuthHeader)
@@ -26,6 +31,10 @@ func (a *AuthMiddleware) Auth() gin.HandlerFunc {
 			ctx.AbortWithStatusJSON(http.StatusUnauthorized, httpLib.Unauthorized())
 			return
 		}
+		if strings.HasPrefix(header, ApiKeySchema) {
+			ctx.AbortWithStatusJSON(http.StatusForbidden, httpLib.Forbidden("api keys are not accepted"))
+			return
+		}
 		token := header[len(BearerSchema):]
 		u, err := a.srv.Authorization(ctx, token)
 		if err != nil {
@@ -37,3 +46,23 @@ func (a *AuthMiddleware) Auth() gin.HandlerFunc {
 		ctx.Next()
 	}
 }
+
+// Introspect also accepts API keys of any scope and sets their scopes on the
+// user, the services asking who the caller is enforce them.
+func (a *AuthMiddleware) Introspect() gin.HandlerFunc {
+	bearer := a.Auth()
+	return func(ctx *gin.Context) {
+		header := ctx.GetHeader(AuthHeader)
+		if !strings.HasPrefix(header, ApiKeySchema) {
+			bearer(ctx)
+			return
+		}
+		u, err := a.keys.Authenticate(ctx, header[len(ApiKeySchema):])
+		if err != nil {
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, httpLib.Unauthorized(services.ErrInvalidApiKey.Error()))
+			return
+		}
+		ctx.Set("user", u)
+		ctx.Next()
+	}
+}
@@ -8,20 +8,30 @@ import (
 	_ "github.com/lib/pq"
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
+	"github.com/zhanbolat18/parcel/libs/health"
+	"github.com/zhanbolat18/parcel/libs/metrics"
+	"github.com/zhanbolat18/parcel/libs/migrate"
+	"github.com/zhanbolat18/parcel/libs/tracing"
 	"github.com/zhanbolat18/parcel/users/app/http/controllers"
 	"github.com/zhanbolat18/parcel/users/app/http/middlewares"
 	"github.com/zhanbolat18/parcel/users/config"
+	"github.com/zhanbolat18/parcel/users/database/postgres/migrations"
 	_ "github.com/zhanbolat18/parcel/users/docs"
+	"github.com/zhanbolat18/parcel/users/internal/repositories"
 	"github.com/zhanbolat18/parcel/users/internal/repositories/postgres"
 	"github.com/zhanbolat18/parcel/users/internal/services"
 	"github.com/zhanbolat18/parcel/users/internal/valueobjects"
 	"github.com/zhanbolat18/parcel/users/pkg/crypto"
 	"github.com/zhanbolat18/parcel/users/pkg/jwt"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 	"go.uber.org/dig"
 	"log"
 	"net/http"
 	"os"
 	"os/signal"
+	"syscall"
+	"time"
 )
 
 // @title Parcel Delivery Service
@@ -37,11 +47,26 @@ import (
 func main() {
 	c := dig.New()
 	provideDependencies(c)
-	mustWork(c.Invoke(func(engine *gin.Engine) {
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		runMigrations(c, os.Args[2:])
+		return
+	}
+	mustWork(c.Invoke(func(cfg *config.Config, migrator *migrate.Migrator) {
+		if !cfg.PgSQL.AutoMigrate {
+			return
+		}
+		if err := migrator.Up(context.Background()); err != nil {
+			log.Fatalf("auto migrate: %v", err)
+		}
+	}))
+	mustWork(c.Invoke(func(engine *gin.Engine, m *metrics.Metrics, h *health.Health) {
 		engine.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
+		engine.GET("/metrics", m.Handler())
+		engine.GET("/healthz", h.Liveness())
+		engine.GET("/readyz", h.Readiness())
 	}))
 	mustWork(c.Invoke(func(engine *gin.Engine, c *controllers.AuthController, mw *middlewares.AuthMiddleware) {
-		engine.POST("/auth", mw.Auth(), c.Auth)
+		engine.POST("/auth", mw.Introspect(), c.Auth)
 		engine.POST("/login", c.Login)
 	}))
 	mustWork(c.Invoke(func(engine *gin.Engine, c *controllers.UserController,
@@ -51,6 +76,21 @@ func main() {
 		engine.GET("/couriers", authMw.Auth(), roleMw.CheckRole(valueobjects.Admin), c.Couriers)
 		engine.GET("/couriers/:id", authMw.Auth(), roleMw.CheckRole(valueobjects.Admin), c.Courier)
 	}))
+	mustWork(c.Invoke(func(engine *gin.Engine, c *controllers.OrganizationController,
+		authMw *middlewares.AuthMiddleware, roleMw *middlewares.RoleMiddleware) {
+		engine.POST("/organizations", authMw.Auth(), roleMw.CheckRole(valueobjects.User), c.Create)
+		engine.GET("/organizations/me", authMw.Auth(), roleMw.CheckRole(valueobjects.User), c.Mine)
+		engine.GET("/organizations/me/members", authMw.Auth(), roleMw.CheckRole(valueobjects.User), c.Members)
+		engine.POST("/organizations/me/members", authMw.Auth(), roleMw.CheckRole(valueobjects.User), c.AddMember)
+		engine.PUT("/organizations/me/members/:id", authMw.Auth(), roleMw.CheckRole(valueobjects.User), c.ChangeRole)
+		engine.DELETE("/organizations/me/members/:id", authMw.Auth(), roleMw.CheckRole(valueobjects.User), c.RemoveMember)
+	}))
+	mustWork(c.Invoke(func(engine *gin.Engine, c *controllers.ApiKeyController,
+		authMw *middlewares.AuthMiddleware, roleMw *middlewares.RoleMiddleware) {
+		engine.POST("/organizations/me/api-keys", authMw.Auth(), roleMw.CheckRole(valueobjects.User), c.Create)
+		engine.GET("/organizations/me/api-keys", authMw.Auth(), roleMw.CheckRole(valueobjects.User), c.GetAll)
+		engine.DELETE("/organizations/me/api-keys/:id", authMw.Auth(), roleMw.CheckRole(valueobjects.User), c.Revoke)
+	}))
 	mustWork(c.Invoke(func(server *http.Server) {
 		go func() {
 			err := server.ListenAndServe()
@@ -70,23 +110,69 @@ func provideDependencies(container *dig.Container) {
 	mustWork(container.Provide(func(cfg *config.Config) crypto.PasswordHasher {
 		return crypto.NewPasswordHasher(cfg.PasswordHasher.Cost)
 	}))
-	mustWork(container.Provide(func(cfg *config.Config) *sqlx.DB {
+	mustWork(container.Provide(func() *metrics.Metrics {
+		return metrics.NewMetrics("users")
+	}))
+	mustWork(container.Provide(func(cfg *config.Config) (*sdktrace.TracerProvider, error) {
+		return tracing.NewTracerProvider(context.Background(), "users", tracing.ExporterConfig{
+			Exporter:     cfg.Tracing.Exporter,
+			OtlpEndpoint: cfg.Tracing.OtlpEndpoint,
+			OtlpInsecure: cfg.Tracing.OtlpInsecure,
+		})
+	}))
+	mustWork(container.Provide(func(tp *sdktrace.TracerProvider) *tracing.Tracing {
+		return tracing.NewTracing(tp, otel.GetTextMapPropagator())
+	}))
+	mustWork(container.Provide(func(cfg *config.Config, m *metrics.Metrics, tr *tracing.Tracing) *sqlx.DB {
 		connStr := fmt.Sprintf("user=%s password=%s dbname=%s host=%s port=%d sslmode=disable",
 			cfg.PgSQL.User, cfg.PgSQL.Password, cfg.PgSQL.DBName, cfg.PgSQL.Host, cfg.PgSQL.Port)
-		return sqlx.MustConnect(cfg.PgSQL.Driver, connStr)
+		sqlDb, err := tr.OpenDB(cfg.PgSQL.Driver, connStr)
+		mustWork(err)
+		err = health.WaitFor(context.Background(), "database", health.DBCheck(sqlDb),
+			cfg.PgSQL.ConnectAttempts, cfg.PgSQL.ConnectInterval)
+		if err != nil {
+			log.Fatalf("connect to database: %v", err)
+		}
+		db := sqlx.NewDb(sqlDb, cfg.PgSQL.Driver)
+		m.RegisterDB(db.DB, cfg.PgSQL.DBName)
+		return db
+	}))
+	mustWork(container.Provide(func(db *sqlx.DB) (*migrate.Migrator, error) {
+		return migrate.NewMigrator(db.DB, migrations.FS, os.Stdout)
 	}))
 
 	mustWork(container.Provide(postgres.NewUserRepository))
+	mustWork(container.Provide(postgres.NewOrganizationRepository))
+	mustWork(container.Provide(postgres.NewApiKeyRepository))
 	mustWork(container.Provide(services.NewUserService))
-	mustWork(container.Provide(services.NewAuthService))
+	mustWork(container.Provide(services.NewOrganizationService))
+	mustWork(container.Provide(services.NewApiKeyService))
+	mustWork(container.Provide(func(
+		hasher crypto.PasswordHasher,
+		jwtManager jwt.Jwt,
+		repo repositories.UserRepository,
+		m *metrics.Metrics,
+	) *services.AuthService {
+		logins := m.Events("logins_total", "Number of login attempts by result.", "result",
+			services.LoginSucceeded, services.LoginFailed)
+		return services.NewAuthService(hasher, jwtManager, repo, logins)
+	}))
 	mustWork(container.Provide(controllers.NewAuthController))
 	mustWork(container.Provide(controllers.NewUserController))
+	mustWork(container.Provide(controllers.NewOrganizationController))
+	mustWork(container.Provide(controllers.NewApiKeyController))
 	mustWork(container.Provide(middlewares.NewAuthMiddleware))
 	mustWork(container.Provide(middlewares.NewRoleMiddleware))
 
-	mustWork(container.Provide(func() *gin.Engine {
+	mustWork(container.Provide(func(cfg *config.Config, db *sqlx.DB) *health.Health {
+		h := health.NewHealth(cfg.Health.CheckTimeout)
+		h.Register("database", health.DBCheck(db.DB))
+		return h
+	}))
+	mustWork(container.Provide(func(m *metrics.Metrics, tr *tracing.Tracing) *gin.Engine {
 		engine := gin.Default()
-		engine.Use(gin.Logger(), gin.Recovery())
+		engine.ContextWithFallback = true
+		engine.Use(gin.Logger(), gin.Recovery(), tr.Middleware(), m.Middleware())
 		return engine
 	}))
 	mustWork(container.Provide(func(engine *gin.Engine, cfg *config.Config) *http.Server {
@@ -98,6 +184,18 @@ func provideDependencies(container *dig.Container) {
 
 }
 
+func runMigrations(c *dig.Container, args []string) {
+	if len(args) != 1 {
+		log.Fatalf("usage: %s migrate %s|%s|%s|%s", os.Args[0],
+			migrate.CommandUp, migrate.CommandDown, migrate.CommandStatus, migrate.CommandRedo)
+	}
+	mustWork(c.Invoke(func(migrator *migrate.Migrator) {
+		if err := migrator.Run(context.Background(), args[0]); err != nil {
+			log.Fatalf("migrate %s: %v", args[0], err)
+		}
+	}))
+}
+
 func mustWork(e error) {
 	if e != nil {
 		panic(e)
@@ -105,15 +203,21 @@ func mustWork(e error) {
 }
 
 func gracefulShutdown(c *dig.Container) {
-	ch := make(chan os.Signal)
-	signal.Notify(ch, os.Interrupt)
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, os.Interrupt, syscall.SIGTERM)
 	<-ch
-	mustWork(c.Invoke(func(server *http.Server, cfg *config.Config) {
+	mustWork(c.Invoke(func(server *http.Server, tp *sdktrace.TracerProvider, h *health.Health, cfg *config.Config) {
+		h.Shutdown()
+		time.Sleep(cfg.Server.ReadinessTime)
 		ctx, cf := context.WithTimeout(context.Background(), cfg.Server.ShutdownTime)
 		defer cf()
 		err := server.Shutdown(ctx)
 		if err != nil {
 			log.Printf("server shutting down with error %v", err)
 		}
+		err = tp.Shutdown(ctx)
+		if err != nil {
+			log.Printf("tracer provider shutting down with error %v", err)
+		}
 	}))
 }
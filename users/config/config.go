@@ -10,6 +10,8 @@ type Config struct {
 	PasswordHasher *PasswordHasherConfig
 	PgSQL          *PgSQLConfig
 	Server         *Listener
+	Tracing        *TracingConfig
+	Health         *HealthConfig
 }
 
 type JwtConfig struct {
@@ -29,11 +31,26 @@ type PgSQLConfig struct {
 	User     string
 	Password string
 	DBName   string
+
+	ConnectAttempts int
+	ConnectInterval time.Duration
+	AutoMigrate     bool
+}
+
+type TracingConfig struct {
+	Exporter     string
+	OtlpEndpoint string
+	OtlpInsecure bool
+}
+
+type HealthConfig struct {
+	CheckTimeout time.Duration
 }
 
 type Listener struct {
-	Port         string
-	ShutdownTime time.Duration
+	Port          string
+	ShutdownTime  time.Duration
+	ReadinessTime time.Duration
 }
 
 func NewConfig() *Config {
@@ -44,7 +61,12 @@ func NewConfig() *Config {
 	vpr.SetDefault(PasswordHashCost, 13)
 	vpr.SetDefault(PgDriverName, "postgres")
 	vpr.SetDefault(Port, ":8080")
+	vpr.SetDefault(PgConnectAttempts, 10)
+	vpr.SetDefault(PgConnectInterval, 2*time.Second)
 	vpr.SetDefault(ShutdownTime, 10*time.Second)
+	vpr.SetDefault(ShutdownReadinessTime, 0)
+	vpr.SetDefault(HealthCheckTimeout, 2*time.Second)
+	vpr.SetDefault(TracingExporter, "none")
 
 	return &Config{
 		Jwt: &JwtConfig{
@@ -62,10 +84,23 @@ func NewConfig() *Config {
 			User:     vpr.GetString(PgUser),
 			Password: vpr.GetString(PgPwd),
 			DBName:   vpr.GetString(PgDbName),
+
+			ConnectAttempts: vpr.GetInt(PgConnectAttempts),
+			ConnectInterval: vpr.GetDuration(PgConnectInterval),
+			AutoMigrate:     vpr.GetBool(AutoMigrate),
 		},
 		Server: &Listener{
-			Port:         vpr.GetString(Port),
-			ShutdownTime: vpr.GetDuration(ShutdownTime),
+			Port:          vpr.GetString(Port),
+			ShutdownTime:  vpr.GetDuration(ShutdownTime),
+			ReadinessTime: vpr.GetDuration(ShutdownReadinessTime),
+		},
+		Health: &HealthConfig{
+			CheckTimeout: vpr.GetDuration(HealthCheckTimeout),
+		},
+		Tracing: &TracingConfig{
+			Exporter:     vpr.GetString(TracingExporter),
+			OtlpEndpoint: vpr.GetString(TracingOtlpEndpoint),
+			OtlpInsecure: vpr.GetBool(TracingOtlpInsecure),
 		},
 	}
 }
@@ -14,9 +14,21 @@ const (
 	PgPwd        = "PG_PASSWORD"
 	PgDbName     = "PG_DBNAME"
 	PgDriverName = "PG_DRIVERNAME"
+
+	PgConnectAttempts = "PG_CONNECT_ATTEMPTS"
+	PgConnectInterval = "PG_CONNECT_INTERVAL"
+	AutoMigrate       = "AUTO_MIGRATE"
+)
+
+const (
+	Port                  = "APP_PORT"
+	ShutdownTime          = "SHUTDOWN_TIME"
+	ShutdownReadinessTime = "SHUTDOWN_READINESS_TIME"
+	HealthCheckTimeout    = "HEALTH_CHECK_TIMEOUT"
 )
 
 const (
-	Port         = "APP_PORT"
-	ShutdownTime = "SHUTDOWN_TIME"
+	TracingExporter     = "TRACING_EXPORTER"
+	TracingOtlpEndpoint = "TRACING_OTLP_ENDPOINT"
+	TracingOtlpInsecure = "TRACING_OTLP_INSECURE"
 )
@@ -0,0 +1,23 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE organizations(
+    id serial PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL
+);
+ALTER TABLE users
+    ADD COLUMN organization_id INTEGER REFERENCES organizations(id),
+    ADD COLUMN org_role VARCHAR(16),
+    ADD CONSTRAINT users_membership_check CHECK ((organization_id IS NULL) = (org_role IS NULL));
+CREATE INDEX users_organization_id_idx ON users(organization_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX users_organization_id_idx;
+ALTER TABLE users
+    DROP CONSTRAINT users_membership_check,
+    DROP COLUMN org_role,
+    DROP COLUMN organization_id;
+DROP TABLE organizations;
+-- +goose StatementEnd
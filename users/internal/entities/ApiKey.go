@@ -0,0 +1,33 @@
+package entities
+
+import (
+	"github.com/zhanbolat18/parcel/users/internal/valueobjects"
+	"time"
+)
+
+// ApiKey lets a merchant backend act for its organization within the scopes.
+// Only the hash of the key is kept, the prefix identifies it in listings and
+// on lookup.
+type ApiKey struct {
+	Id             uint `json:"id"`
+	OrganizationId uint `json:"organization_id"`
+	// UserId is the owner who created the key, requests made with it act as
+	// that user within the organization while they remain a member.
+	UserId     uint                 `json:"user_id"`
+	Name       string               `json:"name"`
+	Prefix     string               `json:"prefix"`
+	Hash       string               `json:"-"`
+	Scopes     []valueobjects.Scope `json:"scopes"`
+	ExpiresAt  *time.Time           `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time           `json:"last_used_at,omitempty"`
+	RevokedAt  *time.Time           `json:"revoked_at,omitempty"`
+	CreatedAt  time.Time            `json:"created_at"`
+}
+
+// Active reports whether the key can authenticate requests at the moment.
+func (k *ApiKey) Active(at time.Time) bool {
+	if k.RevokedAt != nil {
+		return false
+	}
+	return k.ExpiresAt == nil || at.Before(*k.ExpiresAt)
+}
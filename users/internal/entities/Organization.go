@@ -0,0 +1,14 @@
+package entities
+
+import "time"
+
+// Organization is a business whose users share the deliveries.
+type Organization struct {
+	Id        uint      `json:"id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func NewOrganization(name string) *Organization {
+	return &Organization{Name: name, CreatedAt: time.Now()}
+}
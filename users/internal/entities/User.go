@@ -8,6 +8,13 @@ type User struct {
 	PasswordHash string              `json:"-"`
 	Status       valueobjects.Status `json:"status"`
 	Role         valueobjects.Role   `json:"role"`
+	// OrganizationId is set for members of an organization, a user belongs to
+	// one at most.
+	OrganizationId *uint                       `json:"organization_id,omitempty"`
+	OrgRole        valueobjects.MembershipRole `json:"org_role,omitempty"`
+	// Scopes are only set when the request is authenticated by an API key, they
+	// are not stored.
+	Scopes []valueobjects.Scope `json:"scopes,omitempty"`
 }
 
 func NewUser(email, passwordHash string, role valueobjects.Role) *User {
@@ -17,3 +24,18 @@ func NewUser(email, passwordHash string, role valueobjects.Role) *User {
 		Status:       valueobjects.Active,
 		Role:         role}
 }
+
+func (u *User) Join(organizationId uint, role valueobjects.MembershipRole) {
+	u.OrganizationId = &organizationId
+	u.OrgRole = role
+}
+
+func (u *User) Leave() {
+	u.OrganizationId = nil
+	u.OrgRole = ""
+}
+
+// MemberOf reports whether the user belongs to the organization.
+func (u *User) MemberOf(organizationId uint) bool {
+	return u.OrganizationId != nil && *u.OrganizationId == organizationId
+}
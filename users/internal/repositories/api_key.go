@@ -0,0 +1,22 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"github.com/zhanbolat18/parcel/users/internal/entities"
+	"time"
+)
+
+var ErrApiKeyNotFound = errors.New("api key not found")
+
+type ApiKeyRepository interface {
+	GetById(ctx context.Context, id uint) (*entities.ApiKey, error)
+	GetByPrefix(ctx context.Context, prefix string) (*entities.ApiKey, error)
+	GetAllByOrganization(ctx context.Context, organizationId uint) ([]*entities.ApiKey, error)
+	Save(ctx context.Context, key *entities.ApiKey) error
+	// Revoke marks the key revoked, revoking it again keeps the first time.
+	Revoke(ctx context.Context, id uint, at time.Time) error
+	// Touch records the last use of the key without touching anything else, so
+	// it never undoes a concurrent revoke.
+	Touch(ctx context.Context, id uint, at time.Time) error
+}
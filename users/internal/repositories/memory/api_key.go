@@ -0,0 +1,92 @@
+package memory
+
+import (
+	"context"
+	"github.com/zhanbolat18/parcel/users/internal/entities"
+	"github.com/zhanbolat18/parcel/users/internal/repositories"
+	"github.com/zhanbolat18/parcel/users/internal/valueobjects"
+	"sync"
+	"time"
+)
+
+type apiKey struct {
+	mu     sync.RWMutex
+	lastId uint
+	keys   map[uint]*entities.ApiKey
+}
+
+func NewApiKeyRepository() repositories.ApiKeyRepository {
+	return &apiKey{keys: make(map[uint]*entities.ApiKey)}
+}
+
+func (a *apiKey) GetById(_ context.Context, id uint) (*entities.ApiKey, error) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	found, ok := a.keys[id]
+	if !ok {
+		return nil, repositories.ErrApiKeyNotFound
+	}
+	return cloneApiKey(found), nil
+}
+
+func (a *apiKey) GetByPrefix(_ context.Context, prefix string) (*entities.ApiKey, error) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	for _, key := range a.keys {
+		if key.Prefix == prefix {
+			return cloneApiKey(key), nil
+		}
+	}
+	return nil, repositories.ErrApiKeyNotFound
+}
+
+func (a *apiKey) GetAllByOrganization(_ context.Context, organizationId uint) ([]*entities.ApiKey, error) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	keys := make([]*entities.ApiKey, 0)
+	for id := uint(1); id <= a.lastId; id++ {
+		if key, ok := a.keys[id]; ok && key.OrganizationId == organizationId {
+			keys = append(keys, cloneApiKey(key))
+		}
+	}
+	return keys, nil
+}
+
+func (a *apiKey) Save(_ context.Context, key *entities.ApiKey) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.lastId++
+	key.Id = a.lastId
+	a.keys[key.Id] = cloneApiKey(key)
+	return nil
+}
+
+func (a *apiKey) Revoke(_ context.Context, id uint, at time.Time) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	key, ok := a.keys[id]
+	if !ok {
+		return repositories.ErrApiKeyNotFound
+	}
+	if key.RevokedAt == nil {
+		key.RevokedAt = &at
+	}
+	return nil
+}
+
+func (a *apiKey) Touch(_ context.Context, id uint, at time.Time) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	key, ok := a.keys[id]
+	if !ok {
+		return repositories.ErrApiKeyNotFound
+	}
+	key.LastUsedAt = &at
+	return nil
+}
+
+func cloneApiKey(key *entities.ApiKey) *entities.ApiKey {
+	c := *key
+	c.Scopes = append([]valueobjects.Scope(nil), key.Scopes...)
+	return &c
+}
@@ -0,0 +1,26 @@
+package memory_test
+
+import (
+	"github.com/zhanbolat18/parcel/users/internal/repositories"
+	"github.com/zhanbolat18/parcel/users/internal/repositories/memory"
+	"github.com/zhanbolat18/parcel/users/internal/repositories/repositorytest"
+	"testing"
+)
+
+func TestUserRepository(t *testing.T) {
+	repositorytest.UserRepository(t, func(t *testing.T) repositories.UserRepository {
+		return memory.NewUserRepository()
+	})
+}
+
+func TestOrganizationRepository(t *testing.T) {
+	repositorytest.OrganizationRepository(t, func(t *testing.T) (repositories.OrganizationRepository, repositories.UserRepository) {
+		return memory.NewOrganizationRepository(), memory.NewUserRepository()
+	})
+}
+
+func TestApiKeyRepository(t *testing.T) {
+	repositorytest.ApiKeyRepository(t, func(t *testing.T) (repositories.ApiKeyRepository, repositories.OrganizationRepository, repositories.UserRepository) {
+		return memory.NewApiKeyRepository(), memory.NewOrganizationRepository(), memory.NewUserRepository()
+	})
+}
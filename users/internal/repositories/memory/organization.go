@@ -0,0 +1,39 @@
+package memory
+
+import (
+	"context"
+	"github.com/zhanbolat18/parcel/users/internal/entities"
+	"github.com/zhanbolat18/parcel/users/internal/repositories"
+	"sync"
+)
+
+type organization struct {
+	mu            sync.RWMutex
+	lastId        uint
+	organizations map[uint]*entities.Organization
+}
+
+func NewOrganizationRepository() repositories.OrganizationRepository {
+	return &organization{organizations: make(map[uint]*entities.Organization)}
+}
+
+func (o *organization) GetById(_ context.Context, id uint) (*entities.Organization, error) {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	found, ok := o.organizations[id]
+	if !ok {
+		return nil, repositories.ErrOrganizationNotFound
+	}
+	c := *found
+	return &c, nil
+}
+
+func (o *organization) Save(_ context.Context, organization *entities.Organization) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.lastId++
+	organization.Id = o.lastId
+	c := *organization
+	o.organizations[organization.Id] = &c
+	return nil
+}
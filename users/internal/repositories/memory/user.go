@@ -0,0 +1,104 @@
+package memory
+
+import (
+	"context"
+	"github.com/zhanbolat18/parcel/users/internal/entities"
+	"github.com/zhanbolat18/parcel/users/internal/repositories"
+	"github.com/zhanbolat18/parcel/users/internal/valueobjects"
+	"sort"
+	"sync"
+)
+
+type user struct {
+	mu     sync.RWMutex
+	lastId uint
+	users  map[uint]*entities.User
+}
+
+// NewUserRepository keeps users in memory, it behaves like the postgres
+// repository and is meant for tests and local runs.
+func NewUserRepository() repositories.UserRepository {
+	return &user{users: make(map[uint]*entities.User)}
+}
+
+func (u *user) GetById(_ context.Context, id uint) (*entities.User, error) {
+	u.mu.RLock()
+	defer u.mu.RUnlock()
+	if found, ok := u.users[id]; ok {
+		return cloneUser(found), nil
+	}
+	return nil, nil
+}
+
+func (u *user) GetByEmail(_ context.Context, email string) (*entities.User, error) {
+	u.mu.RLock()
+	defer u.mu.RUnlock()
+	if found := u.byEmail(email); found != nil {
+		return cloneUser(found), nil
+	}
+	return nil, nil
+}
+
+func (u *user) GetAllByRole(_ context.Context, role valueobjects.Role) ([]*entities.User, error) {
+	return u.filter(func(found *entities.User) bool { return found.Role == role }), nil
+}
+
+func (u *user) GetAllByOrganization(_ context.Context, organizationId uint) ([]*entities.User, error) {
+	return u.filter(func(found *entities.User) bool { return found.MemberOf(organizationId) }), nil
+}
+
+func (u *user) Save(_ context.Context, user *entities.User) error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if u.byEmail(user.Email) != nil {
+		return repositories.ErrEmailTaken
+	}
+	u.lastId++
+	user.Id = u.lastId
+	u.users[user.Id] = cloneUser(user)
+	return nil
+}
+
+func (u *user) Update(_ context.Context, user *entities.User) error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if _, ok := u.users[user.Id]; !ok {
+		return repositories.ErrUserNotFound
+	}
+	if found := u.byEmail(user.Email); found != nil && found.Id != user.Id {
+		return repositories.ErrEmailTaken
+	}
+	u.users[user.Id] = cloneUser(user)
+	return nil
+}
+
+func (u *user) filter(match func(*entities.User) bool) []*entities.User {
+	u.mu.RLock()
+	defer u.mu.RUnlock()
+	users := make([]*entities.User, 0)
+	for _, found := range u.users {
+		if match(found) {
+			users = append(users, cloneUser(found))
+		}
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i].Id < users[j].Id })
+	return users
+}
+
+func (u *user) byEmail(email string) *entities.User {
+	for _, found := range u.users {
+		if found.Email == email {
+			return found
+		}
+	}
+	return nil
+}
+
+func cloneUser(user *entities.User) *entities.User {
+	c := *user
+	if user.OrganizationId != nil {
+		id := *user.OrganizationId
+		c.OrganizationId = &id
+	}
+	return &c
+}
@@ -0,0 +1,14 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"github.com/zhanbolat18/parcel/users/internal/entities"
+)
+
+var ErrOrganizationNotFound = errors.New("organization not found")
+
+type OrganizationRepository interface {
+	GetById(ctx context.Context, id uint) (*entities.Organization, error)
+	Save(ctx context.Context, organization *entities.Organization) error
+}
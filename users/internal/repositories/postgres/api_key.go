@@ -0,0 +1,141 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/zhanbolat18/parcel/users/internal/entities"
+	"github.com/zhanbolat18/parcel/users/internal/repositories"
+	"github.com/zhanbolat18/parcel/users/internal/valueobjects"
+	"time"
+)
+
+type apiKey struct {
+	db *sqlx.DB
+}
+
+func NewApiKeyRepository(db *sqlx.DB) repositories.ApiKeyRepository {
+	return &apiKey{db: db}
+}
+
+type apiKeyModel struct {
+	Id             uint           `db:"id"`
+	OrganizationId uint           `db:"organization_id"`
+	UserId         uint           `db:"user_id"`
+	Name           string         `db:"name"`
+	Prefix         string         `db:"prefix"`
+	Hash           string         `db:"hash"`
+	Scopes         pq.StringArray `db:"scopes"`
+	ExpiresAt      sql.NullTime   `db:"expires_at"`
+	LastUsedAt     sql.NullTime   `db:"last_used_at"`
+	RevokedAt      sql.NullTime   `db:"revoked_at"`
+	CreatedAt      time.Time      `db:"created_at"`
+}
+
+func (a *apiKey) GetById(ctx context.Context, id uint) (*entities.ApiKey, error) {
+	return a.get(ctx, "SELECT * FROM api_keys WHERE id=$1", id)
+}
+
+func (a *apiKey) GetByPrefix(ctx context.Context, prefix string) (*entities.ApiKey, error) {
+	return a.get(ctx, "SELECT * FROM api_keys WHERE prefix=$1", prefix)
+}
+
+func (a *apiKey) GetAllByOrganization(ctx context.Context, organizationId uint) ([]*entities.ApiKey, error) {
+	models := make([]apiKeyModel, 0)
+	err := a.db.SelectContext(ctx, &models, "SELECT * FROM api_keys WHERE organization_id=$1 ORDER BY id", organizationId)
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]*entities.ApiKey, 0, len(models))
+	for _, model := range models {
+		model := model
+		keys = append(keys, a.hydrateToEntity(&model))
+	}
+	return keys, nil
+}
+
+func (a *apiKey) Save(ctx context.Context, key *entities.ApiKey) error {
+	var id int
+	scopes := make(pq.StringArray, 0, len(key.Scopes))
+	for _, scope := range key.Scopes {
+		scopes = append(scopes, string(scope))
+	}
+	q := `INSERT INTO api_keys(organization_id, user_id, name, prefix, hash, scopes, expires_at, created_at)
+			VALUES($1, $2, $3, $4, $5, $6, $7, $8) RETURNING id`
+	err := a.db.QueryRowContext(ctx, q, key.OrganizationId, key.UserId, key.Name, key.Prefix, key.Hash,
+		scopes, nullTime(key.ExpiresAt), key.CreatedAt).Scan(&id)
+	if err != nil {
+		return err
+	}
+	key.Id = uint(id)
+	return nil
+}
+
+func (a *apiKey) Revoke(ctx context.Context, id uint, at time.Time) error {
+	return a.exec(ctx, "UPDATE api_keys SET revoked_at=COALESCE(revoked_at, $2) WHERE id=$1", id, at)
+}
+
+func (a *apiKey) Touch(ctx context.Context, id uint, at time.Time) error {
+	return a.exec(ctx, "UPDATE api_keys SET last_used_at=$2 WHERE id=$1", id, at)
+}
+
+func (a *apiKey) get(ctx context.Context, q string, arg interface{}) (*entities.ApiKey, error) {
+	model := &apiKeyModel{}
+	if err := a.db.GetContext(ctx, model, q, arg); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, repositories.ErrApiKeyNotFound
+		}
+		return nil, err
+	}
+	return a.hydrateToEntity(model), nil
+}
+
+func (a *apiKey) exec(ctx context.Context, q string, args ...interface{}) error {
+	res, err := a.db.ExecContext(ctx, q, args...)
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return repositories.ErrApiKeyNotFound
+	}
+	return nil
+}
+
+func (a *apiKey) hydrateToEntity(model *apiKeyModel) *entities.ApiKey {
+	key := &entities.ApiKey{
+		Id:             model.Id,
+		OrganizationId: model.OrganizationId,
+		UserId:         model.UserId,
+		Name:           model.Name,
+		Prefix:         model.Prefix,
+		Hash:           model.Hash,
+		Scopes:         make([]valueobjects.Scope, 0, len(model.Scopes)),
+		ExpiresAt:      timePtr(model.ExpiresAt),
+		LastUsedAt:     timePtr(model.LastUsedAt),
+		RevokedAt:      timePtr(model.RevokedAt),
+		CreatedAt:      model.CreatedAt,
+	}
+	for _, scope := range model.Scopes {
+		key.Scopes = append(key.Scopes, valueobjects.Scope(scope))
+	}
+	return key
+}
+
+func nullTime(t *time.Time) sql.NullTime {
+	if t == nil {
+		return sql.NullTime{}
+	}
+	return sql.NullTime{Time: *t, Valid: true}
+}
+
+func timePtr(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
@@ -0,0 +1,46 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"github.com/jmoiron/sqlx"
+	"github.com/zhanbolat18/parcel/users/internal/entities"
+	"github.com/zhanbolat18/parcel/users/internal/repositories"
+	"time"
+)
+
+type organization struct {
+	db *sqlx.DB
+}
+
+func NewOrganizationRepository(db *sqlx.DB) repositories.OrganizationRepository {
+	return &organization{db: db}
+}
+
+type organizationModel struct {
+	Id        uint      `db:"id"`
+	Name      string    `db:"name"`
+	CreatedAt time.Time `db:"created_at"`
+}
+
+func (o *organization) GetById(ctx context.Context, id uint) (*entities.Organization, error) {
+	om := &organizationModel{}
+	err := o.db.GetContext(ctx, om, "SELECT * FROM organizations WHERE id=$1", id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, repositories.ErrOrganizationNotFound
+		}
+		return nil, err
+	}
+	return &entities.Organization{Id: om.Id, Name: om.Name, CreatedAt: om.CreatedAt}, nil
+}
+
+func (o *organization) Save(ctx context.Context, organization *entities.Organization) error {
+	var id int
+	q := "INSERT INTO organizations(name, created_at) VALUES($1, $2) RETURNING id"
+	if err := o.db.QueryRowContext(ctx, q, organization.Name, organization.CreatedAt).Scan(&id); err != nil {
+		return err
+	}
+	organization.Id = uint(id)
+	return nil
+}
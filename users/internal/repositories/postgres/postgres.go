@@ -4,7 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"github.com/jmoiron/sqlx"
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 	"github.com/zhanbolat18/parcel/users/internal/entities"
 	"github.com/zhanbolat18/parcel/users/internal/repositories"
 	"github.com/zhanbolat18/parcel/users/internal/valueobjects"
@@ -24,6 +24,9 @@ type userModel struct {
 	PasswordHash string `db:"password_hash"`
 	Role         string `db:"role"`
 	Status       string `db:"status"`
+
+	OrganizationId sql.NullInt64  `db:"organization_id"`
+	OrgRole        sql.NullString `db:"org_role"`
 }
 
 func (u *user) GetById(ctx context.Context, id uint) (*entities.User, error) {
@@ -52,12 +55,27 @@ func (u *user) GetByEmail(ctx context.Context, email string) (*entities.User, er
 
 func (u *user) GetAllByRole(ctx context.Context, role valueobjects.Role) ([]*entities.User, error) {
 	um := make([]userModel, 0)
-	err := u.db.SelectContext(ctx, um, "SELECT * FROM users WHERE role=$1", role)
+	err := u.db.SelectContext(ctx, &um, "SELECT * FROM users WHERE role=$1 ORDER BY id", role)
+	if err != nil {
+		return nil, err
+	}
+	users := make([]*entities.User, 0, len(um))
+	for _, model := range um {
+		model := model
+		users = append(users, u.hydrateToEntity(&model))
+	}
+	return users, nil
+}
+
+func (u *user) GetAllByOrganization(ctx context.Context, organizationId uint) ([]*entities.User, error) {
+	um := make([]userModel, 0)
+	err := u.db.SelectContext(ctx, &um, "SELECT * FROM users WHERE organization_id=$1 ORDER BY id", organizationId)
 	if err != nil {
 		return nil, err
 	}
 	users := make([]*entities.User, 0, len(um))
 	for _, model := range um {
+		model := model
 		users = append(users, u.hydrateToEntity(&model))
 	}
 	return users, nil
@@ -65,26 +83,62 @@ func (u *user) GetAllByRole(ctx context.Context, role valueobjects.Role) ([]*ent
 
 func (u *user) Save(ctx context.Context, user *entities.User) error {
 	var id int
-	q := "INSERT INTO users(email, password_hash, role, status) VALUES($1, $2, $3, $4) RETURNING id"
-	err := u.db.QueryRowContext(ctx, q, user.Email, user.PasswordHash, user.Role, user.Status).Scan(&id)
+	organizationId, orgRole := u.membership(user)
+	q := `INSERT INTO users(email, password_hash, role, status, organization_id, org_role)
+			VALUES($1, $2, $3, $4, $5, $6) RETURNING id`
+	err := u.db.QueryRowContext(ctx, q, user.Email, user.PasswordHash, user.Role, user.Status,
+		organizationId, orgRole).Scan(&id)
 	if err != nil {
-		return err
+		return u.mapUniqueViolation(err)
 	}
 	user.Id = uint(id)
 	return nil
 }
 
 func (u *user) Update(ctx context.Context, user *entities.User) error {
-	//TODO implement me
-	panic("implement me")
+	organizationId, orgRole := u.membership(user)
+	q := `UPDATE users SET email=$2, password_hash=$3, role=$4, status=$5, organization_id=$6, org_role=$7
+			WHERE id=$1`
+	res, err := u.db.ExecContext(ctx, q, user.Id, user.Email, user.PasswordHash, user.Role, user.Status,
+		organizationId, orgRole)
+	if err != nil {
+		return u.mapUniqueViolation(err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return repositories.ErrUserNotFound
+	}
+	return nil
+}
+
+func (u *user) mapUniqueViolation(err error) error {
+	if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+		return repositories.ErrEmailTaken
+	}
+	return err
+}
+
+func (u *user) membership(user *entities.User) (sql.NullInt64, sql.NullString) {
+	if user.OrganizationId == nil {
+		return sql.NullInt64{}, sql.NullString{}
+	}
+	return sql.NullInt64{Int64: int64(*user.OrganizationId), Valid: true},
+		sql.NullString{String: string(user.OrgRole), Valid: true}
 }
 
 func (u *user) hydrateToEntity(user *userModel) *entities.User {
-	return &entities.User{
+	entity := &entities.User{
 		Id:           user.Id,
 		Email:        user.Email,
 		PasswordHash: user.PasswordHash,
 		Status:       valueobjects.Status(user.Status),
 		Role:         valueobjects.Role(user.Role),
 	}
+	if user.OrganizationId.Valid {
+		entity.Join(uint(user.OrganizationId.Int64), valueobjects.MembershipRole(user.OrgRole.String))
+	}
+	return entity
 }
@@ -0,0 +1,59 @@
+package postgres_test
+
+import (
+	"context"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/require"
+	"github.com/zhanbolat18/parcel/libs/migrate"
+	"github.com/zhanbolat18/parcel/users/database/postgres/migrations"
+	"github.com/zhanbolat18/parcel/users/internal/repositories"
+	"github.com/zhanbolat18/parcel/users/internal/repositories/postgres"
+	"github.com/zhanbolat18/parcel/users/internal/repositories/repositorytest"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+// TEST_PG_DSN points to a disposable database, its tables are truncated.
+const dsnEnv = "TEST_PG_DSN"
+
+func connect(t *testing.T) *sqlx.DB {
+	dsn := os.Getenv(dsnEnv)
+	if dsn == "" {
+		t.Skipf("%s is not set", dsnEnv)
+	}
+	db, err := sqlx.Connect("postgres", dsn)
+	require.Nil(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+	migrator, err := migrate.NewMigrator(db.DB, migrations.FS, ioutil.Discard)
+	require.Nil(t, err)
+	require.Nil(t, migrator.Up(context.Background()))
+	return db
+}
+
+func TestUserRepository(t *testing.T) {
+	db := connect(t)
+	repositorytest.UserRepository(t, func(t *testing.T) repositories.UserRepository {
+		_, err := db.Exec("TRUNCATE users RESTART IDENTITY")
+		require.Nil(t, err)
+		return postgres.NewUserRepository(db)
+	})
+}
+
+func TestOrganizationRepository(t *testing.T) {
+	db := connect(t)
+	repositorytest.OrganizationRepository(t, func(t *testing.T) (repositories.OrganizationRepository, repositories.UserRepository) {
+		_, err := db.Exec("TRUNCATE users, organizations RESTART IDENTITY")
+		require.Nil(t, err)
+		return postgres.NewOrganizationRepository(db), postgres.NewUserRepository(db)
+	})
+}
+
+func TestApiKeyRepository(t *testing.T) {
+	db := connect(t)
+	repositorytest.ApiKeyRepository(t, func(t *testing.T) (repositories.ApiKeyRepository, repositories.OrganizationRepository, repositories.UserRepository) {
+		_, err := db.Exec("TRUNCATE api_keys, users, organizations RESTART IDENTITY")
+		require.Nil(t, err)
+		return postgres.NewApiKeyRepository(db), postgres.NewOrganizationRepository(db), postgres.NewUserRepository(db)
+	})
+}
@@ -0,0 +1,107 @@
+package repositorytest
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zhanbolat18/parcel/users/internal/entities"
+	"github.com/zhanbolat18/parcel/users/internal/repositories"
+	"github.com/zhanbolat18/parcel/users/internal/valueobjects"
+	"testing"
+	"time"
+)
+
+// ApiKeyRepository runs the suite, newRepos must return empty repositories
+// sharing one storage on every call.
+func ApiKeyRepository(
+	t *testing.T,
+	newRepos func(t *testing.T) (repositories.ApiKeyRepository, repositories.OrganizationRepository, repositories.UserRepository),
+) {
+	created := time.Date(2026, 10, 19, 9, 0, 0, 0, time.UTC)
+	setup := func(t *testing.T) (repositories.ApiKeyRepository, *entities.User) {
+		keys, orgs, users := newRepos(t)
+		org := entities.NewOrganization("Acme")
+		require.Nil(t, orgs.Save(ctx, org))
+		owner := entities.NewUser("owner@acme.com", "hash", valueobjects.User)
+		owner.Join(org.Id, valueobjects.Owner)
+		require.Nil(t, users.Save(ctx, owner))
+		return keys, owner
+	}
+	newKey := func(owner *entities.User, prefix string) *entities.ApiKey {
+		return &entities.ApiKey{
+			OrganizationId: *owner.OrganizationId,
+			UserId:         owner.Id,
+			Name:           "shop backend",
+			Prefix:         prefix,
+			Hash:           "hash-" + prefix,
+			Scopes:         []valueobjects.Scope{valueobjects.DeliveriesCreate, valueobjects.DeliveriesRead},
+			CreatedAt:      created,
+		}
+	}
+
+	t.Run("save and get", func(t *testing.T) {
+		keys, owner := setup(t)
+		key := newKey(owner, "pk_first")
+		expires := created.Add(24 * time.Hour)
+		key.ExpiresAt = &expires
+		require.Nil(t, keys.Save(ctx, key))
+		assert.NotZero(t, key.Id)
+
+		for _, get := range []func() (*entities.ApiKey, error){
+			func() (*entities.ApiKey, error) { return keys.GetById(ctx, key.Id) },
+			func() (*entities.ApiKey, error) { return keys.GetByPrefix(ctx, key.Prefix) },
+		} {
+			got, err := get()
+			require.Nil(t, err)
+			assert.Equal(t, key.Hash, got.Hash)
+			assert.Equal(t, key.Scopes, got.Scopes)
+			assert.True(t, expires.Equal(*got.ExpiresAt))
+			assert.True(t, created.Equal(got.CreatedAt))
+			assert.Nil(t, got.LastUsedAt)
+			assert.Nil(t, got.RevokedAt)
+		}
+
+		_, err := keys.GetById(ctx, 404)
+		assert.True(t, errors.Is(err, repositories.ErrApiKeyNotFound))
+		_, err = keys.GetByPrefix(ctx, "pk_missing")
+		assert.True(t, errors.Is(err, repositories.ErrApiKeyNotFound))
+	})
+
+	t.Run("organization keys", func(t *testing.T) {
+		keys, owner := setup(t)
+		first, second := newKey(owner, "pk_first"), newKey(owner, "pk_second")
+		require.Nil(t, keys.Save(ctx, first))
+		require.Nil(t, keys.Save(ctx, second))
+
+		got, err := keys.GetAllByOrganization(ctx, *owner.OrganizationId)
+		require.Nil(t, err)
+		require.Len(t, got, 2)
+		assert.Equal(t, first.Id, got[0].Id)
+		assert.Equal(t, second.Id, got[1].Id)
+
+		got, err = keys.GetAllByOrganization(ctx, 404)
+		require.Nil(t, err)
+		assert.Empty(t, got)
+	})
+
+	t.Run("revoke and touch", func(t *testing.T) {
+		keys, owner := setup(t)
+		key := newKey(owner, "pk_first")
+		require.Nil(t, keys.Save(ctx, key))
+
+		used := created.Add(time.Hour)
+		require.Nil(t, keys.Touch(ctx, key.Id, used))
+		revoked := created.Add(2 * time.Hour)
+		require.Nil(t, keys.Revoke(ctx, key.Id, revoked))
+		require.Nil(t, keys.Revoke(ctx, key.Id, revoked.Add(time.Hour)))
+		require.Nil(t, keys.Touch(ctx, key.Id, revoked.Add(time.Hour)))
+
+		got, err := keys.GetById(ctx, key.Id)
+		require.Nil(t, err)
+		assert.True(t, revoked.Equal(*got.RevokedAt))
+		assert.True(t, revoked.Add(time.Hour).Equal(*got.LastUsedAt))
+
+		assert.True(t, errors.Is(keys.Revoke(ctx, 404, revoked), repositories.ErrApiKeyNotFound))
+		assert.True(t, errors.Is(keys.Touch(ctx, 404, revoked), repositories.ErrApiKeyNotFound))
+	})
+}
@@ -0,0 +1,67 @@
+package repositorytest
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zhanbolat18/parcel/users/internal/entities"
+	"github.com/zhanbolat18/parcel/users/internal/repositories"
+	"github.com/zhanbolat18/parcel/users/internal/valueobjects"
+	"testing"
+	"time"
+)
+
+// OrganizationRepository runs the suite, newRepos must return empty
+// repositories sharing one storage on every call.
+func OrganizationRepository(
+	t *testing.T,
+	newRepos func(t *testing.T) (repositories.OrganizationRepository, repositories.UserRepository),
+) {
+	t.Run("save and get", func(t *testing.T) {
+		orgs, _ := newRepos(t)
+		org := entities.NewOrganization("Acme")
+		org.CreatedAt = time.Date(2026, 10, 19, 9, 0, 0, 0, time.UTC)
+		require.Nil(t, orgs.Save(ctx, org))
+		assert.NotZero(t, org.Id)
+
+		got, err := orgs.GetById(ctx, org.Id)
+		require.Nil(t, err)
+		assert.Equal(t, org.Name, got.Name)
+		assert.True(t, org.CreatedAt.Equal(got.CreatedAt))
+
+		_, err = orgs.GetById(ctx, 404)
+		assert.True(t, errors.Is(err, repositories.ErrOrganizationNotFound))
+	})
+
+	t.Run("members", func(t *testing.T) {
+		orgs, users := newRepos(t)
+		acme, other := entities.NewOrganization("Acme"), entities.NewOrganization("Other")
+		require.Nil(t, orgs.Save(ctx, acme))
+		require.Nil(t, orgs.Save(ctx, other))
+
+		owner := entities.NewUser("owner@acme.com", "hash", valueobjects.User)
+		owner.Join(acme.Id, valueobjects.Owner)
+		viewer := entities.NewUser("viewer@acme.com", "hash", valueobjects.User)
+		stranger := entities.NewUser("stranger@other.com", "hash", valueobjects.User)
+		stranger.Join(other.Id, valueobjects.Staff)
+		for _, u := range []*entities.User{owner, viewer, stranger} {
+			require.Nil(t, users.Save(ctx, u))
+		}
+		viewer.Join(acme.Id, valueobjects.Viewer)
+		require.Nil(t, users.Update(ctx, viewer))
+
+		members, err := users.GetAllByOrganization(ctx, acme.Id)
+		require.Nil(t, err)
+		assert.Equal(t, []*entities.User{owner, viewer}, members)
+
+		viewer.Leave()
+		require.Nil(t, users.Update(ctx, viewer))
+		got, err := users.GetById(ctx, viewer.Id)
+		require.Nil(t, err)
+		assert.Nil(t, got.OrganizationId)
+		assert.Empty(t, got.OrgRole)
+		members, err = users.GetAllByOrganization(ctx, acme.Id)
+		require.Nil(t, err)
+		assert.Equal(t, []*entities.User{owner}, members)
+	})
+}
@@ -0,0 +1,123 @@
+// Package repositorytest checks that repository implementations behave the
+// same, it is shared by the tests of the memory and postgres packages.
+package repositorytest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zhanbolat18/parcel/users/internal/entities"
+	"github.com/zhanbolat18/parcel/users/internal/repositories"
+	"github.com/zhanbolat18/parcel/users/internal/valueobjects"
+	"sync"
+	"testing"
+)
+
+var ctx = context.Background()
+
+// UserRepository runs the suite, newRepo must return an empty repository on
+// every call.
+func UserRepository(t *testing.T, newRepo func(t *testing.T) repositories.UserRepository) {
+	t.Run("save and get", func(t *testing.T) {
+		repo := newRepo(t)
+		u := entities.NewUser("courier@mail.com", "hash", valueobjects.Courier)
+		require.Nil(t, repo.Save(ctx, u))
+		assert.NotZero(t, u.Id)
+
+		byId, err := repo.GetById(ctx, u.Id)
+		require.Nil(t, err)
+		assert.Equal(t, u, byId)
+		byEmail, err := repo.GetByEmail(ctx, u.Email)
+		require.Nil(t, err)
+		assert.Equal(t, u, byEmail)
+	})
+
+	t.Run("get unknown", func(t *testing.T) {
+		repo := newRepo(t)
+		u, err := repo.GetById(ctx, 404)
+		assert.Nil(t, err)
+		assert.Nil(t, u)
+		u, err = repo.GetByEmail(ctx, "unknown@mail.com")
+		assert.Nil(t, err)
+		assert.Nil(t, u)
+	})
+
+	t.Run("email is unique", func(t *testing.T) {
+		repo := newRepo(t)
+		require.Nil(t, repo.Save(ctx, entities.NewUser("user@mail.com", "hash", valueobjects.User)))
+		err := repo.Save(ctx, entities.NewUser("user@mail.com", "other", valueobjects.Courier))
+		assert.True(t, errors.Is(err, repositories.ErrEmailTaken))
+
+		other := entities.NewUser("other@mail.com", "hash", valueobjects.User)
+		require.Nil(t, repo.Save(ctx, other))
+		other.Email = "user@mail.com"
+		assert.True(t, errors.Is(repo.Update(ctx, other), repositories.ErrEmailTaken))
+	})
+
+	t.Run("get all by role", func(t *testing.T) {
+		repo := newRepo(t)
+		first := entities.NewUser("first@mail.com", "hash", valueobjects.Courier)
+		second := entities.NewUser("second@mail.com", "hash", valueobjects.Courier)
+		for _, u := range []*entities.User{
+			first,
+			entities.NewUser("user@mail.com", "hash", valueobjects.User),
+			second,
+		} {
+			require.Nil(t, repo.Save(ctx, u))
+		}
+
+		couriers, err := repo.GetAllByRole(ctx, valueobjects.Courier)
+		require.Nil(t, err)
+		assert.Equal(t, []*entities.User{first, second}, couriers)
+		admins, err := repo.GetAllByRole(ctx, valueobjects.Admin)
+		require.Nil(t, err)
+		assert.Empty(t, admins)
+	})
+
+	t.Run("update", func(t *testing.T) {
+		repo := newRepo(t)
+		u := entities.NewUser("user@mail.com", "hash", valueobjects.User)
+		require.Nil(t, repo.Save(ctx, u))
+		u.Status = valueobjects.Blocked
+		require.Nil(t, repo.Update(ctx, u))
+
+		got, err := repo.GetById(ctx, u.Id)
+		require.Nil(t, err)
+		assert.Equal(t, valueobjects.Blocked, got.Status)
+
+		unknown := entities.NewUser("unknown@mail.com", "hash", valueobjects.User)
+		unknown.Id = 404
+		assert.True(t, errors.Is(repo.Update(ctx, unknown), repositories.ErrUserNotFound))
+	})
+
+	t.Run("returned users are copies", func(t *testing.T) {
+		repo := newRepo(t)
+		u := entities.NewUser("user@mail.com", "hash", valueobjects.User)
+		require.Nil(t, repo.Save(ctx, u))
+		u.Status = valueobjects.Frozen
+
+		got, err := repo.GetById(ctx, u.Id)
+		require.Nil(t, err)
+		assert.Equal(t, valueobjects.Active, got.Status)
+	})
+
+	t.Run("concurrent saves", func(t *testing.T) {
+		repo := newRepo(t)
+		const writers = 10
+		var wg sync.WaitGroup
+		for i := 0; i < writers; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				u := entities.NewUser(fmt.Sprintf("courier%d@mail.com", i), "hash", valueobjects.Courier)
+				assert.Nil(t, repo.Save(ctx, u))
+			}(i)
+		}
+		wg.Wait()
+		couriers, err := repo.GetAllByRole(ctx, valueobjects.Courier)
+		require.Nil(t, err)
+		assert.Len(t, couriers, writers)
+	})
+}
@@ -2,14 +2,22 @@ package repositories
 
 import (
 	"context"
+	"errors"
 	"github.com/zhanbolat18/parcel/users/internal/entities"
 	"github.com/zhanbolat18/parcel/users/internal/valueobjects"
 )
 
+var (
+	ErrUserNotFound = errors.New("user not found")
+	ErrEmailTaken   = errors.New("email is already taken")
+)
+
+// UserRepository returns nil user without error when a lookup finds nothing.
 type UserRepository interface {
 	GetById(ctx context.Context, id uint) (*entities.User, error)
 	GetByEmail(ctx context.Context, email string) (*entities.User, error)
 	GetAllByRole(ctx context.Context, role valueobjects.Role) ([]*entities.User, error)
+	GetAllByOrganization(ctx context.Context, organizationId uint) ([]*entities.User, error)
 	Save(ctx context.Context, user *entities.User) error
 	Update(ctx context.Context, user *entities.User) error
 }
@@ -0,0 +1,190 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/zhanbolat18/parcel/users/internal/entities"
+	"github.com/zhanbolat18/parcel/users/internal/repositories"
+	"github.com/zhanbolat18/parcel/users/internal/valueobjects"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidScope  = errors.New("invalid api key scope")
+	ErrInvalidExpiry = errors.New("api key must expire in the future")
+	// ErrInvalidApiKey hides why a key was refused from the caller.
+	ErrInvalidApiKey = errors.New("invalid api key")
+)
+
+const (
+	apiKeyPrefix = "pk_"
+	// lastUsedPrecision spares busy keys a write on every request.
+	lastUsedPrecision = time.Minute
+)
+
+// ManageApiKey issues the keys merchant backends authenticate with instead of
+// a password. A key is "<prefix>.<secret>", only its sha256 hash is stored.
+type ManageApiKey struct {
+	keysRepo repositories.ApiKeyRepository
+	userRepo repositories.UserRepository
+	now      func() time.Time
+}
+
+func NewApiKeyService(keysRepo repositories.ApiKeyRepository, userRepo repositories.UserRepository) *ManageApiKey {
+	return &ManageApiKey{keysRepo: keysRepo, userRepo: userRepo, now: time.Now}
+}
+
+// Create issues a key for the organization of the owner, the plain key is
+// only returned here.
+func (m *ManageApiKey) Create(
+	ctx context.Context,
+	owner *entities.User,
+	name string,
+	scopes []valueobjects.Scope,
+	expiresAt *time.Time,
+) (*entities.ApiKey, string, error) {
+	if err := checkOwner(owner); err != nil {
+		return nil, "", err
+	}
+	scopes, err := uniqueScopes(scopes)
+	if err != nil {
+		return nil, "", err
+	}
+	now := m.now()
+	if expiresAt != nil && !expiresAt.After(now) {
+		return nil, "", ErrInvalidExpiry
+	}
+	prefix, err := randomString(6, hex.EncodeToString)
+	if err != nil {
+		return nil, "", err
+	}
+	secret, err := randomString(32, base64.RawURLEncoding.EncodeToString)
+	if err != nil {
+		return nil, "", err
+	}
+	plain := apiKeyPrefix + prefix + "." + secret
+	key := &entities.ApiKey{
+		OrganizationId: *owner.OrganizationId,
+		UserId:         owner.Id,
+		Name:           name,
+		Prefix:         apiKeyPrefix + prefix,
+		Hash:           hashApiKey(plain),
+		Scopes:         scopes,
+		ExpiresAt:      expiresAt,
+		CreatedAt:      now,
+	}
+	if err = m.keysRepo.Save(ctx, key); err != nil {
+		return nil, "", fmt.Errorf("save api key: %w", err)
+	}
+	return key, plain, nil
+}
+
+func (m *ManageApiKey) GetAll(ctx context.Context, owner *entities.User) ([]*entities.ApiKey, error) {
+	if err := checkOwner(owner); err != nil {
+		return nil, err
+	}
+	keys, err := m.keysRepo.GetAllByOrganization(ctx, *owner.OrganizationId)
+	if err != nil {
+		return nil, fmt.Errorf("get api keys: %w", err)
+	}
+	return keys, nil
+}
+
+// Revoke stops the key of the organization from authenticating, revoked keys
+// stay listed.
+func (m *ManageApiKey) Revoke(ctx context.Context, owner *entities.User, id uint) error {
+	if err := checkOwner(owner); err != nil {
+		return err
+	}
+	key, err := m.keysRepo.GetById(ctx, id)
+	if err != nil {
+		return fmt.Errorf("get api key by id \"%d\": %w", id, err)
+	}
+	if key.OrganizationId != *owner.OrganizationId {
+		return repositories.ErrApiKeyNotFound
+	}
+	if err = m.keysRepo.Revoke(ctx, id, m.now()); err != nil {
+		return fmt.Errorf("revoke api key \"%d\": %w", id, err)
+	}
+	return nil
+}
+
+// Authenticate resolves a key to its creator acting as staff of the
+// organization, limited to the scopes of the key.
+func (m *ManageApiKey) Authenticate(ctx context.Context, plain string) (*entities.User, error) {
+	prefix, _, ok := strings.Cut(plain, ".")
+	if !ok || !strings.HasPrefix(prefix, apiKeyPrefix) {
+		return nil, ErrInvalidApiKey
+	}
+	key, err := m.keysRepo.GetByPrefix(ctx, prefix)
+	if errors.Is(err, repositories.ErrApiKeyNotFound) {
+		return nil, ErrInvalidApiKey
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get api key by prefix \"%s\": %w", prefix, err)
+	}
+	now := m.now()
+	if subtle.ConstantTimeCompare([]byte(hashApiKey(plain)), []byte(key.Hash)) != 1 || !key.Active(now) {
+		return nil, ErrInvalidApiKey
+	}
+	creator, err := m.userRepo.GetById(ctx, key.UserId)
+	if err != nil {
+		return nil, fmt.Errorf("get user by id \"%d\": %w", key.UserId, err)
+	}
+	// keys of creators who left the organization stop working with them
+	if creator == nil || creator.Status != valueobjects.Active || !creator.MemberOf(key.OrganizationId) {
+		return nil, ErrInvalidApiKey
+	}
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) >= lastUsedPrecision {
+		if err = m.keysRepo.Touch(ctx, key.Id, now); err != nil {
+			return nil, fmt.Errorf("touch api key \"%d\": %w", key.Id, err)
+		}
+	}
+	identity := &entities.User{
+		Id:     creator.Id,
+		Email:  creator.Email,
+		Status: creator.Status,
+		Role:   valueobjects.User,
+		Scopes: key.Scopes,
+	}
+	identity.Join(key.OrganizationId, valueobjects.Staff)
+	return identity, nil
+}
+
+func uniqueScopes(scopes []valueobjects.Scope) ([]valueobjects.Scope, error) {
+	if len(scopes) == 0 {
+		return nil, ErrInvalidScope
+	}
+	unique := make([]valueobjects.Scope, 0, len(scopes))
+	seen := make(map[valueobjects.Scope]bool, len(scopes))
+	for _, scope := range scopes {
+		if !scope.Valid() {
+			return nil, ErrInvalidScope
+		}
+		if !seen[scope] {
+			seen[scope] = true
+			unique = append(unique, scope)
+		}
+	}
+	return unique, nil
+}
+
+func randomString(size int, encode func([]byte) string) (string, error) {
+	b := make([]byte, size)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generate api key: %w", err)
+	}
+	return encode(b), nil
+}
+
+func hashApiKey(plain string) string {
+	sum := sha256.Sum256([]byte(plain))
+	return hex.EncodeToString(sum[:])
+}
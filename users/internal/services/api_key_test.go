@@ -0,0 +1,145 @@
+package services_test
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zhanbolat18/parcel/users/internal/entities"
+	"github.com/zhanbolat18/parcel/users/internal/repositories"
+	"github.com/zhanbolat18/parcel/users/internal/repositories/memory"
+	"github.com/zhanbolat18/parcel/users/internal/services"
+	"github.com/zhanbolat18/parcel/users/internal/valueobjects"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newApiKeys(t *testing.T) (*services.ManageApiKey, repositories.ApiKeyRepository, repositories.UserRepository, *entities.User) {
+	owner := entities.NewUser("owner@acme.com", "hash", valueobjects.User)
+	staff := entities.NewUser("staff@acme.com", "hash", valueobjects.User)
+	repo := newRepo(t, owner, staff)
+	_, err := services.NewOrganizationService(memory.NewOrganizationRepository(), repo).Create(ctx, owner, "Acme")
+	require.Nil(t, err)
+	staff.Join(*owner.OrganizationId, valueobjects.Staff)
+	require.Nil(t, repo.Update(ctx, staff))
+	keys := memory.NewApiKeyRepository()
+	return services.NewApiKeyService(keys, repo), keys, repo, owner
+}
+
+func TestManageApiKey_Create(t *testing.T) {
+	srv, keys, repo, owner := newApiKeys(t)
+	scopes := []valueobjects.Scope{valueobjects.DeliveriesCreate, valueobjects.DeliveriesRead, valueobjects.DeliveriesCreate}
+
+	_, _, err := srv.Create(ctx, owner, "shop", nil, nil)
+	assert.ErrorIs(t, err, services.ErrInvalidScope)
+	_, _, err = srv.Create(ctx, owner, "shop", []valueobjects.Scope{"users:delete"}, nil)
+	assert.ErrorIs(t, err, services.ErrInvalidScope)
+	past := time.Now().Add(-time.Hour)
+	_, _, err = srv.Create(ctx, owner, "shop", scopes, &past)
+	assert.ErrorIs(t, err, services.ErrInvalidExpiry)
+	staff, err := repo.GetByEmail(ctx, "staff@acme.com")
+	require.Nil(t, err)
+	_, _, err = srv.Create(ctx, staff, "shop", scopes, nil)
+	assert.ErrorIs(t, err, services.ErrNotOwner)
+
+	key, plain, err := srv.Create(ctx, owner, "shop", scopes, nil)
+	require.Nil(t, err)
+	assert.True(t, strings.HasPrefix(plain, key.Prefix+"."))
+	assert.Equal(t, []valueobjects.Scope{valueobjects.DeliveriesCreate, valueobjects.DeliveriesRead}, key.Scopes)
+	stored, err := keys.GetById(ctx, key.Id)
+	require.Nil(t, err)
+	assert.NotContains(t, stored.Hash, plain)
+	assert.NotContains(t, stored.Hash, strings.TrimPrefix(plain, key.Prefix+"."))
+
+	listed, err := srv.GetAll(ctx, owner)
+	require.Nil(t, err)
+	require.Len(t, listed, 1)
+	assert.Equal(t, key.Id, listed[0].Id)
+	_, err = srv.GetAll(ctx, staff)
+	assert.ErrorIs(t, err, services.ErrNotOwner)
+}
+
+func TestManageApiKey_Authenticate(t *testing.T) {
+	srv, keys, repo, owner := newApiKeys(t)
+	key, plain, err := srv.Create(ctx, owner, "shop", []valueobjects.Scope{valueobjects.DeliveriesRead}, nil)
+	require.Nil(t, err)
+
+	identity, err := srv.Authenticate(ctx, plain)
+	require.Nil(t, err)
+	assert.Equal(t, owner.Id, identity.Id)
+	assert.Equal(t, valueobjects.User, identity.Role)
+	assert.True(t, identity.MemberOf(*owner.OrganizationId))
+	assert.Equal(t, valueobjects.Staff, identity.OrgRole)
+	assert.Equal(t, []valueobjects.Scope{valueobjects.DeliveriesRead}, identity.Scopes)
+	stored, err := keys.GetById(ctx, key.Id)
+	require.Nil(t, err)
+	assert.NotNil(t, stored.LastUsedAt)
+
+	for _, wrong := range []string{"", "garbage", key.Prefix, plain + "x", "pk_000000000000." + strings.Split(plain, ".")[1]} {
+		_, err = srv.Authenticate(ctx, wrong)
+		assert.ErrorIs(t, err, services.ErrInvalidApiKey, wrong)
+	}
+
+	owner.Status = valueobjects.Blocked
+	require.Nil(t, repo.Update(ctx, owner))
+	_, err = srv.Authenticate(ctx, plain)
+	assert.ErrorIs(t, err, services.ErrInvalidApiKey)
+}
+
+func TestManageApiKey_RevokeAndExpiry(t *testing.T) {
+	srv, keys, repo, owner := newApiKeys(t)
+	key, plain, err := srv.Create(ctx, owner, "shop", []valueobjects.Scope{valueobjects.DeliveriesRead}, nil)
+	require.Nil(t, err)
+
+	stranger := entities.NewUser("owner@globex.com", "hash", valueobjects.User)
+	require.Nil(t, repo.Save(ctx, stranger))
+	stranger.Join(*owner.OrganizationId+1, valueobjects.Owner)
+	assert.ErrorIs(t, srv.Revoke(ctx, stranger, key.Id), repositories.ErrApiKeyNotFound)
+	_, err = srv.Authenticate(ctx, plain)
+	require.Nil(t, err)
+
+	require.Nil(t, srv.Revoke(ctx, owner, key.Id))
+	_, err = srv.Authenticate(ctx, plain)
+	assert.ErrorIs(t, err, services.ErrInvalidApiKey)
+	listed, err := srv.GetAll(ctx, owner)
+	require.Nil(t, err)
+	require.Len(t, listed, 1)
+	assert.NotNil(t, listed[0].RevokedAt)
+
+	expiredPlain := "pk_0123456789ab.secret"
+	sum := sha256.Sum256([]byte(expiredPlain))
+	expired := time.Now().Add(-time.Minute)
+	require.Nil(t, keys.Save(ctx, &entities.ApiKey{
+		OrganizationId: *owner.OrganizationId,
+		UserId:         owner.Id,
+		Prefix:         "pk_0123456789ab",
+		Hash:           hex.EncodeToString(sum[:]),
+		Scopes:         []valueobjects.Scope{valueobjects.DeliveriesRead},
+		ExpiresAt:      &expired,
+	}))
+	_, err = srv.Authenticate(ctx, expiredPlain)
+	assert.ErrorIs(t, err, services.ErrInvalidApiKey)
+}
+
+func TestManageApiKey_CreatorLeft(t *testing.T) {
+	srv, _, repo, owner := newApiKeys(t)
+	acme := *owner.OrganizationId
+	_, plain, err := srv.Create(ctx, owner, "shop", []valueobjects.Scope{valueobjects.DeliveriesRead}, nil)
+	require.Nil(t, err)
+
+	owner.Leave()
+	require.Nil(t, repo.Update(ctx, owner))
+	_, err = srv.Authenticate(ctx, plain)
+	assert.ErrorIs(t, err, services.ErrInvalidApiKey, "the creator was removed")
+
+	owner.Join(acme+1, valueobjects.Owner)
+	require.Nil(t, repo.Update(ctx, owner))
+	_, err = srv.Authenticate(ctx, plain)
+	assert.ErrorIs(t, err, services.ErrInvalidApiKey, "the creator moved to another organization")
+
+	owner.Join(acme, valueobjects.Owner)
+	require.Nil(t, repo.Update(ctx, owner))
+	_, err = srv.Authenticate(ctx, plain)
+	assert.Nil(t, err)
+}
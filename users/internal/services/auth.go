@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"github.com/zhanbolat18/parcel/libs/metrics"
 	"github.com/zhanbolat18/parcel/users/internal/entities"
 	"github.com/zhanbolat18/parcel/users/internal/repositories"
 	. "github.com/zhanbolat18/parcel/users/internal/valueobjects"
@@ -11,17 +12,38 @@ import (
 	"github.com/zhanbolat18/parcel/users/pkg/jwt"
 )
 
+const (
+	LoginSucceeded = "succeeded"
+	LoginFailed    = "failed"
+)
+
 type AuthService struct {
 	hasher crypto.PasswordHasher
 	jwt    jwt.Jwt
 	repo   repositories.UserRepository
+	logins metrics.Counter
 }
 
-func NewAuthService(hasher crypto.PasswordHasher, jwt jwt.Jwt, repo repositories.UserRepository) *AuthService {
-	return &AuthService{hasher: hasher, jwt: jwt, repo: repo}
+func NewAuthService(
+	hasher crypto.PasswordHasher,
+	jwt jwt.Jwt,
+	repo repositories.UserRepository,
+	logins metrics.Counter,
+) *AuthService {
+	return &AuthService{hasher: hasher, jwt: jwt, repo: repo, logins: logins}
 }
 
 func (a *AuthService) Authentication(ctx context.Context, email, password string) (token string, err error) {
+	token, err = a.authenticate(ctx, email, password)
+	if err != nil {
+		a.logins.Inc(LoginFailed)
+		return "", err
+	}
+	a.logins.Inc(LoginSucceeded)
+	return token, nil
+}
+
+func (a *AuthService) authenticate(ctx context.Context, email, password string) (token string, err error) {
 	u, err := a.repo.GetByEmail(ctx, email)
 	if err != nil {
 		return "", fmt.Errorf("get by email \"%s\": %w", email, err)
@@ -0,0 +1,185 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/zhanbolat18/parcel/users/internal/entities"
+	"github.com/zhanbolat18/parcel/users/internal/repositories"
+	"github.com/zhanbolat18/parcel/users/internal/valueobjects"
+)
+
+var (
+	ErrNotMember             = errors.New("user is not a member of an organization")
+	ErrNotOwner              = errors.New("only owners manage the organization")
+	ErrAlreadyMember         = errors.New("user is already a member of an organization")
+	ErrMemberNotFound        = errors.New("member not found")
+	ErrInvalidMembershipRole = errors.New("invalid membership role")
+	// ErrLastOwner is returned when a change would leave the organization
+	// without an owner.
+	ErrLastOwner = errors.New("organization must keep an owner")
+)
+
+// ManageOrganization groups the accounts of a business, its members share the
+// deliveries of the organization.
+type ManageOrganization struct {
+	orgRepo  repositories.OrganizationRepository
+	userRepo repositories.UserRepository
+}
+
+func NewOrganizationService(
+	orgRepo repositories.OrganizationRepository,
+	userRepo repositories.UserRepository,
+) *ManageOrganization {
+	return &ManageOrganization{orgRepo: orgRepo, userRepo: userRepo}
+}
+
+// Create founds an organization owned by the founder.
+func (m *ManageOrganization) Create(ctx context.Context, founder *entities.User, name string) (*entities.Organization, error) {
+	if founder.OrganizationId != nil {
+		return nil, ErrAlreadyMember
+	}
+	org := entities.NewOrganization(name)
+	if err := m.orgRepo.Save(ctx, org); err != nil {
+		return nil, fmt.Errorf("save organization: %w", err)
+	}
+	founder.Join(org.Id, valueobjects.Owner)
+	if err := m.userRepo.Update(ctx, founder); err != nil {
+		return nil, fmt.Errorf("update founder: %w", err)
+	}
+	return org, nil
+}
+
+func (m *ManageOrganization) Get(ctx context.Context, user *entities.User) (*entities.Organization, error) {
+	if user.OrganizationId == nil {
+		return nil, ErrNotMember
+	}
+	org, err := m.orgRepo.GetById(ctx, *user.OrganizationId)
+	if err != nil {
+		return nil, fmt.Errorf("get organization by id \"%d\": %w", *user.OrganizationId, err)
+	}
+	return org, nil
+}
+
+func (m *ManageOrganization) Members(ctx context.Context, user *entities.User) ([]*entities.User, error) {
+	if user.OrganizationId == nil {
+		return nil, ErrNotMember
+	}
+	members, err := m.userRepo.GetAllByOrganization(ctx, *user.OrganizationId)
+	if err != nil {
+		return nil, fmt.Errorf("get members: %w", err)
+	}
+	return members, nil
+}
+
+// AddMember lets an owner add a signed up user that is not a member of any
+// organization yet.
+func (m *ManageOrganization) AddMember(
+	ctx context.Context,
+	owner *entities.User,
+	email string,
+	role valueobjects.MembershipRole,
+) (*entities.User, error) {
+	if !role.Valid() {
+		return nil, ErrInvalidMembershipRole
+	}
+	if err := checkOwner(owner); err != nil {
+		return nil, err
+	}
+	u, err := m.userRepo.GetByEmail(ctx, email)
+	if err != nil {
+		return nil, fmt.Errorf("get user by email \"%s\": %w", email, err)
+	}
+	if u == nil || u.Role != valueobjects.User {
+		return nil, ErrMemberNotFound
+	}
+	if u.OrganizationId != nil {
+		return nil, ErrAlreadyMember
+	}
+	u.Join(*owner.OrganizationId, role)
+	if err = m.userRepo.Update(ctx, u); err != nil {
+		return nil, fmt.Errorf("update member: %w", err)
+	}
+	return u, nil
+}
+
+func (m *ManageOrganization) ChangeRole(
+	ctx context.Context,
+	owner *entities.User,
+	memberId uint,
+	role valueobjects.MembershipRole,
+) (*entities.User, error) {
+	if !role.Valid() {
+		return nil, ErrInvalidMembershipRole
+	}
+	member, err := m.member(ctx, owner, memberId)
+	if err != nil {
+		return nil, err
+	}
+	if member.OrgRole == valueobjects.Owner && role != valueobjects.Owner {
+		if err = m.checkOtherOwner(ctx, member); err != nil {
+			return nil, err
+		}
+	}
+	member.OrgRole = role
+	if err = m.userRepo.Update(ctx, member); err != nil {
+		return nil, fmt.Errorf("update member: %w", err)
+	}
+	return member, nil
+}
+
+// RemoveMember turns the member back into an individual user, the deliveries
+// stay with the organization.
+func (m *ManageOrganization) RemoveMember(ctx context.Context, owner *entities.User, memberId uint) error {
+	member, err := m.member(ctx, owner, memberId)
+	if err != nil {
+		return err
+	}
+	if member.OrgRole == valueobjects.Owner {
+		if err = m.checkOtherOwner(ctx, member); err != nil {
+			return err
+		}
+	}
+	member.Leave()
+	if err = m.userRepo.Update(ctx, member); err != nil {
+		return fmt.Errorf("update member: %w", err)
+	}
+	return nil
+}
+
+func (m *ManageOrganization) member(ctx context.Context, owner *entities.User, memberId uint) (*entities.User, error) {
+	if err := checkOwner(owner); err != nil {
+		return nil, err
+	}
+	member, err := m.userRepo.GetById(ctx, memberId)
+	if err != nil {
+		return nil, fmt.Errorf("get user by id \"%d\": %w", memberId, err)
+	}
+	if member == nil || !member.MemberOf(*owner.OrganizationId) {
+		return nil, ErrMemberNotFound
+	}
+	return member, nil
+}
+
+func checkOwner(user *entities.User) error {
+	if user.OrganizationId == nil {
+		return ErrNotMember
+	}
+	if user.OrgRole != valueobjects.Owner {
+		return ErrNotOwner
+	}
+	return nil
+}
+
+func (m *ManageOrganization) checkOtherOwner(ctx context.Context, owner *entities.User) error {
+	members, err := m.userRepo.GetAllByOrganization(ctx, *owner.OrganizationId)
+	if err != nil {
+		return fmt.Errorf("get members: %w", err)
+	}
+	for _, member := range members {
+		if member.Id != owner.Id && member.OrgRole == valueobjects.Owner {
+			return nil
+		}
+	}
+	return ErrLastOwner
+}
@@ -0,0 +1,102 @@
+package services_test
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zhanbolat18/parcel/libs/metrics"
+	"github.com/zhanbolat18/parcel/users/internal/entities"
+	"github.com/zhanbolat18/parcel/users/internal/repositories"
+	"github.com/zhanbolat18/parcel/users/internal/repositories/memory"
+	"github.com/zhanbolat18/parcel/users/internal/services"
+	"github.com/zhanbolat18/parcel/users/internal/valueobjects"
+	"testing"
+)
+
+func newOrganizations(t *testing.T) (*services.ManageOrganization, repositories.UserRepository, []*entities.User) {
+	users := []*entities.User{
+		entities.NewUser("owner@acme.com", "hash", valueobjects.User),
+		entities.NewUser("staff@acme.com", "hash", valueobjects.User),
+		entities.NewUser("courier@mail.com", "hash", valueobjects.Courier),
+	}
+	repo := newRepo(t, users...)
+	return services.NewOrganizationService(memory.NewOrganizationRepository(), repo), repo, users
+}
+
+func TestManageOrganization_Create(t *testing.T) {
+	srv, repo, users := newOrganizations(t)
+	owner := users[0]
+
+	org, err := srv.Create(ctx, owner, "Acme")
+	require.Nil(t, err)
+	assert.Equal(t, "Acme", org.Name)
+	stored, err := repo.GetById(ctx, owner.Id)
+	require.Nil(t, err)
+	assert.True(t, stored.MemberOf(org.Id))
+	assert.Equal(t, valueobjects.Owner, stored.OrgRole)
+
+	_, err = srv.Create(ctx, stored, "Acme 2")
+	assert.ErrorIs(t, err, services.ErrAlreadyMember)
+	mine, err := srv.Get(ctx, stored)
+	require.Nil(t, err)
+	assert.Equal(t, org.Id, mine.Id)
+	_, err = srv.Get(ctx, users[1])
+	assert.ErrorIs(t, err, services.ErrNotMember)
+}
+
+func TestManageOrganization_Members(t *testing.T) {
+	srv, repo, users := newOrganizations(t)
+	owner := users[0]
+	_, err := srv.Create(ctx, owner, "Acme")
+	require.Nil(t, err)
+
+	_, err = srv.AddMember(ctx, owner, "staff@acme.com", "janitor")
+	assert.ErrorIs(t, err, services.ErrInvalidMembershipRole)
+	_, err = srv.AddMember(ctx, owner, "courier@mail.com", valueobjects.Staff)
+	assert.ErrorIs(t, err, services.ErrMemberNotFound)
+	_, err = srv.AddMember(ctx, owner, "unknown@acme.com", valueobjects.Staff)
+	assert.ErrorIs(t, err, services.ErrMemberNotFound)
+
+	staff, err := srv.AddMember(ctx, owner, "staff@acme.com", valueobjects.Staff)
+	require.Nil(t, err)
+	assert.Equal(t, valueobjects.Staff, staff.OrgRole)
+	_, err = srv.AddMember(ctx, owner, "staff@acme.com", valueobjects.Viewer)
+	assert.ErrorIs(t, err, services.ErrAlreadyMember)
+	_, err = srv.AddMember(ctx, staff, "courier@mail.com", valueobjects.Viewer)
+	assert.ErrorIs(t, err, services.ErrNotOwner)
+
+	members, err := srv.Members(ctx, staff)
+	require.Nil(t, err)
+	assert.Len(t, members, 2)
+
+	staff, err = srv.ChangeRole(ctx, owner, staff.Id, valueobjects.Viewer)
+	require.Nil(t, err)
+	assert.Equal(t, valueobjects.Viewer, staff.OrgRole)
+	_, err = srv.ChangeRole(ctx, owner, users[2].Id, valueobjects.Viewer)
+	assert.ErrorIs(t, err, services.ErrMemberNotFound)
+
+	// the only owner can neither step down nor leave
+	_, err = srv.ChangeRole(ctx, owner, owner.Id, valueobjects.Staff)
+	assert.ErrorIs(t, err, services.ErrLastOwner)
+	assert.ErrorIs(t, srv.RemoveMember(ctx, owner, owner.Id), services.ErrLastOwner)
+
+	require.Nil(t, srv.RemoveMember(ctx, owner, staff.Id))
+	left, err := repo.GetById(ctx, staff.Id)
+	require.Nil(t, err)
+	assert.Nil(t, left.OrganizationId)
+	assert.Empty(t, left.OrgRole)
+}
+
+func TestAuthService_AuthorizationIdentifiesOrganization(t *testing.T) {
+	srv, repo, users := newOrganizations(t)
+	owner := users[0]
+	org, err := srv.Create(ctx, owner, "Acme")
+	require.Nil(t, err)
+
+	auth := services.NewAuthService(hasher, jwt, repo, metrics.NopCounter())
+	token, err := jwt.Generate(owner.Id, owner.Email)
+	require.Nil(t, err)
+	u, err := auth.Authorization(ctx, token)
+	require.Nil(t, err)
+	assert.True(t, u.MemberOf(org.Id))
+	assert.Equal(t, valueobjects.Owner, u.OrgRole)
+}
@@ -3,7 +3,10 @@ package services_test
 import (
 	"context"
 	"github.com/stretchr/testify/assert"
+	"github.com/zhanbolat18/parcel/libs/metrics"
 	"github.com/zhanbolat18/parcel/users/internal/entities"
+	"github.com/zhanbolat18/parcel/users/internal/repositories"
+	"github.com/zhanbolat18/parcel/users/internal/repositories/memory"
 	"github.com/zhanbolat18/parcel/users/internal/services"
 	"github.com/zhanbolat18/parcel/users/internal/valueobjects"
 	"github.com/zhanbolat18/parcel/users/pkg/crypto"
@@ -13,26 +16,14 @@ import (
 	"time"
 )
 
-type mockUserRepo struct {
-	memory map[string]*entities.User
-}
-
-func (m *mockUserRepo) GetById(ctx context.Context, id uint) (*entities.User, error) {
-	return nil, nil
-}
-
-func (m *mockUserRepo) GetByEmail(ctx context.Context, email string) (*entities.User, error) {
-	return m.memory[email], nil
-}
-
-func (m *mockUserRepo) Save(ctx context.Context, user *entities.User) error {
-	m.memory[user.Email] = user
-	return nil
-}
-
-func (m *mockUserRepo) Update(ctx context.Context, user *entities.User) error {
-	m.memory[user.Email] = user
-	return nil
+func newRepo(t *testing.T, users ...*entities.User) repositories.UserRepository {
+	repo := memory.NewUserRepository()
+	for _, u := range users {
+		if err := repo.Save(ctx, u); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return repo
 }
 
 var hasher = crypto.NewPasswordHasher(13)
@@ -41,7 +32,7 @@ var jwt = jwt2.NewJwtManager(10*time.Second, 0, []byte("customKey"))
 
 func TestManageUser_CreateUser(t *testing.T) {
 	assrt := assert.New(t)
-	repo := &mockUserRepo{memory: make(map[string]*entities.User)}
+	repo := memory.NewUserRepository()
 	email := "custom@email.com"
 	password := "custompassword"
 	srv := services.NewUserService(hasher, repo)
@@ -54,7 +45,7 @@ func TestManageUser_CreateUser(t *testing.T) {
 
 func TestManageUser_CreateCourier(t *testing.T) {
 	assrt := assert.New(t)
-	repo := &mockUserRepo{memory: make(map[string]*entities.User)}
+	repo := memory.NewUserRepository()
 	email := "custom@email.com"
 	password := "custompassword"
 	srv := services.NewUserService(hasher, repo)
@@ -70,16 +61,13 @@ func TestAuthService_AuthenticationSuccess(t *testing.T) {
 	email := "active@email.com"
 	password := "custompassword"
 	hash, _ := hasher.Hash(password)
-	repo := &mockUserRepo{memory: map[string]*entities.User{
-		email: {
-			Id:           1,
-			Email:        email,
-			PasswordHash: string(hash),
-			Status:       valueobjects.Active,
-			Role:         valueobjects.User,
-		},
-	}}
-	srv := services.NewAuthService(hasher, jwt, repo)
+	repo := newRepo(t, &entities.User{
+		Email:        email,
+		PasswordHash: string(hash),
+		Status:       valueobjects.Active,
+		Role:         valueobjects.User,
+	})
+	srv := services.NewAuthService(hasher, jwt, repo, metrics.NopCounter())
 	token, err := srv.Authentication(ctx, email, password)
 	assrt.Nil(err)
 	assrt.NotEmpty(token)
@@ -90,22 +78,17 @@ func TestAuthService_AuthenticationFail(t *testing.T) {
 	assrt := assert.New(t)
 	password := "custompassword"
 	hash, _ := hasher.Hash(password)
-	repo := &mockUserRepo{memory: map[string]*entities.User{
-		"frozen@email.com": {
-			Id:           1,
-			Email:        "frozen@email.com",
-			PasswordHash: string(hash),
-			Status:       valueobjects.Frozen,
-			Role:         valueobjects.User,
-		},
-		"blocked@custom.com": {
-			Id:           1,
-			Email:        "blocked@custom.com",
-			PasswordHash: string(hash),
-			Status:       valueobjects.Blocked,
-			Role:         valueobjects.User,
-		},
-	}}
+	repo := newRepo(t, &entities.User{
+		Email:        "frozen@email.com",
+		PasswordHash: string(hash),
+		Status:       valueobjects.Frozen,
+		Role:         valueobjects.User,
+	}, &entities.User{
+		Email:        "blocked@custom.com",
+		PasswordHash: string(hash),
+		Status:       valueobjects.Blocked,
+		Role:         valueobjects.User,
+	})
 
 	failCases := []struct {
 		email, password string
@@ -128,7 +111,7 @@ func TestAuthService_AuthenticationFail(t *testing.T) {
 		},
 	}
 
-	srv := services.NewAuthService(hasher, jwt, repo)
+	srv := services.NewAuthService(hasher, jwt, repo, metrics.NopCounter())
 
 	for i, failCase := range failCases {
 		t.Run(strconv.Itoa(i), func(t *testing.T) {
@@ -0,0 +1,20 @@
+package valueobjects
+
+// MembershipRole is the role of a user within their organization. Owners
+// manage the members, staff work with the deliveries and viewers only see
+// them.
+type MembershipRole string
+
+const (
+	Owner  MembershipRole = "owner"
+	Staff  MembershipRole = "staff"
+	Viewer MembershipRole = "viewer"
+)
+
+func (r MembershipRole) Valid() bool {
+	switch r {
+	case Owner, Staff, Viewer:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,19 @@
+package valueobjects
+
+// Scope limits what a request authenticated by an API key may do.
+type Scope string
+
+const (
+	DeliveriesCreate Scope = "deliveries:create"
+	DeliveriesRead   Scope = "deliveries:read"
+	DeliveriesUpdate Scope = "deliveries:update"
+	InvoicesRead     Scope = "invoices:read"
+)
+
+func (s Scope) Valid() bool {
+	switch s {
+	case DeliveriesCreate, DeliveriesRead, DeliveriesUpdate, InvoicesRead:
+		return true
+	}
+	return false
+}